@@ -0,0 +1,107 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+// Command apftrace prints the APF frames of a trace written by trace.Recorder as typed messages, followed by the data of
+// each channel, and reports the protocol violations found. It exits with status 1 when there are violations.
+//
+// Usage:
+//
+//	apftrace [-streams=false] [-violations] trace.apf
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/apf"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/apf/trace"
+)
+
+// maxData is the number of bytes of APF_CHANNEL_DATA printed with the frame.
+const maxData = 64
+
+func main() {
+	var streams, violationsOnly bool
+	flag.BoolVar(&streams, "streams", true, "print the data of every channel after the frames")
+	flag.BoolVar(&violationsOnly, "violations", false, "print the violations only")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [flags] trace\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	f, err := os.Open(flag.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+
+	violations, err := run(os.Stdout, trace.NewReader(f), streams && !violationsOnly, violationsOnly)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if violations > 0 {
+		os.Exit(1)
+	}
+}
+
+func run(w io.Writer, r *trace.Reader, streams, violationsOnly bool) (int, error) {
+	analyzer := trace.NewAnalyzer()
+	for i := 0; ; i++ {
+		record, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return len(analyzer.Violations), err
+		}
+		violations := analyzer.Add(record)
+		if !violationsOnly {
+			fmt.Fprintf(w, "%5d %s %s %s\n", i, record.Time.Format("15:04:05.000000"), record.Direction, describe(record))
+		}
+		for _, v := range violations {
+			fmt.Fprintf(w, "VIOLATION %s\n", v)
+		}
+	}
+	if streams {
+		for i, c := range analyzer.Channels {
+			fmt.Fprintf(w, "\n=== channel %d: %s opened %s, local %d, peer %d\n", i, c.Type, c.Opener, c.LocalID, c.PeerID)
+			for _, d := range []trace.Direction{trace.Sent, trace.Received} {
+				if len(c.Data[d]) > 0 {
+					fmt.Fprintf(w, "--- %s %d bytes\n%s\n", d, len(c.Data[d]), c.Data[d])
+				}
+			}
+		}
+	}
+	if len(analyzer.Violations) > 0 {
+		fmt.Fprintf(w, "\n%d violations\n", len(analyzer.Violations))
+	}
+	return len(analyzer.Violations), nil
+}
+
+// describe formats the message of record, eliding channel data beyond maxData bytes.
+func describe(record trace.Record) string {
+	message, err := record.Message()
+	if err != nil {
+		return fmt.Sprintf("undecodable %x: %v", record.Frame, err)
+	}
+	name := strings.TrimPrefix(fmt.Sprintf("%T", message), "apf.")
+	if data, ok := message.(apf.APF_CHANNEL_DATA_MESSAGE); ok {
+		payload := data.Data
+		suffix := ""
+		if len(payload) > maxData {
+			payload, suffix = payload[:maxData], "..."
+		}
+		return fmt.Sprintf("%s {RecipientChannel:%d DataLength:%d Data:%q%s}", name, data.RecipientChannel, data.DataLength, payload, suffix)
+	}
+	return fmt.Sprintf("%s %+v", name, message)
+}
@@ -0,0 +1,76 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+// Command wsmananonymize replaces the serial numbers, UUIDs, addresses, names, certificates, keys and other identifying
+// values of captured WS-Man envelopes with fake ones, see package anonymize. Files ending in .json are read as
+// cassettes written by cassette.Recorder, other files as single envelopes. The same value is replaced with the same fake
+// value in every file of an invocation, so files captured together should be anonymized together.
+//
+// Usage:
+//
+//	wsmananonymize [-w] file...
+//
+// The anonymized files are written to standard output, or back to the files with -w.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/anonymize"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/cassette"
+)
+
+func main() {
+	var write bool
+	flag.BoolVar(&write, "w", false, "write the anonymized files back instead of printing them")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [flags] file...\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	a := anonymize.New()
+	for _, path := range flag.Args() {
+		if err := anonymizeFile(a, path, write); err != nil {
+			log.Fatalf("%s: %v", path, err)
+		}
+	}
+}
+
+func anonymizeFile(a *anonymize.Anonymizer, path string, write bool) error {
+	if filepath.Ext(path) == ".json" {
+		c, err := cassette.Load(path)
+		if err != nil {
+			return err
+		}
+		if err := a.Cassette(c); err != nil {
+			return err
+		}
+		if write {
+			return c.Save(path)
+		}
+		return c.Write(os.Stdout)
+	}
+	doc, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	anonymized, err := a.Envelope(doc)
+	if err != nil {
+		return err
+	}
+	if write {
+		return os.WriteFile(path, anonymized, 0o644)
+	}
+	_, err = os.Stdout.Write(anonymized)
+	return err
+}
@@ -0,0 +1,254 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+// Package envelope parses WS-Man envelopes into a canonical form, so that envelopes compare equal regardless of
+// namespace prefixes, attribute order, whitespace between elements and message identifiers.
+package envelope
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Node is a canonical XML element. Names are qualified by namespace URI instead of prefix, namespace declarations are
+// dropped, attributes are sorted and the text is trimmed.
+type Node struct {
+	Name     xml.Name
+	Attrs    []xml.Attr
+	Text     string
+	Children []*Node
+}
+
+// Child returns the first child named local in any namespace, or nil.
+func (n *Node) Child(local string) *Node {
+	if n == nil {
+		return nil
+	}
+	for _, c := range n.Children {
+		if c.Name.Local == local {
+			return c
+		}
+	}
+	return nil
+}
+
+// String returns the canonical form of n, one element per line indented by depth.
+func (n *Node) String() string {
+	sb := strings.Builder{}
+	n.write(&sb, 0)
+	return sb.String()
+}
+
+func (n *Node) write(sb *strings.Builder, depth int) {
+	sb.WriteString(strings.Repeat("  ", depth))
+	sb.WriteString(FormatName(n.Name))
+	for _, a := range n.Attrs {
+		fmt.Fprintf(sb, " %s=%q", FormatName(a.Name), a.Value)
+	}
+	if n.Text != "" {
+		fmt.Fprintf(sb, " %q", n.Text)
+	}
+	sb.WriteString("\n")
+	for _, c := range n.Children {
+		c.write(sb, depth+1)
+	}
+}
+
+// Sorted returns a copy of n whose children, and theirs, are sorted by name. Siblings of the same name keep their
+// order, as it is significant for repeated properties.
+func (n *Node) Sorted() *Node {
+	sorted := *n
+	sorted.Children = make([]*Node, len(n.Children))
+	for i, c := range n.Children {
+		sorted.Children[i] = c.Sorted()
+	}
+	sort.SliceStable(sorted.Children, func(i, j int) bool {
+		return FormatName(sorted.Children[i].Name) < FormatName(sorted.Children[j].Name)
+	})
+	return &sorted
+}
+
+// FormatName returns name as {namespace}local, or local when it has no namespace.
+func FormatName(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+	return "{" + name.Space + "}" + name.Local
+}
+
+// Envelope is a parsed WS-Man envelope.
+type Envelope struct {
+	Action      string
+	ResourceURI string
+	MessageID   string
+	// Selectors maps the names of the selectors of the header to their values. The value of a selector holding an
+	// endpoint reference is the canonical form of its content.
+	Selectors map[string]string
+	Header    *Node
+	Body      *Node
+}
+
+// Parse parses the SOAP envelope msg.
+func Parse(msg string) (*Envelope, error) {
+	root, err := ParseNode(msg)
+	if err != nil {
+		return nil, err
+	}
+	if root.Name.Local != "Envelope" {
+		return nil, fmt.Errorf("root element %s is not an Envelope", FormatName(root.Name))
+	}
+	e := &Envelope{
+		Selectors: map[string]string{},
+		Header:    root.Child("Header"),
+		Body:      root.Child("Body"),
+	}
+	if e.Header != nil {
+		if n := e.Header.Child("Action"); n != nil {
+			e.Action = n.Text
+		}
+		if n := e.Header.Child("ResourceURI"); n != nil {
+			e.ResourceURI = n.Text
+		}
+		if n := e.Header.Child("MessageID"); n != nil {
+			e.MessageID = n.Text
+		}
+		for _, s := range e.Header.Child("SelectorSet").children("Selector") {
+			e.Selectors[s.attr("Name")] = s.value()
+		}
+	}
+	return e, nil
+}
+
+func (n *Node) children(local string) []*Node {
+	if n == nil {
+		return nil
+	}
+	found := []*Node{}
+	for _, c := range n.Children {
+		if c.Name.Local == local {
+			found = append(found, c)
+		}
+	}
+	return found
+}
+
+func (n *Node) attr(local string) string {
+	for _, a := range n.Attrs {
+		if a.Name.Local == local {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// value returns the text of n, or the canonical form of its children when it has any.
+func (n *Node) value() string {
+	if len(n.Children) == 0 {
+		return n.Text
+	}
+	sb := strings.Builder{}
+	for _, c := range n.Children {
+		c.write(&sb, 0)
+	}
+	return sb.String()
+}
+
+// ParseNode parses the XML document doc into its canonical form.
+func ParseNode(doc string) (*Node, error) {
+	decoder := xml.NewDecoder(strings.NewReader(doc))
+	var root *Node
+	stack := []*Node{}
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			if root != nil && len(stack) == 0 {
+				return root, nil
+			}
+			if err == io.EOF && root == nil {
+				return nil, errors.New("no root element")
+			}
+			return nil, err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			n := &Node{Name: t.Name}
+			for _, a := range t.Attr {
+				if a.Name.Space == "xmlns" || a.Name.Space == "" && a.Name.Local == "xmlns" {
+					continue
+				}
+				n.Attrs = append(n.Attrs, a)
+			}
+			sort.Slice(n.Attrs, func(i, j int) bool {
+				return FormatName(n.Attrs[i].Name) < FormatName(n.Attrs[j].Name)
+			})
+			if len(stack) == 0 {
+				if root != nil {
+					return nil, errors.New("more than one root element")
+				}
+				root = n
+			} else {
+				parent := stack[len(stack)-1]
+				parent.Children = append(parent.Children, n)
+			}
+			stack = append(stack, n)
+		case xml.EndElement:
+			top := stack[len(stack)-1]
+			top.Text = strings.TrimSpace(top.Text)
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].Text += string(t)
+			}
+		}
+	}
+}
+
+// XML returns n as an XML element. Namespaces are declared as default namespaces where they change and with generated
+// prefixes for qualified attributes.
+func (n *Node) XML() string {
+	sb := strings.Builder{}
+	n.writeXML(&sb, "")
+	return sb.String()
+}
+
+const xmlNamespace = "http://www.w3.org/XML/1998/namespace"
+
+func (n *Node) writeXML(sb *strings.Builder, space string) {
+	sb.WriteString("<" + n.Name.Local)
+	if n.Name.Space != space {
+		sb.WriteString(` xmlns="`)
+		_ = xml.EscapeText(sb, []byte(n.Name.Space))
+		sb.WriteString(`"`)
+	}
+	prefixes := 0
+	for _, a := range n.Attrs {
+		name := a.Name.Local
+		switch a.Name.Space {
+		case "":
+		case xmlNamespace:
+			name = "xml:" + name
+		default:
+			prefix := fmt.Sprintf("a%d", prefixes)
+			prefixes++
+			sb.WriteString(" xmlns:" + prefix + `="`)
+			_ = xml.EscapeText(sb, []byte(a.Name.Space))
+			sb.WriteString(`"`)
+			name = prefix + ":" + name
+		}
+		sb.WriteString(" " + name + `="`)
+		_ = xml.EscapeText(sb, []byte(a.Value))
+		sb.WriteString(`"`)
+	}
+	sb.WriteString(">")
+	_ = xml.EscapeText(sb, []byte(n.Text))
+	for _, c := range n.Children {
+		c.writeXML(sb, n.Name.Space)
+	}
+	sb.WriteString("</" + n.Name.Local + ">")
+}
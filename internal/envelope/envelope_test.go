@@ -0,0 +1,64 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package envelope
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testEnvelope = `<?xml version="1.0" encoding="utf-8"?><Envelope xmlns="http://www.w3.org/2003/05/soap-envelope" xmlns:a="http://schemas.xmlsoap.org/ws/2004/08/addressing" xmlns:w="http://schemas.dmtf.org/wbem/wsman/1/wsman.xsd"><Header><a:Action>http://schemas.xmlsoap.org/ws/2004/09/transfer/Get</a:Action><a:MessageID>7</a:MessageID><w:ResourceURI>http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_AssociatedPowerManagementService</w:ResourceURI><w:SelectorSet><w:Selector Name="Name">Intel(r) AMT</w:Selector><w:Selector Name="UserOfService"><a:EndpointReference><a:Address>/wsman</a:Address></a:EndpointReference></w:Selector></w:SelectorSet></Header><Body/></Envelope>`
+
+func TestParse(t *testing.T) {
+	e, err := Parse(testEnvelope)
+	require.NoError(t, err)
+	assert.Equal(t, "http://schemas.xmlsoap.org/ws/2004/09/transfer/Get", e.Action)
+	assert.Equal(t, "http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_AssociatedPowerManagementService", e.ResourceURI)
+	assert.Equal(t, "7", e.MessageID)
+	assert.Equal(t, map[string]string{
+		"Name":          "Intel(r) AMT",
+		"UserOfService": "{http://schemas.xmlsoap.org/ws/2004/08/addressing}EndpointReference\n  {http://schemas.xmlsoap.org/ws/2004/08/addressing}Address \"/wsman\"\n",
+	}, e.Selectors)
+	assert.Equal(t, "{http://www.w3.org/2003/05/soap-envelope}Body\n", e.Body.String())
+
+	_, err = Parse("<Body/>")
+	assert.EqualError(t, err, "root element Body is not an Envelope")
+	_, err = Parse("")
+	assert.EqualError(t, err, "no root element")
+	_, err = Parse("<Envelope>")
+	assert.Error(t, err)
+}
+
+func TestParseNode(t *testing.T) {
+	a, err := ParseNode(`<p:A xmlns:p="urn:x" xmlns:q="urn:y" q:b="2" c="1"> <p:B>text</p:B> </p:A>`)
+	require.NoError(t, err)
+	b, err := ParseNode(`<A xmlns="urn:x" c="1" xmlns:r="urn:y" r:b="2"><B> text </B></A>`)
+	require.NoError(t, err)
+	assert.Equal(t, a, b)
+	assert.Equal(t, "{urn:x}A c=\"1\" {urn:y}b=\"2\"\n  {urn:x}B \"text\"\n", a.String())
+
+	_, err = ParseNode(`<A/><B/>`)
+	assert.EqualError(t, err, "more than one root element")
+}
+
+func TestSorted(t *testing.T) {
+	n, err := ParseNode(`<A><C>1</C><B/><C>2</C><D><F/><E/></D></A>`)
+	require.NoError(t, err)
+	assert.Equal(t, "A\n  B\n  C \"1\"\n  C \"2\"\n  D\n    E\n    F\n", n.Sorted().String())
+	assert.Equal(t, "C", n.Children[0].Name.Local, "the original is not modified")
+}
+
+func TestXML(t *testing.T) {
+	doc := `<p:A xmlns:p="urn:x" xmlns:q="urn:y" q:b="2" c="&lt;1&gt;" xml:lang="en"><p:B>a &amp; b</p:B><q:C><D xmlns="">d</D></q:C></p:A>`
+	n, err := ParseNode(doc)
+	require.NoError(t, err)
+	assert.Equal(t, `<A xmlns="urn:x" c="&lt;1&gt;" xml:lang="en" xmlns:a0="urn:y" a0:b="2"><B>a &amp; b</B><C xmlns="urn:y"><D xmlns="">d</D></C></A>`, n.XML())
+	parsed, err := ParseNode(n.XML())
+	require.NoError(t, err)
+	assert.Equal(t, n.String(), parsed.String())
+}
@@ -6,11 +6,15 @@
 package message
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/client"
 )
 
+// ErrNoClient is returned by Execute for a Base created without a client, which can only build messages.
+var ErrNoClient = errors.New("message: no client to send the message with")
+
 func NewBase(wsmanMessageCreator *WSManMessageCreator, className string) Base {
 	return Base{
 		WSManMessageCreator: wsmanMessageCreator,
@@ -82,14 +86,45 @@ func (b *Base) RequestStateChange(actionName string, requestedState int) string
 	return b.WSManMessageCreator.CreateXML(header, body)
 }
 
+// Validate checks data before it is sent when data implements Validator.
+// Validation is skipped when the client opts out through client.RequestValidator.
+func (b *Base) Validate(data interface{}) error {
+	if rv, ok := b.client.(client.RequestValidator); ok && !rv.ValidateRequests() {
+		return nil
+	}
+	if v, ok := data.(Validator); ok {
+		return v.Validate()
+	}
+	return nil
+}
+
+// Execute sends the XMLInput of message with the client of b and stores the response in message, see Decode.
+// It returns ErrNoClient when b has no client.
 func (b *Base) Execute(message *client.Message) error {
-	if b.client != nil {
-		xmlResponse, err := b.client.Post(message.XMLInput)
-		message.XMLOutput = string(xmlResponse)
+	if s, ok := b.streamer(); ok {
+		body, err := s.PostStream(message.XMLInput)
 		if err != nil {
 			return err
 		}
+		message.SetBody(body)
+		return nil
 	}
-	// potentially could return an error that says that client doesn't exist
-	return nil
+	if b.client == nil {
+		return ErrNoClient
+	}
+	xmlResponse, err := b.client.Post(message.XMLInput)
+	message.XMLOutput = string(xmlResponse)
+	return err
+}
+
+// streamer returns the client when it streams responses. Strict decoding needs the whole response, so it disables streaming.
+func (b *Base) streamer() (client.Streamer, bool) {
+	s, ok := b.client.(client.Streamer)
+	if !ok || !s.StreamResponses() {
+		return nil, false
+	}
+	if sd, ok := b.client.(client.StrictDecoder); ok && sd.StrictDecoding() {
+		return nil, false
+	}
+	return s, true
 }
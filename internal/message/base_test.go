@@ -6,9 +6,12 @@
 package message
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/client"
 )
 
 func TestBase(t *testing.T) {
@@ -69,3 +72,61 @@ func TestBase(t *testing.T) {
 		assert.Equal(t, expected, actual)
 	})
 }
+
+type validatedInput struct {
+	err error
+}
+
+func (v validatedInput) Validate() error {
+	return v.err
+}
+
+type optOutClient struct {
+	validate bool
+}
+
+func (c optOutClient) Post(msg string) ([]byte, error) {
+	return nil, nil
+}
+
+func (c optOutClient) ValidateRequests() bool {
+	return c.validate
+}
+
+func TestBaseValidate(t *testing.T) {
+	invalid := validatedInput{err: errors.New("invalid")}
+
+	t.Run("returns the error of a Validator", func(t *testing.T) {
+		base := NewBase(NewWSManMessageCreator("test-uri"), "TestClass")
+		assert.EqualError(t, base.Validate(invalid), "invalid")
+	})
+
+	t.Run("ignores data that does not implement Validator", func(t *testing.T) {
+		base := NewBase(NewWSManMessageCreator("test-uri"), "TestClass")
+		assert.NoError(t, base.Validate("test-data"))
+	})
+
+	t.Run("skips validation when the client opts out", func(t *testing.T) {
+		base := NewBaseWithClient(NewWSManMessageCreator("test-uri"), "TestClass", optOutClient{validate: false})
+		assert.NoError(t, base.Validate(invalid))
+	})
+
+	t.Run("validates when the client opts in", func(t *testing.T) {
+		base := NewBaseWithClient(NewWSManMessageCreator("test-uri"), "TestClass", optOutClient{validate: true})
+		assert.Error(t, base.Validate(invalid))
+	})
+}
+
+func TestBaseExecute(t *testing.T) {
+	t.Run("returns ErrNoClient without a client", func(t *testing.T) {
+		base := NewBase(NewWSManMessageCreator("test-uri"), "TestClass")
+		message := &client.Message{XMLInput: base.Get(nil)}
+		assert.ErrorIs(t, base.Execute(message), ErrNoClient)
+		assert.Empty(t, message.XMLOutput)
+	})
+
+	t.Run("sends the message with the client", func(t *testing.T) {
+		base := NewBaseWithClient(NewWSManMessageCreator("test-uri"), "TestClass", optOutClient{})
+		assert.NoError(t, base.Execute(&client.Message{XMLInput: base.Get(nil)}))
+	})
+}
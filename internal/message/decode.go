@@ -0,0 +1,383 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package message
+
+import (
+	"encoding/xml"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/client"
+)
+
+// Decode unmarshals the XMLOutput of message into v, or the response body when the client streams responses.
+// When the client opts in through client.StrictDecoder the response is also checked against the request and the shape of v, see CheckResponse.
+func (b *Base) Decode(message *client.Message, v interface{}) error {
+	if body := message.TakeBody(); body != nil {
+		defer body.Close()
+		return xml.NewDecoder(body).Decode(v)
+	}
+	err := xml.Unmarshal([]byte(message.XMLOutput), v)
+	if sd, ok := b.client.(client.StrictDecoder); !ok || !sd.StrictDecoding() {
+		return err
+	}
+	if strictErr := CheckResponse(message.XMLInput, message.XMLOutput, v); strictErr != nil {
+		return strictErr
+	}
+	return err
+}
+
+// element is a minimal document tree used to compare a response with the struct it is decoded into.
+type element struct {
+	name     xml.Name
+	children []*element
+	text     string
+}
+
+func (e *element) child(local string) *element {
+	for _, c := range e.children {
+		if c.name.Local == local {
+			return c
+		}
+	}
+	return nil
+}
+
+func parseElement(doc string) (*element, error) {
+	decoder := xml.NewDecoder(strings.NewReader(doc))
+	var root *element
+	stack := []*element{}
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			if root != nil && len(stack) == 0 {
+				return root, nil
+			}
+			return nil, err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			e := &element{name: t.Name}
+			if len(stack) == 0 {
+				if root != nil {
+					return nil, fmt.Errorf("more than one root element")
+				}
+				root = e
+			} else {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, e)
+			}
+			stack = append(stack, e)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text += string(t)
+			}
+		}
+	}
+}
+
+// CheckResponse compares a response with the request it answers and with v, the struct it was decoded into.
+//
+// It verifies that the response is a SOAP envelope whose action answers the request action, that the body element
+// belongs to the namespace of the requested resource, that every property of the response is known to v and that every
+// property v requires is present. AMT leaves out properties that are not set whatever their xml tag says, so a property
+// is only required when its field is tagged wsman:"required".
+// All discrepancies are reported together in a *client.DecodeError.
+func CheckResponse(request, response string, v interface{}) error {
+	c := checker{}
+	c.check(request, response, v)
+	if len(c.discrepancies) == 0 {
+		return nil
+	}
+	return &client.DecodeError{Action: c.action, Discrepancies: c.discrepancies}
+}
+
+type checker struct {
+	action        string
+	discrepancies []string
+}
+
+func (c *checker) add(format string, args ...interface{}) {
+	c.discrepancies = append(c.discrepancies, fmt.Sprintf(format, args...))
+}
+
+func (c *checker) check(request, response string, v interface{}) {
+	var resourceURI string
+	if req, err := parseElement(request); err == nil {
+		if header := req.child("Header"); header != nil {
+			if action := header.child("Action"); action != nil {
+				c.action = strings.TrimSpace(action.text)
+			}
+			if uri := header.child("ResourceURI"); uri != nil {
+				resourceURI = strings.TrimSpace(uri.text)
+			}
+		}
+	}
+	if strings.TrimSpace(response) == "" {
+		c.add("response is empty")
+		return
+	}
+	envelope, err := parseElement(response)
+	if err != nil {
+		c.add("response is not well formed: %v", err)
+		return
+	}
+	if envelope.name.Local != "Envelope" || envelope.name.Space != XMLBodySpace {
+		c.add("root element is %s, expected a SOAP Envelope", formatName(envelope.name))
+		return
+	}
+	header := envelope.child("Header")
+	body := envelope.child("Body")
+	if header == nil {
+		c.add("envelope has no Header")
+	} else if action := header.child("Action"); action == nil {
+		c.add("header has no Action")
+	} else if got := strings.TrimSpace(action.text); c.action != "" && got != c.action+"Response" {
+		c.add("action is %s, expected %sResponse", got, c.action)
+	}
+	if body == nil {
+		c.add("envelope has no Body")
+		return
+	}
+	if len(body.children) == 0 && (c.action == BaseActionsDelete || c.action == BaseActionsPut) {
+		// WS-Transfer allows Delete and Put to answer with an empty body
+		return
+	}
+	if len(body.children) != 1 {
+		c.add("body has %d elements, expected 1", len(body.children))
+		return
+	}
+	content := body.children[0]
+	if content.name.Local == "Fault" {
+		c.add("body is a fault: %s", strings.Join(strings.Fields(content.allText()), " "))
+		return
+	}
+	c.checkNamespace(content, resourceURI)
+	bodyType, ok := bodyField(reflect.TypeOf(v))
+	if !ok {
+		return
+	}
+	c.checkStruct(body, bodyType, "Body", false)
+}
+
+// checkNamespace verifies that the body element, or the items of an enumeration, belong to the requested resource.
+func (c *checker) checkNamespace(content *element, resourceURI string) {
+	if resourceURI == "" {
+		return
+	}
+	className := resourceURI[strings.LastIndex(resourceURI, "/")+1:]
+	switch c.action {
+	case BaseActionsEnumerate, BaseActionsPull:
+		if content.name.Space != XMLPullResponseSpace {
+			c.add("%s is in namespace %q, expected %q", content.name.Local, content.name.Space, XMLPullResponseSpace)
+		}
+		items := content.child("Items")
+		if items == nil {
+			return
+		}
+		for _, item := range items.children {
+			if item.name.Local == className && item.name.Space != resourceURI {
+				c.add("%s is in namespace %q, expected %q", item.name.Local, item.name.Space, resourceURI)
+			}
+		}
+	default:
+		if content.name.Space != resourceURI {
+			c.add("%s is in namespace %q, expected %q", content.name.Local, content.name.Space, resourceURI)
+		}
+	}
+}
+
+// checkStruct reports the children of e that t does not declare and, when required is true, the properties t requires that e lacks.
+func (c *checker) checkStruct(e *element, t reflect.Type, path string, required bool) {
+	fields := xmlFields(t)
+	if fields.any {
+		return
+	}
+	seen := map[string]bool{}
+	for _, child := range e.children {
+		childPath := path + "/" + child.name.Local
+		f, ok := fields.byName[child.name.Local]
+		if !ok {
+			// protocol elements such as EndOfSequence are not properties of the class
+			if child.name.Space != XMLPullResponseSpace {
+				c.add("unknown property %s", childPath)
+			}
+			continue
+		}
+		seen[child.name.Local] = true
+		if f.items != nil {
+			c.checkItems(child, f.items, childPath)
+			continue
+		}
+		if st, ok := structType(f.typ); ok {
+			c.checkStruct(child, st, childPath, true)
+		}
+	}
+	if !required {
+		return
+	}
+	for _, f := range fields.list {
+		if f.required && !seen[f.name] {
+			c.add("missing property %s/%s", path, f.name)
+		}
+	}
+}
+
+// checkItems checks the children of a parent>child tag such as Items>AMT_GeneralSettings, where items maps each child name to its field.
+func (c *checker) checkItems(e *element, items map[string]xmlField, path string) {
+	for _, child := range e.children {
+		childPath := path + "/" + child.name.Local
+		f, ok := items[child.name.Local]
+		if !ok {
+			c.add("unknown property %s", childPath)
+			continue
+		}
+		if st, ok := structType(f.typ); ok {
+			c.checkStruct(child, st, childPath, true)
+		}
+	}
+}
+
+type xmlField struct {
+	name     string
+	typ      reflect.Type
+	required bool
+	items    map[string]xmlField // set for parent>child tags, keyed by child name
+}
+
+type xmlFieldSet struct {
+	byName map[string]*xmlField
+	list   []*xmlField
+	any    bool
+}
+
+// xmlFields lists the elements encoding/xml maps onto the fields of t, including those of embedded structs.
+func xmlFields(t reflect.Type) xmlFieldSet {
+	set := xmlFieldSet{byName: map[string]*xmlField{}}
+	var collect func(t reflect.Type)
+	collect = func(t reflect.Type) {
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			if sf.PkgPath != "" && !sf.Anonymous {
+				continue
+			}
+			tag := sf.Tag.Get("xml")
+			if tag == "-" || sf.Name == "XMLName" {
+				continue
+			}
+			name, options, _ := strings.Cut(tag, ",")
+			if options == "any" {
+				set.any = true
+				continue
+			}
+			if options != "" && options != "omitempty" {
+				// attr, chardata, innerxml and comment fields are not elements
+				continue
+			}
+			if sf.Anonymous && tag == "" {
+				if st, ok := structType(sf.Type); ok {
+					collect(st)
+				}
+				continue
+			}
+			name = localName(name)
+			if name == "" {
+				name = typeXMLName(sf.Type)
+			}
+			if name == "" {
+				name = sf.Name
+			}
+			if parent, child, ok := strings.Cut(name, ">"); ok {
+				parent, child = localName(parent), localName(child)
+				f, ok := set.byName[parent]
+				if !ok {
+					f = &xmlField{name: parent, items: map[string]xmlField{}}
+					set.byName[parent] = f
+					set.list = append(set.list, f)
+				}
+				f.items[child] = xmlField{name: child, typ: sf.Type}
+				continue
+			}
+			f := &xmlField{name: name, typ: sf.Type, required: sf.Tag.Get("wsman") == "required"}
+			set.byName[name] = f
+			set.list = append(set.list, f)
+		}
+	}
+	collect(t)
+	return set
+}
+
+// localName strips the namespace or prefix from a tag name such as "h:HostName".
+func localName(name string) string {
+	if i := strings.LastIndexAny(name, " :"); i >= 0 {
+		return name[i+1:]
+	}
+	return name
+}
+
+// typeXMLName returns the element name declared by the XMLName field of t, which encoding/xml uses for untagged fields.
+func typeXMLName(t reflect.Type) string {
+	st, ok := structType(t)
+	if !ok {
+		return ""
+	}
+	f, ok := st.FieldByName("XMLName")
+	if !ok {
+		return ""
+	}
+	name, _, _ := strings.Cut(f.Tag.Get("xml"), ",")
+	return localName(name)
+}
+
+// structType dereferences pointers and slices and reports whether the result is a struct with properties of its own.
+func structType(t reflect.Type) (reflect.Type, bool) {
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, false
+	}
+	if _, ok := t.FieldByName("XMLName"); ok {
+		return t, true
+	}
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Tag.Get("xml") != "" {
+			return t, true
+		}
+	}
+	return nil, false
+}
+
+// bodyField returns the type of the Body field of the response struct v points to.
+func bodyField(t reflect.Type) (reflect.Type, bool) {
+	st, ok := structType(t)
+	if !ok {
+		return nil, false
+	}
+	f, ok := st.FieldByName("Body")
+	if !ok {
+		return nil, false
+	}
+	return structType(f.Type)
+}
+
+func (e *element) allText() string {
+	text := e.text
+	for _, c := range e.children {
+		text += " " + c.allText()
+	}
+	return text
+}
+
+func formatName(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+	return name.Space + ":" + name.Local
+}
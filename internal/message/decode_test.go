@@ -0,0 +1,158 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package message
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/client"
+	"github.com/stretchr/testify/assert"
+)
+
+type testResponse struct {
+	*client.Message
+	XMLName xml.Name `xml:"Envelope"`
+	Header  Header   `xml:"Header"`
+	Body    testBody `xml:"Body"`
+}
+
+type testBody struct {
+	XMLName      xml.Name `xml:"Body"`
+	GetResponse  testClass
+	PullResponse testPullResponse
+}
+
+type testPullResponse struct {
+	XMLName xml.Name    `xml:"PullResponse"`
+	Items   []testClass `xml:"Items>TestClass"`
+}
+
+type testClass struct {
+	XMLName     xml.Name `xml:"TestClass"`
+	InstanceID  string   `xml:"InstanceID" wsman:"required"`
+	ElementName string   `xml:"ElementName,omitempty"`
+}
+
+type strictClient struct {
+	response string
+}
+
+func (c strictClient) Post(msg string) ([]byte, error) {
+	return []byte(c.response), nil
+}
+
+func (c strictClient) StrictDecoding() bool {
+	return true
+}
+
+const testResponseEnvelope = `<a:Envelope xmlns:a="http://www.w3.org/2003/05/soap-envelope" xmlns:b="http://schemas.xmlsoap.org/ws/2004/08/addressing" xmlns:g="%s"><a:Header><b:Action>%s</b:Action></a:Header><a:Body>%s</a:Body></a:Envelope>`
+
+func testEnvelope(namespace, action, body string) string {
+	return fmt.Sprintf(testResponseEnvelope, namespace, action, body)
+}
+
+func TestCheckResponse(t *testing.T) {
+	wsmanMessageCreator := NewWSManMessageCreator("http://test/")
+	base := NewBase(wsmanMessageCreator, "TestClass")
+	get := base.Get(nil)
+	pull := base.Pull("context")
+	delete := base.Delete(Selector{Name: "InstanceID", Value: "1"})
+
+	tests := []struct {
+		name          string
+		request       string
+		response      string
+		discrepancies []string
+	}{
+		{
+			"matching get response",
+			get,
+			testEnvelope("http://test/TestClass", BaseActionsGet+"Response", `<g:TestClass><g:InstanceID>1</g:InstanceID></g:TestClass>`),
+			nil,
+		},
+		{
+			"matching pull response",
+			pull,
+			testEnvelope("http://test/TestClass", BaseActionsPull+"Response", `<h:PullResponse xmlns:h="http://schemas.xmlsoap.org/ws/2004/09/enumeration"><h:Items><g:TestClass><g:InstanceID>1</g:InstanceID></g:TestClass></h:Items><h:EndOfSequence></h:EndOfSequence></h:PullResponse>`),
+			nil,
+		},
+		{
+			"empty delete response",
+			delete,
+			testEnvelope("http://test/TestClass", BaseActionsDelete+"Response", ``),
+			nil,
+		},
+		{
+			"empty response",
+			get,
+			"",
+			[]string{"response is empty"},
+		},
+		{
+			"mismatched action, namespace and properties",
+			get,
+			testEnvelope("http://test/OtherClass", BaseActionsPut+"Response", `<g:TestClass><g:ElementName>test</g:ElementName><g:NewProperty>1</g:NewProperty></g:TestClass>`),
+			[]string{
+				"action is " + BaseActionsPut + "Response, expected " + BaseActionsGet + "Response",
+				`TestClass is in namespace "http://test/OtherClass", expected "http://test/TestClass"`,
+				"unknown property Body/TestClass/NewProperty",
+				"missing property Body/TestClass/InstanceID",
+			},
+		},
+		{
+			"unknown item in pull response",
+			pull,
+			testEnvelope("http://test/TestClass", BaseActionsPull+"Response", `<h:PullResponse xmlns:h="http://schemas.xmlsoap.org/ws/2004/09/enumeration"><h:Items><g:OtherClass></g:OtherClass></h:Items></h:PullResponse>`),
+			[]string{"unknown property Body/PullResponse/Items/OtherClass"},
+		},
+		{
+			"fault",
+			get,
+			testEnvelope("http://test/TestClass", "http://schemas.xmlsoap.org/ws/2004/08/addressing/fault", `<a:Fault><a:Reason><a:Text>access denied</a:Text></a:Reason></a:Fault>`),
+			[]string{
+				"action is http://schemas.xmlsoap.org/ws/2004/08/addressing/fault, expected " + BaseActionsGet + "Response",
+				"body is a fault: access denied",
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			response := testResponse{}
+			err := CheckResponse(test.request, test.response, &response)
+			if test.discrepancies == nil {
+				assert.NoError(t, err)
+				return
+			}
+			var decodeError *client.DecodeError
+			assert.True(t, errors.As(err, &decodeError))
+			assert.Equal(t, test.discrepancies, decodeError.Discrepancies)
+		})
+	}
+}
+
+func TestBaseDecode(t *testing.T) {
+	wsmanMessageCreator := NewWSManMessageCreator("http://test/")
+	requestBase := NewBase(wsmanMessageCreator, "TestClass")
+	request := requestBase.Get(nil)
+	output := testEnvelope("http://test/TestClass", BaseActionsGet+"Response", `<g:TestClass><g:Unexpected>1</g:Unexpected></g:TestClass>`)
+
+	t.Run("lenient by default", func(t *testing.T) {
+		base := NewBaseWithClient(wsmanMessageCreator, "TestClass", optOutClient{})
+		response := testResponse{Message: &client.Message{XMLInput: request, XMLOutput: output}}
+		assert.NoError(t, base.Decode(response.Message, &response))
+	})
+
+	t.Run("strict when the client opts in", func(t *testing.T) {
+		base := NewBaseWithClient(wsmanMessageCreator, "TestClass", strictClient{response: output})
+		response := testResponse{Message: &client.Message{XMLInput: request}}
+		assert.NoError(t, base.Execute(response.Message))
+		err := base.Decode(response.Message, &response)
+		assert.EqualError(t, err, "unexpected response to "+BaseActionsGet+": unknown property Body/TestClass/Unexpected; missing property Body/TestClass/InstanceID")
+	})
+}
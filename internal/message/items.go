@@ -0,0 +1,64 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package message
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/client"
+)
+
+// DecodeItems decodes the Items of a Pull response one at a time, passing each element named itemName to fn.
+// Only the item being decoded is held in memory, and decoding stops at the first error returned by fn.
+func DecodeItems[T any](message *client.Message, itemName string, fn func(T) error) error {
+	var r io.Reader
+	if body := message.TakeBody(); body != nil {
+		defer body.Close()
+		r = body
+	} else {
+		r = strings.NewReader(message.XMLOutput)
+	}
+	decoder := xml.NewDecoder(r)
+	inItems := false
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			if t.Name.Local == "Items" && t.Name.Space == XMLPullResponseSpace {
+				inItems = true
+				continue
+			}
+			if !inItems {
+				continue
+			}
+			if t.Name.Local != itemName {
+				if err := decoder.Skip(); err != nil {
+					return err
+				}
+				continue
+			}
+			var item T
+			if err := decoder.DecodeElement(&item, &t); err != nil {
+				return err
+			}
+			if err := fn(item); err != nil {
+				return err
+			}
+		case xml.EndElement:
+			if t.Name.Local == "Items" && t.Name.Space == XMLPullResponseSpace {
+				inItems = false
+			}
+		}
+	}
+}
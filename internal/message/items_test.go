@@ -0,0 +1,80 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package message
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/client"
+	"github.com/stretchr/testify/assert"
+)
+
+const testPullEnvelope = `<a:Envelope xmlns:a="http://www.w3.org/2003/05/soap-envelope" xmlns:g="http://schemas.xmlsoap.org/ws/2004/09/enumeration" xmlns:h="http://test/TestClass"><a:Header></a:Header><a:Body><g:PullResponse><g:Items><h:TestClass><h:InstanceID>1</h:InstanceID></h:TestClass><h:OtherClass><h:TestClass><h:InstanceID>nested</h:InstanceID></h:TestClass></h:OtherClass><h:TestClass><h:InstanceID>2</h:InstanceID></h:TestClass></g:Items><g:EndOfSequence></g:EndOfSequence></g:PullResponse></a:Body></a:Envelope>`
+
+type streamingClient struct {
+	response string
+}
+
+func (c streamingClient) Post(msg string) ([]byte, error) {
+	return []byte(c.response), nil
+}
+
+func (c streamingClient) StreamResponses() bool {
+	return true
+}
+
+func (c streamingClient) PostStream(msg string) (io.ReadCloser, error) {
+	return io.NopCloser(strings.NewReader(c.response)), nil
+}
+
+func TestDecodeItems(t *testing.T) {
+	t.Run("decodes each item of the class from XMLOutput", func(t *testing.T) {
+		ids := []string{}
+		err := DecodeItems(&client.Message{XMLOutput: testPullEnvelope}, "TestClass", func(item testClass) error {
+			ids = append(ids, item.InstanceID)
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"1", "2"}, ids)
+	})
+
+	t.Run("stops at the first callback error", func(t *testing.T) {
+		calls := 0
+		err := DecodeItems(&client.Message{XMLOutput: testPullEnvelope}, "TestClass", func(item testClass) error {
+			calls++
+			return errors.New("stop")
+		})
+		assert.EqualError(t, err, "stop")
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("decodes from a streamed body", func(t *testing.T) {
+		base := NewBaseWithClient(NewWSManMessageCreator("http://test/"), "TestClass", streamingClient{response: testPullEnvelope})
+		msg := &client.Message{XMLInput: base.Pull("context")}
+		assert.NoError(t, base.Execute(msg))
+		assert.Empty(t, msg.XMLOutput)
+		ids := []string{}
+		err := DecodeItems(msg, "TestClass", func(item testClass) error {
+			ids = append(ids, item.InstanceID)
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"1", "2"}, ids)
+		assert.Nil(t, msg.TakeBody())
+	})
+}
+
+func TestBaseDecodeStream(t *testing.T) {
+	base := NewBaseWithClient(NewWSManMessageCreator("http://test/"), "TestClass", streamingClient{response: testPullEnvelope})
+	response := testResponse{Message: &client.Message{XMLInput: base.Pull("context")}}
+	assert.NoError(t, base.Execute(response.Message))
+	assert.NoError(t, base.Decode(response.Message, &response))
+	assert.Empty(t, response.XMLOutput)
+	assert.Len(t, response.Body.PullResponse.Items, 2)
+}
@@ -17,6 +17,11 @@ type Base struct {
 	client              client.WSMan
 }
 
+// Validator is implemented by request types that can check their fields before they are sent to Intel® AMT.
+type Validator interface {
+	Validate() error
+}
+
 type Header struct {
 	XMLName     xml.Name `xml:"Header"`
 	To          string   `xml:"To"`
@@ -0,0 +1,311 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+// Command genconvert generates ToRequest methods that convert the response type returned by Get into the request type accepted by Put.
+//
+// Fields are copied when the request has a field of the same name and type, and converted when both types have the same
+// underlying type, such as an int and an enumeration defined on int. XMLName and the namespace field H are left for Put to fill
+// in. The read-only fields listed with -readonly are not copied, since AMT rejects a Put that sets them. Read-only properties are
+// listed explicitly rather than derived from the comments, which often describe a property as read only for one interface only.
+// InstanceID is always copied, as it is the key identifying the instance. Fields listed with -unless Flag=Field,... are only
+// copied when the boolean field Flag of the response is false, for settings that AMT only accepts in one mode. The fields listed
+// with -writeonly, such as credentials, are never returned by Get and are left for the caller to set. Any other request field
+// without a matching response field is an error, so that a Put of the conversion never resets a setting silently.
+//
+// Conversions are generated for the settings classes that can be read, modified and written back. Classes whose Put needs data
+// that Get never returns are left out: AMT_8021XProfile and AMT_MPSUsernamePassword (write-only secrets and certificate
+// references), and AMT_RemoteAccessPolicyRule and AMT_RemoteAccessPolicyAppliesToMPS (policy and MPS references).
+//
+// Usage:
+//
+//	//go:generate go run ../../../../internal/tools/genconvert -type BootSettingDataResponse=BootSettingDataRequest
+//	//go:generate go run ../../../../internal/tools/genconvert -type SettingsResponse=SettingsRequest -readonly LinkIsUp -unless DHCPEnabled=IPAddress,SubnetMask
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+type pair struct {
+	response string
+	request  string
+}
+
+type field struct {
+	name     string
+	typeExpr string
+	slice    bool
+	// convert is set when the response field has another type with the same underlying type.
+	convert bool
+}
+
+// condition is a boolean response field and the fields that are only copied when it is false.
+type condition struct {
+	flag   string
+	fields []string
+}
+
+// conditions is the flag.Value of the repeatable -unless flag.
+type conditions []condition
+
+func (c *conditions) String() string {
+	return fmt.Sprint(*c)
+}
+
+func (c *conditions) Set(value string) error {
+	flagName, fields, ok := strings.Cut(value, "=")
+	if !ok || flagName == "" || fields == "" {
+		return fmt.Errorf("invalid condition %q, expected Flag=Field,...", value)
+	}
+	*c = append(*c, condition{flag: flagName, fields: strings.Split(fields, ",")})
+	return nil
+}
+
+// options are the field selection options of the generated conversions.
+type options struct {
+	readOnly  map[string]bool
+	writeOnly []string
+	unless    conditions
+}
+
+func main() {
+	var types, output, readOnly, writeOnly string
+	var unless conditions
+	flag.StringVar(&types, "type", "", "comma separated list of Response=Request type pairs")
+	flag.StringVar(&output, "output", "convert.go", "name of the generated file")
+	flag.StringVar(&readOnly, "readonly", "", "comma separated list of read-only fields that are not copied")
+	flag.StringVar(&writeOnly, "writeonly", "", "comma separated list of write-only fields that Get does not return")
+	flag.Var(&unless, "unless", "Flag=Field,... fields only copied when the boolean response field Flag is false, repeatable")
+	flag.Parse()
+
+	pairs, err := parsePairs(types)
+	if err != nil {
+		log.Fatal(err)
+	}
+	dir, err := os.Getwd()
+	if err != nil {
+		log.Fatal(err)
+	}
+	opts := options{readOnly: map[string]bool{}, unless: unless}
+	for _, name := range strings.Split(readOnly, ",") {
+		if name != "" {
+			opts.readOnly[name] = true
+		}
+	}
+	for _, name := range strings.Split(writeOnly, ",") {
+		if name != "" {
+			opts.writeOnly = append(opts.writeOnly, name)
+		}
+	}
+	src, err := generate(dir, output, pairs, opts)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, output), src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func parsePairs(types string) ([]pair, error) {
+	if types == "" {
+		return nil, fmt.Errorf("-type is required")
+	}
+	pairs := []pair{}
+	for _, p := range strings.Split(types, ",") {
+		response, request, ok := strings.Cut(strings.TrimSpace(p), "=")
+		if !ok || response == "" || request == "" {
+			return nil, fmt.Errorf("invalid type pair %q, expected Response=Request", p)
+		}
+		pairs = append(pairs, pair{response: response, request: request})
+	}
+	return pairs, nil
+}
+
+// generate parses the package in dir, ignoring the previously generated output and test files, and returns the formatted conversion source.
+func generate(dir, output string, pairs []pair, opts options) ([]byte, error) {
+	fset := token.NewFileSet()
+	filter := func(fi os.FileInfo) bool {
+		return fi.Name() != output && !strings.HasSuffix(fi.Name(), "_test.go")
+	}
+	pkgs, err := parser.ParseDir(fset, dir, filter, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(pkgs) != 1 {
+		return nil, fmt.Errorf("expected a single package in %s, found %d", dir, len(pkgs))
+	}
+	var pkgName string
+	structs := map[string]*ast.StructType{}
+	types := map[string]ast.Expr{}
+	for name, pkg := range pkgs {
+		pkgName = name
+		for _, file := range pkg.Files {
+			ast.Inspect(file, func(n ast.Node) bool {
+				if spec, ok := n.(*ast.TypeSpec); ok {
+					types[spec.Name.Name] = spec.Type
+					if st, ok := spec.Type.(*ast.StructType); ok {
+						structs[spec.Name.Name] = st
+					}
+				}
+				return true
+			})
+		}
+	}
+	t := typeResolver{fset: fset, types: types}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by genconvert. DO NOT EDIT.\n\npackage %s\n", pkgName)
+	for _, p := range pairs {
+		response, ok := structs[p.response]
+		if !ok {
+			return nil, fmt.Errorf("type %s not found", p.response)
+		}
+		request, ok := structs[p.request]
+		if !ok {
+			return nil, fmt.Errorf("type %s not found", p.request)
+		}
+		skip := map[string]bool{}
+		for name := range opts.readOnly {
+			skip[name] = true
+		}
+		for _, name := range opts.writeOnly {
+			skip[name] = true
+		}
+		fields, err := t.matchFields(response, request, skip)
+		if err != nil {
+			return nil, fmt.Errorf("%s=%s: %w", p.response, p.request, err)
+		}
+		conditional := map[string]string{}
+		for _, c := range opts.unless {
+			for _, name := range c.fields {
+				conditional[name] = c.flag
+			}
+		}
+		fmt.Fprintf(&buf, "\n// ToRequest converts the %s returned by Get into a %s that can be modified and passed to Put.\n", p.response, p.request)
+		fmt.Fprintf(&buf, "// Read-only fields are left out")
+		for _, c := range opts.unless {
+			fmt.Fprintf(&buf, ", and %s only when %s is false", strings.Join(c.fields, ", "), c.flag)
+		}
+		fmt.Fprintf(&buf, ".\n")
+		if len(opts.writeOnly) > 0 {
+			fmt.Fprintf(&buf, "// Get does not return the write-only fields %s, set them before the Put.\n", strings.Join(opts.writeOnly, ", "))
+		}
+		if len(opts.unless) == 0 {
+			fmt.Fprintf(&buf, "func (r %s) ToRequest() %s {\n\treturn %s{\n", p.response, p.request, p.request)
+			for _, f := range fields {
+				fmt.Fprintf(&buf, "\t\t%s: %s,\n", f.name, f.value())
+			}
+			fmt.Fprintf(&buf, "\t}\n}\n")
+			continue
+		}
+		fmt.Fprintf(&buf, "func (r %s) ToRequest() %s {\n\trequest := %s{\n", p.response, p.request, p.request)
+		for _, f := range fields {
+			if _, ok := conditional[f.name]; !ok {
+				fmt.Fprintf(&buf, "\t\t%s: %s,\n", f.name, f.value())
+			}
+		}
+		fmt.Fprintf(&buf, "\t}\n")
+		for _, c := range opts.unless {
+			fmt.Fprintf(&buf, "\tif !r.%s {\n", c.flag)
+			for _, f := range fields {
+				if conditional[f.name] == c.flag {
+					fmt.Fprintf(&buf, "\t\trequest.%s = %s\n", f.name, f.value())
+				}
+			}
+			fmt.Fprintf(&buf, "\t}\n")
+		}
+		fmt.Fprintf(&buf, "\treturn request\n}\n")
+	}
+	return format.Source(buf.Bytes())
+}
+
+// value returns the expression copying f from the response r.
+func (f field) value() string {
+	if f.slice {
+		return fmt.Sprintf("append(%s(nil), r.%s...)", f.typeExpr, f.name)
+	}
+	if f.convert {
+		return fmt.Sprintf("%s(r.%s)", f.typeExpr, f.name)
+	}
+	return "r." + f.name
+}
+
+// typeResolver resolves the types declared in the package.
+type typeResolver struct {
+	fset  *token.FileSet
+	types map[string]ast.Expr
+}
+
+// underlying returns the underlying type of expr, following the named types declared in the package.
+func (t typeResolver) underlying(expr ast.Expr) string {
+	for {
+		ident, ok := expr.(*ast.Ident)
+		if !ok {
+			break
+		}
+		next, ok := t.types[ident.Name]
+		if !ok {
+			break
+		}
+		if _, ok := next.(*ast.StructType); ok {
+			break
+		}
+		expr = next
+	}
+	return typeString(t.fset, expr)
+}
+
+// matchFields returns the writable fields the response and request have in common, in request order. It fails when a
+// request field that is not skipped has no response field it can be copied or converted from.
+func (t typeResolver) matchFields(response, request *ast.StructType, skip map[string]bool) ([]field, error) {
+	responseFields := map[string]ast.Expr{}
+	for _, f := range response.Fields.List {
+		for _, name := range f.Names {
+			responseFields[name.Name] = f.Type
+		}
+	}
+	fields := []field{}
+	unmatched := []string{}
+	for _, f := range request.Fields.List {
+		typeExpr := typeString(t.fset, f.Type)
+		_, slice := f.Type.(*ast.ArrayType)
+		for _, name := range f.Names {
+			if name.Name == "XMLName" || name.Name == "H" || (name.Name != "InstanceID" && skip[name.Name]) {
+				continue
+			}
+			responseType, ok := responseFields[name.Name]
+			switch {
+			case ok && typeString(t.fset, responseType) == typeExpr:
+				fields = append(fields, field{name: name.Name, typeExpr: typeExpr, slice: slice})
+			case ok && !slice && t.underlying(responseType) == t.underlying(f.Type):
+				fields = append(fields, field{name: name.Name, typeExpr: typeExpr, convert: true})
+			default:
+				unmatched = append(unmatched, name.Name)
+			}
+		}
+	}
+	if len(unmatched) > 0 {
+		sort.Strings(unmatched)
+		return nil, fmt.Errorf("request fields without a matching response field, list them with -readonly or -writeonly: %s", strings.Join(unmatched, ", "))
+	}
+	return fields, nil
+}
+
+func typeString(fset *token.FileSet, expr ast.Expr) string {
+	var buf bytes.Buffer
+	_ = format.Node(&buf, fset, expr)
+	return buf.String()
+}
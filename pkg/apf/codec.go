@@ -0,0 +1,1224 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package apf
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sync"
+)
+
+// MaxMessageSize is the size of the largest message ReadMessage accepts.
+const MaxMessageSize = 1 << 20
+
+// Errors returned when decoding APF messages. They are wrapped with the name of the message and field being read.
+var (
+	ErrShortMessage          = errors.New("apf: message too short")
+	ErrTrailingData          = errors.New("apf: unexpected data after message")
+	ErrUnexpectedMessageType = errors.New("apf: unexpected message type")
+	ErrUnknownMessageType    = errors.New("apf: unknown message type")
+	ErrLengthMismatch        = errors.New("apf: length field does not match data")
+	ErrStringTooLong         = errors.New("apf: string too long")
+	ErrMessageTooLarge       = errors.New("apf: message too large")
+)
+
+// decoder reads big endian APF fields from a single message, returning an error instead of reading past its end.
+type decoder struct {
+	name   string
+	data   []byte
+	offset int
+}
+
+func newDecoder(name string, data []byte, messageType byte) (*decoder, error) {
+	d := &decoder{name: name, data: data}
+	t, err := d.byte("MessageType")
+	if err != nil {
+		return nil, err
+	}
+	if t != messageType {
+		return nil, fmt.Errorf("%w: %s expects %d, got %d", ErrUnexpectedMessageType, name, messageType, t)
+	}
+	return d, nil
+}
+
+func (d *decoder) take(field string, n int) ([]byte, error) {
+	if n < 0 || len(d.data)-d.offset < n {
+		return nil, fmt.Errorf("%w: %s.%s needs %d bytes at offset %d, %d left", ErrShortMessage, d.name, field, n, d.offset, len(d.data)-d.offset)
+	}
+	b := d.data[d.offset : d.offset+n]
+	d.offset += n
+	return b, nil
+}
+
+func (d *decoder) byte(field string) (byte, error) {
+	b, err := d.take(field, 1)
+	if err != nil {
+		return 0, err
+	}
+	return b[0], nil
+}
+
+func (d *decoder) uint16(field string) (uint16, error) {
+	b, err := d.take(field, 2)
+	if err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint16(b), nil
+}
+
+func (d *decoder) uint32(field string) (uint32, error) {
+	b, err := d.take(field, 4)
+	if err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint32(b), nil
+}
+
+// string reads a uint32 length followed by that many bytes.
+func (d *decoder) string(field string) (uint32, string, error) {
+	length, err := d.uint32(field + "Length")
+	if err != nil {
+		return 0, "", err
+	}
+	if uint64(length) > uint64(len(d.data)-d.offset) {
+		return 0, "", fmt.Errorf("%w: %s.%s declares %d bytes at offset %d, %d left", ErrLengthMismatch, d.name, field, length, d.offset, len(d.data)-d.offset)
+	}
+	b, err := d.take(field, int(length))
+	if err != nil {
+		return 0, "", err
+	}
+	return length, string(b), nil
+}
+
+// rest returns the unread bytes.
+func (d *decoder) rest() []byte {
+	b := d.data[d.offset:]
+	d.offset = len(d.data)
+	return b
+}
+
+// end reports trailing bytes as an error.
+func (d *decoder) end() error {
+	if d.offset != len(d.data) {
+		return fmt.Errorf("%w: %s has %d bytes after offset %d", ErrTrailingData, d.name, len(d.data)-d.offset, d.offset)
+	}
+	return nil
+}
+
+// encoder appends big endian APF fields.
+type encoder struct {
+	buf []byte
+	err error
+}
+
+func (e *encoder) byte(v byte) {
+	e.buf = append(e.buf, v)
+}
+
+func (e *encoder) uint16(v uint16) {
+	e.buf = binary.BigEndian.AppendUint16(e.buf, v)
+}
+
+func (e *encoder) uint32(v uint32) {
+	e.buf = binary.BigEndian.AppendUint32(e.buf, v)
+}
+
+func (e *encoder) bytes(v []byte) {
+	e.buf = append(e.buf, v...)
+}
+
+// string writes the length of v followed by v. Length fields of the message structs are ignored and derived from the string.
+func (e *encoder) string(v string) {
+	if uint64(len(v)) > math.MaxUint32 {
+		e.err = ErrStringTooLong
+		return
+	}
+	e.uint32(uint32(len(v)))
+	e.buf = append(e.buf, v...)
+}
+
+func (e *encoder) result() ([]byte, error) {
+	if e.err != nil {
+		return nil, e.err
+	}
+	return e.buf, nil
+}
+
+// ReadMessage reads the next complete APF message from r, reading no further than its end.
+// An APF_REQUEST_SUCCESS is read as the reply to a tcpip-forward request, which carries the bound port. Use a Reader to
+// read the replies to other global requests.
+func ReadMessage(r io.Reader) ([]byte, error) {
+	return readMessage(r, func() bool { return true })
+}
+
+// Reader reads APF messages from a stream like ReadMessage, framing each APF_REQUEST_SUCCESS by the global request it
+// answers: the reply to a tcpip-forward request carries the bound port, the replies to other requests do not. The global
+// requests sent to the peer must be passed to Sent. A reply without a pending request is framed like ReadMessage does.
+type Reader struct {
+	r io.Reader
+
+	mu      sync.Mutex
+	pending []bool // for each request awaiting a reply, in order, whether the reply carries a port
+}
+
+// NewReader returns a Reader of the messages sent by the peer on r.
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: r}
+}
+
+// Sent notes data, a message sent to the peer, so that the reply to a global request that wants one is framed by it.
+// Any other message is ignored.
+func (r *Reader) Sent(data []byte) {
+	if len(data) == 0 || data[0] != APF_GLOBAL_REQUEST {
+		return
+	}
+	request := APF_GLOBAL_REQUEST_MESSAGE{}
+	if err := request.UnmarshalBinary(data); err != nil || request.Payload[0] == 0 {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pending = append(r.pending, request.String == APF_GLOBAL_REQUEST_STR_TCP_FORWARD_REQUEST)
+}
+
+// ReadMessage reads the next complete message of the peer.
+func (r *Reader) ReadMessage() ([]byte, error) {
+	// the request is looked up once the message arrives, as it may be sent while the read is blocked
+	port := func() bool {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		return len(r.pending) == 0 || r.pending[0]
+	}
+	data, err := readMessage(r.r, port)
+	if err == nil && (data[0] == APF_REQUEST_SUCCESS || data[0] == APF_REQUEST_FAILURE) {
+		r.mu.Lock()
+		if len(r.pending) > 0 {
+			r.pending = r.pending[1:]
+		}
+		r.mu.Unlock()
+	}
+	return data, err
+}
+
+// readMessage reads a message from r, reading an APF_REQUEST_SUCCESS with a port when port returns true.
+func readMessage(r io.Reader, port func() bool) ([]byte, error) {
+	f := &frameReader{r: r}
+	if err := f.read(1); err != nil {
+		return nil, err
+	}
+	var err error
+	switch f.buf[0] {
+	case APF_USERAUTH_SUCCESS, APF_REQUEST_FAILURE:
+	case APF_REQUEST_SUCCESS:
+		if port() {
+			err = f.read(4)
+		}
+	case APF_CHANNEL_CLOSE, APF_KEEPALIVE_REQUEST, APF_KEEPALIVE_REPLY:
+		err = f.read(4)
+	case APF_DISCONNECT:
+		err = f.read(6)
+	case APF_CHANNEL_WINDOW_ADJUST, APF_KEEPALIVE_OPTIONS_REQUEST, APF_KEEPALIVE_OPTIONS_REPLY:
+		err = f.read(8)
+	case APF_CHANNEL_OPEN_CONFIRMATION, APF_CHANNEL_OPEN_FAILURE:
+		err = f.read(16)
+	case APF_PROTOCOLVERSION:
+		err = f.read(92)
+	case APF_SERVICE_REQUEST, APF_SERVICE_ACCEPT:
+		err = f.field()
+	case APF_CHANNEL_DATA:
+		err = f.fields(f.uint32s(1), f.field)
+	case APF_USERAUTH_FAILURE:
+		err = f.fields(f.field, f.byte)
+	case APF_CHANNEL_OPEN:
+		err = f.fields(f.field, f.uint32s(3), f.field, f.uint32s(1), f.field, f.uint32s(1))
+	case APF_USERAUTH_REQUEST:
+		var method string
+		if err = f.fields(f.field, f.field); err == nil {
+			method, err = f.string()
+		}
+		if err == nil {
+			err = f.authentication(method)
+		}
+	case APF_GLOBAL_REQUEST:
+		var request string
+		if request, err = f.string(); err == nil {
+			switch request {
+			case APF_GLOBAL_REQUEST_STR_TCP_FORWARD_REQUEST, APF_GLOBAL_REQUEST_STR_TCP_FORWARD_CANCEL_REQUEST:
+				err = f.fields(f.byte, f.field, f.uint32s(1))
+			case APF_GLOBAL_REQUEST_STR_UDP_SEND_TO:
+				err = f.fields(f.byte, f.field, f.uint32s(1), f.field, f.uint32s(1), f.field)
+			default:
+				err = fmt.Errorf("%w: cannot frame global request %q", ErrUnknownMessageType, request)
+			}
+		}
+	default:
+		err = fmt.Errorf("%w: %d", ErrUnknownMessageType, f.buf[0])
+	}
+	if err != nil {
+		return nil, err
+	}
+	return f.buf, nil
+}
+
+// frameReader reads the fields of a message from a stream into buf.
+type frameReader struct {
+	r   io.Reader
+	buf []byte
+}
+
+func (f *frameReader) read(n int) error {
+	if len(f.buf)+n > MaxMessageSize {
+		return fmt.Errorf("%w: message type %d exceeds %d bytes", ErrMessageTooLarge, f.buf[0], MaxMessageSize)
+	}
+	start := len(f.buf)
+	f.buf = append(f.buf, make([]byte, n)...)
+	_, err := io.ReadFull(f.r, f.buf[start:])
+	if err == io.EOF && start > 0 {
+		err = io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+func (f *frameReader) byte() error {
+	return f.read(1)
+}
+
+// field reads a uint32 length followed by that many bytes.
+func (f *frameReader) field() error {
+	if err := f.read(4); err != nil {
+		return err
+	}
+	length := binary.BigEndian.Uint32(f.buf[len(f.buf)-4:])
+	if uint64(length) > MaxMessageSize {
+		return fmt.Errorf("%w: message type %d declares a %d byte field", ErrMessageTooLarge, f.buf[0], length)
+	}
+	return f.read(int(length))
+}
+
+// string reads a field and returns its value.
+func (f *frameReader) string() (string, error) {
+	start := len(f.buf) + 4
+	if err := f.field(); err != nil {
+		return "", err
+	}
+	return string(f.buf[start:]), nil
+}
+
+// authentication reads the method specific fields of an APF_USERAUTH_REQUEST. The fields of the SSH methods are defined
+// by RFC 4252 and RFC 4256; any other method is read without method specific fields, as the stream does not tell their
+// length, and UnmarshalBinary returns the fields of a complete message as MethodData.
+func (f *frameReader) authentication(method string) error {
+	switch method {
+	case APF_AUTH_PASSWORD:
+		return f.fields(f.byte, f.field)
+	case "publickey":
+		start := len(f.buf)
+		if err := f.fields(f.byte, f.field, f.field); err != nil {
+			return err
+		}
+		if f.buf[start] != 0 {
+			return f.field()
+		}
+		return nil
+	case "hostbased":
+		return f.fields(f.field, f.field, f.field, f.field, f.field)
+	case "keyboard-interactive":
+		return f.fields(f.field, f.field)
+	}
+	return nil
+}
+
+func (f *frameReader) uint32s(n int) func() error {
+	return func() error {
+		return f.read(4 * n)
+	}
+}
+
+func (f *frameReader) fields(fields ...func() error) error {
+	for _, field := range fields {
+		if err := field(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Marshal encodes any APF message struct, or a pointer to one, in its wire format.
+// Length fields are derived from the strings and data they describe.
+func Marshal(message interface{}) ([]byte, error) {
+	if m, ok := message.(interface{ MarshalBinary() ([]byte, error) }); ok {
+		return m.MarshalBinary()
+	}
+	return nil, fmt.Errorf("%w: cannot marshal %T", ErrUnknownMessageType, message)
+}
+
+// Unmarshal decodes a single complete APF message and returns it as the matching message struct.
+// An APF_REQUEST_SUCCESS carrying a port is returned as APF_TCP_FORWARD_REPLY_MESSAGE.
+func Unmarshal(data []byte) (interface{}, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("%w: empty message", ErrShortMessage)
+	}
+	var message interface {
+		UnmarshalBinary([]byte) error
+	}
+	switch data[0] {
+	case APF_DISCONNECT:
+		message = &APF_DISCONNECT_MESSAGE{}
+	case APF_SERVICE_REQUEST:
+		message = &APF_SERVICE_REQUEST_MESSAGE{}
+	case APF_SERVICE_ACCEPT:
+		message = &APF_SERVICE_ACCEPT_MESSAGE{}
+	case APF_USERAUTH_REQUEST:
+		message = &APF_USERAUTH_REQUEST_MESSAGE{}
+	case APF_USERAUTH_FAILURE:
+		message = &APF_USERAUTH_FAILURE_MESSAGE{}
+	case APF_USERAUTH_SUCCESS:
+		message = &APF_USERAUTH_SUCCESS_MESSAGE{}
+	case APF_GLOBAL_REQUEST:
+		message = &APF_GLOBAL_REQUEST_MESSAGE{}
+	case APF_REQUEST_SUCCESS:
+		if len(data) == 1 {
+			message = &APF_REQUEST_SUCCESS_MESSAGE{}
+		} else {
+			message = &APF_TCP_FORWARD_REPLY_MESSAGE{}
+		}
+	case APF_REQUEST_FAILURE:
+		message = &APF_REQUEST_FAILURE_MESSAGE{}
+	case APF_CHANNEL_OPEN:
+		message = &APF_CHANNEL_OPEN_MESSAGE{}
+	case APF_CHANNEL_OPEN_CONFIRMATION:
+		message = &APF_CHANNEL_OPEN_CONFIRMATION_MESSAGE{}
+	case APF_CHANNEL_OPEN_FAILURE:
+		message = &APF_CHANNEL_OPEN_FAILURE_MESSAGE{}
+	case APF_CHANNEL_WINDOW_ADJUST:
+		message = &APF_CHANNEL_WINDOW_ADJUST_MESSAGE{}
+	case APF_CHANNEL_DATA:
+		message = &APF_CHANNEL_DATA_MESSAGE{}
+	case APF_CHANNEL_CLOSE:
+		message = &APF_CHANNEL_CLOSE_MESSAGE{}
+	case APF_PROTOCOLVERSION:
+		message = &APF_PROTOCOL_VERSION_MESSAGE{}
+	case APF_KEEPALIVE_REQUEST:
+		message = &APF_KEEPALIVE_REQUEST_MESSAGE{}
+	case APF_KEEPALIVE_REPLY:
+		message = &APF_KEEPALIVE_REPLY_MESSAGE{}
+	case APF_KEEPALIVE_OPTIONS_REQUEST:
+		message = &APF_KEEPALIVE_OPTIONS_REQUEST_MESSAGE{}
+	case APF_KEEPALIVE_OPTIONS_REPLY:
+		message = &APF_KEEPALIVE_OPTIONS_REPLY_MESSAGE{}
+	default:
+		return nil, fmt.Errorf("%w: %d", ErrUnknownMessageType, data[0])
+	}
+	if err := message.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	// return the struct itself, matching the values returned by the message constructors
+	switch m := message.(type) {
+	case *APF_DISCONNECT_MESSAGE:
+		return *m, nil
+	case *APF_SERVICE_REQUEST_MESSAGE:
+		return *m, nil
+	case *APF_SERVICE_ACCEPT_MESSAGE:
+		return *m, nil
+	case *APF_USERAUTH_REQUEST_MESSAGE:
+		return *m, nil
+	case *APF_USERAUTH_FAILURE_MESSAGE:
+		return *m, nil
+	case *APF_USERAUTH_SUCCESS_MESSAGE:
+		return *m, nil
+	case *APF_GLOBAL_REQUEST_MESSAGE:
+		return *m, nil
+	case *APF_REQUEST_SUCCESS_MESSAGE:
+		return *m, nil
+	case *APF_TCP_FORWARD_REPLY_MESSAGE:
+		return *m, nil
+	case *APF_REQUEST_FAILURE_MESSAGE:
+		return *m, nil
+	case *APF_CHANNEL_OPEN_MESSAGE:
+		return *m, nil
+	case *APF_CHANNEL_OPEN_CONFIRMATION_MESSAGE:
+		return *m, nil
+	case *APF_CHANNEL_OPEN_FAILURE_MESSAGE:
+		return *m, nil
+	case *APF_CHANNEL_WINDOW_ADJUST_MESSAGE:
+		return *m, nil
+	case *APF_CHANNEL_DATA_MESSAGE:
+		return *m, nil
+	case *APF_CHANNEL_CLOSE_MESSAGE:
+		return *m, nil
+	case *APF_KEEPALIVE_REQUEST_MESSAGE:
+		return *m, nil
+	case *APF_KEEPALIVE_REPLY_MESSAGE:
+		return *m, nil
+	case *APF_KEEPALIVE_OPTIONS_REQUEST_MESSAGE:
+		return *m, nil
+	case *APF_KEEPALIVE_OPTIONS_REPLY_MESSAGE:
+		return *m, nil
+	default:
+		return *message.(*APF_PROTOCOL_VERSION_MESSAGE), nil
+	}
+}
+
+// MarshalBinary encodes the message type.
+func (m APF_MESSAGE_HEADER) MarshalBinary() ([]byte, error) {
+	return []byte{m.MessageType}, nil
+}
+
+// UnmarshalBinary reads the message type of any APF message. The rest of data is ignored.
+func (m *APF_MESSAGE_HEADER) UnmarshalBinary(data []byte) error {
+	if len(data) == 0 {
+		return fmt.Errorf("%w: APF_MESSAGE_HEADER.MessageType needs 1 byte", ErrShortMessage)
+	}
+	m.MessageType = data[0]
+	return nil
+}
+
+// MarshalBinary encodes the message type and request string.
+func (m APF_GENERIC_HEADER) MarshalBinary() ([]byte, error) {
+	e := encoder{}
+	e.byte(m.MessageType)
+	e.string(m.String)
+	return e.result()
+}
+
+// UnmarshalBinary reads the message type and request string that start global requests. The rest of data is ignored.
+func (m *APF_GENERIC_HEADER) UnmarshalBinary(data []byte) error {
+	_, err := m.decode(data)
+	return err
+}
+
+// decode reads the header and returns the bytes that follow it.
+func (m *APF_GENERIC_HEADER) decode(data []byte) ([]byte, error) {
+	d := &decoder{name: "APF_GENERIC_HEADER", data: data}
+	messageType, err := d.byte("MessageType")
+	if err != nil {
+		return nil, err
+	}
+	length, s, err := d.string("String")
+	if err != nil {
+		return nil, err
+	}
+	m.MessageType, m.StringLength, m.String = messageType, length, s
+	return d.rest(), nil
+}
+
+// MarshalBinary encodes the global request header followed by its payload.
+func (m APF_GLOBAL_REQUEST_MESSAGE) MarshalBinary() ([]byte, error) {
+	header := m.APF_GENERIC_HEADER
+	header.MessageType = APF_GLOBAL_REQUEST
+	b, err := header.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return append(b, m.Payload...), nil
+}
+
+// UnmarshalBinary decodes a global request, keeping the request specific payload undecoded.
+func (m *APF_GLOBAL_REQUEST_MESSAGE) UnmarshalBinary(data []byte) error {
+	header := APF_GENERIC_HEADER{}
+	payload, err := header.decode(data)
+	if err != nil {
+		return err
+	}
+	if header.MessageType != APF_GLOBAL_REQUEST {
+		return fmt.Errorf("%w: APF_GLOBAL_REQUEST_MESSAGE expects %d, got %d", ErrUnexpectedMessageType, APF_GLOBAL_REQUEST, header.MessageType)
+	}
+	if len(payload) == 0 {
+		return fmt.Errorf("%w: APF_GLOBAL_REQUEST_MESSAGE.WantReply needs 1 byte", ErrShortMessage)
+	}
+	m.APF_GENERIC_HEADER = header
+	m.Payload = append([]byte(nil), payload...)
+	return nil
+}
+
+// TcpForwardRequest decodes the payload of a tcpip-forward or cancel-tcpip-forward request.
+func (m APF_GLOBAL_REQUEST_MESSAGE) TcpForwardRequest() (APF_TCP_FORWARD_REQUEST, error) {
+	request := APF_TCP_FORWARD_REQUEST{}
+	err := request.UnmarshalBinary(m.Payload)
+	return request, err
+}
+
+// UdpSendToRequest decodes the payload of a udp-send-to request.
+func (m APF_GLOBAL_REQUEST_MESSAGE) UdpSendToRequest() (APF_UDP_SEND_TO_REQUEST, error) {
+	request := APF_UDP_SEND_TO_REQUEST{}
+	err := request.UnmarshalBinary(m.Payload)
+	return request, err
+}
+
+// MarshalBinary encodes the payload that follows the header of a udp-send-to global request.
+func (m APF_UDP_SEND_TO_REQUEST) MarshalBinary() ([]byte, error) {
+	e := encoder{}
+	e.byte(m.WantReply)
+	e.string(m.Address)
+	e.uint32(m.Port)
+	e.string(m.OriginatorAddress)
+	e.uint32(m.OriginatorPort)
+	e.string(string(m.Data))
+	return e.result()
+}
+
+// UnmarshalBinary decodes the payload that follows the header of a udp-send-to global request.
+func (m *APF_UDP_SEND_TO_REQUEST) UnmarshalBinary(data []byte) error {
+	d := &decoder{name: "APF_UDP_SEND_TO_REQUEST", data: data}
+	wantReply, err := d.byte("WantReply")
+	if err != nil {
+		return err
+	}
+	addressLength, address, err := d.string("Address")
+	if err != nil {
+		return err
+	}
+	port, err := d.uint32("Port")
+	if err != nil {
+		return err
+	}
+	originatorLength, originator, err := d.string("OriginatorAddress")
+	if err != nil {
+		return err
+	}
+	originatorPort, err := d.uint32("OriginatorPort")
+	if err != nil {
+		return err
+	}
+	dataLength, datagram, err := d.string("Data")
+	if err != nil {
+		return err
+	}
+	if err := d.end(); err != nil {
+		return err
+	}
+	*m = APF_UDP_SEND_TO_REQUEST{
+		WantReply:               wantReply,
+		AddressLength:           addressLength,
+		Address:                 address,
+		Port:                    port,
+		OriginatorAddressLength: originatorLength,
+		OriginatorAddress:       originator,
+		OriginatorPort:          originatorPort,
+		DataLength:              dataLength,
+		Data:                    []byte(datagram),
+	}
+	return nil
+}
+
+// MarshalBinary encodes the payload that follows the header of a tcpip-forward global request.
+func (m APF_TCP_FORWARD_REQUEST) MarshalBinary() ([]byte, error) {
+	e := encoder{}
+	e.byte(m.WantReply)
+	e.string(m.Address)
+	e.uint32(m.Port)
+	return e.result()
+}
+
+// UnmarshalBinary decodes the payload that follows the header of a tcpip-forward global request.
+func (m *APF_TCP_FORWARD_REQUEST) UnmarshalBinary(data []byte) error {
+	d := &decoder{name: "APF_TCP_FORWARD_REQUEST", data: data}
+	wantReply, err := d.byte("WantReply")
+	if err != nil {
+		return err
+	}
+	length, address, err := d.string("Address")
+	if err != nil {
+		return err
+	}
+	port, err := d.uint32("Port")
+	if err != nil {
+		return err
+	}
+	if err := d.end(); err != nil {
+		return err
+	}
+	*m = APF_TCP_FORWARD_REQUEST{WantReply: wantReply, AddressLength: length, Address: address, Port: port}
+	return nil
+}
+
+// MarshalBinary encodes the message.
+func (m APF_TCP_FORWARD_REPLY_MESSAGE) MarshalBinary() ([]byte, error) {
+	e := encoder{}
+	e.byte(APF_REQUEST_SUCCESS)
+	e.uint32(m.PortBound)
+	return e.result()
+}
+
+// UnmarshalBinary decodes the message.
+func (m *APF_TCP_FORWARD_REPLY_MESSAGE) UnmarshalBinary(data []byte) error {
+	d, err := newDecoder("APF_TCP_FORWARD_REPLY_MESSAGE", data, APF_REQUEST_SUCCESS)
+	if err != nil {
+		return err
+	}
+	port, err := d.uint32("PortBound")
+	if err != nil {
+		return err
+	}
+	if err := d.end(); err != nil {
+		return err
+	}
+	*m = APF_TCP_FORWARD_REPLY_MESSAGE{MessageType: APF_REQUEST_SUCCESS, PortBound: port}
+	return nil
+}
+
+// MarshalBinary encodes the message.
+func (m APF_REQUEST_SUCCESS_MESSAGE) MarshalBinary() ([]byte, error) {
+	return []byte{APF_REQUEST_SUCCESS}, nil
+}
+
+// UnmarshalBinary decodes the message.
+func (m *APF_REQUEST_SUCCESS_MESSAGE) UnmarshalBinary(data []byte) error {
+	d, err := newDecoder("APF_REQUEST_SUCCESS_MESSAGE", data, APF_REQUEST_SUCCESS)
+	if err != nil {
+		return err
+	}
+	m.MessageType = APF_REQUEST_SUCCESS
+	return d.end()
+}
+
+// MarshalBinary encodes the message.
+func (m APF_REQUEST_FAILURE_MESSAGE) MarshalBinary() ([]byte, error) {
+	return []byte{APF_REQUEST_FAILURE}, nil
+}
+
+// UnmarshalBinary decodes the message.
+func (m *APF_REQUEST_FAILURE_MESSAGE) UnmarshalBinary(data []byte) error {
+	d, err := newDecoder("APF_REQUEST_FAILURE_MESSAGE", data, APF_REQUEST_FAILURE)
+	if err != nil {
+		return err
+	}
+	m.MessageType = APF_REQUEST_FAILURE
+	return d.end()
+}
+
+// MarshalBinary encodes the message.
+func (m APF_CHANNEL_OPEN_CONFIRMATION_MESSAGE) MarshalBinary() ([]byte, error) {
+	e := encoder{}
+	e.byte(APF_CHANNEL_OPEN_CONFIRMATION)
+	e.uint32(m.RecipientChannel)
+	e.uint32(m.SenderChannel)
+	e.uint32(m.InitialWindowSize)
+	e.uint32(m.Reserved)
+	return e.result()
+}
+
+// UnmarshalBinary decodes the message.
+func (m *APF_CHANNEL_OPEN_CONFIRMATION_MESSAGE) UnmarshalBinary(data []byte) error {
+	d, err := newDecoder("APF_CHANNEL_OPEN_CONFIRMATION_MESSAGE", data, APF_CHANNEL_OPEN_CONFIRMATION)
+	if err != nil {
+		return err
+	}
+	values, err := d.uint32s("RecipientChannel", "SenderChannel", "InitialWindowSize", "Reserved")
+	if err != nil {
+		return err
+	}
+	if err := d.end(); err != nil {
+		return err
+	}
+	*m = APF_CHANNEL_OPEN_CONFIRMATION_MESSAGE{
+		MessageType:       APF_CHANNEL_OPEN_CONFIRMATION,
+		RecipientChannel:  values[0],
+		SenderChannel:     values[1],
+		InitialWindowSize: values[2],
+		Reserved:          values[3],
+	}
+	return nil
+}
+
+// MarshalBinary encodes the message.
+func (m APF_CHANNEL_OPEN_FAILURE_MESSAGE) MarshalBinary() ([]byte, error) {
+	e := encoder{}
+	e.byte(APF_CHANNEL_OPEN_FAILURE)
+	e.uint32(m.RecipientChannel)
+	e.uint32(m.ReasonCode)
+	e.uint32(m.Reserved)
+	e.uint32(m.Reserved2)
+	return e.result()
+}
+
+// UnmarshalBinary decodes the message.
+func (m *APF_CHANNEL_OPEN_FAILURE_MESSAGE) UnmarshalBinary(data []byte) error {
+	d, err := newDecoder("APF_CHANNEL_OPEN_FAILURE_MESSAGE", data, APF_CHANNEL_OPEN_FAILURE)
+	if err != nil {
+		return err
+	}
+	values, err := d.uint32s("RecipientChannel", "ReasonCode", "Reserved", "Reserved2")
+	if err != nil {
+		return err
+	}
+	if err := d.end(); err != nil {
+		return err
+	}
+	*m = APF_CHANNEL_OPEN_FAILURE_MESSAGE{
+		MessageType:      APF_CHANNEL_OPEN_FAILURE,
+		RecipientChannel: values[0],
+		ReasonCode:       values[1],
+		Reserved:         values[2],
+		Reserved2:        values[3],
+	}
+	return nil
+}
+
+// MarshalBinary encodes the message.
+func (m APF_CHANNEL_CLOSE_MESSAGE) MarshalBinary() ([]byte, error) {
+	e := encoder{}
+	e.byte(APF_CHANNEL_CLOSE)
+	e.uint32(m.RecipientChannel)
+	return e.result()
+}
+
+// UnmarshalBinary decodes the message.
+func (m *APF_CHANNEL_CLOSE_MESSAGE) UnmarshalBinary(data []byte) error {
+	d, err := newDecoder("APF_CHANNEL_CLOSE_MESSAGE", data, APF_CHANNEL_CLOSE)
+	if err != nil {
+		return err
+	}
+	recipient, err := d.uint32("RecipientChannel")
+	if err != nil {
+		return err
+	}
+	if err := d.end(); err != nil {
+		return err
+	}
+	*m = APF_CHANNEL_CLOSE_MESSAGE{MessageType: APF_CHANNEL_CLOSE, RecipientChannel: recipient}
+	return nil
+}
+
+// MarshalBinary encodes the message. DataLength is derived from Data.
+func (m APF_CHANNEL_DATA_MESSAGE) MarshalBinary() ([]byte, error) {
+	if uint64(len(m.Data)) > math.MaxUint32 {
+		return nil, ErrStringTooLong
+	}
+	e := encoder{buf: make([]byte, 0, 9+len(m.Data))}
+	e.byte(APF_CHANNEL_DATA)
+	e.uint32(m.RecipientChannel)
+	e.uint32(uint32(len(m.Data)))
+	e.bytes(m.Data)
+	return e.result()
+}
+
+// UnmarshalBinary decodes the message. Data refers to data rather than a copy of it.
+func (m *APF_CHANNEL_DATA_MESSAGE) UnmarshalBinary(data []byte) error {
+	d, err := newDecoder("APF_CHANNEL_DATA_MESSAGE", data, APF_CHANNEL_DATA)
+	if err != nil {
+		return err
+	}
+	recipient, err := d.uint32("RecipientChannel")
+	if err != nil {
+		return err
+	}
+	length, err := d.uint32("DataLength")
+	if err != nil {
+		return err
+	}
+	if uint64(length) != uint64(len(data)-d.offset) {
+		return fmt.Errorf("%w: APF_CHANNEL_DATA_MESSAGE.DataLength is %d but %d bytes follow", ErrLengthMismatch, length, len(data)-d.offset)
+	}
+	*m = APF_CHANNEL_DATA_MESSAGE{MessageType: APF_CHANNEL_DATA, RecipientChannel: recipient, DataLength: length, Data: d.rest()}
+	return nil
+}
+
+// MarshalBinary encodes the message.
+func (m APF_CHANNEL_WINDOW_ADJUST_MESSAGE) MarshalBinary() ([]byte, error) {
+	e := encoder{}
+	e.byte(APF_CHANNEL_WINDOW_ADJUST)
+	e.uint32(m.RecipientChannel)
+	e.uint32(m.BytesToAdd)
+	return e.result()
+}
+
+// UnmarshalBinary decodes the message.
+func (m *APF_CHANNEL_WINDOW_ADJUST_MESSAGE) UnmarshalBinary(data []byte) error {
+	d, err := newDecoder("APF_CHANNEL_WINDOW_ADJUST_MESSAGE", data, APF_CHANNEL_WINDOW_ADJUST)
+	if err != nil {
+		return err
+	}
+	values, err := d.uint32s("RecipientChannel", "BytesToAdd")
+	if err != nil {
+		return err
+	}
+	if err := d.end(); err != nil {
+		return err
+	}
+	*m = APF_CHANNEL_WINDOW_ADJUST_MESSAGE{MessageType: APF_CHANNEL_WINDOW_ADJUST, RecipientChannel: values[0], BytesToAdd: values[1]}
+	return nil
+}
+
+// MarshalBinary encodes the message.
+func (m APF_DISCONNECT_MESSAGE) MarshalBinary() ([]byte, error) {
+	e := encoder{}
+	e.byte(APF_DISCONNECT)
+	e.uint32(m.ReasonCode)
+	e.uint16(m.Reserved)
+	return e.result()
+}
+
+// UnmarshalBinary decodes the message.
+func (m *APF_DISCONNECT_MESSAGE) UnmarshalBinary(data []byte) error {
+	d, err := newDecoder("APF_DISCONNECT_MESSAGE", data, APF_DISCONNECT)
+	if err != nil {
+		return err
+	}
+	reason, err := d.uint32("ReasonCode")
+	if err != nil {
+		return err
+	}
+	reserved, err := d.uint16("Reserved")
+	if err != nil {
+		return err
+	}
+	if err := d.end(); err != nil {
+		return err
+	}
+	*m = APF_DISCONNECT_MESSAGE{MessageType: APF_DISCONNECT, ReasonCode: reason, Reserved: reserved}
+	return nil
+}
+
+// MarshalBinary encodes the message.
+func (m APF_SERVICE_REQUEST_MESSAGE) MarshalBinary() ([]byte, error) {
+	e := encoder{}
+	e.byte(APF_SERVICE_REQUEST)
+	e.string(m.ServiceName)
+	return e.result()
+}
+
+// UnmarshalBinary decodes the message.
+func (m *APF_SERVICE_REQUEST_MESSAGE) UnmarshalBinary(data []byte) error {
+	d, err := newDecoder("APF_SERVICE_REQUEST_MESSAGE", data, APF_SERVICE_REQUEST)
+	if err != nil {
+		return err
+	}
+	length, name, err := d.string("ServiceName")
+	if err != nil {
+		return err
+	}
+	if err := d.end(); err != nil {
+		return err
+	}
+	*m = APF_SERVICE_REQUEST_MESSAGE{MessageType: APF_SERVICE_REQUEST, ServiceNameLength: length, ServiceName: name}
+	return nil
+}
+
+// MarshalBinary encodes the message.
+func (m APF_SERVICE_ACCEPT_MESSAGE) MarshalBinary() ([]byte, error) {
+	e := encoder{}
+	e.byte(APF_SERVICE_ACCEPT)
+	e.string(m.ServiceName)
+	return e.result()
+}
+
+// UnmarshalBinary decodes the message.
+func (m *APF_SERVICE_ACCEPT_MESSAGE) UnmarshalBinary(data []byte) error {
+	d, err := newDecoder("APF_SERVICE_ACCEPT_MESSAGE", data, APF_SERVICE_ACCEPT)
+	if err != nil {
+		return err
+	}
+	length, name, err := d.string("ServiceName")
+	if err != nil {
+		return err
+	}
+	if err := d.end(); err != nil {
+		return err
+	}
+	*m = APF_SERVICE_ACCEPT_MESSAGE{MessageType: APF_SERVICE_ACCEPT, ServiceNameLength: length, ServiceName: name}
+	return nil
+}
+
+// MarshalBinary encodes the message.
+func (m APF_PROTOCOL_VERSION_MESSAGE) MarshalBinary() ([]byte, error) {
+	e := encoder{buf: make([]byte, 0, 93)}
+	e.byte(APF_PROTOCOLVERSION)
+	e.uint32(m.MajorVersion)
+	e.uint32(m.MinorVersion)
+	e.uint32(m.TriggerReason)
+	e.bytes(m.UUID[:])
+	e.bytes(m.Reserved[:])
+	return e.result()
+}
+
+// UnmarshalBinary decodes the message.
+func (m *APF_PROTOCOL_VERSION_MESSAGE) UnmarshalBinary(data []byte) error {
+	d, err := newDecoder("APF_PROTOCOL_VERSION_MESSAGE", data, APF_PROTOCOLVERSION)
+	if err != nil {
+		return err
+	}
+	values, err := d.uint32s("MajorVersion", "MinorVersion", "TriggerReason")
+	if err != nil {
+		return err
+	}
+	uuid, err := d.take("UUID", 16)
+	if err != nil {
+		return err
+	}
+	reserved, err := d.take("Reserved", 64)
+	if err != nil {
+		return err
+	}
+	if err := d.end(); err != nil {
+		return err
+	}
+	*m = APF_PROTOCOL_VERSION_MESSAGE{MessageType: APF_PROTOCOLVERSION, MajorVersion: values[0], MinorVersion: values[1], TriggerReason: values[2]}
+	copy(m.UUID[:], uuid)
+	copy(m.Reserved[:], reserved)
+	return nil
+}
+
+// MarshalBinary encodes the message.
+func (m APF_USERAUTH_SUCCESS_MESSAGE) MarshalBinary() ([]byte, error) {
+	return []byte{APF_USERAUTH_SUCCESS}, nil
+}
+
+// UnmarshalBinary decodes the message.
+func (m *APF_USERAUTH_SUCCESS_MESSAGE) UnmarshalBinary(data []byte) error {
+	d, err := newDecoder("APF_USERAUTH_SUCCESS_MESSAGE", data, APF_USERAUTH_SUCCESS)
+	if err != nil {
+		return err
+	}
+	m.MessageType = APF_USERAUTH_SUCCESS
+	return d.end()
+}
+
+// MarshalBinary encodes the message. The password fields are only written for the password method and MethodData for any other.
+func (m APF_USERAUTH_REQUEST_MESSAGE) MarshalBinary() ([]byte, error) {
+	e := encoder{}
+	e.byte(APF_USERAUTH_REQUEST)
+	e.string(m.Username)
+	e.string(m.ServiceName)
+	e.string(m.MethodName)
+	switch m.MethodName {
+	case APF_AUTH_PASSWORD:
+		e.byte(m.Reserved)
+		e.string(m.Password)
+	case APF_AUTH_NONE:
+	default:
+		e.bytes(m.MethodData)
+	}
+	return e.result()
+}
+
+// UnmarshalBinary decodes the message.
+func (m *APF_USERAUTH_REQUEST_MESSAGE) UnmarshalBinary(data []byte) error {
+	d, err := newDecoder("APF_USERAUTH_REQUEST_MESSAGE", data, APF_USERAUTH_REQUEST)
+	if err != nil {
+		return err
+	}
+	request := APF_USERAUTH_REQUEST_MESSAGE{MessageType: APF_USERAUTH_REQUEST}
+	if request.UsernameLength, request.Username, err = d.string("Username"); err != nil {
+		return err
+	}
+	if request.ServiceNameLength, request.ServiceName, err = d.string("ServiceName"); err != nil {
+		return err
+	}
+	if request.MethodNameLength, request.MethodName, err = d.string("MethodName"); err != nil {
+		return err
+	}
+	switch request.MethodName {
+	case APF_AUTH_PASSWORD:
+		if request.Reserved, err = d.byte("Reserved"); err != nil {
+			return err
+		}
+		if request.PasswordLength, request.Password, err = d.string("Password"); err != nil {
+			return err
+		}
+	case APF_AUTH_NONE:
+	default:
+		if rest := d.rest(); len(rest) > 0 {
+			request.MethodData = append([]byte(nil), rest...)
+		}
+	}
+	if err := d.end(); err != nil {
+		return err
+	}
+	*m = request
+	return nil
+}
+
+// MarshalBinary encodes the message.
+func (m APF_USERAUTH_FAILURE_MESSAGE) MarshalBinary() ([]byte, error) {
+	e := encoder{}
+	e.byte(APF_USERAUTH_FAILURE)
+	e.string(m.Authentications)
+	e.byte(m.PartialSuccess)
+	return e.result()
+}
+
+// UnmarshalBinary decodes the message.
+func (m *APF_USERAUTH_FAILURE_MESSAGE) UnmarshalBinary(data []byte) error {
+	d, err := newDecoder("APF_USERAUTH_FAILURE_MESSAGE", data, APF_USERAUTH_FAILURE)
+	if err != nil {
+		return err
+	}
+	length, authentications, err := d.string("Authentications")
+	if err != nil {
+		return err
+	}
+	partial, err := d.byte("PartialSuccess")
+	if err != nil {
+		return err
+	}
+	if err := d.end(); err != nil {
+		return err
+	}
+	*m = APF_USERAUTH_FAILURE_MESSAGE{MessageType: APF_USERAUTH_FAILURE, AuthenticationsLength: length, Authentications: authentications, PartialSuccess: partial}
+	return nil
+}
+
+// MarshalBinary encodes the message.
+func (m APF_KEEPALIVE_REQUEST_MESSAGE) MarshalBinary() ([]byte, error) {
+	e := encoder{}
+	e.byte(APF_KEEPALIVE_REQUEST)
+	e.uint32(m.Cookie)
+	return e.result()
+}
+
+// UnmarshalBinary decodes the message.
+func (m *APF_KEEPALIVE_REQUEST_MESSAGE) UnmarshalBinary(data []byte) error {
+	cookie, err := decodeCookie("APF_KEEPALIVE_REQUEST_MESSAGE", data, APF_KEEPALIVE_REQUEST)
+	if err != nil {
+		return err
+	}
+	*m = APF_KEEPALIVE_REQUEST_MESSAGE{MessageType: APF_KEEPALIVE_REQUEST, Cookie: cookie}
+	return nil
+}
+
+// MarshalBinary encodes the message.
+func (m APF_KEEPALIVE_REPLY_MESSAGE) MarshalBinary() ([]byte, error) {
+	e := encoder{}
+	e.byte(APF_KEEPALIVE_REPLY)
+	e.uint32(m.Cookie)
+	return e.result()
+}
+
+// UnmarshalBinary decodes the message.
+func (m *APF_KEEPALIVE_REPLY_MESSAGE) UnmarshalBinary(data []byte) error {
+	cookie, err := decodeCookie("APF_KEEPALIVE_REPLY_MESSAGE", data, APF_KEEPALIVE_REPLY)
+	if err != nil {
+		return err
+	}
+	*m = APF_KEEPALIVE_REPLY_MESSAGE{MessageType: APF_KEEPALIVE_REPLY, Cookie: cookie}
+	return nil
+}
+
+func decodeCookie(name string, data []byte, messageType byte) (uint32, error) {
+	d, err := newDecoder(name, data, messageType)
+	if err != nil {
+		return 0, err
+	}
+	cookie, err := d.uint32("Cookie")
+	if err != nil {
+		return 0, err
+	}
+	return cookie, d.end()
+}
+
+// MarshalBinary encodes the message.
+func (m APF_KEEPALIVE_OPTIONS_REQUEST_MESSAGE) MarshalBinary() ([]byte, error) {
+	e := encoder{}
+	e.byte(APF_KEEPALIVE_OPTIONS_REQUEST)
+	e.uint32(m.KeepaliveInterval)
+	e.uint32(m.ReadTimeout)
+	return e.result()
+}
+
+// UnmarshalBinary decodes the message.
+func (m *APF_KEEPALIVE_OPTIONS_REQUEST_MESSAGE) UnmarshalBinary(data []byte) error {
+	values, err := decodeKeepAliveOptions("APF_KEEPALIVE_OPTIONS_REQUEST_MESSAGE", data, APF_KEEPALIVE_OPTIONS_REQUEST)
+	if err != nil {
+		return err
+	}
+	*m = APF_KEEPALIVE_OPTIONS_REQUEST_MESSAGE{MessageType: APF_KEEPALIVE_OPTIONS_REQUEST, KeepaliveInterval: values[0], ReadTimeout: values[1]}
+	return nil
+}
+
+// MarshalBinary encodes the message.
+func (m APF_KEEPALIVE_OPTIONS_REPLY_MESSAGE) MarshalBinary() ([]byte, error) {
+	e := encoder{}
+	e.byte(APF_KEEPALIVE_OPTIONS_REPLY)
+	e.uint32(m.KeepaliveInterval)
+	e.uint32(m.ReadTimeout)
+	return e.result()
+}
+
+// UnmarshalBinary decodes the message.
+func (m *APF_KEEPALIVE_OPTIONS_REPLY_MESSAGE) UnmarshalBinary(data []byte) error {
+	values, err := decodeKeepAliveOptions("APF_KEEPALIVE_OPTIONS_REPLY_MESSAGE", data, APF_KEEPALIVE_OPTIONS_REPLY)
+	if err != nil {
+		return err
+	}
+	*m = APF_KEEPALIVE_OPTIONS_REPLY_MESSAGE{MessageType: APF_KEEPALIVE_OPTIONS_REPLY, KeepaliveInterval: values[0], ReadTimeout: values[1]}
+	return nil
+}
+
+func decodeKeepAliveOptions(name string, data []byte, messageType byte) ([]uint32, error) {
+	d, err := newDecoder(name, data, messageType)
+	if err != nil {
+		return nil, err
+	}
+	values, err := d.uint32s("KeepaliveInterval", "ReadTimeout")
+	if err != nil {
+		return nil, err
+	}
+	return values, d.end()
+}
+
+// MarshalBinary encodes the message.
+func (m APF_CHANNEL_OPEN_MESSAGE) MarshalBinary() ([]byte, error) {
+	e := encoder{}
+	e.byte(APF_CHANNEL_OPEN)
+	e.string(m.ChannelType)
+	e.uint32(m.SenderChannel)
+	e.uint32(m.InitialWindowSize)
+	e.uint32(m.Reserved)
+	e.string(m.ConnectedAddress)
+	e.uint32(m.ConnectedPort)
+	e.string(m.OriginatorIPAddress)
+	e.uint32(m.OriginatorPort)
+	return e.result()
+}
+
+// UnmarshalBinary decodes the message.
+func (m *APF_CHANNEL_OPEN_MESSAGE) UnmarshalBinary(data []byte) error {
+	d, err := newDecoder("APF_CHANNEL_OPEN_MESSAGE", data, APF_CHANNEL_OPEN)
+	if err != nil {
+		return err
+	}
+	open := APF_CHANNEL_OPEN_MESSAGE{MessageType: APF_CHANNEL_OPEN}
+	if open.ChannelTypeLength, open.ChannelType, err = d.string("ChannelType"); err != nil {
+		return err
+	}
+	values, err := d.uint32s("SenderChannel", "InitialWindowSize", "Reserved")
+	if err != nil {
+		return err
+	}
+	open.SenderChannel, open.InitialWindowSize, open.Reserved = values[0], values[1], values[2]
+	if open.ConnectedAddressLength, open.ConnectedAddress, err = d.string("ConnectedAddress"); err != nil {
+		return err
+	}
+	if open.ConnectedPort, err = d.uint32("ConnectedPort"); err != nil {
+		return err
+	}
+	if open.OriginatorIPAddressLength, open.OriginatorIPAddress, err = d.string("OriginatorIPAddress"); err != nil {
+		return err
+	}
+	if open.OriginatorPort, err = d.uint32("OriginatorPort"); err != nil {
+		return err
+	}
+	if err := d.end(); err != nil {
+		return err
+	}
+	*m = open
+	return nil
+}
+
+func (d *decoder) uint32s(fields ...string) ([]uint32, error) {
+	values := make([]uint32, len(fields))
+	for i, field := range fields {
+		v, err := d.uint32(field)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = v
+	}
+	return values, nil
+}
@@ -0,0 +1,351 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package apf
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func codecMessages() []interface{} {
+	tcpForward, _ := APF_TCP_FORWARD_REQUEST{WantReply: 1, Address: "0.0.0.0", Port: 16993}.MarshalBinary()
+	udpSendTo, _ := APF_UDP_SEND_TO_REQUEST{Address: "192.168.0.1", Port: 162, OriginatorAddress: "192.168.0.10", OriginatorPort: 1024, Data: []byte{0x30}}.MarshalBinary()
+	return []interface{}{
+		APF_DISCONNECT_MESSAGE{MessageType: APF_DISCONNECT, ReasonCode: APF_DISCONNECT_BY_APPLICATION},
+		APF_SERVICE_REQUEST_MESSAGE{MessageType: APF_SERVICE_REQUEST, ServiceNameLength: 18, ServiceName: APF_SERVICE_PFWD},
+		ServiceAccept(APF_SERVICE_AUTH),
+		APF_USERAUTH_SUCCESS_MESSAGE{MessageType: APF_USERAUTH_SUCCESS},
+		APF_GLOBAL_REQUEST_MESSAGE{
+			APF_GENERIC_HEADER: APF_GENERIC_HEADER{MessageType: APF_GLOBAL_REQUEST, StringLength: 13, String: APF_GLOBAL_REQUEST_STR_TCP_FORWARD_REQUEST},
+			Payload:            tcpForward,
+		},
+		APF_GLOBAL_REQUEST_MESSAGE{
+			APF_GENERIC_HEADER: APF_GENERIC_HEADER{MessageType: APF_GLOBAL_REQUEST, StringLength: 25, String: APF_GLOBAL_REQUEST_STR_UDP_SEND_TO},
+			Payload:            udpSendTo,
+		},
+		APF_REQUEST_SUCCESS_MESSAGE{MessageType: APF_REQUEST_SUCCESS},
+		APF_REQUEST_FAILURE_MESSAGE{MessageType: APF_REQUEST_FAILURE},
+		TcpForwardReplySuccess(16992),
+		APF_CHANNEL_OPEN_MESSAGE{
+			MessageType:               APF_CHANNEL_OPEN,
+			ChannelTypeLength:         12,
+			ChannelType:               APF_OPEN_CHANNEL_REQUEST_DIRECT,
+			SenderChannel:             7,
+			InitialWindowSize:         LME_RX_WINDOW_SIZE,
+			Reserved:                  0xFFFFFFFF,
+			ConnectedAddressLength:    11,
+			ConnectedAddress:          "192.168.1.2",
+			ConnectedPort:             16993,
+			OriginatorIPAddressLength: 9,
+			OriginatorIPAddress:       "127.0.0.1",
+			OriginatorPort:            50000,
+		},
+		ChannelOpenReplySuccess(1, 2),
+		ChannelOpenReplyFailure(1, OPEN_FAILURE_REASON_CONNECT_FAILED),
+		ChannelWindowAdjust(2, 1024),
+		ChannelData(2, []byte("hello")),
+		ChannelClose(2),
+		ProtocolVersion(1, 0, APF_TRIGGER_REASON_USER_INITIATED_REQUEST),
+		APF_USERAUTH_REQUEST_MESSAGE{
+			MessageType:       APF_USERAUTH_REQUEST,
+			UsernameLength:    5,
+			Username:          "admin",
+			ServiceNameLength: 18,
+			ServiceName:       APF_SERVICE_PFWD,
+			MethodNameLength:  8,
+			MethodName:        APF_AUTH_PASSWORD,
+			PasswordLength:    6,
+			Password:          "secret",
+		},
+		APF_USERAUTH_REQUEST_MESSAGE{MessageType: APF_USERAUTH_REQUEST, ServiceNameLength: 18, ServiceName: APF_SERVICE_PFWD, MethodNameLength: 4, MethodName: APF_AUTH_NONE},
+		APF_USERAUTH_REQUEST_MESSAGE{MessageType: APF_USERAUTH_REQUEST, MethodNameLength: 9, MethodName: "publickey", MethodData: []byte{0x00}},
+		UserAuthFailure(),
+		KeepAliveRequest(42),
+		KeepAliveReply(42),
+		KeepAliveOptionsRequest(30, 90),
+		APF_KEEPALIVE_OPTIONS_REPLY_MESSAGE{MessageType: APF_KEEPALIVE_OPTIONS_REPLY, KeepaliveInterval: 30, ReadTimeout: 90},
+	}
+}
+
+func TestMarshalUnmarshalRoundTrip(t *testing.T) {
+	for _, message := range codecMessages() {
+		data, err := Marshal(message)
+		assert.NoError(t, err)
+		result, err := Unmarshal(data)
+		assert.NoError(t, err)
+		assert.Equal(t, message, result)
+		if _, ok := message.(APF_GLOBAL_REQUEST_MESSAGE); ok {
+			// the payload of a global request is decoded on demand
+			continue
+		}
+		if request, ok := message.(APF_USERAUTH_REQUEST_MESSAGE); ok && request.MethodData != nil {
+			// so is the data of unknown authentication methods
+			continue
+		}
+		_, err = Unmarshal(data[:len(data)-1])
+		assert.Error(t, err, "%T truncated by one byte", message)
+	}
+}
+
+func TestMarshalChannelOpen(t *testing.T) {
+	data, err := Marshal(APF_CHANNEL_OPEN_MESSAGE{ChannelType: "abc", ConnectedAddress: "h", OriginatorIPAddress: ""})
+	assert.NoError(t, err)
+	expected := []byte{APF_CHANNEL_OPEN,
+		0x00, 0x00, 0x00, 0x03, 'a', 'b', 'c',
+		0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x01, 'h',
+		0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00}
+	assert.Equal(t, expected, data)
+}
+
+func TestMarshalUnknownType(t *testing.T) {
+	_, err := Marshal(42)
+	assert.ErrorIs(t, err, ErrUnknownMessageType)
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		err  error
+	}{
+		{"empty", nil, ErrShortMessage},
+		{"unknown type", []byte{0x02}, ErrUnknownMessageType},
+		{"short close", []byte{APF_CHANNEL_CLOSE, 0x00}, ErrShortMessage},
+		{"data length too large", []byte{APF_CHANNEL_DATA, 0, 0, 0, 0, 0, 0, 0, 2, 0x01}, ErrLengthMismatch},
+		{"data length too small", []byte{APF_CHANNEL_DATA, 0, 0, 0, 0, 0, 0, 0, 0, 0x01}, ErrLengthMismatch},
+		{"huge channel type", []byte{APF_CHANNEL_OPEN, 0xFF, 0xFF, 0xFF, 0xFF}, ErrLengthMismatch},
+		{"global request without want reply", []byte{APF_GLOBAL_REQUEST, 0, 0, 0, 1, 'x'}, ErrShortMessage},
+		{"trailing success data", []byte{APF_REQUEST_SUCCESS, 0, 0, 0, 0, 0}, ErrTrailingData},
+		{"trailing userauth data", []byte{APF_USERAUTH_SUCCESS, 0}, ErrTrailingData},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := Unmarshal(test.data)
+			assert.ErrorIs(t, err, test.err)
+		})
+	}
+}
+
+func TestUnmarshalBinaryUnexpectedType(t *testing.T) {
+	message := APF_CHANNEL_CLOSE_MESSAGE{}
+	err := message.UnmarshalBinary([]byte{APF_CHANNEL_DATA, 0, 0, 0, 0})
+	assert.ErrorIs(t, err, ErrUnexpectedMessageType)
+}
+
+func TestTcpForwardRequest(t *testing.T) {
+	request := APF_GLOBAL_REQUEST_MESSAGE{Payload: []byte{0x01, 0x00, 0x00, 0x00, 0x01, 'a', 0x00, 0x00, 0x42, 0x60}}
+	forward, err := request.TcpForwardRequest()
+	assert.NoError(t, err)
+	assert.Equal(t, APF_TCP_FORWARD_REQUEST{WantReply: 1, AddressLength: 1, Address: "a", Port: 16992}, forward)
+
+	request.Payload = request.Payload[:6]
+	_, err = request.TcpForwardRequest()
+	assert.ErrorIs(t, err, ErrShortMessage)
+}
+
+func TestUdpSendToRequest(t *testing.T) {
+	payload := []byte{0x00, 0x00, 0x00, 0x00, 0x01, 'a', 0x00, 0x00, 0x00, 0xA2, 0x00, 0x00, 0x00, 0x01, 'b', 0x00, 0x00, 0x04, 0x00, 0x00, 0x00, 0x00, 0x02, 0x30, 0x00}
+	request := APF_GLOBAL_REQUEST_MESSAGE{Payload: payload}
+	datagram, err := request.UdpSendToRequest()
+	assert.NoError(t, err)
+	assert.Equal(t, APF_UDP_SEND_TO_REQUEST{AddressLength: 1, Address: "a", Port: 162, OriginatorAddressLength: 1, OriginatorAddress: "b", OriginatorPort: 1024, DataLength: 2, Data: []byte{0x30, 0x00}}, datagram)
+	encoded, err := datagram.MarshalBinary()
+	assert.NoError(t, err)
+	assert.Equal(t, payload, encoded)
+
+	request.Payload = payload[:len(payload)-1]
+	_, err = request.UdpSendToRequest()
+	assert.ErrorIs(t, err, ErrLengthMismatch)
+}
+
+func TestReadMessage(t *testing.T) {
+	stream := bytes.Buffer{}
+	expected := [][]byte{}
+	for _, message := range codecMessages() {
+		if request, ok := message.(APF_USERAUTH_REQUEST_MESSAGE); ok && request.MethodData != nil {
+			continue
+		}
+		if _, ok := message.(APF_REQUEST_SUCCESS_MESSAGE); ok {
+			continue
+		}
+		data, err := Marshal(message)
+		assert.NoError(t, err)
+		stream.Write(data)
+		expected = append(expected, data)
+	}
+	for _, data := range expected {
+		message, err := ReadMessage(&stream)
+		assert.NoError(t, err)
+		assert.Equal(t, data, message)
+	}
+	_, err := ReadMessage(&stream)
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestReadMessageErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		err  error
+	}{
+		{"truncated", []byte{APF_CHANNEL_CLOSE, 0x00}, io.ErrUnexpectedEOF},
+		{"unknown type", []byte{0x02}, ErrUnknownMessageType},
+		{"too large", []byte{APF_CHANNEL_DATA, 0, 0, 0, 0, 0x7F, 0xFF, 0xFF, 0xFF}, ErrMessageTooLarge},
+		{"unknown global request", []byte{APF_GLOBAL_REQUEST, 0, 0, 0, 1, 'x', 0}, ErrUnknownMessageType},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := ReadMessage(bytes.NewReader(test.data))
+			assert.ErrorIs(t, err, test.err)
+		})
+	}
+}
+
+func TestReadMessageAuthenticationMethods(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		methodData []byte
+	}{
+		{"unknown method without data", "x", nil},
+		{"publickey without signature", "publickey", []byte{0, 0, 0, 0, 1, 'a', 0, 0, 0, 1, 'k'}},
+		{"publickey with signature", "publickey", []byte{1, 0, 0, 0, 1, 'a', 0, 0, 0, 1, 'k', 0, 0, 0, 1, 's'}},
+		{"keyboard-interactive", "keyboard-interactive", []byte{0, 0, 0, 0, 0, 0, 0, 0}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data, err := Marshal(APF_USERAUTH_REQUEST_MESSAGE{Username: "user", ServiceName: APF_SERVICE_PFWD, MethodName: test.method, MethodData: test.methodData})
+			assert.NoError(t, err)
+			keepalive, _ := Marshal(APF_KEEPALIVE_REQUEST_MESSAGE{MessageType: APF_KEEPALIVE_REQUEST, Cookie: 1})
+			stream := bytes.NewReader(append(append([]byte(nil), data...), keepalive...))
+			message, err := ReadMessage(stream)
+			assert.NoError(t, err)
+			assert.Equal(t, data, message)
+			decoded, err := Unmarshal(message)
+			assert.NoError(t, err)
+			assert.Equal(t, test.methodData, decoded.(APF_USERAUTH_REQUEST_MESSAGE).MethodData)
+			message, err = ReadMessage(stream)
+			assert.NoError(t, err)
+			assert.Equal(t, keepalive, message)
+		})
+	}
+}
+
+func TestReaderFramesRequestSuccessByRequest(t *testing.T) {
+	forward, _ := APF_TCP_FORWARD_REQUEST{WantReply: 1, Address: "0.0.0.0", Port: 16992}.MarshalBinary()
+	request := func(name string) []byte {
+		data, err := Marshal(APF_GLOBAL_REQUEST_MESSAGE{APF_GENERIC_HEADER: APF_GENERIC_HEADER{String: name}, Payload: forward})
+		assert.NoError(t, err)
+		return data
+	}
+	bound, _ := Marshal(TcpForwardReplySuccess(16992))
+	success, _ := Marshal(APF_REQUEST_SUCCESS_MESSAGE{MessageType: APF_REQUEST_SUCCESS})
+	failure, _ := Marshal(APF_REQUEST_FAILURE_MESSAGE{MessageType: APF_REQUEST_FAILURE})
+	channelClose, _ := Marshal(APF_CHANNEL_CLOSE_MESSAGE{MessageType: APF_CHANNEL_CLOSE, RecipientChannel: 7})
+
+	stream := bytes.Buffer{}
+	for _, data := range [][]byte{bound, success, failure, success, channelClose} {
+		stream.Write(data)
+	}
+	r := NewReader(&stream)
+	r.Sent(request(APF_GLOBAL_REQUEST_STR_TCP_FORWARD_REQUEST))
+	r.Sent(request(APF_GLOBAL_REQUEST_STR_TCP_FORWARD_CANCEL_REQUEST))
+	r.Sent(request(APF_GLOBAL_REQUEST_STR_TCP_FORWARD_REQUEST))
+	r.Sent(request(APF_GLOBAL_REQUEST_STR_TCP_FORWARD_CANCEL_REQUEST))
+	for _, expected := range [][]byte{bound, success, failure, success, channelClose} {
+		message, err := r.ReadMessage()
+		assert.NoError(t, err)
+		assert.Equal(t, expected, message)
+	}
+}
+
+func FuzzReadMessage(f *testing.F) {
+	for _, message := range codecMessages() {
+		data, _ := Marshal(message)
+		f.Add(data)
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		message, err := ReadMessage(bytes.NewReader(data))
+		if err != nil {
+			return
+		}
+		if !bytes.HasPrefix(data, message) {
+			t.Fatalf("read %x from %x", message, data)
+		}
+	})
+}
+
+func FuzzUnmarshal(f *testing.F) {
+	for _, message := range codecMessages() {
+		data, _ := Marshal(message)
+		f.Add(data)
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		message, err := Unmarshal(data)
+		if err != nil {
+			return
+		}
+		// anything accepted is a complete message and encodes back to the same bytes
+		encoded, err := Marshal(message)
+		if err != nil {
+			t.Fatalf("marshal %T: %v", message, err)
+		}
+		if !bytes.Equal(encoded, data) {
+			t.Fatalf("%T encoded to %x, decoded from %x", message, encoded, data)
+		}
+	})
+}
+
+func FuzzTcpForwardRequest(f *testing.F) {
+	f.Add([]byte{0x01, 0x00, 0x00, 0x00, 0x01, 'a', 0x00, 0x00, 0x42, 0x60})
+	f.Fuzz(func(t *testing.T, data []byte) {
+		request := APF_GLOBAL_REQUEST_MESSAGE{Payload: data}
+		forward, err := request.TcpForwardRequest()
+		if err != nil {
+			return
+		}
+		encoded, err := forward.MarshalBinary()
+		if err != nil || !bytes.Equal(encoded, data) {
+			t.Fatalf("tcp forward request encoded to %x, decoded from %x", encoded, data)
+		}
+	})
+}
+
+func FuzzUdpSendToRequest(f *testing.F) {
+	f.Add([]byte{0x00, 0x00, 0x00, 0x00, 0x01, 'a', 0x00, 0x00, 0x00, 0xA2, 0x00, 0x00, 0x00, 0x01, 'b', 0x00, 0x00, 0x04, 0x00, 0x00, 0x00, 0x00, 0x02, 0x30, 0x00})
+	f.Fuzz(func(t *testing.T, data []byte) {
+		request := APF_GLOBAL_REQUEST_MESSAGE{Payload: data}
+		datagram, err := request.UdpSendToRequest()
+		if err != nil {
+			return
+		}
+		encoded, err := datagram.MarshalBinary()
+		if err != nil || !bytes.Equal(encoded, data) {
+			t.Fatalf("udp send to request encoded to %x, decoded from %x", encoded, data)
+		}
+	})
+}
+
+func FuzzProcess(f *testing.F) {
+	for _, message := range codecMessages() {
+		data, _ := Marshal(message)
+		f.Add(data)
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		if len(data) > 0 && (data[0] == APF_CHANNEL_OPEN_CONFIRMATION || data[0] == APF_CHANNEL_OPEN_FAILURE || data[0] == APF_CHANNEL_DATA) {
+			// these report to the session channels and timer, which are covered by the processor tests
+			return
+		}
+		_, _ = Process(data, &Session{})
+	})
+}
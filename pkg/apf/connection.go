@@ -0,0 +1,692 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package apf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Errors returned by a Connection and its channels.
+var (
+	ErrConnectionClosed  = errors.New("apf: connection closed")
+	ErrChannelClosed     = errors.New("apf: channel closed by peer")
+	ErrChannelOpenFailed = errors.New("apf: channel open failed")
+	ErrWindowExceeded    = errors.New("apf: channel data exceeds window")
+	ErrDisconnected      = errors.New("apf: disconnected by peer")
+	ErrListening         = errors.New("apf: connection already has a listener")
+)
+
+// listenBacklog is the number of channels opened by AMT that a ChannelListener holds until they are accepted.
+const listenBacklog = 16
+
+// maxChannelData is the largest APF_CHANNEL_DATA payload sent on a channel.
+const maxChannelData = LME_RX_WINDOW_SIZE
+
+// messageReader reads the messages of the peer, framing replies by the requests noted with Sent.
+type messageReader interface {
+	ReadMessage() ([]byte, error)
+	Sent(data []byte)
+}
+
+// Connection multiplexes APF channels over a single connection with AMT.
+//
+// Serve reads the connection until it fails, is closed or its context is cancelled. Channel messages are dispatched
+// to the channels opened with Open, anything else is handed to Process along with the Session given to NewConnection.
+type Connection struct {
+	conn    io.ReadWriteCloser
+	reader  messageReader
+	session *Session
+
+	writeMu sync.Mutex
+
+	mu       sync.Mutex
+	channels map[uint32]*Channel
+	next     uint32
+	listener *ChannelListener
+	err      error
+	done     chan struct{}
+	once     sync.Once
+}
+
+// NewConnection returns a Connection over conn. session configures the handling of non-channel messages, see Process.
+func NewConnection(conn io.ReadWriteCloser, session *Session) *Connection {
+	if session == nil {
+		session = &Session{}
+	}
+	return &Connection{
+		conn:     conn,
+		reader:   NewReader(conn),
+		session:  session,
+		channels: map[uint32]*Channel{},
+		done:     make(chan struct{}),
+	}
+}
+
+// Serve processes incoming messages until the connection fails or ctx is done. It always returns a non-nil error:
+// ErrConnectionClosed after Close, the context error after cancellation, or the error that ended the connection.
+func (c *Connection) Serve(ctx context.Context) error {
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			c.conn.Close()
+		case <-stop:
+		}
+	}()
+	var err error
+	for err == nil {
+		var data []byte
+		if data, err = c.reader.ReadMessage(); err == nil {
+			err = c.handle(data)
+		}
+	}
+	if ctx.Err() != nil {
+		err = ctx.Err()
+	}
+	c.shutdown(err)
+	return c.Err()
+}
+
+// Close closes the underlying connection and every channel.
+func (c *Connection) Close() error {
+	c.shutdown(ErrConnectionClosed)
+	return nil
+}
+
+// Done is closed once the connection has stopped.
+func (c *Connection) Done() <-chan struct{} {
+	return c.done
+}
+
+// Err returns the reason the connection stopped, or nil while it is running.
+func (c *Connection) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+func (c *Connection) shutdown(err error) {
+	c.once.Do(func() {
+		c.mu.Lock()
+		c.err = err
+		channels := c.channels
+		c.channels = map[uint32]*Channel{}
+		listener := c.listener
+		c.mu.Unlock()
+		if listener != nil {
+			listener.Close()
+		}
+		c.conn.Close()
+		if closer, ok := c.reader.(io.Closer); ok {
+			closer.Close()
+		}
+		for _, ch := range channels {
+			ch.mu.Lock()
+			ch.err = ErrConnectionClosed
+			ch.broadcast()
+			ch.mu.Unlock()
+		}
+		close(c.done)
+	})
+}
+
+// Open opens a forwarded-tcpip channel to the AMT web server port and waits for AMT to confirm it.
+func (c *Connection) Open(ctx context.Context) (*Channel, error) {
+	return c.OpenChannel(ctx, ChannelOpenOptions{})
+}
+
+// OpenChannel opens the channel described by options and waits for AMT to confirm it.
+func (c *Connection) OpenChannel(ctx context.Context, options ChannelOpenOptions) (*Channel, error) {
+	options = options.withDefaults()
+	if options.ChannelType != APF_OPEN_CHANNEL_REQUEST_FORWARDED && options.ChannelType != APF_OPEN_CHANNEL_REQUEST_DIRECT {
+		return nil, fmt.Errorf("%w: unknown channel type %q", ErrChannelOpenFailed, options.ChannelType)
+	}
+	c.mu.Lock()
+	if c.err != nil {
+		c.mu.Unlock()
+		return nil, ErrConnectionClosed
+	}
+	ch := c.newChannel(Addr{Host: options.OriginatorAddress, Port: options.OriginatorPort}, Addr{Host: options.ConnectedAddress, Port: options.ConnectedPort})
+	c.mu.Unlock()
+
+	if err := c.send(ChannelOpenMessage(ch.id, options)); err != nil {
+		c.remove(ch.id)
+		return nil, err
+	}
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+	for !ch.opened {
+		if ch.openErr != nil {
+			return nil, ch.openErr
+		}
+		if ch.err != nil {
+			return nil, ch.err
+		}
+		if err := ch.wait(ctx, time.Time{}); err != nil {
+			// the channel is closed as soon as AMT confirms it
+			ch.localClosed = true
+			return nil, err
+		}
+	}
+	return ch, nil
+}
+
+// newChannel registers a channel with an unused id. It is called with mu held.
+func (c *Connection) newChannel(local, remote Addr) *Channel {
+	c.next++
+	for c.channels[c.next] != nil || c.next == 0 {
+		c.next++
+	}
+	ch := newChannel(c, c.next, local, remote)
+	c.channels[ch.id] = ch
+	return ch
+}
+
+// Listen returns a listener for the channels AMT opens, such as those carrying WS-Man events. Channels AMT opens are
+// rejected while no listener is open, and only one listener can be open at a time.
+func (c *Connection) Listen() (*ChannelListener, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return nil, ErrConnectionClosed
+	}
+	if c.listener != nil {
+		return nil, ErrListening
+	}
+	c.listener = &ChannelListener{conn: c, queue: make(chan *Channel, listenBacklog), done: make(chan struct{})}
+	return c.listener, nil
+}
+
+func (c *Connection) channel(id uint32) *Channel {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.channels[id]
+}
+
+func (c *Connection) remove(id uint32) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.channels, id)
+}
+
+func (c *Connection) send(message interface{}) error {
+	b, err := Marshal(message)
+	if err != nil {
+		return err
+	}
+	return c.write(b)
+}
+
+func (c *Connection) write(b []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	c.reader.Sent(b)
+	_, err := c.conn.Write(b)
+	return err
+}
+
+func (c *Connection) handle(data []byte) error {
+	switch data[0] {
+	case APF_CHANNEL_OPEN_CONFIRMATION:
+		message := APF_CHANNEL_OPEN_CONFIRMATION_MESSAGE{}
+		if err := message.UnmarshalBinary(data); err != nil {
+			return err
+		}
+		return c.handleOpenConfirmation(message)
+	case APF_CHANNEL_OPEN_FAILURE:
+		message := APF_CHANNEL_OPEN_FAILURE_MESSAGE{}
+		if err := message.UnmarshalBinary(data); err != nil {
+			return err
+		}
+		if ch := c.channel(message.RecipientChannel); ch != nil {
+			c.remove(message.RecipientChannel)
+			ch.mu.Lock()
+			ch.openErr = fmt.Errorf("%w: reason code %d", ErrChannelOpenFailed, message.ReasonCode)
+			ch.broadcast()
+			ch.mu.Unlock()
+		}
+		return nil
+	case APF_CHANNEL_WINDOW_ADJUST:
+		message := APF_CHANNEL_WINDOW_ADJUST_MESSAGE{}
+		if err := message.UnmarshalBinary(data); err != nil {
+			return err
+		}
+		if ch := c.channel(message.RecipientChannel); ch != nil {
+			ch.mu.Lock()
+			if uint64(ch.txWindow)+uint64(message.BytesToAdd) > math.MaxUint32 {
+				ch.txWindow = math.MaxUint32
+			} else {
+				ch.txWindow += message.BytesToAdd
+			}
+			ch.broadcast()
+			ch.mu.Unlock()
+		}
+		return nil
+	case APF_CHANNEL_DATA:
+		message := APF_CHANNEL_DATA_MESSAGE{}
+		if err := message.UnmarshalBinary(data); err != nil {
+			return err
+		}
+		return c.handleData(message)
+	case APF_CHANNEL_CLOSE:
+		message := APF_CHANNEL_CLOSE_MESSAGE{}
+		if err := message.UnmarshalBinary(data); err != nil {
+			return err
+		}
+		return c.handleClose(message)
+	case APF_CHANNEL_OPEN:
+		message := APF_CHANNEL_OPEN_MESSAGE{}
+		if err := message.UnmarshalBinary(data); err != nil {
+			return err
+		}
+		return c.handleOpen(message)
+	case APF_DISCONNECT:
+		message := APF_DISCONNECT_MESSAGE{}
+		if err := message.UnmarshalBinary(data); err != nil {
+			return err
+		}
+		return fmt.Errorf("%w: reason code %d", ErrDisconnected, message.ReasonCode)
+	}
+	reply, err := Process(data, c.session)
+	if err != nil {
+		return err
+	}
+	if reply.Len() == 0 {
+		return nil
+	}
+	return c.write(reply.Bytes())
+}
+
+// handleOpen confirms a channel AMT opens when a listener has room for it.
+func (c *Connection) handleOpen(message APF_CHANNEL_OPEN_MESSAGE) error {
+	reason := channelOpenFailureReason(message.ChannelType)
+	c.mu.Lock()
+	listener := c.listener
+	var ch *Channel
+	if reason == OPEN_FAILURE_REASON_ADMINISTRATIVELY_PROHIBITED && listener != nil {
+		listener.mu.Lock()
+		switch {
+		case listener.closed:
+		case len(listener.queue) == cap(listener.queue):
+			reason = OPEN_FAILURE_REASON_RESOURCE_SHORTAGE
+		default:
+			// the channel is confirmed before it is queued, once the listener is unlocked nothing else fills the queue
+			ch = c.newChannel(Addr{Host: message.ConnectedAddress, Port: message.ConnectedPort}, Addr{Host: message.OriginatorIPAddress, Port: message.OriginatorPort})
+			ch.opened = true
+			ch.peer = message.SenderChannel
+			ch.txWindow = message.InitialWindowSize
+		}
+		listener.mu.Unlock()
+	}
+	c.mu.Unlock()
+	if ch == nil {
+		log.Debugf("rejecting APF_CHANNEL_OPEN of %s from %s:%d, reason code %d", message.ChannelType, message.OriginatorIPAddress, message.OriginatorPort, reason)
+		return c.send(ChannelOpenReplyFailure(message.SenderChannel, reason))
+	}
+	if err := c.send(ChannelOpenReplySuccess(message.SenderChannel, ch.id)); err != nil {
+		return err
+	}
+	listener.deliver(ch)
+	return nil
+}
+
+func (c *Connection) handleOpenConfirmation(message APF_CHANNEL_OPEN_CONFIRMATION_MESSAGE) error {
+	ch := c.channel(message.RecipientChannel)
+	if ch == nil {
+		log.Debugf("ignoring APF_CHANNEL_OPEN_CONFIRMATION for unknown channel %d", message.RecipientChannel)
+		return nil
+	}
+	ch.mu.Lock()
+	ch.opened = true
+	ch.peer = message.SenderChannel
+	ch.txWindow = message.InitialWindowSize
+	abandoned := ch.localClosed
+	ch.sentClose = abandoned
+	ch.broadcast()
+	ch.mu.Unlock()
+	if abandoned {
+		return c.send(ChannelClose(message.SenderChannel))
+	}
+	return nil
+}
+
+func (c *Connection) handleData(message APF_CHANNEL_DATA_MESSAGE) error {
+	ch := c.channel(message.RecipientChannel)
+	if ch == nil {
+		log.Debugf("ignoring APF_CHANNEL_DATA for unknown channel %d", message.RecipientChannel)
+		return nil
+	}
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+	if message.DataLength > ch.rxWindow {
+		return fmt.Errorf("%w: %d bytes on channel %d with a window of %d", ErrWindowExceeded, message.DataLength, ch.id, ch.rxWindow)
+	}
+	ch.rxWindow -= message.DataLength
+	if !ch.localClosed {
+		ch.rx = append(ch.rx, message.Data...)
+		ch.broadcast()
+	}
+	return nil
+}
+
+func (c *Connection) handleClose(message APF_CHANNEL_CLOSE_MESSAGE) error {
+	ch := c.channel(message.RecipientChannel)
+	if ch == nil {
+		log.Debugf("ignoring APF_CHANNEL_CLOSE for unknown channel %d", message.RecipientChannel)
+		return nil
+	}
+	c.remove(message.RecipientChannel)
+	ch.mu.Lock()
+	ch.remoteClosed = true
+	reply := !ch.sentClose
+	ch.sentClose = true
+	peer := ch.peer
+	ch.broadcast()
+	ch.mu.Unlock()
+	if reply {
+		return c.send(ChannelClose(peer))
+	}
+	return nil
+}
+
+// ChannelListener accepts the channels AMT opens on a Connection. It implements net.Listener, so the channels can be
+// served with http.Serve for example.
+type ChannelListener struct {
+	conn  *Connection
+	queue chan *Channel
+	done  chan struct{}
+
+	mu     sync.Mutex
+	closed bool
+}
+
+// Accept waits for the next channel AMT opens. It returns net.ErrClosed once the listener or the connection is closed.
+func (l *ChannelListener) Accept() (net.Conn, error) {
+	select {
+	case ch := <-l.queue:
+		return ch, nil
+	case <-l.done:
+		return nil, net.ErrClosed
+	}
+}
+
+// Close stops accepting channels. Channels that were not accepted yet are closed.
+func (l *ChannelListener) Close() error {
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return net.ErrClosed
+	}
+	l.closed = true
+	close(l.done)
+	l.mu.Unlock()
+
+	l.conn.mu.Lock()
+	if l.conn.listener == l {
+		l.conn.listener = nil
+	}
+	l.conn.mu.Unlock()
+	for {
+		select {
+		case ch := <-l.queue:
+			ch.Close()
+		default:
+			return nil
+		}
+	}
+}
+
+// Addr returns an address naming the connection.
+func (l *ChannelListener) Addr() net.Addr {
+	return Addr{Host: "apf"}
+}
+
+// deliver queues a confirmed channel, or closes it when the listener was closed meanwhile.
+func (l *ChannelListener) deliver(ch *Channel) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		go ch.Close()
+		return
+	}
+	l.queue <- ch
+}
+
+// Addr is the address of one end of an APF channel.
+type Addr struct {
+	Host string
+	Port uint32
+}
+
+// Network returns "apf".
+func (a Addr) Network() string {
+	return "apf"
+}
+
+func (a Addr) String() string {
+	return net.JoinHostPort(a.Host, strconv.FormatUint(uint64(a.Port), 10))
+}
+
+// Channel is an APF channel of a Connection. It implements net.Conn.
+//
+// Writes block while the window granted by AMT is exhausted, and data read from the channel is granted back to AMT
+// with APF_CHANNEL_WINDOW_ADJUST messages. Close sends APF_CHANNEL_CLOSE, the channel is released once AMT answers it.
+type Channel struct {
+	conn   *Connection
+	id     uint32
+	local  Addr
+	remote Addr
+
+	writeMu sync.Mutex
+
+	mu            sync.Mutex
+	changed       chan struct{}
+	opened        bool
+	openErr       error
+	peer          uint32
+	txWindow      uint32
+	rxWindow      uint32
+	rxConsumed    uint32
+	rx            []byte
+	localClosed   bool
+	sentClose     bool
+	remoteClosed  bool
+	err           error
+	readDeadline  time.Time
+	writeDeadline time.Time
+}
+
+func newChannel(conn *Connection, id uint32, local, remote Addr) *Channel {
+	return &Channel{
+		conn:     conn,
+		id:       id,
+		local:    local,
+		remote:   remote,
+		changed:  make(chan struct{}),
+		rxWindow: LME_RX_WINDOW_SIZE,
+	}
+}
+
+// broadcast wakes the goroutines waiting on the channel. It is called with mu held.
+func (ch *Channel) broadcast() {
+	close(ch.changed)
+	ch.changed = make(chan struct{})
+}
+
+// wait releases mu until the channel changes, ctx is done or deadline passes.
+func (ch *Channel) wait(ctx context.Context, deadline time.Time) error {
+	changed := ch.changed
+	ch.mu.Unlock()
+	defer ch.mu.Lock()
+	var timeout <-chan time.Time
+	if !deadline.IsZero() {
+		d := time.Until(deadline)
+		if d <= 0 {
+			return os.ErrDeadlineExceeded
+		}
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	select {
+	case <-changed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timeout:
+		return os.ErrDeadlineExceeded
+	}
+}
+
+// Read reads data sent by AMT. It returns io.EOF once AMT has closed the channel and the data it sent has been read.
+func (ch *Channel) Read(p []byte) (int, error) {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+	for len(ch.rx) == 0 {
+		switch {
+		case ch.localClosed:
+			return 0, net.ErrClosed
+		case ch.remoteClosed:
+			return 0, io.EOF
+		case ch.err != nil:
+			return 0, ch.err
+		}
+		if err := ch.wait(context.Background(), ch.readDeadline); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, ch.rx)
+	ch.rx = ch.rx[n:]
+	ch.rxConsumed += uint32(n)
+	if ch.rxConsumed < LME_RX_WINDOW_SIZE/2 || ch.sentClose {
+		return n, nil
+	}
+	adjust := ch.rxConsumed
+	ch.rxConsumed = 0
+	ch.rxWindow += adjust
+	peer := ch.peer
+	ch.mu.Unlock()
+	err := ch.conn.send(ChannelWindowAdjust(peer, adjust))
+	ch.mu.Lock()
+	return n, err
+}
+
+// Write sends p to AMT, blocking while the channel window is exhausted.
+func (ch *Channel) Write(p []byte) (int, error) {
+	ch.writeMu.Lock()
+	defer ch.writeMu.Unlock()
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+	written := 0
+	for written < len(p) {
+		if err := ch.writable(); err != nil {
+			return written, err
+		}
+		if ch.txWindow == 0 {
+			if err := ch.wait(context.Background(), ch.writeDeadline); err != nil {
+				return written, err
+			}
+			continue
+		}
+		n := len(p) - written
+		if n > maxChannelData {
+			n = maxChannelData
+		}
+		if uint32(n) > ch.txWindow {
+			n = int(ch.txWindow)
+		}
+		ch.txWindow -= uint32(n)
+		peer := ch.peer
+		ch.mu.Unlock()
+		err := ch.conn.send(ChannelData(peer, p[written:written+n]))
+		ch.mu.Lock()
+		if err != nil {
+			return written, err
+		}
+		written += n
+	}
+	return written, nil
+}
+
+func (ch *Channel) writable() error {
+	switch {
+	case ch.localClosed:
+		return net.ErrClosed
+	case ch.remoteClosed:
+		return ErrChannelClosed
+	case ch.err != nil:
+		return ch.err
+	case !ch.writeDeadline.IsZero() && !time.Now().Before(ch.writeDeadline):
+		return os.ErrDeadlineExceeded
+	}
+	return nil
+}
+
+// Close sends APF_CHANNEL_CLOSE unless AMT closed the channel first. Unread data is discarded.
+func (ch *Channel) Close() error {
+	ch.mu.Lock()
+	if ch.localClosed {
+		ch.mu.Unlock()
+		return net.ErrClosed
+	}
+	ch.localClosed = true
+	ch.rx = nil
+	send := !ch.sentClose && ch.err == nil
+	ch.sentClose = true
+	peer := ch.peer
+	ch.broadcast()
+	ch.mu.Unlock()
+	if !send {
+		return nil
+	}
+	return ch.conn.send(ChannelClose(peer))
+}
+
+// LocalAddr returns the originator address given when the channel was opened.
+func (ch *Channel) LocalAddr() net.Addr {
+	return ch.local
+}
+
+// RemoteAddr returns the connected address given when the channel was opened.
+func (ch *Channel) RemoteAddr() net.Addr {
+	return ch.remote
+}
+
+func (ch *Channel) SetDeadline(t time.Time) error {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+	ch.readDeadline = t
+	ch.writeDeadline = t
+	ch.broadcast()
+	return nil
+}
+
+func (ch *Channel) SetReadDeadline(t time.Time) error {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+	ch.readDeadline = t
+	ch.broadcast()
+	return nil
+}
+
+func (ch *Channel) SetWriteDeadline(t time.Time) error {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+	ch.writeDeadline = t
+	ch.broadcast()
+	return nil
+}
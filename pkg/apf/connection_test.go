@@ -0,0 +1,369 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package apf
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAMT plays the AMT end of a Connection.
+type fakeAMT struct {
+	conn     net.Conn
+	messages chan interface{}
+}
+
+func newFakeAMT(conn net.Conn) *fakeAMT {
+	amt := &fakeAMT{conn: conn, messages: make(chan interface{}, 100)}
+	go func() {
+		defer close(amt.messages)
+		for {
+			data, err := ReadMessage(conn)
+			if err != nil {
+				return
+			}
+			message, err := Unmarshal(data)
+			if err != nil {
+				return
+			}
+			amt.messages <- message
+		}
+	}()
+	return amt
+}
+
+func (amt *fakeAMT) send(t *testing.T, message interface{}) {
+	data, err := Marshal(message)
+	require.NoError(t, err)
+	_, err = amt.conn.Write(data)
+	require.NoError(t, err)
+}
+
+func (amt *fakeAMT) expect(t *testing.T) interface{} {
+	select {
+	case message := <-amt.messages:
+		return message
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for a message")
+		return nil
+	}
+}
+
+type testConnection struct {
+	*Connection
+	amt    *fakeAMT
+	cancel context.CancelFunc
+	served chan error
+}
+
+func newTestConnection(t *testing.T, session *Session) *testConnection {
+	local, remote := net.Pipe()
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &testConnection{Connection: NewConnection(local, session), amt: newFakeAMT(remote), cancel: cancel, served: make(chan error, 1)}
+	go func() {
+		c.served <- c.Serve(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		remote.Close()
+	})
+	return c
+}
+
+func (c *testConnection) open(t *testing.T, amtChannel, window uint32) *Channel {
+	result := make(chan *Channel, 1)
+	go func() {
+		ch, err := c.Open(context.Background())
+		assert.NoError(t, err)
+		result <- ch
+	}()
+	open := c.amt.expect(t).(APF_CHANNEL_OPEN_MESSAGE)
+	assert.Equal(t, APF_OPEN_CHANNEL_REQUEST_FORWARDED, open.ChannelType)
+	c.amt.send(t, APF_CHANNEL_OPEN_CONFIRMATION_MESSAGE{RecipientChannel: open.SenderChannel, SenderChannel: amtChannel, InitialWindowSize: window})
+	return <-result
+}
+
+func TestConnectionWriteBlocksOnWindow(t *testing.T) {
+	c := newTestConnection(t, nil)
+	ch := c.open(t, 7, 4)
+
+	written := make(chan int, 1)
+	go func() {
+		n, err := ch.Write([]byte("hello world"))
+		assert.NoError(t, err)
+		written <- n
+	}()
+	assert.Equal(t, ChannelData(7, []byte("hell")), c.amt.expect(t))
+	select {
+	case <-written:
+		t.Fatal("write returned before the window was adjusted")
+	case <-time.After(50 * time.Millisecond):
+	}
+	c.amt.send(t, ChannelWindowAdjust(ch.id, 100))
+	assert.Equal(t, ChannelData(7, []byte("o world")), c.amt.expect(t))
+	assert.Equal(t, 11, <-written)
+}
+
+func TestConnectionReadAdjustsWindow(t *testing.T) {
+	c := newTestConnection(t, nil)
+	ch := c.open(t, 7, 4096)
+
+	data := bytes.Repeat([]byte{0x2A}, LME_RX_WINDOW_SIZE/2)
+	c.amt.send(t, ChannelData(ch.id, data))
+	received := make([]byte, len(data))
+	_, err := io.ReadFull(ch, received)
+	assert.NoError(t, err)
+	assert.Equal(t, data, received)
+	assert.Equal(t, ChannelWindowAdjust(7, uint32(len(data))), c.amt.expect(t))
+
+	// the adjusted window accepts a full window of data
+	c.amt.send(t, ChannelData(ch.id, bytes.Repeat([]byte{0x2A}, LME_RX_WINDOW_SIZE)))
+	_, err = io.ReadFull(ch, make([]byte, LME_RX_WINDOW_SIZE))
+	assert.NoError(t, err)
+}
+
+func TestConnectionWindowExceeded(t *testing.T) {
+	c := newTestConnection(t, nil)
+	ch := c.open(t, 7, 4096)
+
+	c.amt.send(t, ChannelData(ch.id, make([]byte, LME_RX_WINDOW_SIZE+1)))
+	assert.ErrorIs(t, <-c.served, ErrWindowExceeded)
+	_, err := ch.Read(make([]byte, 1))
+	assert.ErrorIs(t, err, ErrConnectionClosed)
+}
+
+func TestConnectionLocalClose(t *testing.T) {
+	c := newTestConnection(t, nil)
+	ch := c.open(t, 7, 4096)
+
+	assert.NoError(t, ch.Close())
+	assert.Equal(t, ChannelClose(7), c.amt.expect(t))
+	assert.NotNil(t, c.channel(ch.id), "channel is kept until AMT answers the close")
+	_, err := ch.Write([]byte("x"))
+	assert.ErrorIs(t, err, net.ErrClosed)
+	assert.ErrorIs(t, ch.Close(), net.ErrClosed)
+
+	c.amt.send(t, ChannelClose(ch.id))
+	assert.Eventually(t, func() bool { return c.channel(ch.id) == nil }, time.Second, time.Millisecond)
+}
+
+func TestConnectionRemoteClose(t *testing.T) {
+	c := newTestConnection(t, nil)
+	ch := c.open(t, 7, 4096)
+
+	c.amt.send(t, ChannelData(ch.id, []byte("bye")))
+	c.amt.send(t, ChannelClose(ch.id))
+	assert.Equal(t, ChannelClose(7), c.amt.expect(t))
+	received, err := io.ReadAll(ch)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("bye"), received)
+	_, err = ch.Write([]byte("x"))
+	assert.ErrorIs(t, err, ErrChannelClosed)
+	assert.NoError(t, ch.Close())
+	assert.Nil(t, c.channel(ch.id))
+}
+
+func TestConnectionOpenFailure(t *testing.T) {
+	c := newTestConnection(t, nil)
+	result := make(chan error, 1)
+	go func() {
+		_, err := c.Open(context.Background())
+		result <- err
+	}()
+	open := c.amt.expect(t).(APF_CHANNEL_OPEN_MESSAGE)
+	c.amt.send(t, ChannelOpenReplyFailure(open.SenderChannel, OPEN_FAILURE_REASON_CONNECT_FAILED))
+	assert.ErrorIs(t, <-result, ErrChannelOpenFailed)
+	assert.Nil(t, c.channel(open.SenderChannel))
+}
+
+func TestConnectionOpenCancelled(t *testing.T) {
+	c := newTestConnection(t, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	result := make(chan error, 1)
+	go func() {
+		_, err := c.Open(ctx)
+		result <- err
+	}()
+	open := c.amt.expect(t).(APF_CHANNEL_OPEN_MESSAGE)
+	cancel()
+	assert.ErrorIs(t, <-result, context.Canceled)
+
+	// a late confirmation closes the abandoned channel
+	c.amt.send(t, ChannelOpenReplySuccess(open.SenderChannel, 9))
+	assert.Equal(t, ChannelClose(9), c.amt.expect(t))
+}
+
+func TestConnectionConcurrentChannels(t *testing.T) {
+	c := newTestConnection(t, nil)
+	channels := map[uint32]*Channel{}
+	for i := uint32(1); i <= 3; i++ {
+		channels[100+i] = c.open(t, 100+i, 4096)
+	}
+
+	payload := bytes.Repeat([]byte("0123456789"), 1000)
+	var wg sync.WaitGroup
+	for _, ch := range channels {
+		wg.Add(1)
+		go func(ch *Channel) {
+			defer wg.Done()
+			n, err := ch.Write(payload)
+			assert.NoError(t, err)
+			assert.Equal(t, len(payload), n)
+		}(ch)
+	}
+	received := map[uint32][]byte{}
+	for total := 0; total < 3*LME_RX_WINDOW_SIZE; {
+		data := c.amt.expect(t).(APF_CHANNEL_DATA_MESSAGE)
+		received[data.RecipientChannel] = append(received[data.RecipientChannel], data.Data...)
+		total += len(data.Data)
+	}
+	for id := range channels {
+		assert.Len(t, received[id], LME_RX_WINDOW_SIZE)
+		c.amt.send(t, ChannelWindowAdjust(channels[id].id, uint32(len(payload))))
+	}
+	for total := 0; total < 3*(len(payload)-LME_RX_WINDOW_SIZE); {
+		data := c.amt.expect(t).(APF_CHANNEL_DATA_MESSAGE)
+		received[data.RecipientChannel] = append(received[data.RecipientChannel], data.Data...)
+		total += len(data.Data)
+	}
+	wg.Wait()
+	for id := range channels {
+		assert.Equal(t, payload, received[id])
+	}
+}
+
+func TestConnectionServeContext(t *testing.T) {
+	c := newTestConnection(t, nil)
+	ch := c.open(t, 7, 4096)
+
+	read := make(chan error, 1)
+	go func() {
+		_, err := ch.Read(make([]byte, 1))
+		read <- err
+	}()
+	c.cancel()
+	assert.ErrorIs(t, <-c.served, context.Canceled)
+	assert.ErrorIs(t, <-read, ErrConnectionClosed)
+	<-c.Done()
+	_, err := c.Open(context.Background())
+	assert.ErrorIs(t, err, ErrConnectionClosed)
+}
+
+func TestConnectionClose(t *testing.T) {
+	c := newTestConnection(t, nil)
+	assert.NoError(t, c.Close())
+	assert.ErrorIs(t, <-c.served, ErrConnectionClosed)
+}
+
+func TestConnectionDisconnect(t *testing.T) {
+	c := newTestConnection(t, nil)
+	c.amt.send(t, APF_DISCONNECT_MESSAGE{ReasonCode: APF_DISCONNECT_BY_APPLICATION})
+	assert.ErrorIs(t, <-c.served, ErrDisconnected)
+}
+
+func TestConnectionProcessesOtherMessages(t *testing.T) {
+	c := newTestConnection(t, nil)
+	c.amt.send(t, KeepAliveRequest(5))
+	assert.Equal(t, KeepAliveReply(5), c.amt.expect(t))
+	c.amt.send(t, APF_CHANNEL_OPEN_MESSAGE{ChannelType: APF_OPEN_CHANNEL_REQUEST_FORWARDED, SenderChannel: 3})
+	assert.Equal(t, ChannelOpenReplyFailure(3, OPEN_FAILURE_REASON_ADMINISTRATIVELY_PROHIBITED), c.amt.expect(t))
+}
+
+func TestChannelDeadlines(t *testing.T) {
+	c := newTestConnection(t, nil)
+	ch := c.open(t, 7, 0)
+
+	assert.NoError(t, ch.SetDeadline(time.Now().Add(20*time.Millisecond)))
+	_, err := ch.Read(make([]byte, 1))
+	assert.ErrorIs(t, err, os.ErrDeadlineExceeded)
+	_, err = ch.Write([]byte("x"))
+	assert.ErrorIs(t, err, os.ErrDeadlineExceeded)
+
+	assert.NoError(t, ch.SetReadDeadline(time.Time{}))
+	c.amt.send(t, ChannelData(ch.id, []byte("x")))
+	n, err := ch.Read(make([]byte, 1))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, "[::1]:16992", ch.RemoteAddr().String())
+	assert.Equal(t, "apf", ch.LocalAddr().Network())
+}
+
+func TestConnectionOpenChannel(t *testing.T) {
+	c := newTestConnection(t, nil)
+	result := make(chan *Channel, 1)
+	go func() {
+		ch, err := c.OpenChannel(context.Background(), ChannelOpenOptions{ChannelType: APF_OPEN_CHANNEL_REQUEST_DIRECT, ConnectedAddress: "127.0.0.1", ConnectedPort: 16993})
+		assert.NoError(t, err)
+		result <- ch
+	}()
+	open := c.amt.expect(t).(APF_CHANNEL_OPEN_MESSAGE)
+	assert.Equal(t, APF_OPEN_CHANNEL_REQUEST_DIRECT, open.ChannelType)
+	assert.Equal(t, "127.0.0.1", open.ConnectedAddress)
+	assert.Equal(t, uint32(16993), open.ConnectedPort)
+	c.amt.send(t, APF_CHANNEL_OPEN_CONFIRMATION_MESSAGE{RecipientChannel: open.SenderChannel, SenderChannel: 7, InitialWindowSize: 4096})
+	ch := <-result
+	assert.Equal(t, "127.0.0.1:16993", ch.RemoteAddr().String())
+
+	_, err := c.OpenChannel(context.Background(), ChannelOpenOptions{ChannelType: "session"})
+	assert.ErrorIs(t, err, ErrChannelOpenFailed)
+}
+
+func TestConnectionListen(t *testing.T) {
+	c := newTestConnection(t, nil)
+	listener, err := c.Listen()
+	require.NoError(t, err)
+	_, err = c.Listen()
+	assert.ErrorIs(t, err, ErrListening)
+
+	c.amt.send(t, APF_CHANNEL_OPEN_MESSAGE{ChannelType: APF_OPEN_CHANNEL_REQUEST_FORWARDED, SenderChannel: 3, InitialWindowSize: 4096, ConnectedAddress: "mps", ConnectedPort: 4433, OriginatorIPAddress: "192.168.0.10", OriginatorPort: 50000})
+	confirmation := c.amt.expect(t).(APF_CHANNEL_OPEN_CONFIRMATION_MESSAGE)
+	assert.Equal(t, uint32(3), confirmation.RecipientChannel)
+	conn, err := listener.Accept()
+	require.NoError(t, err)
+	assert.Equal(t, "mps:4433", conn.LocalAddr().String())
+	assert.Equal(t, "192.168.0.10:50000", conn.RemoteAddr().String())
+
+	c.amt.send(t, ChannelData(confirmation.SenderChannel, []byte("event")))
+	received := make([]byte, 5)
+	_, err = io.ReadFull(conn, received)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("event"), received)
+	_, err = conn.Write([]byte("ok"))
+	assert.NoError(t, err)
+	assert.Equal(t, ChannelData(3, []byte("ok")), c.amt.expect(t))
+
+	c.amt.send(t, APF_CHANNEL_OPEN_MESSAGE{ChannelType: "session", SenderChannel: 4})
+	assert.Equal(t, ChannelOpenReplyFailure(4, OPEN_FAILURE_REASON_UNKNOWN_CHANNEL_TYPE), c.amt.expect(t))
+
+	// channels are rejected once the listener is closed
+	assert.NoError(t, listener.Close())
+	_, err = listener.Accept()
+	assert.ErrorIs(t, err, net.ErrClosed)
+	c.amt.send(t, APF_CHANNEL_OPEN_MESSAGE{ChannelType: APF_OPEN_CHANNEL_REQUEST_FORWARDED, SenderChannel: 5})
+	assert.Equal(t, ChannelOpenReplyFailure(5, OPEN_FAILURE_REASON_ADMINISTRATIVELY_PROHIBITED), c.amt.expect(t))
+	_, err = c.Listen()
+	assert.NoError(t, err)
+}
+
+func TestConnectionListenBacklog(t *testing.T) {
+	c := newTestConnection(t, nil)
+	_, err := c.Listen()
+	require.NoError(t, err)
+	for i := uint32(0); i < listenBacklog; i++ {
+		c.amt.send(t, APF_CHANNEL_OPEN_MESSAGE{ChannelType: APF_OPEN_CHANNEL_REQUEST_FORWARDED, SenderChannel: i})
+		assert.IsType(t, APF_CHANNEL_OPEN_CONFIRMATION_MESSAGE{}, c.amt.expect(t))
+	}
+	c.amt.send(t, APF_CHANNEL_OPEN_MESSAGE{ChannelType: APF_OPEN_CHANNEL_REQUEST_FORWARDED, SenderChannel: 99})
+	assert.Equal(t, ChannelOpenReplyFailure(99, OPEN_FAILURE_REASON_RESOURCE_SHORTAGE), c.amt.expect(t))
+}
@@ -0,0 +1,339 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package apf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Errors returned by NewHost when the peer refuses the handshake.
+var (
+	ErrServiceRejected        = errors.New("apf: service request rejected")
+	ErrAuthenticationRejected = errors.New("apf: authentication rejected")
+	ErrForwardRejected        = errors.New("apf: tcpip-forward rejected")
+)
+
+// HostOptions configures the handshake of a Host.
+type HostOptions struct {
+	// MajorVersion and MinorVersion are sent in APF_PROTOCOLVERSION, 1.0 when both are zero.
+	MajorVersion  uint32
+	MinorVersion  uint32
+	TriggerReason uint32
+	UUID          [16]byte
+	// Username and Password authenticate with the password method before the pfwd service is requested. Authentication
+	// is skipped when Username is empty.
+	Username string
+	Password string
+	// Forwards are registered with tcpip-forward requests, each of them must succeed.
+	Forwards []APF_TCP_FORWARD_REQUEST
+}
+
+// Host is the endpoint of an APF connection that initiates it, the role a Local Manageability Service or AMT itself
+// plays towards the MPS. It announces itself, requests the port forwarding service and registers forwards during the
+// handshake, after which the embedded Connection serves channels as it does for the MPS.
+type Host struct {
+	*Connection
+	// Peer is the APF_PROTOCOLVERSION the peer answered with.
+	Peer APF_PROTOCOL_VERSION_MESSAGE
+	// Forwards are the replies to HostOptions.Forwards.
+	Forwards []APF_TCP_FORWARD_REPLY_MESSAGE
+}
+
+// NewHost performs the handshake described by options over conn and returns a Host whose Serve must be called to
+// process the connection. conn is closed when the handshake fails or ctx is done before it completes.
+func NewHost(ctx context.Context, conn io.ReadWriteCloser, options HostOptions) (*Host, error) {
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+	h := &hostHandshake{conn: conn, reader: newQueuedReader(conn), session: &Session{}}
+	host, err := h.run(options)
+	if err != nil {
+		conn.Close()
+		h.reader.Close()
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		}
+		return nil, err
+	}
+	host.Connection = NewConnection(conn, h.session)
+	// the queue may already hold messages sent after the handshake, the connection goes on reading from it
+	host.Connection.reader = h.reader
+	return host, nil
+}
+
+// hostHandshake sends the requests of the handshake and waits for their replies, answering keep-alive messages the
+// peer sends meanwhile. The replies are read on a separate goroutine, as the peer may still be writing a message after
+// its reply when the next request is sent, which blocks both sides on connections that do not buffer writes.
+type hostHandshake struct {
+	conn    io.ReadWriteCloser
+	reader  *queuedReader
+	session *Session
+}
+
+func (h *hostHandshake) run(options HostOptions) (*Host, error) {
+	if options.MajorVersion == 0 && options.MinorVersion == 0 {
+		options.MajorVersion = 1
+	}
+	host := &Host{}
+	version := ProtocolVersion(options.MajorVersion, options.MinorVersion, options.TriggerReason)
+	version.UUID = options.UUID
+	reply, err := h.request(version)
+	if err != nil {
+		return nil, err
+	}
+	if err := host.Peer.UnmarshalBinary(reply); err != nil {
+		return nil, err
+	}
+	if options.Username != "" {
+		if err := h.service(APF_SERVICE_AUTH); err != nil {
+			return nil, err
+		}
+		reply, err := h.request(APF_USERAUTH_REQUEST_MESSAGE{
+			Username:    options.Username,
+			ServiceName: APF_SERVICE_PFWD,
+			MethodName:  APF_AUTH_PASSWORD,
+			Password:    options.Password,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if reply[0] != APF_USERAUTH_SUCCESS {
+			return nil, fmt.Errorf("%w: %s", ErrAuthenticationRejected, options.Username)
+		}
+	}
+	if err := h.service(APF_SERVICE_PFWD); err != nil {
+		return nil, err
+	}
+	for _, forward := range options.Forwards {
+		forward.WantReply = 1
+		payload, err := forward.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		reply, err := h.request(APF_GLOBAL_REQUEST_MESSAGE{APF_GENERIC_HEADER: APF_GENERIC_HEADER{String: APF_GLOBAL_REQUEST_STR_TCP_FORWARD_REQUEST}, Payload: payload})
+		if err != nil {
+			return nil, err
+		}
+		if reply[0] != APF_REQUEST_SUCCESS {
+			return nil, fmt.Errorf("%w: %s", ErrForwardRejected, net.JoinHostPort(forward.Address, strconv.FormatUint(uint64(forward.Port), 10)))
+		}
+		bound := APF_TCP_FORWARD_REPLY_MESSAGE{}
+		if err := bound.UnmarshalBinary(reply); err != nil {
+			return nil, err
+		}
+		host.Forwards = append(host.Forwards, bound)
+	}
+	return host, nil
+}
+
+func (h *hostHandshake) service(name string) error {
+	reply, err := h.request(APF_SERVICE_REQUEST_MESSAGE{ServiceName: name})
+	if err != nil {
+		return err
+	}
+	accept := APF_SERVICE_ACCEPT_MESSAGE{}
+	if err := accept.UnmarshalBinary(reply); err != nil {
+		return err
+	}
+	if accept.ServiceName != name {
+		return fmt.Errorf("%w: %s", ErrServiceRejected, name)
+	}
+	return nil
+}
+
+// request sends message and returns the next message of the peer that is not a keep-alive.
+func (h *hostHandshake) request(message interface{}) ([]byte, error) {
+	if err := h.send(message); err != nil {
+		return nil, err
+	}
+	for {
+		data, err := h.reader.ReadMessage()
+		if err != nil {
+			return nil, err
+		}
+		switch data[0] {
+		case APF_KEEPALIVE_REQUEST, APF_KEEPALIVE_REPLY, APF_KEEPALIVE_OPTIONS_REQUEST:
+			reply, err := Process(data, h.session)
+			if err != nil {
+				return nil, err
+			}
+			if reply.Len() > 0 {
+				if _, err := h.conn.Write(reply.Bytes()); err != nil {
+					return nil, err
+				}
+			}
+		case APF_DISCONNECT:
+			return nil, ErrDisconnected
+		default:
+			return data, nil
+		}
+	}
+}
+
+func (h *hostHandshake) send(message interface{}) error {
+	b, err := Marshal(message)
+	if err != nil {
+		return err
+	}
+	h.reader.Sent(b)
+	_, err = h.conn.Write(b)
+	return err
+}
+
+// readQueueSize is the number of messages a queuedReader holds until they are read.
+const readQueueSize = 16
+
+// queuedReader reads the messages of the peer with a Reader on its own goroutine and queues them until they are read,
+// so the peer never waits on the local side to read while it writes. Reading stops while readQueueSize messages are
+// queued, which leaves a peer that writes faster than the messages are read to the flow control of the connection.
+type queuedReader struct {
+	reader *Reader
+
+	mu     sync.Mutex
+	cond   *sync.Cond
+	queue  [][]byte
+	err    error
+	closed bool
+}
+
+func newQueuedReader(conn io.Reader) *queuedReader {
+	q := &queuedReader{reader: NewReader(conn)}
+	q.cond = sync.NewCond(&q.mu)
+	go q.run()
+	return q
+}
+
+func (q *queuedReader) run() {
+	for {
+		data, err := q.reader.ReadMessage()
+		q.mu.Lock()
+		for err == nil && len(q.queue) >= readQueueSize && !q.closed {
+			q.cond.Wait()
+		}
+		stop := err != nil || q.closed
+		if !stop {
+			q.queue = append(q.queue, data)
+		} else if q.err == nil {
+			q.err = err
+		}
+		q.cond.Broadcast()
+		q.mu.Unlock()
+		if stop {
+			return
+		}
+	}
+}
+
+// ReadMessage returns the next queued message, or the error that stopped reading once the queue is empty.
+func (q *queuedReader) ReadMessage() ([]byte, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for len(q.queue) == 0 && q.err == nil {
+		q.cond.Wait()
+	}
+	if len(q.queue) == 0 {
+		return nil, q.err
+	}
+	data := q.queue[0]
+	q.queue = q.queue[1:]
+	q.cond.Broadcast()
+	return data, nil
+}
+
+// Close stops the reading goroutine, including when it waits for the queue to have room. ReadMessage returns the
+// messages still queued, then ErrConnectionClosed unless reading had already failed.
+func (q *queuedReader) Close() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.closed = true
+	if q.err == nil {
+		q.err = ErrConnectionClosed
+	}
+	q.cond.Broadcast()
+	return nil
+}
+
+// Sent notes a message sent to the peer, see Reader.Sent.
+func (q *queuedReader) Sent(data []byte) {
+	q.reader.Sent(data)
+}
+
+// Forward accepts connections on listener and bridges each of them to a channel opened with options, with the address
+// of the accepted connection as originator. It returns when accepting fails, closing listener once the host stops.
+func (h *Host) Forward(listener net.Listener, options ChannelOpenOptions) error {
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-h.Done():
+			listener.Close()
+		case <-stop:
+		}
+	}()
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			select {
+			case <-h.Done():
+				return ErrConnectionClosed
+			default:
+				return err
+			}
+		}
+		go h.forward(conn, options)
+	}
+}
+
+func (h *Host) forward(conn net.Conn, options ChannelOpenOptions) {
+	if host, port, err := net.SplitHostPort(conn.RemoteAddr().String()); err == nil {
+		options.OriginatorAddress = host
+		if p, err := strconv.ParseUint(port, 10, 32); err == nil {
+			options.OriginatorPort = uint32(p)
+		}
+	}
+	ch, err := h.OpenChannel(context.Background(), options)
+	if err != nil {
+		log.Debugf("closing connection from %s: %v", conn.RemoteAddr(), err)
+		conn.Close()
+		return
+	}
+	Bridge(conn, ch)
+}
+
+// Bridge copies data between a and b in both directions until either side ends, then closes both.
+func Bridge(a, b net.Conn) {
+	var once sync.Once
+	closeBoth := func() {
+		a.Close()
+		b.Close()
+	}
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		io.Copy(a, b)
+		once.Do(closeBoth)
+	}()
+	go func() {
+		defer wg.Done()
+		io.Copy(b, a)
+		once.Do(closeBoth)
+	}()
+	wg.Wait()
+}
@@ -0,0 +1,179 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package apf
+
+import (
+	"context"
+	"io"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mpsPeer answers the handshake of a Host with Process until a tcpip-forward succeeds, then serves a Connection.
+func mpsPeer(t *testing.T, conn net.Conn, session *Session) chan *Connection {
+	result := make(chan *Connection, 1)
+	go func() {
+		defer close(result)
+		for {
+			data, err := ReadMessage(conn)
+			if err != nil {
+				return
+			}
+			reply, err := Process(data, session)
+			if !assert.NoError(t, err) {
+				return
+			}
+			if reply.Len() == 0 {
+				continue
+			}
+			if _, err := conn.Write(reply.Bytes()); err != nil {
+				return
+			}
+			if data[0] == APF_GLOBAL_REQUEST && reply.Bytes()[0] == APF_REQUEST_SUCCESS {
+				c := NewConnection(conn, session)
+				go c.Serve(context.Background())
+				result <- c
+				return
+			}
+		}
+	}()
+	return result
+}
+
+// pipe returns both ends of a net.Pipe, which does not buffer writes.
+func pipe(t *testing.T) (net.Conn, net.Conn) {
+	local, remote := net.Pipe()
+	t.Cleanup(func() {
+		local.Close()
+		remote.Close()
+	})
+	return local, remote
+}
+
+func testSession() *Session {
+	return &Session{
+		Authenticate: func(request APF_USERAUTH_REQUEST_MESSAGE) bool {
+			return request.Username == "admin" && request.Password == "P@ssw0rd"
+		},
+		KeepAliveInterval:    30,
+		KeepAliveReadTimeout: 90,
+	}
+}
+
+func TestHost(t *testing.T) {
+	local, remote := pipe(t)
+	peer := mpsPeer(t, remote, testSession())
+	host, err := NewHost(context.Background(), local, HostOptions{
+		TriggerReason: APF_TRIGGER_REASON_LME_REQUEST,
+		Username:      "admin",
+		Password:      "P@ssw0rd",
+		Forwards:      []APF_TCP_FORWARD_REQUEST{{Address: "127.0.0.1", Port: 16992}},
+	})
+	require.NoError(t, err)
+	defer host.Close()
+	go host.Serve(context.Background())
+	mps := <-peer
+	require.NotNil(t, mps)
+	defer mps.Close()
+
+	assert.Equal(t, uint32(1), host.Peer.MajorVersion)
+	assert.Equal(t, []APF_TCP_FORWARD_REPLY_MESSAGE{TcpForwardReplySuccess(16992)}, host.Forwards)
+	assert.Equal(t, uint32(30), host.session.KeepAliveInterval)
+	assert.Equal(t, uint32(90), host.session.KeepAliveReadTimeout)
+
+	channels, err := mps.Listen()
+	require.NoError(t, err)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go host.Forward(listener, ChannelOpenOptions{ConnectedAddress: "127.0.0.1", ConnectedPort: 16992})
+
+	client, err := net.Dial("tcp", listener.Addr().String())
+	require.NoError(t, err)
+	defer client.Close()
+	_, err = client.Write([]byte("ping"))
+	require.NoError(t, err)
+
+	ch, err := channels.Accept()
+	require.NoError(t, err)
+	assert.Equal(t, "127.0.0.1:16992", ch.LocalAddr().String())
+	assert.Equal(t, client.LocalAddr().String(), ch.RemoteAddr().String())
+	received := make([]byte, 4)
+	_, err = io.ReadFull(ch, received)
+	require.NoError(t, err)
+	assert.Equal(t, "ping", string(received))
+	_, err = ch.Write([]byte("pong"))
+	require.NoError(t, err)
+	_, err = io.ReadFull(client, received)
+	require.NoError(t, err)
+	assert.Equal(t, "pong", string(received))
+
+	// closing the channel closes the bridged connection
+	require.NoError(t, ch.Close())
+	_, err = client.Read(received)
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestHostAuthenticationRejected(t *testing.T) {
+	local, remote := pipe(t)
+	mpsPeer(t, remote, testSession())
+	_, err := NewHost(context.Background(), local, HostOptions{Username: "admin", Password: "wrong"})
+	assert.ErrorIs(t, err, ErrAuthenticationRejected)
+}
+
+func TestHostForwardRejected(t *testing.T) {
+	local, remote := pipe(t)
+	mpsPeer(t, remote, testSession())
+	_, err := NewHost(context.Background(), local, HostOptions{
+		Username: "admin",
+		Password: "P@ssw0rd",
+		Forwards: []APF_TCP_FORWARD_REQUEST{{Port: 16992}, {Port: 80}},
+	})
+	assert.ErrorIs(t, err, ErrForwardRejected)
+}
+
+func TestHostCancelled(t *testing.T) {
+	local, _ := pipe(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := NewHost(ctx, local, HostOptions{})
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestQueuedReaderBounded(t *testing.T) {
+	local, remote := pipe(t)
+	q := newQueuedReader(local)
+	message, err := Marshal(KeepAliveRequest(1))
+	require.NoError(t, err)
+
+	// the queue fills up and the reader holds one more message, after which writes wait for the queue to be read
+	for i := 0; i <= readQueueSize; i++ {
+		require.NoError(t, remote.SetWriteDeadline(time.Now().Add(time.Second)))
+		_, err := remote.Write(message)
+		require.NoError(t, err, "message %d", i)
+	}
+	require.NoError(t, remote.SetWriteDeadline(time.Now().Add(50*time.Millisecond)))
+	_, err = remote.Write(message)
+	assert.ErrorIs(t, err, os.ErrDeadlineExceeded)
+
+	data, err := q.ReadMessage()
+	require.NoError(t, err)
+	assert.Equal(t, message, data)
+	require.NoError(t, remote.SetWriteDeadline(time.Now().Add(time.Second)))
+	_, err = remote.Write(message)
+	assert.NoError(t, err, "reading makes room for the next message")
+
+	q.Close()
+	for i := 0; i < readQueueSize; i++ {
+		_, err = q.ReadMessage()
+		require.NoError(t, err)
+	}
+	_, err = q.ReadMessage()
+	assert.ErrorIs(t, err, ErrConnectionClosed)
+}
@@ -6,7 +6,6 @@ package apf
 
 import (
 	"bytes"
-	"encoding/binary"
 	"errors"
 	"fmt"
 	"time"
@@ -14,216 +13,239 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
-func Process(data []byte, session *Session) bytes.Buffer {
+// Process decodes a single APF message received from AMT, updates session and returns the encoded reply, if any.
+// Malformed messages are returned as errors rather than partially processed.
+func Process(data []byte, session *Session) (bytes.Buffer, error) {
 	var bin_buf bytes.Buffer
 	var dataToSend interface{}
+	var err error
+	if len(data) == 0 {
+		return bin_buf, fmt.Errorf("%w: empty message", ErrShortMessage)
+	}
 	switch data[0] {
 	case APF_GLOBAL_REQUEST: // 80
 		log.Debug("received APF_GLOBAL_REQUEST")
-		dataToSend = ProcessGlobalRequest(data)
+		dataToSend, err = ProcessGlobalRequest(data, session)
 	case APF_CHANNEL_OPEN: // (90) Sent by Intel AMT when a channel needs to be open from Intel AMT. This is not common, but WSMAN events are a good example of channel coming from AMT.
 		log.Debug("received APF_CHANNEL_OPEN")
+		dataToSend, err = ProcessChannelOpen(data)
 	case APF_DISCONNECT: // (1) Intel AMT wants to completely disconnect. Not sure when this happens.
 		log.Debug("received APF_DISCONNECT")
+		err = (&APF_DISCONNECT_MESSAGE{}).UnmarshalBinary(data)
 	case APF_SERVICE_REQUEST: // (5)
 		log.Debug("received APF SERVICE REQUEST")
-		dataToSend = ProcessServiceRequest(data)
+		var accept APF_SERVICE_ACCEPT_MESSAGE
+		accept, err = ProcessServiceRequest(data)
+		if err == nil && accept.MessageType == APF_SERVICE_ACCEPT {
+			dataToSend = accept
+		}
 	case APF_CHANNEL_OPEN_CONFIRMATION: // (91) Intel AMT confirmation to an APF_CHANNEL_OPEN request.
 		log.Debug("received APF_CHANNEL_OPEN_CONFIRMATION")
-		ProcessChannelOpenConfirmation(data, session)
+		err = ProcessChannelOpenConfirmation(data, session)
 	case APF_CHANNEL_OPEN_FAILURE: // (92) Intel AMT rejected our connection attempt.
 		log.Debug("received APF_CHANNEL_OPEN_FAILURE")
-		ProcessChannelOpenFailure(data, session)
+		err = ProcessChannelOpenFailure(data, session)
 	case APF_CHANNEL_CLOSE: // (97) Intel AMT is closing this channel, we need to disconnect the LMS TCP connection
 		log.Debug("received APF_CHANNEL_CLOSE")
-		ProcessChannelClose(data, session)
+		_, err = ProcessChannelClose(data, session)
 	case APF_CHANNEL_DATA: // (94) Intel AMT is sending data that we must relay into an LMS TCP connection.
-		ProcessChannelData(data, session)
+		err = ProcessChannelData(data, session)
 	case APF_CHANNEL_WINDOW_ADJUST: // 93
 		log.Debug("received APF_CHANNEL_WINDOW_ADJUST")
-		ProcessChannelWindowAdjust(data, session)
+		err = ProcessChannelWindowAdjust(data, session)
 	case APF_PROTOCOLVERSION: // 192
 		log.Debug("received APF PROTOCOL VERSION")
-		dataToSend = ProcessProtocolVersion(data)
+		dataToSend, err = ProcessProtocolVersion(data)
 	case APF_USERAUTH_REQUEST: // 50
+		log.Debug("received APF_USERAUTH_REQUEST")
+		dataToSend, err = ProcessUserAuthRequest(data, session)
+	case APF_KEEPALIVE_REQUEST: // 208
+		log.Debug("received APF_KEEPALIVE_REQUEST")
+		dataToSend, err = ProcessKeepAliveRequest(data)
+	case APF_KEEPALIVE_REPLY: // 209
+		log.Debug("received APF_KEEPALIVE_REPLY")
+		err = (&APF_KEEPALIVE_REPLY_MESSAGE{}).UnmarshalBinary(data)
+	case APF_KEEPALIVE_OPTIONS_REQUEST: // 210
+		log.Debug("received APF_KEEPALIVE_OPTIONS_REQUEST")
+		dataToSend, err = ProcessKeepAliveOptionsRequest(data, session)
+	case APF_KEEPALIVE_OPTIONS_REPLY: // 211
+		log.Debug("received APF_KEEPALIVE_OPTIONS_REPLY")
+		err = ProcessKeepAliveOptionsReply(data, session)
 	default:
+		err = fmt.Errorf("%w: %d", ErrUnknownMessageType, data[0])
+	}
+	if err != nil {
+		return bin_buf, err
 	}
-	if dataToSend != nil {
-		err := binary.Write(&bin_buf, binary.BigEndian, dataToSend)
+	replies, ok := dataToSend.([]interface{})
+	if !ok && dataToSend != nil {
+		replies = []interface{}{dataToSend}
+	}
+	for _, reply := range replies {
+		b, err := Marshal(reply)
 		if err != nil {
-			log.Error(err)
+			return bin_buf, err
 		}
+		bin_buf.Write(b)
 	}
-	return bin_buf
+	return bin_buf, nil
+}
+
+// ProcessChannelOpen answers a channel AMT opens. Process has no channel to serve it with, so the channel is rejected; use
+// Connection.Listen to accept them.
+func ProcessChannelOpen(data []byte) (APF_CHANNEL_OPEN_FAILURE_MESSAGE, error) {
+	message := APF_CHANNEL_OPEN_MESSAGE{}
+	if err := message.UnmarshalBinary(data); err != nil {
+		return APF_CHANNEL_OPEN_FAILURE_MESSAGE{}, err
+	}
+	log.Tracef("%+v", message)
+	return ChannelOpenReplyFailure(message.SenderChannel, channelOpenFailureReason(message.ChannelType)), nil
 }
 
-func ProcessChannelWindowAdjust(data []byte, session *Session) {
+// channelOpenFailureReason returns the reason a channel of channelType that cannot be served is rejected with.
+func channelOpenFailureReason(channelType string) uint32 {
+	if channelType == APF_OPEN_CHANNEL_REQUEST_FORWARDED || channelType == APF_OPEN_CHANNEL_REQUEST_DIRECT {
+		return OPEN_FAILURE_REASON_ADMINISTRATIVELY_PROHIBITED
+	}
+	return OPEN_FAILURE_REASON_UNKNOWN_CHANNEL_TYPE
+}
+
+func ProcessChannelWindowAdjust(data []byte, session *Session) error {
 	adjustMessage := APF_CHANNEL_WINDOW_ADJUST_MESSAGE{}
-	dataBuffer := bytes.NewBuffer(data)
-	err := binary.Read(dataBuffer, binary.BigEndian, &adjustMessage)
-	if err != nil {
-		log.Error(err)
+	if err := adjustMessage.UnmarshalBinary(data); err != nil {
+		return err
 	}
 	session.TXWindow += adjustMessage.BytesToAdd
 	log.Tracef("%+v", adjustMessage)
+	return nil
 }
-func ProcessChannelClose(data []byte, session *Session) APF_CHANNEL_CLOSE_MESSAGE {
+func ProcessChannelClose(data []byte, session *Session) (APF_CHANNEL_CLOSE_MESSAGE, error) {
 	closeMessage := APF_CHANNEL_CLOSE_MESSAGE{}
-	dataBuffer := bytes.NewBuffer(data)
-	err := binary.Read(dataBuffer, binary.BigEndian, &closeMessage)
-	if err != nil {
-		log.Error(err)
+	if err := closeMessage.UnmarshalBinary(data); err != nil {
+		return closeMessage, err
 	}
 	log.Tracef("%+v", closeMessage)
-	// session.DataBuffer <- session.Tempdata
-	// session.Tempdata = []byte{}
 	close := ChannelClose(closeMessage.RecipientChannel)
-	return close
+	return close, nil
 }
-func ProcessGlobalRequest(data []byte) interface{} {
-	genericHeader := APF_GENERIC_HEADER{}
-	dataBuffer := bytes.NewBuffer(data)
 
-	err := binary.Read(dataBuffer, binary.BigEndian, &genericHeader.MessageType)
-	if err != nil {
-		log.Error(err)
-	}
-	err = binary.Read(dataBuffer, binary.BigEndian, &genericHeader.StringLength)
-	if err != nil {
-		log.Error(err)
+// ProcessGlobalRequest answers a global request. tcpip-forward requests succeed when the forward policy of session allows
+// them and are tracked until AMT cancels them, udp-send-to datagrams are handed to session.UdpSendTo and not answered.
+func ProcessGlobalRequest(data []byte, session *Session) (interface{}, error) {
+	request := APF_GLOBAL_REQUEST_MESSAGE{}
+	if err := request.UnmarshalBinary(data); err != nil {
+		return nil, err
 	}
+	log.Tracef("%+v", request.APF_GENERIC_HEADER)
 
 	var reply interface{}
-	if int(genericHeader.StringLength) > 0 {
-		stringBuffer := make([]byte, genericHeader.StringLength)
-		tcpForwardRequest := APF_TCP_FORWARD_REQUEST{}
-
-		err = binary.Read(dataBuffer, binary.BigEndian, &stringBuffer)
+	switch request.String {
+	case APF_GLOBAL_REQUEST_STR_TCP_FORWARD_REQUEST:
+		tcpForwardRequest, err := request.TcpForwardRequest()
 		if err != nil {
-			log.Error(err)
+			return nil, err
 		}
-		genericHeader.String = string(stringBuffer[:int(genericHeader.StringLength)])
-		err = binary.Read(dataBuffer, binary.BigEndian, &tcpForwardRequest.WantReply)
-		if err != nil {
-			log.Error(err)
+		log.Tracef("%+v", tcpForwardRequest)
+		policy := session.ForwardPolicy
+		if policy == nil {
+			policy = DefaultForwardPolicy
 		}
-		err = binary.Read(dataBuffer, binary.BigEndian, &tcpForwardRequest.AddressLength)
+		if policy(tcpForwardRequest) {
+			session.addForward(tcpForwardRequest)
+			reply = TcpForwardReplySuccess(tcpForwardRequest.Port)
+		} else {
+			log.Debugf("rejecting tcpip-forward of %s:%d", tcpForwardRequest.Address, tcpForwardRequest.Port)
+			reply = APF_REQUEST_FAILURE_MESSAGE{MessageType: APF_REQUEST_FAILURE}
+		}
+	case APF_GLOBAL_REQUEST_STR_TCP_FORWARD_CANCEL_REQUEST:
+		tcpForwardRequest, err := request.TcpForwardRequest()
 		if err != nil {
-			log.Error(err)
+			return nil, err
 		}
-		if int(tcpForwardRequest.AddressLength) > 0 {
-			addressBuffer := make([]byte, tcpForwardRequest.AddressLength)
-			err = binary.Read(dataBuffer, binary.BigEndian, &addressBuffer)
-			if err != nil {
-				log.Error(err)
-			}
-			tcpForwardRequest.Address = string(addressBuffer[:int(tcpForwardRequest.AddressLength)])
+		log.Tracef("%+v", tcpForwardRequest)
+		if session.removeForward(tcpForwardRequest) {
+			reply = APF_REQUEST_SUCCESS_MESSAGE{MessageType: APF_REQUEST_SUCCESS}
+		} else {
+			reply = APF_REQUEST_FAILURE_MESSAGE{MessageType: APF_REQUEST_FAILURE}
 		}
-		err = binary.Read(dataBuffer, binary.BigEndian, &tcpForwardRequest.Port)
+	case APF_GLOBAL_REQUEST_STR_UDP_SEND_TO:
+		udpSendToRequest, err := request.UdpSendToRequest()
 		if err != nil {
-			log.Error(err)
+			return nil, err
 		}
-		log.Tracef("%+v", genericHeader)
-		log.Tracef("%+v", tcpForwardRequest)
-
-		if genericHeader.String == APF_GLOBAL_REQUEST_STR_TCP_FORWARD_REQUEST {
-			if tcpForwardRequest.Port == 16992 || tcpForwardRequest.Port == 16993 {
-				reply = TcpForwardReplySuccess(tcpForwardRequest.Port)
-			} else {
-				reply = APF_REQUEST_FAILURE
-			}
-		} else if genericHeader.String == APF_GLOBAL_REQUEST_STR_TCP_FORWARD_CANCEL_REQUEST {
-			reply = APF_REQUEST_SUCCESS
+		log.Tracef("%+v", udpSendToRequest)
+		if session.UdpSendTo != nil {
+			session.UdpSendTo(udpSendToRequest)
 		}
 	}
-	return reply
+	return reply, nil
 }
-func ProcessChannelData(data []byte, session *Session) {
-	channelData := APF_CHANNEL_DATA_MESSAGE{}
-	buf2 := bytes.NewBuffer(data)
 
-	err := binary.Read(buf2, binary.BigEndian, &channelData.MessageType)
-	if err != nil {
-		log.Error(err)
+// DefaultForwardPolicy allows AMT to forward its web server ports, 16992 and 16993.
+func DefaultForwardPolicy(request APF_TCP_FORWARD_REQUEST) bool {
+	return request.Port == 16992 || request.Port == 16993
+}
+
+// Forwards returns the tcpip-forward requests that succeeded and were not cancelled.
+func (session *Session) Forwards() []APF_TCP_FORWARD_REQUEST {
+	session.forwardsMu.Lock()
+	defer session.forwardsMu.Unlock()
+	return append([]APF_TCP_FORWARD_REQUEST(nil), session.forwards...)
+}
+
+func (session *Session) addForward(request APF_TCP_FORWARD_REQUEST) {
+	session.forwardsMu.Lock()
+	defer session.forwardsMu.Unlock()
+	for i, forward := range session.forwards {
+		if forward.Address == request.Address && forward.Port == request.Port {
+			session.forwards[i] = request
+			return
+		}
 	}
-	err = binary.Read(buf2, binary.BigEndian, &channelData.RecipientChannel)
-	if err != nil {
-		log.Error(err)
+	session.forwards = append(session.forwards, request)
+}
+
+func (session *Session) removeForward(request APF_TCP_FORWARD_REQUEST) bool {
+	session.forwardsMu.Lock()
+	defer session.forwardsMu.Unlock()
+	for i, forward := range session.forwards {
+		if forward.Address == request.Address && forward.Port == request.Port {
+			session.forwards = append(session.forwards[:i], session.forwards[i+1:]...)
+			return true
+		}
 	}
-	err = binary.Read(buf2, binary.BigEndian, &channelData.DataLength)
-	if err != nil {
-		log.Error(err)
+	return false
+}
+
+func ProcessChannelData(data []byte, session *Session) error {
+	channelData := APF_CHANNEL_DATA_MESSAGE{}
+	if err := channelData.UnmarshalBinary(data); err != nil {
+		return err
 	}
 	session.RXWindow = channelData.DataLength
-	dataBuffer := make([]byte, channelData.DataLength)
-	err = binary.Read(buf2, binary.BigEndian, &dataBuffer)
-	if err != nil {
-		log.Error(err)
-	}
-	//log.Debug("received APF_CHANNEL_DATA - " + fmt.Sprint(channelData.DataLength))
-	//log.Tracef("%+v", channelData)
-
-	session.Tempdata = append(session.Tempdata, dataBuffer[:channelData.DataLength]...)
-	// var windowAdjust APF_CHANNEL_WINDOW_ADJUST_MESSAGE
-	// if session.RXWindow > 1024 { // TODO: Check this
-	// 	windowAdjust = ChannelWindowAdjust(channelData.RecipientChannel, session.RXWindow)
-	// 	session.RXWindow = 0
-	// }
+	session.Tempdata = append(session.Tempdata, channelData.Data...)
 	session.Timer.Reset(3 * time.Second)
-	// var windowAdjust APF_CHANNEL_WINDOW_ADJUST_MESSAGE
-	// if session.RXWindow > 1024 { // TODO: Check this
-	// 	windowAdjust = ChannelWindowAdjust(channelData.RecipientChannel, session.RXWindow)
-	// 	session.RXWindow = 0
-	// }
-	// // log.Tracef("%+v", session)
-	// return windowAdjust
-	//return windowAdjust
-}
-func ProcessServiceRequest(data []byte) APF_SERVICE_ACCEPT_MESSAGE {
-	service := 0
+	return nil
+}
+func ProcessServiceRequest(data []byte) (APF_SERVICE_ACCEPT_MESSAGE, error) {
 	message := APF_SERVICE_REQUEST_MESSAGE{}
-	dataBuffer := bytes.NewBuffer(data)
-	err := binary.Read(dataBuffer, binary.BigEndian, &message.MessageType)
-	if err != nil {
-		log.Error(err)
-	}
-	err = binary.Read(dataBuffer, binary.BigEndian, &message.ServiceNameLength)
-	if err != nil {
-		log.Error(err)
-	}
-
-	if int(message.ServiceNameLength) > 0 {
-		serviceNameBuffer := make([]byte, message.ServiceNameLength)
-		err = binary.Read(dataBuffer, binary.BigEndian, &serviceNameBuffer)
-		if err != nil {
-			log.Error(err)
-		}
-		message.ServiceName = string(serviceNameBuffer[:int(message.ServiceNameLength)])
+	if err := message.UnmarshalBinary(data); err != nil {
+		return APF_SERVICE_ACCEPT_MESSAGE{}, err
 	}
 	log.Tracef("%+v", message)
 
-	if message.ServiceNameLength == 18 {
-		if message.ServiceName == "pfwd@amt.intel.com" {
-			service = 1
-		} else if message.ServiceName == "auth@amt.intel.com" {
-			service = 2
-		}
-	}
 	var serviceAccept APF_SERVICE_ACCEPT_MESSAGE
-	if service > 0 {
+	if message.ServiceName == APF_SERVICE_PFWD || message.ServiceName == APF_SERVICE_AUTH {
 		serviceAccept = ServiceAccept(message.ServiceName)
 	}
-	return serviceAccept
+	return serviceAccept, nil
 }
-func ProcessChannelOpenConfirmation(data []byte, session *Session) {
+func ProcessChannelOpenConfirmation(data []byte, session *Session) error {
 	confirmationMessage := APF_CHANNEL_OPEN_CONFIRMATION_MESSAGE{}
-	dataBuffer := bytes.NewBuffer(data)
-	err := binary.Read(dataBuffer, binary.BigEndian, &confirmationMessage)
-	if err != nil {
-		log.Error(err)
+	if err := confirmationMessage.UnmarshalBinary(data); err != nil {
+		return err
 	}
 	log.Tracef("%+v", confirmationMessage)
-	// replySuccess := ChannelOpenReplySuccess(confirmationMessage.RecipientChannel, confirmationMessage.SenderChannel)
 
 	log.Trace("our channel: "+fmt.Sprint(confirmationMessage.RecipientChannel), " AMT's channel: "+fmt.Sprint(confirmationMessage.SenderChannel))
 	log.Trace("initial window: " + fmt.Sprint(confirmationMessage.InitialWindowSize))
@@ -231,42 +253,82 @@ func ProcessChannelOpenConfirmation(data []byte, session *Session) {
 	session.RecipientChannel = confirmationMessage.RecipientChannel
 	session.TXWindow = confirmationMessage.InitialWindowSize
 	session.Status <- true
+	return nil
 }
-func ProcessChannelOpenFailure(data []byte, session *Session) {
+func ProcessChannelOpenFailure(data []byte, session *Session) error {
 	channelOpenFailure := APF_CHANNEL_OPEN_FAILURE_MESSAGE{}
-	dataBuffer := bytes.NewBuffer(data)
-	err := binary.Read(dataBuffer, binary.BigEndian, &channelOpenFailure)
-	if err != nil {
-		log.Error(err)
+	if err := channelOpenFailure.UnmarshalBinary(data); err != nil {
+		return err
 	}
 	log.Tracef("%+v", channelOpenFailure)
 	session.Status <- false
 	session.ErrorBuffer <- errors.New("error opening APF channel, reason code: " + fmt.Sprint(channelOpenFailure.ReasonCode))
+	return nil
 }
-func ProcessProtocolVersion(data []byte) APF_PROTOCOL_VERSION_MESSAGE {
+func ProcessProtocolVersion(data []byte) (APF_PROTOCOL_VERSION_MESSAGE, error) {
 	message := APF_PROTOCOL_VERSION_MESSAGE{}
-	dataBuffer := bytes.NewBuffer(data)
-	err := binary.Read(dataBuffer, binary.BigEndian, &message)
-	if err != nil {
-		log.Error(err)
+	if err := message.UnmarshalBinary(data); err != nil {
+		return message, err
 	}
 	log.Tracef("%+v", message)
 	version := ProtocolVersion(message.MajorVersion, message.MinorVersion, message.TriggerReason)
-	return version
+	return version, nil
+}
+
+// ProcessUserAuthRequest verifies the credentials of an APF_USERAUTH_REQUEST with session.Authenticate and returns the replies to send.
+// When the request succeeds and session.KeepAliveInterval is set, the keep-alive options are requested as well.
+func ProcessUserAuthRequest(data []byte, session *Session) (interface{}, error) {
+	request := APF_USERAUTH_REQUEST_MESSAGE{}
+	if err := request.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	log.Tracef("username: %s, service: %s, method: %s", request.Username, request.ServiceName, request.MethodName)
+	if session.Authenticate == nil || !session.Authenticate(request) {
+		return UserAuthFailure(), nil
+	}
+	if session.KeepAliveInterval == 0 {
+		return UserAuthSuccess(), nil
+	}
+	return []interface{}{UserAuthSuccess(), KeepAliveOptionsRequest(session.KeepAliveInterval, session.KeepAliveReadTimeout)}, nil
+}
+func ProcessKeepAliveRequest(data []byte) (APF_KEEPALIVE_REPLY_MESSAGE, error) {
+	request := APF_KEEPALIVE_REQUEST_MESSAGE{}
+	if err := request.UnmarshalBinary(data); err != nil {
+		return APF_KEEPALIVE_REPLY_MESSAGE{}, err
+	}
+	log.Tracef("%+v", request)
+	return KeepAliveReply(request.Cookie), nil
+}
+
+// ProcessKeepAliveOptionsRequest accepts the keep-alive options the MPS requests, storing them in session.
+func ProcessKeepAliveOptionsRequest(data []byte, session *Session) (APF_KEEPALIVE_OPTIONS_REPLY_MESSAGE, error) {
+	request := APF_KEEPALIVE_OPTIONS_REQUEST_MESSAGE{}
+	if err := request.UnmarshalBinary(data); err != nil {
+		return APF_KEEPALIVE_OPTIONS_REPLY_MESSAGE{}, err
+	}
+	log.Tracef("%+v", request)
+	session.KeepAliveInterval = request.KeepaliveInterval
+	session.KeepAliveReadTimeout = request.ReadTimeout
+	return KeepAliveOptionsReply(request.KeepaliveInterval, request.ReadTimeout), nil
+}
+func ProcessKeepAliveOptionsReply(data []byte, session *Session) error {
+	reply := APF_KEEPALIVE_OPTIONS_REPLY_MESSAGE{}
+	if err := reply.UnmarshalBinary(data); err != nil {
+		return err
+	}
+	log.Tracef("%+v", reply)
+	session.KeepAliveInterval = reply.KeepaliveInterval
+	session.KeepAliveReadTimeout = reply.ReadTimeout
+	return nil
 }
 
 // Send the AFP service accept message to the MEI
 func ServiceAccept(serviceName string) APF_SERVICE_ACCEPT_MESSAGE {
 	log.Debug("sending APF_SERVICE_ACCEPT_MESSAGE")
-	if len(serviceName) != 18 {
-		serviceName = fmt.Sprintf("'%-18s'", serviceName)
-	}
-	var test [18]byte
-	copy(test[:], []byte(serviceName)[:18])
 	serviceAcceptMessage := APF_SERVICE_ACCEPT_MESSAGE{
 		MessageType:       APF_SERVICE_ACCEPT,
-		ServiceNameLength: 18,
-		ServiceName:       test,
+		ServiceNameLength: uint32(len(serviceName)),
+		ServiceName:       serviceName,
 	}
 	log.Tracef("%+v", serviceAcceptMessage)
 	return serviceAcceptMessage
@@ -293,31 +355,64 @@ func TcpForwardReplySuccess(port uint32) APF_TCP_FORWARD_REPLY_MESSAGE {
 	return message
 }
 
-func ChannelOpen(senderChannel int) bytes.Buffer {
-	var channelType [15]byte
-	copy(channelType[:], []byte(APF_OPEN_CHANNEL_REQUEST_FORWARDED)[:15])
-	var address [3]byte
-	copy(address[:], []byte("::1")[:3])
+// ChannelOpenOptions describes the channel opened by ChannelOpenMessage. Zero fields take the values used by ChannelOpen:
+// a forwarded-tcpip channel to port 16992 of ::1, originating from port 123 of ::1.
+type ChannelOpenOptions struct {
+	ChannelType       string // APF_OPEN_CHANNEL_REQUEST_FORWARDED or APF_OPEN_CHANNEL_REQUEST_DIRECT
+	ConnectedAddress  string
+	ConnectedPort     uint32 // 16992 or 16993 for WS-Man, 16994 or 16995 for redirection
+	OriginatorAddress string
+	OriginatorPort    uint32
+}
+
+func (o ChannelOpenOptions) withDefaults() ChannelOpenOptions {
+	if o.ChannelType == "" {
+		o.ChannelType = APF_OPEN_CHANNEL_REQUEST_FORWARDED
+	}
+	if o.ConnectedAddress == "" {
+		o.ConnectedAddress = "::1"
+	}
+	if o.ConnectedPort == 0 {
+		o.ConnectedPort = 16992
+	}
+	if o.OriginatorAddress == "" {
+		o.OriginatorAddress = "::1"
+	}
+	if o.OriginatorPort == 0 {
+		o.OriginatorPort = 123
+	}
+	return o
+}
+
+// ChannelOpenMessage builds the request to open the channel described by options, identified by senderChannel on our side.
+func ChannelOpenMessage(senderChannel uint32, options ChannelOpenOptions) APF_CHANNEL_OPEN_MESSAGE {
+	log.Debug("sending APF_CHANNEL_OPEN")
+	options = options.withDefaults()
 	openMessage := APF_CHANNEL_OPEN_MESSAGE{
 		MessageType:               APF_CHANNEL_OPEN,
-		ChannelTypeLength:         15,
-		ChannelType:               channelType,
-		SenderChannel:             uint32(senderChannel), //hmm
+		ChannelTypeLength:         uint32(len(options.ChannelType)),
+		ChannelType:               options.ChannelType,
+		SenderChannel:             senderChannel,
 		Reserved:                  0xFFFFFFFF,
 		InitialWindowSize:         LME_RX_WINDOW_SIZE,
-		ConnectedAddressLength:    3,
-		ConnectedAddress:          address,
-		ConnectedPort:             16992,
-		OriginatorIPAddressLength: 3,
-		OriginatorIPAddress:       address,
-		OriginatorPort:            123,
+		ConnectedAddressLength:    uint32(len(options.ConnectedAddress)),
+		ConnectedAddress:          options.ConnectedAddress,
+		ConnectedPort:             options.ConnectedPort,
+		OriginatorIPAddressLength: uint32(len(options.OriginatorAddress)),
+		OriginatorIPAddress:       options.OriginatorAddress,
+		OriginatorPort:            options.OriginatorPort,
 	}
 	log.Tracef("%+v", openMessage)
+	return openMessage
+}
+
+func ChannelOpen(senderChannel int) bytes.Buffer {
 	var bin_buf bytes.Buffer
-	err := binary.Write(&bin_buf, binary.BigEndian, openMessage)
+	b, err := ChannelOpenMessage(uint32(senderChannel), ChannelOpenOptions{}).MarshalBinary()
 	if err != nil {
 		log.Error(err)
 	}
+	bin_buf.Write(b)
 	return bin_buf
 }
 
@@ -371,3 +466,47 @@ func ChannelWindowAdjust(recipientChannel uint32, len uint32) APF_CHANNEL_WINDOW
 	message.BytesToAdd = len
 	return message
 }
+
+func UserAuthSuccess() APF_USERAUTH_SUCCESS_MESSAGE {
+	log.Debug("sending APF_USERAUTH_SUCCESS_MESSAGE")
+	return APF_USERAUTH_SUCCESS_MESSAGE{MessageType: APF_USERAUTH_SUCCESS}
+}
+
+func UserAuthFailure() APF_USERAUTH_FAILURE_MESSAGE {
+	log.Debug("sending APF_USERAUTH_FAILURE_MESSAGE")
+	message := APF_USERAUTH_FAILURE_MESSAGE{}
+	message.MessageType = APF_USERAUTH_FAILURE
+	message.AuthenticationsLength = uint32(len(APF_AUTH_PASSWORD))
+	message.Authentications = APF_AUTH_PASSWORD
+	return message
+}
+
+func KeepAliveRequest(cookie uint32) APF_KEEPALIVE_REQUEST_MESSAGE {
+	log.Debug("sending APF_KEEPALIVE_REQUEST_MESSAGE")
+	return APF_KEEPALIVE_REQUEST_MESSAGE{MessageType: APF_KEEPALIVE_REQUEST, Cookie: cookie}
+}
+
+func KeepAliveReply(cookie uint32) APF_KEEPALIVE_REPLY_MESSAGE {
+	log.Debug("sending APF_KEEPALIVE_REPLY_MESSAGE")
+	return APF_KEEPALIVE_REPLY_MESSAGE{MessageType: APF_KEEPALIVE_REPLY, Cookie: cookie}
+}
+
+// KeepAliveOptionsRequest asks AMT to send a keep-alive every interval seconds and to drop the connection after timeout seconds without data.
+func KeepAliveOptionsRequest(interval uint32, timeout uint32) APF_KEEPALIVE_OPTIONS_REQUEST_MESSAGE {
+	log.Debug("sending APF_KEEPALIVE_OPTIONS_REQUEST_MESSAGE")
+	message := APF_KEEPALIVE_OPTIONS_REQUEST_MESSAGE{}
+	message.MessageType = APF_KEEPALIVE_OPTIONS_REQUEST
+	message.KeepaliveInterval = interval
+	message.ReadTimeout = timeout
+	return message
+}
+
+// KeepAliveOptionsReply accepts the keep-alive options of an APF_KEEPALIVE_OPTIONS_REQUEST.
+func KeepAliveOptionsReply(interval uint32, timeout uint32) APF_KEEPALIVE_OPTIONS_REPLY_MESSAGE {
+	log.Debug("sending APF_KEEPALIVE_OPTIONS_REPLY_MESSAGE")
+	message := APF_KEEPALIVE_OPTIONS_REPLY_MESSAGE{}
+	message.MessageType = APF_KEEPALIVE_OPTIONS_REPLY
+	message.KeepaliveInterval = interval
+	message.ReadTimeout = timeout
+	return message
+}
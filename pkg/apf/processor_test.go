@@ -12,15 +12,55 @@ import (
 )
 
 func TestProcess(t *testing.T) {
-	data := []byte{0x01}
+	data := []byte{0x01, 0x00, 0x00, 0x00, 0x0B, 0x00, 0x00}
 
 	session := &Session{}
 
-	result := Process(data, session)
-	assert.NotNil(t, result)
+	result, err := Process(data, session)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, result.Len())
+}
+func TestProcessMalformed(t *testing.T) {
+	session := &Session{}
+	tests := []struct {
+		name string
+		data []byte
+		err  error
+	}{
+		{"empty", []byte{}, ErrShortMessage},
+		{"short disconnect", []byte{0x01}, ErrShortMessage},
+		{"unknown type", []byte{0xFF}, ErrUnknownMessageType},
+		{"service name longer than message", []byte{0x05, 0xFF, 0xFF, 0xFF, 0xFF, 0x61}, ErrLengthMismatch},
+		{"window adjust with trailing data", []byte{0x5D, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00}, ErrTrailingData},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := Process(test.data, session)
+			assert.ErrorIs(t, err, test.err)
+		})
+	}
+}
+func TestProcessGlobalRequestReply(t *testing.T) {
+	request, err := Marshal(APF_GLOBAL_REQUEST_MESSAGE{
+		APF_GENERIC_HEADER: APF_GENERIC_HEADER{String: APF_GLOBAL_REQUEST_STR_TCP_FORWARD_REQUEST},
+		Payload:            []byte{0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x42, 0x60},
+	})
+	assert.NoError(t, err)
+	result, err := Process(request, &Session{})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{APF_REQUEST_SUCCESS, 0x00, 0x00, 0x42, 0x60}, result.Bytes())
+
+	request[len(request)-2], request[len(request)-1] = 0x00, 0x50
+	result, err = Process(request, &Session{})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{APF_REQUEST_FAILURE}, result.Bytes())
 }
 func TestProcessChannelOpenFailure(t *testing.T) {
-	data := []byte{0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}
+	data := []byte{0x5C,
+		0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x02,
+		0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00}
 	errorChannel := make(chan error)
 	statusChannel := make(chan bool)
 
@@ -35,21 +75,25 @@ func TestProcessChannelOpenFailure(t *testing.T) {
 		assert.Error(t, err)
 		assert.False(t, status)
 	}()
-	ProcessChannelOpenFailure(data, session)
+	err := ProcessChannelOpenFailure(data, session)
+	assert.NoError(t, err)
 }
 func TestProcessChannelWindowAdjust(t *testing.T) {
-	data := []byte{0x01}
+	data := []byte{0x5D, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x20}
 	session := &Session{}
-	ProcessChannelWindowAdjust(data, session)
+	err := ProcessChannelWindowAdjust(data, session)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(32), session.TXWindow)
 }
 func TestProcessChannelClose(t *testing.T) {
-	data := []byte{0x01}
+	data := []byte{0x61, 0x00, 0x00, 0x00, 0x01}
 	session := &Session{}
-	result := ProcessChannelClose(data, session)
-	assert.NotNil(t, result)
+	result, err := ProcessChannelClose(data, session)
+	assert.NoError(t, err)
+	assert.Equal(t, ChannelClose(1), result)
 }
 func TestProcessGlobalRequest(t *testing.T) {
-	data := []byte{0x01,
+	data := []byte{0x50,
 		0x00, 0x00, 0x00, 0x0D,
 		0x74, 0x63, 0x70, 0x69, 0x70, 0x2d, 0x66, 0x6f, 0x72, 0x77, 0x61, 0x72, 0x64,
 		0x00,
@@ -57,13 +101,73 @@ func TestProcessGlobalRequest(t *testing.T) {
 		0x00, 0x00, 0x00,
 		0x00, 0x00, 0x42, 0x60}
 
-	result := ProcessGlobalRequest(data)
-	assert.NotNil(t, result)
+	session := &Session{}
+	result, err := ProcessGlobalRequest(data, session)
+	assert.NoError(t, err)
+	assert.Equal(t, TcpForwardReplySuccess(16992), result)
+	assert.Equal(t, []APF_TCP_FORWARD_REQUEST{{AddressLength: 3, Address: "\x00\x00\x00", Port: 16992}}, session.Forwards())
+}
+
+func globalRequest(t *testing.T, name string, payload interface{ MarshalBinary() ([]byte, error) }) []byte {
+	b, err := payload.MarshalBinary()
+	assert.NoError(t, err)
+	data, err := Marshal(APF_GLOBAL_REQUEST_MESSAGE{APF_GENERIC_HEADER: APF_GENERIC_HEADER{String: name}, Payload: b})
+	assert.NoError(t, err)
+	return data
+}
+
+func TestProcessGlobalRequestForwardPolicy(t *testing.T) {
+	session := &Session{ForwardPolicy: func(request APF_TCP_FORWARD_REQUEST) bool {
+		return request.Port == 16994
+	}}
+	result, err := ProcessGlobalRequest(globalRequest(t, APF_GLOBAL_REQUEST_STR_TCP_FORWARD_REQUEST, APF_TCP_FORWARD_REQUEST{WantReply: 1, Port: 16992}), session)
+	assert.NoError(t, err)
+	assert.Equal(t, APF_REQUEST_FAILURE_MESSAGE{MessageType: APF_REQUEST_FAILURE}, result)
+	result, err = ProcessGlobalRequest(globalRequest(t, APF_GLOBAL_REQUEST_STR_TCP_FORWARD_REQUEST, APF_TCP_FORWARD_REQUEST{WantReply: 1, Port: 16994}), session)
+	assert.NoError(t, err)
+	assert.Equal(t, TcpForwardReplySuccess(16994), result)
+	assert.Equal(t, []APF_TCP_FORWARD_REQUEST{{WantReply: 1, Port: 16994}}, session.Forwards())
+}
+
+func TestProcessGlobalRequestForwardCancel(t *testing.T) {
+	session := &Session{}
+	for _, port := range []uint32{16992, 16993, 16992} {
+		_, err := ProcessGlobalRequest(globalRequest(t, APF_GLOBAL_REQUEST_STR_TCP_FORWARD_REQUEST, APF_TCP_FORWARD_REQUEST{WantReply: 1, Address: "a", AddressLength: 1, Port: port}), session)
+		assert.NoError(t, err)
+	}
+	assert.Len(t, session.Forwards(), 2)
+
+	result, err := ProcessGlobalRequest(globalRequest(t, APF_GLOBAL_REQUEST_STR_TCP_FORWARD_CANCEL_REQUEST, APF_TCP_FORWARD_REQUEST{WantReply: 1, Address: "a", Port: 16992}), session)
+	assert.NoError(t, err)
+	assert.Equal(t, APF_REQUEST_SUCCESS_MESSAGE{MessageType: APF_REQUEST_SUCCESS}, result)
+	assert.Equal(t, []APF_TCP_FORWARD_REQUEST{{WantReply: 1, Address: "a", AddressLength: 1, Port: 16993}}, session.Forwards())
+
+	result, err = ProcessGlobalRequest(globalRequest(t, APF_GLOBAL_REQUEST_STR_TCP_FORWARD_CANCEL_REQUEST, APF_TCP_FORWARD_REQUEST{WantReply: 1, Address: "a", Port: 16992}), session)
+	assert.NoError(t, err)
+	assert.Equal(t, APF_REQUEST_FAILURE_MESSAGE{MessageType: APF_REQUEST_FAILURE}, result)
+}
+
+func TestProcessGlobalRequestUdpSendTo(t *testing.T) {
+	request := APF_UDP_SEND_TO_REQUEST{Address: "192.168.0.1", Port: 162, OriginatorAddress: "192.168.0.10", OriginatorPort: 1024, Data: []byte{0x30, 0x00}}
+	var received []APF_UDP_SEND_TO_REQUEST
+	session := &Session{UdpSendTo: func(request APF_UDP_SEND_TO_REQUEST) {
+		received = append(received, request)
+	}}
+	result, err := Process(globalRequest(t, APF_GLOBAL_REQUEST_STR_UDP_SEND_TO, request), session)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, result.Len())
+	request.AddressLength, request.OriginatorAddressLength, request.DataLength = 11, 12, 2
+	assert.Equal(t, []APF_UDP_SEND_TO_REQUEST{request}, received)
+
+	// datagrams are dropped without a handler
+	_, err = Process(globalRequest(t, APF_GLOBAL_REQUEST_STR_UDP_SEND_TO, request), &Session{})
+	assert.NoError(t, err)
 }
 func TestProcessChannelData(t *testing.T) {
-	data := []byte{0x01,
+	data := []byte{0x5E,
 		0x00, 0x00, 0x00, 0x00,
 		0x00, 0x00, 0x00, 0x01,
+		0x2A,
 	}
 	timer := time.NewTimer(time.Duration(2 * time.Second))
 	session := &Session{
@@ -72,32 +176,33 @@ func TestProcessChannelData(t *testing.T) {
 	go func() {
 		<-timer.C
 	}()
-	ProcessChannelData(data, session)
-
+	err := ProcessChannelData(data, session)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0x2A}, session.Tempdata)
 }
 func TestProcessServiceRequestWhenAUTH(t *testing.T) {
-	data := []byte{0x01, 0x00, 0x00, 0x00, 0x12, 0x61, 0x75, 0x74, 0x68, 0x40, 0x61, 0x6d, 0x74, 0x2e, 0x69, 0x6e, 0x74, 0x65, 0x6c, 0x2e, 0x63, 0x6f, 0x6d}
-	hi := int(0x12)
-	println(hi)
-	result := ProcessServiceRequest(data)
-	assert.NotNil(t, result)
+	data := []byte{0x05, 0x00, 0x00, 0x00, 0x12, 0x61, 0x75, 0x74, 0x68, 0x40, 0x61, 0x6d, 0x74, 0x2e, 0x69, 0x6e, 0x74, 0x65, 0x6c, 0x2e, 0x63, 0x6f, 0x6d}
+	result, err := ProcessServiceRequest(data)
+	assert.NoError(t, err)
 	assert.Equal(t, uint8(0x6), result.MessageType) // APF_SERVICE_ACCEPT
 	assert.Equal(t, uint32(0x12), result.ServiceNameLength)
-	assert.Equal(t, [18]uint8{0x61, 0x75, 0x74, 0x68, 0x40, 0x61, 0x6d, 0x74, 0x2e, 0x69, 0x6e, 0x74, 0x65, 0x6c, 0x2e, 0x63, 0x6f, 0x6d}, result.ServiceName)
+	assert.Equal(t, APF_SERVICE_AUTH, result.ServiceName)
 }
 
 func TestProcessServiceRequestWhenPWFD(t *testing.T) {
-	data := []byte{0x01, 0x00, 0x00, 0x00, 0x12, 0x70, 0x66, 0x77, 0x64, 0x40, 0x61, 0x6d, 0x74, 0x2e, 0x69, 0x6e, 0x74, 0x65, 0x6c, 0x2e, 0x63, 0x6f, 0x6d}
-	hi := int(0x12)
-	println(hi)
-	result := ProcessServiceRequest(data)
-	assert.NotNil(t, result)
+	data := []byte{0x05, 0x00, 0x00, 0x00, 0x12, 0x70, 0x66, 0x77, 0x64, 0x40, 0x61, 0x6d, 0x74, 0x2e, 0x69, 0x6e, 0x74, 0x65, 0x6c, 0x2e, 0x63, 0x6f, 0x6d}
+	result, err := ProcessServiceRequest(data)
+	assert.NoError(t, err)
 	assert.Equal(t, uint8(0x6), result.MessageType) // APF_SERVICE_ACCEPT
 	assert.Equal(t, uint32(0x12), result.ServiceNameLength)
-	assert.Equal(t, [18]uint8{0x70, 0x66, 0x77, 0x64, 0x40, 0x61, 0x6d, 0x74, 0x2e, 0x69, 0x6e, 0x74, 0x65, 0x6c, 0x2e, 0x63, 0x6f, 0x6d}, result.ServiceName)
+	assert.Equal(t, APF_SERVICE_PFWD, result.ServiceName)
 }
 func TestProcessChannelOpenConfirmation(t *testing.T) {
-	data := []byte{0x01}
+	data := []byte{0x5B,
+		0x00, 0x00, 0x00, 0x01,
+		0x00, 0x00, 0x00, 0x02,
+		0x00, 0x00, 0x10, 0x00,
+		0xFF, 0xFF, 0xFF, 0xFF}
 	statusChannel := make(chan bool)
 	session := &Session{
 		Status: statusChannel,
@@ -107,12 +212,20 @@ func TestProcessChannelOpenConfirmation(t *testing.T) {
 		<-statusChannel
 		println("Hello, status  is done")
 	}()
-	ProcessChannelOpenConfirmation(data, session)
+	err := ProcessChannelOpenConfirmation(data, session)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(2), session.SenderChannel)
+	assert.Equal(t, uint32(4096), session.TXWindow)
 }
 func TestProcessProtocolVersion(t *testing.T) {
-	data := []byte{0x01}
-	result := ProcessProtocolVersion(data)
-	assert.NotNil(t, result)
+	data, err := Marshal(ProtocolVersion(1, 0, APF_TRIGGER_REASON_PERIODIC_REQUEST))
+	assert.NoError(t, err)
+	result, err := ProcessProtocolVersion(data)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(1), result.MajorVersion)
+
+	_, err = ProcessProtocolVersion(data[:10])
+	assert.ErrorIs(t, err, ErrShortMessage)
 }
 
 func TestServiceAcceptLessThan18Characters(t *testing.T) {
@@ -148,6 +261,40 @@ func TestChannelOpen(t *testing.T) {
 	result := ChannelOpen(1)
 	assert.NotNil(t, result)
 }
+func TestChannelOpenMessage(t *testing.T) {
+	result := ChannelOpenMessage(1, ChannelOpenOptions{})
+	assert.Equal(t, APF_OPEN_CHANNEL_REQUEST_FORWARDED, result.ChannelType)
+	assert.Equal(t, "::1", result.ConnectedAddress)
+	assert.Equal(t, uint32(16992), result.ConnectedPort)
+	assert.Equal(t, uint32(LME_RX_WINDOW_SIZE), result.InitialWindowSize)
+
+	result = ChannelOpenMessage(2, ChannelOpenOptions{ChannelType: APF_OPEN_CHANNEL_REQUEST_DIRECT, ConnectedAddress: "192.168.0.10", ConnectedPort: 16995, OriginatorAddress: "10.0.0.1", OriginatorPort: 50000})
+	assert.Equal(t, APF_OPEN_CHANNEL_REQUEST_DIRECT, result.ChannelType)
+	assert.Equal(t, uint32(2), result.SenderChannel)
+	assert.Equal(t, "192.168.0.10", result.ConnectedAddress)
+	assert.Equal(t, uint32(16995), result.ConnectedPort)
+	assert.Equal(t, "10.0.0.1", result.OriginatorIPAddress)
+	assert.Equal(t, uint32(50000), result.OriginatorPort)
+}
+func TestProcessChannelOpen(t *testing.T) {
+	tests := []struct {
+		channelType string
+		reason      uint32
+	}{
+		{APF_OPEN_CHANNEL_REQUEST_FORWARDED, OPEN_FAILURE_REASON_ADMINISTRATIVELY_PROHIBITED},
+		{APF_OPEN_CHANNEL_REQUEST_DIRECT, OPEN_FAILURE_REASON_ADMINISTRATIVELY_PROHIBITED},
+		{"session", OPEN_FAILURE_REASON_UNKNOWN_CHANNEL_TYPE},
+	}
+	for _, test := range tests {
+		data, err := Marshal(APF_CHANNEL_OPEN_MESSAGE{ChannelType: test.channelType, SenderChannel: 4})
+		assert.NoError(t, err)
+		result, err := ProcessChannelOpen(data)
+		assert.NoError(t, err)
+		assert.Equal(t, ChannelOpenReplyFailure(4, test.reason), result)
+	}
+	_, err := ProcessChannelOpen([]byte{APF_CHANNEL_OPEN})
+	assert.Error(t, err)
+}
 func TestChannelOpenReplySuccess(t *testing.T) {
 	result := ChannelOpenReplySuccess(0, 1)
 	assert.NotNil(t, result)
@@ -169,3 +316,76 @@ func TestChannelWindowAdjust(t *testing.T) {
 	result := ChannelWindowAdjust(0, 32)
 	assert.NotNil(t, result)
 }
+
+func TestProcessUserAuthRequest(t *testing.T) {
+	request := APF_USERAUTH_REQUEST_MESSAGE{Username: "admin", ServiceName: APF_SERVICE_PFWD, MethodName: APF_AUTH_PASSWORD, Password: "P@ssw0rd"}
+	data, err := Marshal(request)
+	assert.NoError(t, err)
+	var received APF_USERAUTH_REQUEST_MESSAGE
+	session := &Session{Authenticate: func(r APF_USERAUTH_REQUEST_MESSAGE) bool {
+		received = r
+		return r.Username == "admin" && r.Password == "P@ssw0rd"
+	}}
+
+	result, err := Process(data, session)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{APF_USERAUTH_SUCCESS}, result.Bytes())
+	assert.Equal(t, "P@ssw0rd", received.Password)
+	assert.Equal(t, APF_SERVICE_PFWD, received.ServiceName)
+
+	request.Password = "wrong"
+	data, err = Marshal(request)
+	assert.NoError(t, err)
+	result, err = Process(data, session)
+	assert.NoError(t, err)
+	expected, _ := Marshal(UserAuthFailure())
+	assert.Equal(t, expected, result.Bytes())
+}
+func TestProcessUserAuthRequestWithoutAuthenticate(t *testing.T) {
+	data, err := Marshal(APF_USERAUTH_REQUEST_MESSAGE{MethodName: APF_AUTH_NONE})
+	assert.NoError(t, err)
+	result, err := ProcessUserAuthRequest(data, &Session{})
+	assert.NoError(t, err)
+	assert.Equal(t, UserAuthFailure(), result)
+
+	_, err = ProcessUserAuthRequest(data[:len(data)-1], &Session{})
+	assert.ErrorIs(t, err, ErrLengthMismatch)
+}
+func TestProcessUserAuthRequestRequestsKeepAliveOptions(t *testing.T) {
+	data, err := Marshal(APF_USERAUTH_REQUEST_MESSAGE{MethodName: APF_AUTH_NONE})
+	assert.NoError(t, err)
+	session := &Session{
+		Authenticate:         func(APF_USERAUTH_REQUEST_MESSAGE) bool { return true },
+		KeepAliveInterval:    30,
+		KeepAliveReadTimeout: 90,
+	}
+	result, err := Process(data, session)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{APF_USERAUTH_SUCCESS, APF_KEEPALIVE_OPTIONS_REQUEST, 0x00, 0x00, 0x00, 0x1E, 0x00, 0x00, 0x00, 0x5A}, result.Bytes())
+
+	result, err = Process([]byte{APF_KEEPALIVE_OPTIONS_REPLY, 0x00, 0x00, 0x00, 0x3C, 0x00, 0x00, 0x00, 0x78}, session)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, result.Len())
+	assert.Equal(t, uint32(60), session.KeepAliveInterval)
+	assert.Equal(t, uint32(120), session.KeepAliveReadTimeout)
+}
+func TestProcessKeepAlive(t *testing.T) {
+	result, err := Process([]byte{APF_KEEPALIVE_REQUEST, 0x01, 0x02, 0x03, 0x04}, &Session{})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{APF_KEEPALIVE_REPLY, 0x01, 0x02, 0x03, 0x04}, result.Bytes())
+
+	result, err = Process([]byte{APF_KEEPALIVE_REPLY, 0x01, 0x02, 0x03, 0x04}, &Session{})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, result.Len())
+
+	_, err = Process([]byte{APF_KEEPALIVE_REQUEST, 0x01}, &Session{})
+	assert.ErrorIs(t, err, ErrShortMessage)
+}
+func TestProcessKeepAliveOptionsRequest(t *testing.T) {
+	session := &Session{}
+	result, err := Process([]byte{APF_KEEPALIVE_OPTIONS_REQUEST, 0x00, 0x00, 0x00, 0x1E, 0x00, 0x00, 0x00, 0x5A}, session)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{APF_KEEPALIVE_OPTIONS_REPLY, 0x00, 0x00, 0x00, 0x1E, 0x00, 0x00, 0x00, 0x5A}, result.Bytes())
+	assert.Equal(t, uint32(30), session.KeepAliveInterval)
+	assert.Equal(t, uint32(90), session.KeepAliveReadTimeout)
+}
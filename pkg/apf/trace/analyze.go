@@ -0,0 +1,186 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package trace
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/apf"
+)
+
+// Violation is a frame of a trace that breaks the APF protocol.
+type Violation struct {
+	Record    int // index of the record in the trace
+	Time      time.Time
+	Direction Direction
+	Reason    string
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("record %d %s: %s", v.Record, v.Direction, v.Reason)
+}
+
+// Channel is an APF channel reconstructed from a trace.
+type Channel struct {
+	Type string
+	// Opener is the direction of the APF_CHANNEL_OPEN.
+	Opener Direction
+	// LocalID and PeerID are the channel numbers allocated by the recording side and by its peer.
+	LocalID uint32
+	PeerID  uint32
+	// Confirmed is set once the channel open was confirmed, Failed when it was rejected.
+	Confirmed bool
+	Failed    bool
+	// Data holds the payloads of APF_CHANNEL_DATA by direction, for example the HTTP requests and responses of WS-Man.
+	Data [2][]byte
+
+	window [2]uint32
+	closed [2]bool
+}
+
+// Analyzer reconstructs the channels of a trace record by record and checks the frames against the protocol: frames
+// must decode, channel messages must address an open channel, data must fit the window of its receiver and no side may
+// send data after closing a channel.
+type Analyzer struct {
+	// Channels lists every channel in the order they were opened.
+	Channels []*Channel
+	// Violations lists every violation found so far.
+	Violations []Violation
+
+	records int
+	// channels indexes the channels that are not closed yet by the numbers allocated by the side sending in a direction.
+	channels [2]map[uint32]*Channel
+}
+
+// NewAnalyzer returns an Analyzer for a new trace.
+func NewAnalyzer() *Analyzer {
+	return &Analyzer{channels: [2]map[uint32]*Channel{{}, {}}}
+}
+
+// Analyze reads every record of r and returns the analysis.
+func Analyze(r *Reader) (*Analyzer, error) {
+	a := NewAnalyzer()
+	for {
+		record, err := r.Next()
+		if err != nil {
+			if err == io.EOF {
+				return a, nil
+			}
+			return a, err
+		}
+		a.Add(record)
+	}
+}
+
+// Add analyzes the next record of the trace and returns the violations it causes.
+func (a *Analyzer) Add(record Record) []Violation {
+	index := a.records
+	a.records++
+	found := len(a.Violations)
+	violate := func(format string, args ...interface{}) {
+		a.Violations = append(a.Violations, Violation{Record: index, Time: record.Time, Direction: record.Direction, Reason: fmt.Sprintf(format, args...)})
+	}
+	d := record.Direction
+	message, err := record.Message()
+	if err != nil {
+		violate("undecodable frame: %v", err)
+		return a.Violations[found:]
+	}
+	switch m := message.(type) {
+	case apf.APF_CHANNEL_OPEN_MESSAGE:
+		if a.channels[d][m.SenderChannel] != nil {
+			violate("APF_CHANNEL_OPEN reuses open channel %d", m.SenderChannel)
+		}
+		c := &Channel{Type: m.ChannelType, Opener: d}
+		if d == Sent {
+			c.LocalID = m.SenderChannel
+		} else {
+			c.PeerID = m.SenderChannel
+		}
+		c.window[d.reverse()] = m.InitialWindowSize
+		a.channels[d][m.SenderChannel] = c
+		a.Channels = append(a.Channels, c)
+	case apf.APF_CHANNEL_OPEN_CONFIRMATION_MESSAGE:
+		c := a.channels[d.reverse()][m.RecipientChannel]
+		switch {
+		case c == nil:
+			violate("APF_CHANNEL_OPEN_CONFIRMATION for unknown channel %d", m.RecipientChannel)
+		case c.Opener == d || c.Confirmed:
+			violate("unexpected APF_CHANNEL_OPEN_CONFIRMATION for channel %d", m.RecipientChannel)
+		default:
+			c.Confirmed = true
+			if d == Sent {
+				c.LocalID = m.SenderChannel
+			} else {
+				c.PeerID = m.SenderChannel
+			}
+			c.window[d.reverse()] = m.InitialWindowSize
+			a.channels[d][m.SenderChannel] = c
+		}
+	case apf.APF_CHANNEL_OPEN_FAILURE_MESSAGE:
+		c := a.channels[d.reverse()][m.RecipientChannel]
+		if c == nil || c.Opener == d || c.Confirmed {
+			violate("unexpected APF_CHANNEL_OPEN_FAILURE for channel %d", m.RecipientChannel)
+			break
+		}
+		c.Failed = true
+		delete(a.channels[d.reverse()], m.RecipientChannel)
+	case apf.APF_CHANNEL_DATA_MESSAGE:
+		c := a.channel(d, m.RecipientChannel, "APF_CHANNEL_DATA", violate)
+		if c == nil {
+			break
+		}
+		if c.closed[d] {
+			violate("APF_CHANNEL_DATA on channel %d after APF_CHANNEL_CLOSE", m.RecipientChannel)
+		}
+		if m.DataLength > c.window[d] {
+			violate("APF_CHANNEL_DATA of %d bytes on channel %d exceeds the window of %d bytes", m.DataLength, m.RecipientChannel, c.window[d])
+			c.window[d] = 0
+		} else {
+			c.window[d] -= m.DataLength
+		}
+		c.Data[d] = append(c.Data[d], m.Data...)
+	case apf.APF_CHANNEL_WINDOW_ADJUST_MESSAGE:
+		if c := a.channel(d, m.RecipientChannel, "APF_CHANNEL_WINDOW_ADJUST", violate); c != nil {
+			if uint64(c.window[d.reverse()])+uint64(m.BytesToAdd) > math.MaxUint32 {
+				c.window[d.reverse()] = math.MaxUint32
+			} else {
+				c.window[d.reverse()] += m.BytesToAdd
+			}
+		}
+	case apf.APF_CHANNEL_CLOSE_MESSAGE:
+		c := a.channel(d, m.RecipientChannel, "APF_CHANNEL_CLOSE", violate)
+		if c == nil {
+			break
+		}
+		if c.closed[d] {
+			violate("repeated APF_CHANNEL_CLOSE on channel %d", m.RecipientChannel)
+		}
+		c.closed[d] = true
+		if c.closed[d.reverse()] {
+			delete(a.channels[Sent], c.LocalID)
+			delete(a.channels[Received], c.PeerID)
+		}
+	}
+	return a.Violations[found:]
+}
+
+// channel returns the confirmed channel a message sent in direction addresses by the number its receiver allocated.
+func (a *Analyzer) channel(d Direction, recipient uint32, name string, violate func(string, ...interface{})) *Channel {
+	c := a.channels[d.reverse()][recipient]
+	if c == nil {
+		violate("%s on unknown channel %d", name, recipient)
+		return nil
+	}
+	if !c.Confirmed {
+		violate("%s on channel %d before it was confirmed", name, recipient)
+		return nil
+	}
+	return c
+}
@@ -0,0 +1,102 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package trace
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/apf"
+)
+
+type step struct {
+	direction Direction
+	message   interface{}
+}
+
+func analyze(t *testing.T, steps ...step) *Analyzer {
+	trace := bytes.Buffer{}
+	recorder := NewRecorder(&trace)
+	for _, s := range steps {
+		require.NoError(t, recorder.Record(s.direction, marshal(t, s.message)))
+	}
+	a, err := Analyze(NewReader(&trace))
+	require.NoError(t, err)
+	return a
+}
+
+func open(id, window uint32) apf.APF_CHANNEL_OPEN_MESSAGE {
+	message := apf.ChannelOpenMessage(id, apf.ChannelOpenOptions{})
+	message.InitialWindowSize = window
+	return message
+}
+
+func confirm(recipient, sender, window uint32) apf.APF_CHANNEL_OPEN_CONFIRMATION_MESSAGE {
+	message := apf.ChannelOpenReplySuccess(recipient, sender)
+	message.InitialWindowSize = window
+	return message
+}
+
+func TestAnalyzeChannelStreams(t *testing.T) {
+	a := analyze(t,
+		step{Sent, open(1, 4096)},
+		step{Received, confirm(1, 7, 8)},
+		step{Sent, apf.ChannelData(7, []byte("GET /"))},
+		step{Received, apf.ChannelWindowAdjust(1, 6)},
+		step{Received, apf.ChannelData(1, []byte("200 OK"))},
+		step{Sent, apf.ChannelData(7, []byte(" HTTP/1.1"))},
+		step{Sent, apf.ChannelClose(7)},
+		step{Received, apf.ChannelClose(1)},
+		step{Received, open(1, 4096)},
+		step{Sent, apf.ChannelOpenReplyFailure(1, apf.OPEN_FAILURE_REASON_ADMINISTRATIVELY_PROHIBITED)},
+	)
+	assert.Empty(t, a.Violations)
+	require.Len(t, a.Channels, 2)
+	c := a.Channels[0]
+	assert.Equal(t, apf.APF_OPEN_CHANNEL_REQUEST_FORWARDED, c.Type)
+	assert.Equal(t, Sent, c.Opener)
+	assert.Equal(t, uint32(1), c.LocalID)
+	assert.Equal(t, uint32(7), c.PeerID)
+	assert.True(t, c.Confirmed)
+	assert.Equal(t, "GET / HTTP/1.1", string(c.Data[Sent]))
+	assert.Equal(t, "200 OK", string(c.Data[Received]))
+	assert.Equal(t, Received, a.Channels[1].Opener)
+	assert.True(t, a.Channels[1].Failed)
+}
+
+func TestAnalyzeViolations(t *testing.T) {
+	a := analyze(t,
+		step{Sent, open(1, 4)},
+		step{Sent, apf.ChannelData(7, []byte("early"))},
+		step{Received, confirm(1, 7, 4)},
+		step{Received, apf.ChannelData(1, []byte("overrun"))},
+		step{Received, apf.ChannelData(2, []byte("x"))},
+		step{Sent, apf.ChannelClose(7)},
+		step{Sent, apf.ChannelData(7, []byte("x"))},
+		step{Received, confirm(9, 8, 0)},
+	)
+	reasons := []string{}
+	for _, v := range a.Violations {
+		reasons = append(reasons, v.String())
+	}
+	assert.Equal(t, []string{
+		"record 1 ->: APF_CHANNEL_DATA on unknown channel 7",
+		"record 3 <-: APF_CHANNEL_DATA of 7 bytes on channel 1 exceeds the window of 4 bytes",
+		"record 4 <-: APF_CHANNEL_DATA on unknown channel 2",
+		"record 6 ->: APF_CHANNEL_DATA on channel 7 after APF_CHANNEL_CLOSE",
+		"record 7 <-: APF_CHANNEL_OPEN_CONFIRMATION for unknown channel 9",
+	}, reasons)
+}
+
+func TestAnalyzerUndecodableFrame(t *testing.T) {
+	a := NewAnalyzer()
+	violations := a.Add(Record{Direction: Received, Frame: []byte{apf.APF_CHANNEL_DATA, 0x00}})
+	require.Len(t, violations, 1)
+	assert.Contains(t, violations[0].Reason, "undecodable frame")
+}
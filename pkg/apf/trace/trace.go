@@ -0,0 +1,278 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+// Package trace records the APF frames exchanged over a connection to a file, and reads and analyzes such traces.
+//
+// A trace starts with the 8 byte magic "APFTRACE" and a version byte, followed by one record per frame: the direction
+// byte, the time in nanoseconds since the Unix epoch as a big endian int64, the frame length as a big endian uint32 and
+// the frame itself.
+package trace
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/apf"
+)
+
+const (
+	magic   = "APFTRACE"
+	version = 1
+)
+
+// ErrFormat is returned when reading data that is not a trace of a supported version.
+var ErrFormat = errors.New("trace: invalid trace")
+
+// Direction tells which side of the traced connection sent a frame.
+type Direction byte
+
+const (
+	Sent     Direction = 0 // sent by the side the recorder runs on
+	Received Direction = 1 // received from the peer
+)
+
+func (d Direction) String() string {
+	if d == Sent {
+		return "->"
+	}
+	return "<-"
+}
+
+// reverse returns the direction of the replies to frames sent in d.
+func (d Direction) reverse() Direction {
+	return 1 - d
+}
+
+// Record is a frame of a trace.
+type Record struct {
+	Time      time.Time
+	Direction Direction
+	Frame     []byte
+}
+
+// Message decodes the frame, see apf.Unmarshal.
+func (r Record) Message() (interface{}, error) {
+	return apf.Unmarshal(r.Frame)
+}
+
+// Recorder writes the frames exchanged over a connection as a trace. The bytes of each direction are split into frames
+// with an apf.Reader that knows the global requests of the other direction, once a direction holds bytes that cannot be
+// framed they are recorded as they arrive.
+type Recorder struct {
+	mu      sync.Mutex
+	w       io.Writer
+	started bool
+	streams [2]stream
+	err     error
+}
+
+// stream holds the bytes of a direction that do not form a complete frame yet.
+type stream struct {
+	pending []byte
+	broken  bool
+	source  pendingReader
+	reader  *apf.Reader
+}
+
+// pendingReader reads the pending bytes of a stream, from the start again after rewind.
+type pendingReader struct {
+	s      *stream
+	offset int
+}
+
+func (p *pendingReader) Read(b []byte) (int, error) {
+	if p.offset >= len(p.s.pending) {
+		return 0, io.EOF
+	}
+	n := copy(b, p.s.pending[p.offset:])
+	p.offset += n
+	return n, nil
+}
+
+// NewRecorder returns a Recorder writing to w.
+func NewRecorder(w io.Writer) *Recorder {
+	r := &Recorder{w: w}
+	for i := range r.streams {
+		s := &r.streams[i]
+		s.source.s = s
+		s.reader = apf.NewReader(&s.source)
+	}
+	return r
+}
+
+// Observe records the bytes b transferred in direction, as part of the stream of frames in that direction.
+func (r *Recorder) Observe(direction Direction, b []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	s := &r.streams[direction]
+	s.pending = append(s.pending, b...)
+	for !s.broken && len(s.pending) > 0 {
+		s.source.offset = 0
+		frame, err := s.reader.ReadMessage()
+		if err == io.ErrUnexpectedEOF {
+			return r.err
+		}
+		if err != nil {
+			log.Debugf("trace: recording %s bytes unframed: %v", direction, err)
+			s.broken = true
+			break
+		}
+		r.write(now, direction, frame)
+		r.streams[direction.reverse()].reader.Sent(frame)
+		s.pending = s.pending[len(frame):]
+	}
+	for s.broken && len(s.pending) > 0 {
+		n := len(s.pending)
+		if n > apf.MaxMessageSize {
+			n = apf.MaxMessageSize
+		}
+		r.write(now, direction, s.pending[:n])
+		s.pending = s.pending[n:]
+	}
+	s.pending = nil
+	return r.err
+}
+
+// Record records frame as sent in direction.
+func (r *Recorder) Record(direction Direction, frame []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(frame) > apf.MaxMessageSize {
+		return fmt.Errorf("%w: %d byte frame", apf.ErrMessageTooLarge, len(frame))
+	}
+	r.write(time.Now(), direction, frame)
+	r.streams[direction.reverse()].reader.Sent(frame)
+	return r.err
+}
+
+// write writes a record, or nothing once writing failed. It is called with mu held.
+func (r *Recorder) write(t time.Time, direction Direction, frame []byte) {
+	if r.err != nil {
+		return
+	}
+	b := make([]byte, 0, len(magic)+1+13+len(frame))
+	if !r.started {
+		b = append(b, magic...)
+		b = append(b, version)
+		r.started = true
+	}
+	b = append(b, byte(direction))
+	b = binary.BigEndian.AppendUint64(b, uint64(t.UnixNano()))
+	b = binary.BigEndian.AppendUint32(b, uint32(len(frame)))
+	b = append(b, frame...)
+	_, r.err = r.w.Write(b)
+}
+
+// Wrap returns conn recording what is read as Received and what is written as Sent. Failing to record does not fail
+// the connection.
+func (r *Recorder) Wrap(conn io.ReadWriteCloser) io.ReadWriteCloser {
+	return &tracedConn{ReadWriteCloser: conn, recorder: r}
+}
+
+// WrapConn is Wrap for a net.Conn.
+func (r *Recorder) WrapConn(conn net.Conn) net.Conn {
+	return &tracedNetConn{Conn: conn, traced: tracedConn{ReadWriteCloser: conn, recorder: r}}
+}
+
+type tracedConn struct {
+	io.ReadWriteCloser
+	recorder *Recorder
+}
+
+func (c *tracedConn) Read(b []byte) (int, error) {
+	n, err := c.ReadWriteCloser.Read(b)
+	if n > 0 {
+		c.observe(Received, b[:n])
+	}
+	return n, err
+}
+
+func (c *tracedConn) Write(b []byte) (int, error) {
+	n, err := c.ReadWriteCloser.Write(b)
+	if n > 0 {
+		c.observe(Sent, b[:n])
+	}
+	return n, err
+}
+
+func (c *tracedConn) observe(direction Direction, b []byte) {
+	if err := c.recorder.Observe(direction, b); err != nil {
+		log.Debugf("trace: %v", err)
+	}
+}
+
+type tracedNetConn struct {
+	net.Conn
+	traced tracedConn
+}
+
+func (c *tracedNetConn) Read(b []byte) (int, error) {
+	return c.traced.Read(b)
+}
+
+func (c *tracedNetConn) Write(b []byte) (int, error) {
+	return c.traced.Write(b)
+}
+
+// Reader reads the records of a trace.
+type Reader struct {
+	r       *bufio.Reader
+	started bool
+}
+
+// NewReader returns a Reader reading the trace from r.
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: bufio.NewReader(r)}
+}
+
+// Next returns the next record, or io.EOF at the end of the trace.
+func (r *Reader) Next() (Record, error) {
+	if !r.started {
+		header := make([]byte, len(magic)+1)
+		if _, err := io.ReadFull(r.r, header); err != nil {
+			if err == io.EOF {
+				return Record{}, io.EOF
+			}
+			return Record{}, fmt.Errorf("%w: %v", ErrFormat, err)
+		}
+		if string(header[:len(magic)]) != magic || header[len(magic)] != version {
+			return Record{}, fmt.Errorf("%w: unknown header %q", ErrFormat, header)
+		}
+		r.started = true
+	}
+	head := make([]byte, 13)
+	if _, err := io.ReadFull(r.r, head); err != nil {
+		if err == io.EOF {
+			return Record{}, io.EOF
+		}
+		return Record{}, fmt.Errorf("%w: %v", ErrFormat, err)
+	}
+	direction := Direction(head[0])
+	if direction != Sent && direction != Received {
+		return Record{}, fmt.Errorf("%w: unknown direction %d", ErrFormat, head[0])
+	}
+	length := binary.BigEndian.Uint32(head[9:])
+	if length > apf.MaxMessageSize {
+		return Record{}, fmt.Errorf("%w: %d byte frame", ErrFormat, length)
+	}
+	frame := make([]byte, length)
+	if _, err := io.ReadFull(r.r, frame); err != nil {
+		return Record{}, fmt.Errorf("%w: %v", ErrFormat, err)
+	}
+	return Record{
+		Time:      time.Unix(0, int64(binary.BigEndian.Uint64(head[1:]))),
+		Direction: direction,
+		Frame:     frame,
+	}, nil
+}
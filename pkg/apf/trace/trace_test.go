@@ -0,0 +1,151 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package trace
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/apf"
+)
+
+func marshal(t *testing.T, messages ...interface{}) []byte {
+	b := []byte{}
+	for _, message := range messages {
+		data, err := apf.Marshal(message)
+		require.NoError(t, err)
+		b = append(b, data...)
+	}
+	return b
+}
+
+func readAll(t *testing.T, r io.Reader) []Record {
+	reader := NewReader(r)
+	records := []Record{}
+	for {
+		record, err := reader.Next()
+		if err == io.EOF {
+			return records
+		}
+		require.NoError(t, err)
+		records = append(records, record)
+	}
+}
+
+func TestRecorderSplitsFrames(t *testing.T) {
+	trace := bytes.Buffer{}
+	recorder := NewRecorder(&trace)
+	sent := marshal(t, apf.KeepAliveRequest(1), apf.ChannelData(3, []byte("hello")))
+	received := marshal(t, apf.KeepAliveReply(1))
+
+	// frames split across writes and writes holding several frames
+	require.NoError(t, recorder.Observe(Sent, sent[:3]))
+	require.NoError(t, recorder.Observe(Received, received))
+	require.NoError(t, recorder.Observe(Sent, sent[3:10]))
+	require.NoError(t, recorder.Observe(Sent, sent[10:]))
+
+	records := readAll(t, &trace)
+	require.Len(t, records, 3)
+	assert.Equal(t, Received, records[0].Direction)
+	assert.Equal(t, received, records[0].Frame)
+	assert.Equal(t, Sent, records[1].Direction)
+	assert.Equal(t, marshal(t, apf.KeepAliveRequest(1)), records[1].Frame)
+	message, err := records[2].Message()
+	assert.NoError(t, err)
+	assert.Equal(t, apf.ChannelData(3, []byte("hello")), message)
+	assert.False(t, records[2].Time.Before(records[1].Time))
+}
+
+func TestRecorderUnframedBytes(t *testing.T) {
+	trace := bytes.Buffer{}
+	recorder := NewRecorder(&trace)
+	require.NoError(t, recorder.Observe(Received, append(marshal(t, apf.KeepAliveRequest(1)), 0xFF, 0x01)))
+	require.NoError(t, recorder.Observe(Received, marshal(t, apf.KeepAliveRequest(2))))
+
+	records := readAll(t, &trace)
+	require.Len(t, records, 3)
+	assert.Equal(t, []byte{0xFF, 0x01}, records[1].Frame)
+	// once a direction cannot be framed its bytes are recorded as they arrive
+	assert.Equal(t, marshal(t, apf.KeepAliveRequest(2)), records[2].Frame)
+}
+
+func TestRecorderFramesRequestSuccessByRequest(t *testing.T) {
+	trace := bytes.Buffer{}
+	recorder := NewRecorder(&trace)
+	forward, err := apf.APF_TCP_FORWARD_REQUEST{WantReply: 1, Address: "0.0.0.0", Port: 16992}.MarshalBinary()
+	require.NoError(t, err)
+	request := func(name string) []byte {
+		return marshal(t, apf.APF_GLOBAL_REQUEST_MESSAGE{APF_GENERIC_HEADER: apf.APF_GENERIC_HEADER{String: name}, Payload: forward})
+	}
+	bound := marshal(t, apf.TcpForwardReplySuccess(16992))
+	cancelled := marshal(t, apf.APF_REQUEST_SUCCESS_MESSAGE{MessageType: apf.APF_REQUEST_SUCCESS})
+	keepAlive := marshal(t, apf.KeepAliveRequest(1))
+
+	require.NoError(t, recorder.Observe(Received, request(apf.APF_GLOBAL_REQUEST_STR_TCP_FORWARD_REQUEST)))
+	require.NoError(t, recorder.Observe(Sent, bound))
+	require.NoError(t, recorder.Observe(Received, request(apf.APF_GLOBAL_REQUEST_STR_TCP_FORWARD_CANCEL_REQUEST)))
+	// the one byte reply to the cancel shares a write with the next frame
+	require.NoError(t, recorder.Observe(Sent, append(append([]byte(nil), cancelled...), keepAlive...)))
+
+	records := readAll(t, &trace)
+	require.Len(t, records, 5)
+	assert.Equal(t, bound, records[1].Frame)
+	assert.Equal(t, cancelled, records[3].Frame)
+	assert.Equal(t, keepAlive, records[4].Frame)
+
+	a := NewAnalyzer()
+	for _, record := range records {
+		a.Add(record)
+	}
+	assert.Empty(t, a.Violations)
+}
+
+func TestRecorderWrapConn(t *testing.T) {
+	trace := bytes.Buffer{}
+	recorder := NewRecorder(&trace)
+	local, remote := net.Pipe()
+	conn := recorder.WrapConn(local)
+	defer conn.Close()
+	go func() {
+		remote.Write(marshal(t, apf.KeepAliveRequest(7)))
+		apf.ReadMessage(remote)
+		remote.Close()
+	}()
+
+	data, err := apf.ReadMessage(conn)
+	require.NoError(t, err)
+	_, err = conn.Write(marshal(t, apf.KeepAliveReply(7)))
+	require.NoError(t, err)
+	assert.Equal(t, "pipe", conn.LocalAddr().Network())
+
+	records := readAll(t, &trace)
+	require.Len(t, records, 2)
+	assert.Equal(t, Record{Time: records[0].Time, Direction: Received, Frame: data}, records[0])
+	assert.Equal(t, Record{Time: records[1].Time, Direction: Sent, Frame: marshal(t, apf.KeepAliveReply(7))}, records[1])
+}
+
+func TestReaderErrors(t *testing.T) {
+	_, err := NewReader(bytes.NewReader(nil)).Next()
+	assert.Equal(t, io.EOF, err)
+
+	_, err = NewReader(bytes.NewReader([]byte("NOTATRACE"))).Next()
+	assert.ErrorIs(t, err, ErrFormat)
+
+	trace := bytes.Buffer{}
+	require.NoError(t, NewRecorder(&trace).Record(Sent, []byte{apf.APF_USERAUTH_SUCCESS}))
+	_, err = NewReader(bytes.NewReader(trace.Bytes()[:trace.Len()-1])).Next()
+	assert.ErrorIs(t, err, ErrFormat)
+
+	b := trace.Bytes()
+	b[len(magic)+1] = 2
+	_, err = NewReader(bytes.NewReader(b)).Next()
+	assert.ErrorIs(t, err, ErrFormat)
+}
@@ -4,7 +4,10 @@
  **********************************************************************/
 package apf
 
-import "time"
+import (
+	"sync"
+	"time"
+)
 
 const LMS_PROTOCOL_VERSION = 4
 const LME_RX_WINDOW_SIZE = 4096
@@ -26,6 +29,10 @@ const APF_CHANNEL_WINDOW_ADJUST = 93
 const APF_CHANNEL_DATA = 94
 const APF_CHANNEL_CLOSE = 97
 const APF_PROTOCOLVERSION = 192
+const APF_KEEPALIVE_REQUEST = 208
+const APF_KEEPALIVE_REPLY = 209
+const APF_KEEPALIVE_OPTIONS_REQUEST = 210
+const APF_KEEPALIVE_OPTIONS_REPLY = 211
 
 // disconnect reason codes
 const APF_DISCONNECT_HOST_NOT_ALLOWED_TO_CONNECT = 1
@@ -87,6 +94,33 @@ type APF_GENERIC_HEADER struct {
 	StringLength uint32
 	String       string
 }
+
+/**
+ * global request sent by AMT, such as tcpip-forward or udp-send-to
+ * @APF_GENERIC_HEADER - the request string
+ * @Payload - WantReply followed by the request specific data
+ **/
+type APF_GLOBAL_REQUEST_MESSAGE struct {
+	APF_GENERIC_HEADER
+	Payload []byte
+}
+
+/**
+ * reply to a global request without request specific data
+ * @MessageType - APF_REQUEST_SUCCESS
+ **/
+type APF_REQUEST_SUCCESS_MESSAGE struct {
+	MessageType byte
+}
+
+/**
+ * reply to a global request that could not be fulfilled
+ * @MessageType - APF_REQUEST_FAILURE
+ **/
+type APF_REQUEST_FAILURE_MESSAGE struct {
+	MessageType byte
+}
+
 type APF_TCP_FORWARD_REQUEST struct {
 	WantReply     uint8
 	AddressLength uint32
@@ -94,6 +128,24 @@ type APF_TCP_FORWARD_REQUEST struct {
 	Port          uint32
 }
 
+/**
+ * payload of a udp-send-to global request, a datagram AMT sends through the tunnel such as a PET alert
+ * @Address, @Port - destination of the datagram
+ * @OriginatorAddress, @OriginatorPort - source of the datagram
+ * @Data - the datagram
+ **/
+type APF_UDP_SEND_TO_REQUEST struct {
+	WantReply               uint8
+	AddressLength           uint32
+	Address                 string
+	Port                    uint32
+	OriginatorAddressLength uint32
+	OriginatorAddress       string
+	OriginatorPort          uint32
+	DataLength              uint32
+	Data                    []byte
+}
+
 /**
  * TCP forward reply message
  * @MessageType - Protocol's Major version
@@ -179,7 +231,7 @@ type APF_CHANNEL_WINDOW_ADJUST_MESSAGE struct {
 type APF_DISCONNECT_MESSAGE struct {
 	MessageType byte
 	ReasonCode  uint32
-	Reserved    uint //short32
+	Reserved    uint16
 }
 
 /**
@@ -201,7 +253,7 @@ type APF_SERVICE_REQUEST_MESSAGE struct {
 type APF_SERVICE_ACCEPT_MESSAGE struct {
 	MessageType       byte
 	ServiceNameLength uint32
-	ServiceName       [18]byte
+	ServiceName       string
 }
 
 /**
@@ -226,18 +278,103 @@ type APF_PROTOCOL_VERSION_MESSAGE struct {
 type APF_USERAUTH_SUCCESS_MESSAGE struct {
 	MessageType byte
 }
+
+/**
+ * sent by AMT to authenticate itself to the MPS
+ * @Username - user name provisioned through AMT_MPSUsernamePassword
+ * @ServiceName - service to start once authenticated, pfwd@amt.intel.com
+ * @MethodName - APF_AUTH_PASSWORD or APF_AUTH_NONE
+ * @Reserved - FALSE, present for the password method only
+ * @Password - password, present for the password method only
+ * @MethodData - method specific data of any other method
+ **/
+type APF_USERAUTH_REQUEST_MESSAGE struct {
+	MessageType       byte
+	UsernameLength    uint32
+	Username          string
+	ServiceNameLength uint32
+	ServiceName       string
+	MethodNameLength  uint32
+	MethodName        string
+	Reserved          byte
+	PasswordLength    uint32
+	Password          string
+	MethodData        []byte
+}
+
+/**
+ * holds the user authentication request failure response.
+ * @Authentications - comma separated methods that may continue
+ * @PartialSuccess - always FALSE
+ **/
+type APF_USERAUTH_FAILURE_MESSAGE struct {
+	MessageType           byte
+	AuthenticationsLength uint32
+	Authentications       string
+	PartialSuccess        byte
+}
+
+/**
+ * keep-alive request, sent by either side
+ * @Cookie - value echoed by the keep-alive reply
+ **/
+type APF_KEEPALIVE_REQUEST_MESSAGE struct {
+	MessageType byte
+	Cookie      uint32
+}
+
+/**
+ * reply to a keep-alive request
+ * @Cookie - value of the keep-alive request
+ **/
+type APF_KEEPALIVE_REPLY_MESSAGE struct {
+	MessageType byte
+	Cookie      uint32
+}
+
+/**
+ * sent by the MPS to configure the keep-alive of AMT
+ * @KeepaliveInterval - seconds between keep-alive requests sent by AMT
+ * @ReadTimeout - seconds AMT waits for data before closing the connection
+ **/
+type APF_KEEPALIVE_OPTIONS_REQUEST_MESSAGE struct {
+	MessageType       byte
+	KeepaliveInterval uint32
+	ReadTimeout       uint32
+}
+
+/**
+ * keep-alive options AMT applied
+ * @KeepaliveInterval - seconds between keep-alive requests sent by AMT
+ * @ReadTimeout - seconds AMT waits for data before closing the connection
+ **/
+type APF_KEEPALIVE_OPTIONS_REPLY_MESSAGE struct {
+	MessageType       byte
+	KeepaliveInterval uint32
+	ReadTimeout       uint32
+}
+
+// AuthenticateFunc verifies the credentials of an APF_USERAUTH_REQUEST, for example against those provisioned through mps.UsernamePassword.
+type AuthenticateFunc func(request APF_USERAUTH_REQUEST_MESSAGE) bool
+
+// ForwardPolicyFunc decides whether AMT may register the port of a tcpip-forward request.
+type ForwardPolicyFunc func(request APF_TCP_FORWARD_REQUEST) bool
+
+// UdpSendToFunc receives a datagram AMT sends with a udp-send-to request.
+type UdpSendToFunc func(request APF_UDP_SEND_TO_REQUEST)
+
 type APF_CHANNEL_OPEN_MESSAGE struct {
 	MessageType               byte
 	ChannelTypeLength         uint32
-	ChannelType               [15]uint8
+	ChannelType               string
 	SenderChannel             uint32
 	InitialWindowSize         uint32
 	Reserved                  uint32
 	ConnectedAddressLength    uint32
-	ConnectedAddress          [3]uint8
+	ConnectedAddress          string
 	ConnectedPort             uint32
 	OriginatorIPAddressLength uint32
-	OriginatorIPAddress       [3]uint8
+	OriginatorIPAddress       string
 	OriginatorPort            uint32
 }
 
@@ -251,4 +388,17 @@ type Session struct {
 	ErrorBuffer      chan error
 	Status           chan bool
 	Timer            *time.Timer
+	// Authenticate verifies APF_USERAUTH_REQUEST credentials, requests are rejected when it is nil
+	Authenticate AuthenticateFunc
+	// KeepAliveInterval and KeepAliveReadTimeout are requested from AMT once it is authenticated when KeepAliveInterval is set,
+	// and updated with the options AMT replies with
+	KeepAliveInterval    uint32
+	KeepAliveReadTimeout uint32
+	// ForwardPolicy decides which tcpip-forward requests succeed, DefaultForwardPolicy when nil
+	ForwardPolicy ForwardPolicyFunc
+	// UdpSendTo receives the datagrams of udp-send-to requests, they are dropped when it is nil
+	UdpSendTo UdpSendToFunc
+
+	forwardsMu sync.Mutex
+	forwards   []APF_TCP_FORWARD_REQUEST
 }
@@ -0,0 +1,100 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package cira
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/apf"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/client"
+)
+
+// Device is an AMT device connected to the Server.
+type Device struct {
+	UUID          string    // System UUID sent in APF_PROTOCOLVERSION.
+	Username      string    // User name AMT authenticated with.
+	MajorVersion  uint32    // APF protocol major version.
+	MinorVersion  uint32    // APF protocol minor version.
+	TriggerReason uint32    // Why AMT connected, one of the APF_TRIGGER_REASON constants.
+	RemoteAddr    net.Addr  // Address AMT connected from.
+	ConnectedAt   time.Time // When the handshake completed.
+
+	conn    *apf.Connection
+	session *apf.Session
+}
+
+// Dial opens an APF channel to the AMT web server of the device.
+func (d *Device) Dial(ctx context.Context) (net.Conn, error) {
+	return d.DialChannel(ctx, apf.ChannelOpenOptions{})
+}
+
+// DialChannel opens the APF channel described by options, for example a direct-tcpip channel to the TLS port of AMT or
+// to a redirection port.
+func (d *Device) DialChannel(ctx context.Context, options apf.ChannelOpenOptions) (net.Conn, error) {
+	ch, err := d.conn.OpenChannel(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+	return ch, nil
+}
+
+// Listen returns a listener for the channels the device opens towards the server.
+func (d *Device) Listen() (net.Listener, error) {
+	listener, err := d.conn.Listen()
+	if err != nil {
+		return nil, err
+	}
+	return listener, nil
+}
+
+// Client returns a WS-Man client that tunnels its requests through APF channels of the device, to use with wsman.NewMessagesWithClient.
+// The Target of parameters is ignored, the other parameters apply as they do to client.NewWsman. With UseTLS the channels
+// connect to the TLS port of AMT and carry TLS.
+func (d *Device) Client(parameters client.Parameters) *client.Target {
+	parameters.Target = d.UUID
+	target := client.NewWsman(parameters)
+	target.Transport = &http.Transport{
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: parameters.SelfSignedAllowed},
+		DialContext: func(ctx context.Context, network, address string) (net.Conn, error) {
+			options := apf.ChannelOpenOptions{}
+			if _, port, err := net.SplitHostPort(address); err == nil {
+				if p, err := strconv.ParseUint(port, 10, 32); err == nil {
+					options.ConnectedPort = uint32(p)
+				}
+			}
+			return d.DialChannel(ctx, options)
+		},
+	}
+	return target
+}
+
+// Forwards returns the ports the device currently forwards.
+func (d *Device) Forwards() []apf.APF_TCP_FORWARD_REQUEST {
+	return d.session.Forwards()
+}
+
+// Close disconnects the device.
+func (d *Device) Close() error {
+	return d.conn.Close()
+}
+
+// Done is closed once the device has disconnected.
+func (d *Device) Done() <-chan struct{} {
+	return d.conn.Done()
+}
+
+// formatUUID formats the UUID of APF_PROTOCOLVERSION, whose first three fields are little endian.
+func formatUUID(b [16]byte) string {
+	u := uuid.UUID{b[3], b[2], b[1], b[0], b[5], b[4], b[7], b[6], b[8], b[9], b[10], b[11], b[12], b[13], b[14], b[15]}
+	return u.String()
+}
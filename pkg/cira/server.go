@@ -0,0 +1,239 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+// Package cira implements a Management Presence Server that accepts Client Initiated Remote Access connections from AMT devices.
+package cira
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/apf"
+)
+
+// ErrHandshake is returned when AMT does not follow the CIRA connection handshake.
+var ErrHandshake = errors.New("cira: handshake failed")
+
+// DefaultHandshakeTimeout bounds the handshake when Options.HandshakeTimeout is not set.
+const DefaultHandshakeTimeout = 30 * time.Second
+
+// Options configures a Server.
+type Options struct {
+	// TLSConfig is used by ListenAndServe, it must contain the server certificate.
+	TLSConfig *tls.Config
+	// Authenticate verifies the credentials of AMT, for example against those provisioned through mps.UsernamePassword.
+	// Every device is rejected when it is nil.
+	Authenticate apf.AuthenticateFunc
+	// KeepAliveInterval and KeepAliveReadTimeout, in seconds, are requested from AMT once it is authenticated when KeepAliveInterval is set.
+	KeepAliveInterval    uint32
+	KeepAliveReadTimeout uint32
+	// ForwardPolicy decides which ports a device may forward, apf.DefaultForwardPolicy when nil. The handshake completes
+	// with the first forward it allows.
+	ForwardPolicy apf.ForwardPolicyFunc
+	// OnUdpSendTo receives the datagrams, such as PET alerts, a device sends through the tunnel.
+	OnUdpSendTo func(device *Device, request apf.APF_UDP_SEND_TO_REQUEST)
+	// HandshakeTimeout bounds the time from accepting a connection to the first tcpip-forward request, DefaultHandshakeTimeout when zero.
+	HandshakeTimeout time.Duration
+	// OnConnect is called once a device has completed the handshake.
+	OnConnect func(device *Device)
+	// OnDisconnect is called when a connected device disconnects, with the reason.
+	OnDisconnect func(device *Device, err error)
+}
+
+// Server accepts CIRA connections and keeps a registry of the connected devices, keyed by UUID.
+type Server struct {
+	options Options
+
+	mu      sync.Mutex
+	devices map[string]*Device
+}
+
+// NewServer returns a Server configured by options.
+func NewServer(options Options) *Server {
+	if options.HandshakeTimeout == 0 {
+		options.HandshakeTimeout = DefaultHandshakeTimeout
+	}
+	return &Server{options: options, devices: map[string]*Device{}}
+}
+
+// ListenAndServe listens on the TCP address with the TLS configuration of the server and serves connections until ctx is done.
+func (s *Server) ListenAndServe(ctx context.Context, address string) error {
+	if s.options.TLSConfig == nil {
+		return errors.New("cira: TLSConfig is required")
+	}
+	listener, err := tls.Listen("tcp", address, s.options.TLSConfig)
+	if err != nil {
+		return err
+	}
+	return s.Serve(ctx, listener)
+}
+
+// Serve accepts connections on listener until ctx is done or accepting fails. It closes listener and disconnects every device
+// before returning, and returns ctx.Err() after cancellation.
+func (s *Server) Serve(ctx context.Context, listener net.Listener) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		<-ctx.Done()
+		listener.Close()
+	}()
+	var wg sync.WaitGroup
+	var err error
+	for {
+		var conn net.Conn
+		conn, err = listener.Accept()
+		if err != nil {
+			break
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.serveConn(ctx, conn)
+		}()
+	}
+	if ctx.Err() != nil {
+		err = ctx.Err()
+	}
+	cancel()
+	wg.Wait()
+	return err
+}
+
+// Device returns the connected device with uuid.
+func (s *Server) Device(uuid string) (*Device, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	device, ok := s.devices[uuid]
+	return device, ok
+}
+
+// Devices returns the connected devices.
+func (s *Server) Devices() []*Device {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	devices := make([]*Device, 0, len(s.devices))
+	for _, device := range s.devices {
+		devices = append(devices, device)
+	}
+	return devices
+}
+
+func (s *Server) serveConn(ctx context.Context, conn net.Conn) {
+	handshaking := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-handshaking:
+		}
+	}()
+	conn.SetDeadline(time.Now().Add(s.options.HandshakeTimeout))
+	device, session, err := s.handshake(conn)
+	close(handshaking)
+	if err != nil {
+		log.Debugf("cira: closing connection from %s: %v", conn.RemoteAddr(), err)
+		conn.Close()
+		return
+	}
+	conn.SetDeadline(time.Time{})
+
+	device.conn = apf.NewConnection(conn, session)
+	device.ConnectedAt = time.Now()
+	s.mu.Lock()
+	previous := s.devices[device.UUID]
+	s.devices[device.UUID] = device
+	s.mu.Unlock()
+	if previous != nil {
+		log.Debugf("cira: %s reconnected, closing its previous connection", device.UUID)
+		previous.Close()
+	}
+	if s.options.OnConnect != nil {
+		s.options.OnConnect(device)
+	}
+
+	err = device.conn.Serve(ctx)
+
+	s.mu.Lock()
+	if s.devices[device.UUID] == device {
+		delete(s.devices, device.UUID)
+	}
+	s.mu.Unlock()
+	if s.options.OnDisconnect != nil {
+		s.options.OnDisconnect(device, err)
+	}
+}
+
+// handshake processes the messages AMT sends until its first tcpip-forward request succeeds.
+func (s *Server) handshake(conn net.Conn) (*Device, *apf.Session, error) {
+	device := &Device{RemoteAddr: conn.RemoteAddr()}
+	versioned, authenticated := false, false
+	session := &apf.Session{
+		KeepAliveInterval:    s.options.KeepAliveInterval,
+		KeepAliveReadTimeout: s.options.KeepAliveReadTimeout,
+		ForwardPolicy:        s.options.ForwardPolicy,
+		Authenticate: func(request apf.APF_USERAUTH_REQUEST_MESSAGE) bool {
+			if s.options.Authenticate == nil || !s.options.Authenticate(request) {
+				return false
+			}
+			device.Username = request.Username
+			authenticated = true
+			return true
+		},
+	}
+	if s.options.OnUdpSendTo != nil {
+		session.UdpSendTo = func(request apf.APF_UDP_SEND_TO_REQUEST) {
+			s.options.OnUdpSendTo(device, request)
+		}
+	}
+	device.session = session
+	for {
+		data, err := apf.ReadMessage(conn)
+		if err != nil {
+			return nil, nil, err
+		}
+		switch data[0] {
+		case apf.APF_PROTOCOLVERSION:
+			version := apf.APF_PROTOCOL_VERSION_MESSAGE{}
+			if err := version.UnmarshalBinary(data); err != nil {
+				return nil, nil, err
+			}
+			device.UUID = formatUUID(version.UUID)
+			device.MajorVersion = version.MajorVersion
+			device.MinorVersion = version.MinorVersion
+			device.TriggerReason = version.TriggerReason
+			versioned = true
+		case apf.APF_SERVICE_REQUEST, apf.APF_USERAUTH_REQUEST, apf.APF_KEEPALIVE_REQUEST, apf.APF_KEEPALIVE_REPLY, apf.APF_KEEPALIVE_OPTIONS_REPLY:
+			if !versioned {
+				return nil, nil, fmt.Errorf("%w: message type %d before APF_PROTOCOLVERSION", ErrHandshake, data[0])
+			}
+		case apf.APF_GLOBAL_REQUEST:
+			if !authenticated {
+				return nil, nil, fmt.Errorf("%w: global request before authentication", ErrHandshake)
+			}
+		default:
+			return nil, nil, fmt.Errorf("%w: unexpected message type %d", ErrHandshake, data[0])
+		}
+		reply, err := apf.Process(data, session)
+		if err != nil {
+			return nil, nil, err
+		}
+		if reply.Len() == 0 {
+			continue
+		}
+		if _, err := conn.Write(reply.Bytes()); err != nil {
+			return nil, nil, err
+		}
+		if data[0] == apf.APF_GLOBAL_REQUEST && reply.Bytes()[0] == apf.APF_REQUEST_SUCCESS {
+			return device, session, nil
+		}
+	}
+}
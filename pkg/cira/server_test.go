@@ -0,0 +1,387 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package cira
+
+import (
+	"bufio"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/apf"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/client"
+)
+
+var testUUID = [16]byte{0x33, 0x22, 0x11, 0x00, 0x55, 0x44, 0x77, 0x66, 0x88, 0x99, 0xAA, 0xBB, 0xCC, 0xDD, 0xEE, 0xFF}
+
+const testUUIDString = "00112233-4455-6677-8899-aabbccddeeff"
+
+func testTLSConfig(t *testing.T) *tls.Config {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "mps"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	return &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}}
+}
+
+// fakeAMT connects to the server like AMT does and answers HTTP requests sent through the channels the server opens.
+type fakeAMT struct {
+	conn     net.Conn
+	reader   *apf.Reader
+	writeMu  sync.Mutex
+	messages chan interface{}
+	response string
+	channels map[uint32]*io.PipeWriter
+	ports    chan uint32
+}
+
+func dialFakeAMT(t *testing.T, address string, response string) *fakeAMT {
+	conn, err := tls.Dial("tcp", address, &tls.Config{InsecureSkipVerify: true})
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	amt := &fakeAMT{conn: conn, reader: apf.NewReader(conn), messages: make(chan interface{}, 100), response: response, channels: map[uint32]*io.PipeWriter{}, ports: make(chan uint32, 100)}
+	go amt.run()
+	return amt
+}
+
+func (amt *fakeAMT) run() {
+	defer close(amt.messages)
+	for {
+		data, err := amt.reader.ReadMessage()
+		if err != nil {
+			return
+		}
+		message, err := apf.Unmarshal(data)
+		if err != nil {
+			return
+		}
+		switch m := message.(type) {
+		case apf.APF_CHANNEL_OPEN_MESSAGE:
+			amt.ports <- m.ConnectedPort
+			reader, writer := io.Pipe()
+			amt.channels[m.SenderChannel] = writer
+			go amt.serveHTTP(m.SenderChannel, reader)
+			amt.send(apf.ChannelOpenReplySuccess(m.SenderChannel, m.SenderChannel+1000))
+		case apf.APF_CHANNEL_DATA_MESSAGE:
+			amt.channels[m.RecipientChannel-1000].Write(m.Data)
+		case apf.APF_CHANNEL_CLOSE_MESSAGE:
+			if writer, ok := amt.channels[m.RecipientChannel-1000]; ok {
+				writer.Close()
+				delete(amt.channels, m.RecipientChannel-1000)
+				amt.send(apf.ChannelClose(m.RecipientChannel - 1000))
+			}
+		case apf.APF_CHANNEL_WINDOW_ADJUST_MESSAGE:
+		default:
+			amt.messages <- message
+		}
+	}
+}
+
+func (amt *fakeAMT) serveHTTP(channel uint32, reader io.Reader) {
+	requests := bufio.NewReader(reader)
+	for {
+		request, err := http.ReadRequest(requests)
+		if err != nil {
+			return
+		}
+		io.Copy(io.Discard, request.Body)
+		response := "HTTP/1.1 200 OK\r\nContent-Type: application/soap+xml; charset=UTF-8\r\nContent-Length: " +
+			strconv.Itoa(len(amt.response)) + "\r\n\r\n" + amt.response
+		for len(response) > 0 {
+			n := len(response)
+			if n > apf.LME_RX_WINDOW_SIZE/2 {
+				n = apf.LME_RX_WINDOW_SIZE / 2
+			}
+			amt.send(apf.ChannelData(channel, []byte(response[:n])))
+			response = response[n:]
+		}
+	}
+}
+
+func (amt *fakeAMT) send(message interface{}) error {
+	data, err := apf.Marshal(message)
+	if err != nil {
+		return err
+	}
+	amt.writeMu.Lock()
+	defer amt.writeMu.Unlock()
+	amt.reader.Sent(data)
+	_, err = amt.conn.Write(data)
+	return err
+}
+
+func (amt *fakeAMT) expect(t *testing.T) interface{} {
+	select {
+	case message, ok := <-amt.messages:
+		if !ok {
+			return nil
+		}
+		return message
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for a message")
+		return nil
+	}
+}
+
+// handshake sends the messages AMT sends when it connects, checking the replies.
+func (amt *fakeAMT) handshake(t *testing.T, password string) {
+	require.NoError(t, amt.send(apf.APF_PROTOCOL_VERSION_MESSAGE{MajorVersion: 1, TriggerReason: apf.APF_TRIGGER_REASON_PERIODIC_REQUEST, UUID: testUUID}))
+	assert.IsType(t, apf.APF_PROTOCOL_VERSION_MESSAGE{}, amt.expect(t))
+	require.NoError(t, amt.send(apf.APF_SERVICE_REQUEST_MESSAGE{ServiceName: apf.APF_SERVICE_AUTH}))
+	assert.Equal(t, apf.ServiceAccept(apf.APF_SERVICE_AUTH), amt.expect(t))
+	require.NoError(t, amt.send(apf.APF_USERAUTH_REQUEST_MESSAGE{Username: "admin", ServiceName: apf.APF_SERVICE_PFWD, MethodName: apf.APF_AUTH_PASSWORD, Password: password}))
+	if password != "P@ssw0rd" {
+		assert.Equal(t, apf.UserAuthFailure(), amt.expect(t))
+		return
+	}
+	assert.Equal(t, apf.UserAuthSuccess(), amt.expect(t))
+	require.NoError(t, amt.send(apf.APF_SERVICE_REQUEST_MESSAGE{ServiceName: apf.APF_SERVICE_PFWD}))
+	assert.Equal(t, apf.ServiceAccept(apf.APF_SERVICE_PFWD), amt.expect(t))
+	forward, err := apf.APF_TCP_FORWARD_REQUEST{WantReply: 1, Address: "", Port: 16992}.MarshalBinary()
+	require.NoError(t, err)
+	require.NoError(t, amt.send(apf.APF_GLOBAL_REQUEST_MESSAGE{APF_GENERIC_HEADER: apf.APF_GENERIC_HEADER{String: apf.APF_GLOBAL_REQUEST_STR_TCP_FORWARD_REQUEST}, Payload: forward}))
+	assert.Equal(t, apf.TcpForwardReplySuccess(16992), amt.expect(t))
+}
+
+type testServer struct {
+	*Server
+	address      string
+	cancel       context.CancelFunc
+	served       chan error
+	connected    chan *Device
+	disconnected chan *Device
+}
+
+func newTestServer(t *testing.T) *testServer {
+	return newTestServerWithOptions(t, Options{})
+}
+
+func newTestServerWithOptions(t *testing.T, options Options) *testServer {
+	s := &testServer{served: make(chan error, 1), connected: make(chan *Device, 10), disconnected: make(chan *Device, 10)}
+	config := testTLSConfig(t)
+	options.TLSConfig = config
+	options.Authenticate = func(request apf.APF_USERAUTH_REQUEST_MESSAGE) bool {
+		return request.Username == "admin" && request.Password == "P@ssw0rd"
+	}
+	options.OnConnect = func(device *Device) { s.connected <- device }
+	options.OnDisconnect = func(device *Device, err error) { s.disconnected <- device }
+	s.Server = NewServer(options)
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", config)
+	require.NoError(t, err)
+	s.address = listener.Addr().String()
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	go func() {
+		s.served <- s.Serve(ctx, listener)
+	}()
+	t.Cleanup(cancel)
+	return s
+}
+
+func receive(t *testing.T, devices chan *Device) *Device {
+	select {
+	case device := <-devices:
+		return device
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for a device")
+		return nil
+	}
+}
+
+func TestServerDeviceWSMan(t *testing.T) {
+	response, err := os.ReadFile("../wsman/wsmantesting/responses/amt/redirectionservice/get.xml")
+	require.NoError(t, err)
+	s := newTestServer(t)
+	amt := dialFakeAMT(t, s.address, string(response))
+	amt.handshake(t, "P@ssw0rd")
+
+	device := receive(t, s.connected)
+	assert.Equal(t, testUUIDString, device.UUID)
+	assert.Equal(t, "admin", device.Username)
+	assert.Equal(t, uint32(apf.APF_TRIGGER_REASON_PERIODIC_REQUEST), device.TriggerReason)
+	registered, ok := s.Device(testUUIDString)
+	assert.True(t, ok)
+	assert.Same(t, device, registered)
+	assert.Len(t, s.Devices(), 1)
+
+	messages := wsman.NewMessagesWithClient(device.Client(client.Parameters{Username: "admin", Password: "amtpassword"}))
+	for i := 0; i < 2; i++ {
+		result, err := messages.AMT.RedirectionService.Get()
+		require.NoError(t, err)
+		assert.Equal(t, "Intel(r) AMT Redirection Service", result.Body.GetAndPutResponse.Name)
+	}
+
+	amt.conn.Close()
+	assert.Same(t, device, receive(t, s.disconnected))
+	_, ok = s.Device(testUUIDString)
+	assert.False(t, ok)
+}
+
+func TestDeviceDialChannel(t *testing.T) {
+	s := newTestServer(t)
+	amt := dialFakeAMT(t, s.address, "")
+	amt.handshake(t, "P@ssw0rd")
+	device := receive(t, s.connected)
+
+	conn, err := device.DialChannel(context.Background(), apf.ChannelOpenOptions{ChannelType: apf.APF_OPEN_CHANNEL_REQUEST_DIRECT, ConnectedPort: 16994})
+	require.NoError(t, err)
+	assert.Equal(t, uint32(16994), <-amt.ports)
+	assert.Equal(t, "[::1]:16994", conn.RemoteAddr().String())
+
+	// the TLS handshake fails against the plain HTTP of fakeAMT, but the channel goes to the TLS port
+	target := device.Client(client.Parameters{UseTLS: true, SelfSignedAllowed: true})
+	target.Timeout = 100 * time.Millisecond
+	_, err = wsman.NewMessagesWithClient(target).AMT.RedirectionService.Get()
+	assert.Error(t, err)
+	assert.Equal(t, uint32(16993), <-amt.ports)
+}
+
+func TestDeviceListen(t *testing.T) {
+	s := newTestServer(t)
+	amt := dialFakeAMT(t, s.address, "")
+	amt.handshake(t, "P@ssw0rd")
+	device := receive(t, s.connected)
+
+	listener, err := device.Listen()
+	require.NoError(t, err)
+	defer listener.Close()
+	require.NoError(t, amt.send(apf.APF_CHANNEL_OPEN_MESSAGE{ChannelType: apf.APF_OPEN_CHANNEL_REQUEST_FORWARDED, SenderChannel: 5, InitialWindowSize: 4096}))
+	confirmation := amt.expect(t).(apf.APF_CHANNEL_OPEN_CONFIRMATION_MESSAGE)
+	assert.Equal(t, uint32(5), confirmation.RecipientChannel)
+	conn, err := listener.Accept()
+	require.NoError(t, err)
+	require.NoError(t, amt.send(apf.ChannelData(confirmation.SenderChannel, []byte("event"))))
+	received := make([]byte, 5)
+	_, err = io.ReadFull(conn, received)
+	assert.NoError(t, err)
+	assert.Equal(t, "event", string(received))
+}
+
+func TestServerForwardsAndUdpSendTo(t *testing.T) {
+	datagrams := make(chan apf.APF_UDP_SEND_TO_REQUEST, 1)
+	s := newTestServerWithOptions(t, Options{
+		ForwardPolicy: func(request apf.APF_TCP_FORWARD_REQUEST) bool {
+			return request.Port == 16992 || request.Port == 623
+		},
+		OnUdpSendTo: func(device *Device, request apf.APF_UDP_SEND_TO_REQUEST) {
+			assert.Equal(t, testUUIDString, device.UUID)
+			datagrams <- request
+		},
+	})
+	amt := dialFakeAMT(t, s.address, "")
+	amt.handshake(t, "P@ssw0rd")
+	device := receive(t, s.connected)
+
+	send := func(name string, port uint32) {
+		payload, err := apf.APF_TCP_FORWARD_REQUEST{WantReply: 1, Port: port}.MarshalBinary()
+		require.NoError(t, err)
+		require.NoError(t, amt.send(apf.APF_GLOBAL_REQUEST_MESSAGE{APF_GENERIC_HEADER: apf.APF_GENERIC_HEADER{String: name}, Payload: payload}))
+	}
+	forward := func(port uint32) interface{} {
+		send(apf.APF_GLOBAL_REQUEST_STR_TCP_FORWARD_REQUEST, port)
+		return amt.expect(t)
+	}
+	assert.Equal(t, apf.TcpForwardReplySuccess(623), forward(623))
+	assert.IsType(t, apf.APF_REQUEST_FAILURE_MESSAGE{}, forward(16993))
+	assert.Len(t, device.Forwards(), 2)
+
+	payload, err := apf.APF_UDP_SEND_TO_REQUEST{Address: "mps", Port: 162, OriginatorAddress: "amt", OriginatorPort: 1024, Data: []byte("alert")}.MarshalBinary()
+	require.NoError(t, err)
+	require.NoError(t, amt.send(apf.APF_GLOBAL_REQUEST_MESSAGE{APF_GENERIC_HEADER: apf.APF_GENERIC_HEADER{String: apf.APF_GLOBAL_REQUEST_STR_UDP_SEND_TO}, Payload: payload}))
+	select {
+	case datagram := <-datagrams:
+		assert.Equal(t, uint32(162), datagram.Port)
+		assert.Equal(t, []byte("alert"), datagram.Data)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for a datagram")
+	}
+
+	send(apf.APF_GLOBAL_REQUEST_STR_TCP_FORWARD_CANCEL_REQUEST, 623)
+	assert.Equal(t, apf.APF_REQUEST_SUCCESS_MESSAGE{MessageType: apf.APF_REQUEST_SUCCESS}, amt.expect(t))
+	assert.Equal(t, []apf.APF_TCP_FORWARD_REQUEST{{WantReply: 1, Port: 16992}}, device.Forwards())
+	assert.IsType(t, apf.APF_TCP_FORWARD_REPLY_MESSAGE{}, forward(623))
+}
+
+func TestServerRejectsInvalidCredentials(t *testing.T) {
+	s := newTestServer(t)
+	amt := dialFakeAMT(t, s.address, "")
+	amt.handshake(t, "wrong")
+
+	forward, _ := apf.APF_TCP_FORWARD_REQUEST{WantReply: 1, Port: 16992}.MarshalBinary()
+	amt.send(apf.APF_GLOBAL_REQUEST_MESSAGE{APF_GENERIC_HEADER: apf.APF_GENERIC_HEADER{String: apf.APF_GLOBAL_REQUEST_STR_TCP_FORWARD_REQUEST}, Payload: forward})
+	assert.Nil(t, amt.expect(t), "connection is closed without a reply")
+	assert.Empty(t, s.Devices())
+}
+
+func TestServerRequiresProtocolVersionFirst(t *testing.T) {
+	s := newTestServer(t)
+	amt := dialFakeAMT(t, s.address, "")
+	amt.send(apf.APF_SERVICE_REQUEST_MESSAGE{ServiceName: apf.APF_SERVICE_AUTH})
+	assert.Nil(t, amt.expect(t))
+}
+
+func TestServerReconnectReplacesDevice(t *testing.T) {
+	s := newTestServer(t)
+	first := dialFakeAMT(t, s.address, "")
+	first.handshake(t, "P@ssw0rd")
+	previous := receive(t, s.connected)
+
+	second := dialFakeAMT(t, s.address, "")
+	second.handshake(t, "P@ssw0rd")
+	current := receive(t, s.connected)
+	assert.Same(t, previous, receive(t, s.disconnected))
+	device, ok := s.Device(testUUIDString)
+	assert.True(t, ok)
+	assert.Same(t, current, device)
+}
+
+func TestServerShutdown(t *testing.T) {
+	s := newTestServer(t)
+	amt := dialFakeAMT(t, s.address, "")
+	amt.handshake(t, "P@ssw0rd")
+	device := receive(t, s.connected)
+
+	s.cancel()
+	assert.ErrorIs(t, <-s.served, context.Canceled)
+	<-device.Done()
+	_, err := device.Dial(context.Background())
+	assert.ErrorIs(t, err, apf.ErrConnectionClosed)
+	assert.Empty(t, s.Devices())
+}
+
+func TestListenAndServeRequiresTLS(t *testing.T) {
+	err := NewServer(Options{}).ListenAndServe(context.Background(), "127.0.0.1:0")
+	assert.True(t, strings.Contains(err.Error(), "TLSConfig"))
+}
+
+func TestFormatUUID(t *testing.T) {
+	assert.Equal(t, testUUIDString, formatUUID(testUUID))
+}
@@ -7,7 +7,6 @@
 package alarmclock
 
 import (
-	"encoding/xml"
 	"strconv"
 	"strings"
 	"time"
@@ -39,7 +38,7 @@ func (acs Service) Get() (response Response, err error) {
 	}
 
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = acs.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -61,7 +60,7 @@ func (acs Service) Enumerate() (response Response, err error) {
 	}
 
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = acs.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -83,7 +82,7 @@ func (acs Service) Pull(enumerationContext string) (response Response, err error
 	}
 
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = acs.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -93,6 +92,9 @@ func (acs Service) Pull(enumerationContext string) (response Response, err error
 
 // AddAlarm creates an alarm that would wake the system at a given time. The method receives as input an embedded instance of type IPS_AlarmClockOccurrence, with the following fields set: StartTime, Interval, InstanceID, DeleteOnCompletion. Upon success, the method creates an instance of IPS_AlarmClockOccurrence which is associated with AlarmClockService. The method would fail if 5 instances or more of IPS_AlarmClockOccurrence already exist in the system.
 func (acs Service) AddAlarm(alarmClockOccurrence AlarmClockOccurrence) (response Response, err error) {
+	if err = acs.base.Validate(alarmClockOccurrence); err != nil {
+		return
+	}
 	header := acs.base.WSManMessageCreator.CreateHeader(methods.GenerateAction(AMT_AlarmClockService, AddAlarm), AMT_AlarmClockService, nil, "", "")
 	startTime := alarmClockOccurrence.StartTime.UTC().Format(time.RFC3339Nano)
 	startTime = strings.Split(startTime, ".")[0]
@@ -142,7 +144,7 @@ func (acs Service) AddAlarm(alarmClockOccurrence AlarmClockOccurrence) (response
 	}
 
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = acs.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -0,0 +1,23 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package alarmclock
+
+import (
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/common"
+)
+
+// Validate checks that the occurrence has an InstanceID, a start time and a non negative interval.
+func (r AlarmClockOccurrence) Validate() error {
+	v := common.NewValidationError("AlarmClockOccurrence")
+	v.Required("InstanceID", r.InstanceID)
+	if r.StartTime.IsZero() {
+		v.Add("StartTime", "is required")
+	}
+	if r.Interval < 0 {
+		v.Add("Interval", "must not be negative, got %d", r.Interval)
+	}
+	return v.Err()
+}
@@ -7,8 +7,6 @@
 package auditlog
 
 import (
-	"encoding/xml"
-
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/internal/message"
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/amt/methods"
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/client"
@@ -34,7 +32,7 @@ func (service Service) Get() (response Response, err error) {
 		return
 	}
 
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = service.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -53,7 +51,7 @@ func (service Service) Enumerate() (response Response, err error) {
 		return
 	}
 
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = service.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -71,7 +69,7 @@ func (service Service) Pull(enumerationContext string) (response Response, err e
 	if err != nil {
 		return
 	}
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = service.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -96,7 +94,7 @@ func (service Service) ReadRecords(startIndex int) (response Response, err error
 	if err != nil {
 		return
 	}
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = service.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
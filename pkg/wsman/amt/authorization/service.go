@@ -15,8 +15,6 @@
 package authorization
 
 import (
-	"encoding/xml"
-
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/internal/message"
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/amt/methods"
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/client"
@@ -42,7 +40,7 @@ func (as AuthorizationService) Get() (response Response, err error) {
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = as.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -62,7 +60,7 @@ func (as AuthorizationService) Enumerate() (response Response, err error) {
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = as.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -82,7 +80,7 @@ func (as AuthorizationService) Pull(enumerationContext string) (response Respons
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = as.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -95,7 +93,11 @@ func (as AuthorizationService) EnumerateUserAclEntries(startIndex int) (response
 		startIndex = 1
 	}
 	header := as.base.WSManMessageCreator.CreateHeader(methods.GenerateAction(AMT_AuthorizationService, EnumerateUserAclEntries), AMT_AuthorizationService, nil, "", "")
-	body := as.base.WSManMessageCreator.CreateBody(methods.GenerateInputMethod(EnumerateUserAclEntries), AMT_AuthorizationService, &EnumerateUserAclEntries_INPUT{StartIndex: startIndex})
+	input := EnumerateUserAclEntries_INPUT{StartIndex: startIndex}
+	if err = as.base.Validate(input); err != nil {
+		return
+	}
+	body := as.base.WSManMessageCreator.CreateBody(methods.GenerateInputMethod(EnumerateUserAclEntries), AMT_AuthorizationService, &input)
 	response = Response{
 		Message: &client.Message{
 			XMLInput: as.base.WSManMessageCreator.CreateXML(header, body),
@@ -107,7 +109,7 @@ func (as AuthorizationService) EnumerateUserAclEntries(startIndex int) (response
 	}
 
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = as.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -117,7 +119,11 @@ func (as AuthorizationService) EnumerateUserAclEntries(startIndex int) (response
 // Gets the state of a user ACL entry (enabled/disabled)
 func (as AuthorizationService) GetAclEnabledState(handle int) (response Response, err error) {
 	header := as.base.WSManMessageCreator.CreateHeader(methods.GenerateAction(AMT_AuthorizationService, GetAclEnabledState), AMT_AuthorizationService, nil, "", "")
-	body := as.base.WSManMessageCreator.CreateBody(methods.GenerateInputMethod(GetAclEnabledState), AMT_AuthorizationService, &GetAclEnabledState_INPUT{Handle: handle})
+	input := GetAclEnabledState_INPUT{Handle: handle}
+	if err = as.base.Validate(input); err != nil {
+		return
+	}
+	body := as.base.WSManMessageCreator.CreateBody(methods.GenerateInputMethod(GetAclEnabledState), AMT_AuthorizationService, &input)
 	response = Response{
 		Message: &client.Message{
 			XMLInput: as.base.WSManMessageCreator.CreateXML(header, body),
@@ -129,7 +135,7 @@ func (as AuthorizationService) GetAclEnabledState(handle int) (response Response
 	}
 
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = as.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -151,7 +157,7 @@ func (as AuthorizationService) GetAdminAclEntry() (response Response, err error)
 	}
 
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = as.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -173,7 +179,7 @@ func (as AuthorizationService) GetAdminAclEntryStatus() (response Response, err
 	}
 
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = as.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -195,7 +201,7 @@ func (as AuthorizationService) GetAdminNetAclEntryStatus() (response Response, e
 	}
 
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = as.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -205,7 +211,11 @@ func (as AuthorizationService) GetAdminNetAclEntryStatus() (response Response, e
 // Reads a user entry from the Intel® AMT device. Note: confidential information, such as password (hash) is omitted or zeroed in the response.
 func (as AuthorizationService) GetUserAclEntryEx(handle int) (response Response, err error) {
 	header := as.base.WSManMessageCreator.CreateHeader(methods.GenerateAction(AMT_AuthorizationService, GetUserAclEntryEx), AMT_AuthorizationService, nil, "", "")
-	body := as.base.WSManMessageCreator.CreateBody(methods.GenerateInputMethod(GetUserAclEntryEx), AMT_AuthorizationService, &GetUserAclEntryEx_INPUT{Handle: handle})
+	input := GetUserAclEntryEx_INPUT{Handle: handle}
+	if err = as.base.Validate(input); err != nil {
+		return
+	}
+	body := as.base.WSManMessageCreator.CreateBody(methods.GenerateInputMethod(GetUserAclEntryEx), AMT_AuthorizationService, &input)
 	response = Response{
 		Message: &client.Message{
 			XMLInput: as.base.WSManMessageCreator.CreateXML(header, body),
@@ -217,7 +227,7 @@ func (as AuthorizationService) GetUserAclEntryEx(handle int) (response Response,
 	}
 
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = as.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -227,7 +237,11 @@ func (as AuthorizationService) GetUserAclEntryEx(handle int) (response Response,
 // Removes an entry from the User Access Control List (ACL), given a handle.
 func (as AuthorizationService) RemoveUserAclEntry(handle int) (response Response, err error) {
 	header := as.base.WSManMessageCreator.CreateHeader(methods.GenerateAction(AMT_AuthorizationService, RemoveUserAclEntry), AMT_AuthorizationService, nil, "", "")
-	body := as.base.WSManMessageCreator.CreateBody(methods.GenerateInputMethod(RemoveUserAclEntry), AMT_AuthorizationService, &RemoveUserAclEntry_INPUT{Handle: handle})
+	input := RemoveUserAclEntry_INPUT{Handle: handle}
+	if err = as.base.Validate(input); err != nil {
+		return
+	}
+	body := as.base.WSManMessageCreator.CreateBody(methods.GenerateInputMethod(RemoveUserAclEntry), AMT_AuthorizationService, &input)
 	response = Response{
 		Message: &client.Message{
 			XMLInput: as.base.WSManMessageCreator.CreateXML(header, body),
@@ -239,7 +253,7 @@ func (as AuthorizationService) RemoveUserAclEntry(handle int) (response Response
 	}
 
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = as.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -249,7 +263,11 @@ func (as AuthorizationService) RemoveUserAclEntry(handle int) (response Response
 // Enables or disables a user ACL entry. Disabling ACL entries is useful when accounts that cannot be removed (system accounts - starting with $$) are required to be disabled.
 func (as AuthorizationService) SetAclEnabledState(handle int, enabled bool) (response Response, err error) {
 	header := as.base.WSManMessageCreator.CreateHeader(methods.GenerateAction(AMT_AuthorizationService, SetAclEnabledState), AMT_AuthorizationService, nil, "", "")
-	body := as.base.WSManMessageCreator.CreateBody(methods.GenerateInputMethod(SetAclEnabledState), AMT_AuthorizationService, &SetAclEnabledState_INPUT{Handle: handle, Enabled: enabled})
+	input := SetAclEnabledState_INPUT{Handle: handle, Enabled: enabled}
+	if err = as.base.Validate(input); err != nil {
+		return
+	}
+	body := as.base.WSManMessageCreator.CreateBody(methods.GenerateInputMethod(SetAclEnabledState), AMT_AuthorizationService, &input)
 	response = Response{
 		Message: &client.Message{
 			XMLInput: as.base.WSManMessageCreator.CreateXML(header, body),
@@ -261,7 +279,7 @@ func (as AuthorizationService) SetAclEnabledState(handle int, enabled bool) (res
 	}
 
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = as.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -271,7 +289,11 @@ func (as AuthorizationService) SetAclEnabledState(handle int, enabled bool) (res
 // Updates an Admin entry in the Intel® AMT device.
 func (as AuthorizationService) SetAdminACLEntryEx(username, digestPassword string) (response Response, err error) {
 	header := as.base.WSManMessageCreator.CreateHeader(methods.GenerateAction(AMT_AuthorizationService, SetAdminAclEntryEx), AMT_AuthorizationService, nil, "", "")
-	body := as.base.WSManMessageCreator.CreateBody(methods.GenerateInputMethod(SetAdminAclEntryEx), AMT_AuthorizationService, &SetAdminACLEntryEx_INPUT{Username: username, DigestPassword: digestPassword})
+	adminACLEntry := SetAdminACLEntryEx_INPUT{Username: username, DigestPassword: digestPassword}
+	if err = as.base.Validate(adminACLEntry); err != nil {
+		return
+	}
+	body := as.base.WSManMessageCreator.CreateBody(methods.GenerateInputMethod(SetAdminAclEntryEx), AMT_AuthorizationService, &adminACLEntry)
 	response = Response{
 		Message: &client.Message{
 			XMLInput: as.base.WSManMessageCreator.CreateXML(header, body),
@@ -283,7 +305,7 @@ func (as AuthorizationService) SetAdminACLEntryEx(username, digestPassword strin
 	}
 
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = as.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -0,0 +1,57 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package authorization
+
+import (
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/common"
+)
+
+// Validate checks that the username is a non empty string of at most 16 characters and that a digest password is provided.
+func (r SetAdminACLEntryEx_INPUT) Validate() error {
+	v := common.NewValidationError("SetAdminACLEntryEx_INPUT")
+	v.Required("Username", r.Username)
+	v.MaxLength("Username", r.Username, 16)
+	v.Required("DigestPassword", r.DigestPassword)
+	return v.Err()
+}
+
+// Validate checks that StartIndex is at least 1, the index of the first entry.
+func (r EnumerateUserAclEntries_INPUT) Validate() error {
+	v := common.NewValidationError("EnumerateUserAclEntries_INPUT")
+	if r.StartIndex < 1 {
+		v.Add("StartIndex", "must be at least 1, got %d", r.StartIndex)
+	}
+	return v.Err()
+}
+
+// Validate checks that the handle is not negative.
+func (r GetAclEnabledState_INPUT) Validate() error {
+	return validateHandle("GetAclEnabledState_INPUT", r.Handle)
+}
+
+// Validate checks that the handle is not negative.
+func (r GetUserAclEntryEx_INPUT) Validate() error {
+	return validateHandle("GetUserAclEntryEx_INPUT", r.Handle)
+}
+
+// Validate checks that the handle is not negative.
+func (r RemoveUserAclEntry_INPUT) Validate() error {
+	return validateHandle("RemoveUserAclEntry_INPUT", r.Handle)
+}
+
+// Validate checks that the handle is not negative.
+func (r SetAclEnabledState_INPUT) Validate() error {
+	return validateHandle("SetAclEnabledState_INPUT", r.Handle)
+}
+
+// validateHandle checks the ACL entry handle of the named request.
+func validateHandle(request string, handle int) error {
+	v := common.NewValidationError(request)
+	if handle < 0 {
+		v.Add("Handle", "must not be negative, got %d", handle)
+	}
+	return v.Err()
+}
@@ -0,0 +1,27 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package authorization
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/internal/message"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/wsmantesting"
+)
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, EnumerateUserAclEntries_INPUT{StartIndex: 1}.Validate())
+	assert.EqualError(t, EnumerateUserAclEntries_INPUT{StartIndex: -1}.Validate(), "invalid EnumerateUserAclEntries_INPUT: StartIndex: must be at least 1, got -1")
+	assert.NoError(t, SetAclEnabledState_INPUT{Handle: 0}.Validate())
+	assert.EqualError(t, RemoveUserAclEntry_INPUT{Handle: -2}.Validate(), "invalid RemoveUserAclEntry_INPUT: Handle: must not be negative, got -2")
+
+	client := wsmantesting.MockClient{PackageUnderTest: "amt/authorization"}
+	elementUnderTest := NewServiceWithClient(message.NewWSManMessageCreator(message.AMTSchema), &client)
+	_, err := elementUnderTest.GetUserAclEntryEx(-1)
+	assert.EqualError(t, err, "invalid GetUserAclEntryEx_INPUT: Handle: must not be negative, got -1")
+}
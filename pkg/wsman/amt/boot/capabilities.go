@@ -11,8 +11,6 @@
 package boot
 
 import (
-	"encoding/xml"
-
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/internal/message"
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/client"
 )
@@ -37,7 +35,7 @@ func (bootCapabilities Capabilities) Get() (response Response, err error) {
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = bootCapabilities.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -57,7 +55,7 @@ func (bootCapabilities Capabilities) Enumerate() (response Response, err error)
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = bootCapabilities.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -77,7 +75,7 @@ func (bootCapabilities Capabilities) Pull(enumerationContext string) (response R
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = bootCapabilities.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -6,11 +6,11 @@
 package boot
 
 import (
-	"encoding/xml"
 	"fmt"
 
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/internal/message"
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/client"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/common"
 )
 
 // Instantiates a new Boot Setting Data service
@@ -33,7 +33,7 @@ func (settingData SettingData) Get() (response Response, err error) {
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = settingData.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -53,7 +53,7 @@ func (settingData SettingData) Enumerate() (response Response, err error) {
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = settingData.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -73,7 +73,7 @@ func (settingData SettingData) Pull(enumerationContext string) (response Respons
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = settingData.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -83,6 +83,9 @@ func (settingData SettingData) Pull(enumerationContext string) (response Respons
 // Put will change properties of the selected instance
 func (settingData SettingData) Put(bootSettingData BootSettingDataRequest) (response Response, err error) {
 	bootSettingData.H = fmt.Sprintf("%s%s", message.AMTSchema, AMT_BootSettingData)
+	if err = settingData.base.Validate(bootSettingData); err != nil {
+		return
+	}
 	response = Response{
 		Message: &client.Message{
 			XMLInput: settingData.base.Put(bootSettingData, false, nil),
@@ -94,9 +97,24 @@ func (settingData SettingData) Put(bootSettingData BootSettingDataRequest) (resp
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = settingData.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
 	return
 }
+
+// Update reads the boot setting data, applies mutate to it and writes it back with Put.
+// Pass common.WithConcurrentModificationDetection to abort with common.ErrConcurrentModification when the setting data changes before the Put.
+func (settingData SettingData) Update(mutate func(*BootSettingDataRequest), opts ...common.UpdateOption) (response Response, err error) {
+	get := func() (BootSettingDataRequest, error) {
+		current, err := settingData.Get()
+		return current.Body.BootSettingDataGetResponse.ToRequest(), err
+	}
+	put := func(bootSettingData BootSettingDataRequest) (err error) {
+		response, err = settingData.Put(bootSettingData)
+		return
+	}
+	err = common.Update(get, put, mutate, opts...)
+	return
+}
@@ -13,6 +13,8 @@ import (
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/common"
 )
 
+//go:generate go run ../../../../internal/tools/genconvert -type BootSettingDataResponse=BootSettingDataRequest -readonly OptionsCleared,WinREBootEnabled,UEFILocalPBABootEnabled,UEFIHTTPSBootEnabled,SecureBootControlEnabled,BootguardStatus,BIOSLastStatus
+
 type SettingData struct {
 	base message.Base
 }
@@ -0,0 +1,27 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package boot
+
+import (
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/common"
+)
+
+// Validate checks that enumerated properties hold supported values and that IDER specific settings are only used together with UseIDER.
+func (r BootSettingDataRequest) Validate() error {
+	v := common.NewValidationError("BootSettingDataRequest")
+	v.Range("FirmwareVerbosity", int(r.FirmwareVerbosity), int(SystemDefault), int(ScreenBlank))
+	v.OneOf("IDERBootDevice", int(r.IDERBootDevice), int(FloppyBoot), int(CDBoot))
+	if r.BootMediaIndex < 0 {
+		v.Add("BootMediaIndex", "must not be negative, got %d", r.BootMediaIndex)
+	}
+	if r.IDERBootDevice != FloppyBoot && !r.UseIDER {
+		v.Add("IDERBootDevice", "can only be set when UseIDER is true")
+	}
+	if r.RSEPassword != "" && !r.SecureErase {
+		v.Add("RSEPassword", "can only be set when SecureErase is true")
+	}
+	return v.Err()
+}
@@ -7,11 +7,11 @@
 package environmentdetection
 
 import (
-	"encoding/xml"
 	"fmt"
 
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/internal/message"
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/client"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/common"
 )
 
 // NewEnvironmentDetectionSettingDataWithClient instantiates a new Environment Detection Setting Data service
@@ -34,7 +34,7 @@ func (sd SettingData) Get() (response Response, err error) {
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = sd.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -54,7 +54,7 @@ func (sd SettingData) Enumerate() (response Response, err error) {
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = sd.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -74,7 +74,7 @@ func (sd SettingData) Pull(enumerationContext string) (response Response, err er
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = sd.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -88,6 +88,9 @@ func (sd SettingData) Put(environmentDetectionSettingData EnvironmentDetectionSe
 		Name:  "InstanceID",
 		Value: "Intel(r) AMT Environment Detection Settings",
 	}
+	if err = sd.base.Validate(environmentDetectionSettingData); err != nil {
+		return
+	}
 	response = Response{
 		Message: &client.Message{
 			XMLInput: sd.base.Put(environmentDetectionSettingData, true, &selector),
@@ -99,9 +102,24 @@ func (sd SettingData) Put(environmentDetectionSettingData EnvironmentDetectionSe
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = sd.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
 	return
 }
+
+// Update reads the environment detection settings, applies mutate to them and writes them back with Put.
+// Pass common.WithConcurrentModificationDetection to abort with common.ErrConcurrentModification when the settings change before the Put.
+func (sd SettingData) Update(mutate func(*EnvironmentDetectionSettingDataRequest), opts ...common.UpdateOption) (response Response, err error) {
+	get := func() (EnvironmentDetectionSettingDataRequest, error) {
+		current, err := sd.Get()
+		return current.Body.GetAndPutResponse.ToRequest(), err
+	}
+	put := func(environmentDetectionSettingData EnvironmentDetectionSettingDataRequest) (err error) {
+		response, err = sd.Put(environmentDetectionSettingData)
+		return
+	}
+	err = common.Update(get, put, mutate, opts...)
+	return
+}
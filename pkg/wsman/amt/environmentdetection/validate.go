@@ -0,0 +1,30 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package environmentdetection
+
+import (
+	"net"
+
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/common"
+)
+
+// Validate checks the detection algorithm, that detection strings are not empty and that IPv6 prefixes use the "XXXX:XXXX:XXXX:XXXX/Y" format.
+func (r EnvironmentDetectionSettingDataRequest) Validate() error {
+	v := common.NewValidationError("EnvironmentDetectionSettingDataRequest")
+	v.Required("ElementName", r.ElementName)
+	v.OneOf("DetectionAlgorithm", int(r.DetectionAlgorithm), int(LocalDomains), int(RemoteURLs))
+	for i, s := range r.DetectionStrings {
+		if s == "" {
+			v.Add(common.Index("DetectionStrings", i), "must not be empty")
+		}
+	}
+	for i, prefix := range r.DetectionIPv6LocalPrefixes {
+		if ip, _, err := net.ParseCIDR(prefix); err != nil || ip.To4() != nil {
+			v.Add(common.Index("DetectionIPv6LocalPrefixes", i), "must be an IPv6 prefix, got %q", prefix)
+		}
+	}
+	return v.Err()
+}
@@ -9,11 +9,11 @@
 package ethernetport
 
 import (
-	"encoding/xml"
 	"fmt"
 
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/internal/message"
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/client"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/common"
 )
 
 // NewEthernetPortSettingsWithClient instantiates a new Ethernet Port Settings service
@@ -40,7 +40,7 @@ func (s Settings) Get(instanceID string) (response Response, err error) {
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = s.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -60,7 +60,7 @@ func (s Settings) Enumerate() (response Response, err error) {
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = s.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -80,7 +80,7 @@ func (s Settings) Pull(enumerationContext string) (response Response, err error)
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = s.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -89,6 +89,9 @@ func (s Settings) Pull(enumerationContext string) (response Response, err error)
 
 // Put will change properties of the selected instance
 func (s Settings) Put(ethernetPortSettings SettingsRequest, instanceId int) (response Response, err error) {
+	if err = s.base.Validate(ethernetPortSettings); err != nil {
+		return
+	}
 	ethernetPortSettings.H = fmt.Sprintf("%s%s", message.AMTSchema, AMT_EthernetPortSettings)
 	selector := message.Selector{
 		Name:  "InstanceID",
@@ -106,9 +109,24 @@ func (s Settings) Put(ethernetPortSettings SettingsRequest, instanceId int) (res
 	}
 
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = s.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
 	return
 }
+
+// Update reads the ethernet port settings of instanceId, applies mutate to them and writes them back with Put.
+// Pass common.WithConcurrentModificationDetection to abort with common.ErrConcurrentModification when the settings change before the Put.
+func (s Settings) Update(instanceId int, mutate func(*SettingsRequest), opts ...common.UpdateOption) (response Response, err error) {
+	get := func() (SettingsRequest, error) {
+		current, err := s.Get(fmt.Sprintf("Intel(r) AMT Ethernet Port Settings %d", instanceId))
+		return current.Body.GetAndPutResponse.ToRequest(), err
+	}
+	put := func(ethernetPortSettings SettingsRequest) (err error) {
+		response, err = s.Put(ethernetPortSettings, instanceId)
+		return
+	}
+	err = common.Update(get, put, mutate, opts...)
+	return
+}
@@ -374,3 +374,38 @@ func TestNegativeAMT_EthernetPortSettings(t *testing.T) {
 		}
 	})
 }
+
+func TestUpdateAMT_EthernetPortSettings(t *testing.T) {
+	wsmanMessageCreator := message.NewWSManMessageCreator("http://intel.com/wbem/wscim/1/amt-schema/1/")
+	client := wsmantesting.ActionClient{MockClient: wsmantesting.MockClient{PackageUnderTest: "amt/ethernetport"}}
+	elementUnderTest := NewEthernetPortSettingsWithClient(wsmanMessageCreator, &client)
+
+	t.Run("leaves read-only and static addressing fields out of a DHCP port", func(t *testing.T) {
+		response, err := elementUnderTest.Get("Intel(r) AMT Ethernet Port Settings 0")
+		assert.NoError(t, err)
+		request := response.Body.GetAndPutResponse.ToRequest()
+		assert.Equal(t, SettingsRequest{
+			ElementName:   "Intel(r) AMT Ethernet Port Settings",
+			InstanceID:    "Intel(r) AMT Ethernet Port Settings 0",
+			SharedMAC:     true,
+			LinkPolicy:    []LinkPolicy{S0AC, SxAC},
+			IpSyncEnabled: true,
+			DHCPEnabled:   true,
+		}, request)
+		assert.NoError(t, request.Validate())
+	})
+
+	t.Run("puts the mutated settings of a DHCP port", func(t *testing.T) {
+		client.Sent = nil
+		_, err := elementUnderTest.Update(0, func(r *SettingsRequest) {
+			r.LinkPolicy = append(r.LinkPolicy, S0DC)
+		}, common.WithConcurrentModificationDetection())
+		assert.NoError(t, err)
+		assert.Len(t, client.Sent, 3)
+		assert.Contains(t, client.Sent[2], "<h:LinkPolicy>1</h:LinkPolicy><h:LinkPolicy>14</h:LinkPolicy><h:LinkPolicy>16</h:LinkPolicy>")
+		assert.Contains(t, client.Sent[2], "<h:LinkIsUp>false</h:LinkIsUp>")
+		for _, field := range []string{"SubnetMask", "DefaultGateway", "PrimaryDNS", "SecondaryDNS", "MACAddress", "PhysicalConnectionType"} {
+			assert.NotContains(t, client.Sent[2], "<h:"+field+">")
+		}
+	})
+}
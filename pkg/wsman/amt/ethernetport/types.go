@@ -13,6 +13,8 @@ import (
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/common"
 )
 
+//go:generate go run ../../../../internal/tools/genconvert -type SettingsResponse=SettingsRequest -readonly MACAddress,LinkIsUp,LinkControl,SharedDynamicIP,WLANLinkProtectionLevel,PhysicalConnectionType,PhysicalNicMedium -unless DHCPEnabled=IPAddress,SubnetMask,DefaultGateway,PrimaryDNS,SecondaryDNS
+
 type Settings struct {
 	base message.Base
 }
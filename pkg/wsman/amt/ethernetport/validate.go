@@ -0,0 +1,55 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package ethernetport
+
+import (
+	"net"
+
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/common"
+)
+
+// Validate checks that the static addresses, IPAddress, SubnetMask, DefaultGateway, PrimaryDNS and SecondaryDNS, are
+// IPv4 addresses left empty when DHCPEnabled is true, and that IPAddress and a contiguous SubnetMask are set when the
+// address comes neither from DHCP nor from the host, IpSyncEnabled. It also checks the ranges of VLANTag,
+// ConsoleTcpMaxRetransmissions, LinkPreference and LinkPolicy.
+func (r SettingsRequest) Validate() error {
+	v := common.NewValidationError("SettingsRequest")
+	static := map[string]string{
+		"IPAddress":      r.IPAddress,
+		"SubnetMask":     r.SubnetMask,
+		"DefaultGateway": r.DefaultGateway,
+		"PrimaryDNS":     r.PrimaryDNS,
+		"SecondaryDNS":   r.SecondaryDNS,
+	}
+	for _, field := range []string{"IPAddress", "SubnetMask", "DefaultGateway", "PrimaryDNS", "SecondaryDNS"} {
+		if r.DHCPEnabled && static[field] != "" {
+			v.Add(field, "must not be set when DHCPEnabled is true")
+		}
+		v.IPv4(field, static[field])
+	}
+	if !r.DHCPEnabled && !r.IpSyncEnabled {
+		v.Required("IPAddress", r.IPAddress)
+		v.Required("SubnetMask", r.SubnetMask)
+	}
+	if r.SubnetMask != "" {
+		if mask := net.ParseIP(r.SubnetMask).To4(); mask != nil {
+			if _, bits := net.IPMask(mask).Size(); bits == 0 {
+				v.Add("SubnetMask", "must have all ones before the zeros, got %q", r.SubnetMask)
+			}
+		}
+	}
+	v.Range("VLANTag", r.VLANTag, 0, 4094)
+	if r.ConsoleTcpMaxRetransmissions != 0 {
+		v.Range("ConsoleTcpMaxRetransmissions", int(r.ConsoleTcpMaxRetransmissions), int(ConsoleTcpMaxRetransmissions5), int(ConsoleTcpMaxRetransmissions7))
+	}
+	if r.LinkPreference != 0 {
+		v.OneOf("LinkPreference", int(r.LinkPreference), int(LinkPreferenceME), int(LinkPreferenceHOST))
+	}
+	for i, policy := range r.LinkPolicy {
+		v.OneOf(common.Index("LinkPolicy", i), int(policy), int(S0AC), int(SxAC), int(S0DC), int(SxDC))
+	}
+	return v.Err()
+}
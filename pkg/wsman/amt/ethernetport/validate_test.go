@@ -0,0 +1,55 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package ethernetport
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/internal/message"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/common"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/wsmantesting"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSettingsRequestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		request SettingsRequest
+		fields  []string
+	}{
+		{"DHCP enabled", SettingsRequest{DHCPEnabled: true, IpSyncEnabled: true}, nil},
+		{"static with IP sync", SettingsRequest{IpSyncEnabled: true, SharedStaticIp: true}, nil},
+		{"static", SettingsRequest{IPAddress: "192.168.0.24", SubnetMask: "255.255.255.0", DefaultGateway: "192.168.0.1", PrimaryDNS: "8.8.8.8"}, nil},
+		{"DHCP with static addresses", SettingsRequest{DHCPEnabled: true, IPAddress: "192.168.0.24", SubnetMask: "255.255.255.0"}, []string{"IPAddress", "SubnetMask"}},
+		{"static without address", SettingsRequest{}, []string{"IPAddress", "SubnetMask"}},
+		{"malformed addresses", SettingsRequest{IPAddress: "192.168.0", SubnetMask: "255.0.255.0"}, []string{"IPAddress", "SubnetMask"}},
+		{"out of range values", SettingsRequest{DHCPEnabled: true, VLANTag: 4095, ConsoleTcpMaxRetransmissions: 8, LinkPolicy: []LinkPolicy{S0AC, 2}}, []string{"VLANTag", "ConsoleTcpMaxRetransmissions", "LinkPolicy[1]"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.request.Validate()
+			if test.fields == nil {
+				assert.NoError(t, err)
+				return
+			}
+			var validationError *common.ValidationError
+			assert.True(t, errors.As(err, &validationError))
+			fields := []string{}
+			for _, v := range validationError.Violations {
+				fields = append(fields, v.Field)
+			}
+			assert.Equal(t, test.fields, fields)
+		})
+	}
+}
+
+func TestSettingsPutRejectsInvalidRequest(t *testing.T) {
+	client := wsmantesting.MockClient{PackageUnderTest: "amt/ethernetport"}
+	elementUnderTest := NewEthernetPortSettingsWithClient(message.NewWSManMessageCreator(message.AMTSchema), &client)
+	_, err := elementUnderTest.Put(SettingsRequest{DHCPEnabled: true, IPAddress: "192.168.0.24"}, 0)
+	assert.EqualError(t, err, "invalid SettingsRequest: IPAddress: must not be set when DHCPEnabled is true")
+}
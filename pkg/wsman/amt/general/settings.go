@@ -7,10 +7,11 @@
 package general
 
 import (
-	"encoding/xml"
+	"fmt"
 
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/internal/message"
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/client"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/common"
 )
 
 // NewGeneralSettingsWithClient instantiates a new General Settings service
@@ -34,7 +35,7 @@ func (GeneralSettings Settings) Get() (response Response, err error) {
 	}
 
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = GeneralSettings.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -55,7 +56,7 @@ func (GeneralSettings Settings) Enumerate() (response Response, err error) {
 	}
 
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = GeneralSettings.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -77,7 +78,7 @@ func (GeneralSettings Settings) Pull(enumerationContext string) (response Respon
 	}
 
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = GeneralSettings.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -86,6 +87,9 @@ func (GeneralSettings Settings) Pull(enumerationContext string) (response Respon
 }
 
 // Put will change properties of the selected instance
+//
+// Deprecated: the response holds read-only properties that AMT refuses in a Put, use PutRequest with
+// generalSettings.ToRequest() instead.
 func (GeneralSettings Settings) Put(generalSettings GeneralSettingsResponse) (response Response, err error) {
 	response = Response{
 		Message: &client.Message{
@@ -98,9 +102,48 @@ func (GeneralSettings Settings) Put(generalSettings GeneralSettingsResponse) (re
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = GeneralSettings.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
 	return
 }
+
+// PutRequest changes the writable properties of the general settings to those of generalSettings, see ToRequest.
+func (GeneralSettings Settings) PutRequest(generalSettings GeneralSettingsRequest) (response Response, err error) {
+	generalSettings.H = fmt.Sprintf("%s%s", message.AMTSchema, AMT_GeneralSettings)
+	if err = GeneralSettings.base.Validate(generalSettings); err != nil {
+		return
+	}
+	response = Response{
+		Message: &client.Message{
+			XMLInput: GeneralSettings.base.Put(generalSettings, false, nil),
+		},
+	}
+	// send the message to AMT
+	err = GeneralSettings.base.Execute(response.Message)
+	if err != nil {
+		return
+	}
+	// put the xml response into the go struct
+	err = GeneralSettings.base.Decode(response.Message, &response)
+	if err != nil {
+		return
+	}
+	return
+}
+
+// Update reads the general settings, applies mutate to them and writes them back with PutRequest.
+// Pass common.WithConcurrentModificationDetection to abort with common.ErrConcurrentModification when the settings change before the Put.
+func (GeneralSettings Settings) Update(mutate func(*GeneralSettingsRequest), opts ...common.UpdateOption) (response Response, err error) {
+	get := func() (GeneralSettingsRequest, error) {
+		current, err := GeneralSettings.Get()
+		return current.Body.GetResponse.ToRequest(), err
+	}
+	put := func(generalSettings GeneralSettingsRequest) (err error) {
+		response, err = GeneralSettings.PutRequest(generalSettings)
+		return
+	}
+	err = common.Update(get, put, mutate, opts...)
+	return
+}
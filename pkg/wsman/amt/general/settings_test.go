@@ -279,3 +279,64 @@ func TestNegativeAMT_GeneralSettings(t *testing.T) {
 		}
 	})
 }
+
+func TestPutAMT_GeneralSettings(t *testing.T) {
+	wsmanMessageCreator := message.NewWSManMessageCreator("http://intel.com/wbem/wscim/1/amt-schema/1/")
+	client := wsmantesting.ActionClient{MockClient: wsmantesting.MockClient{PackageUnderTest: "amt/general"}}
+	elementUnderTest := NewGeneralSettingsWithClient(wsmanMessageCreator, &client)
+
+	t.Run("puts a request", func(t *testing.T) {
+		client.Sent = nil
+		response, err := elementUnderTest.PutRequest(GeneralSettingsRequest{
+			ElementName:     "Intel(r) AMT: General Settings",
+			InstanceID:      "Intel(r) AMT: General Settings",
+			HostName:        "Test Host Name",
+			IdleWakeTimeout: 1,
+		})
+		assert.NoError(t, err)
+		assert.Len(t, client.Sent, 1)
+		assert.Contains(t, client.Sent[0], "<h:ElementName>Intel(r) AMT: General Settings</h:ElementName><h:InstanceID>Intel(r) AMT: General Settings</h:InstanceID><h:IdleWakeTimeout>1</h:IdleWakeTimeout><h:HostName>Test Host Name</h:HostName>")
+		assert.Equal(t, "Test Host Name", response.Body.GetResponse.HostName)
+	})
+
+	t.Run("rejects an invalid request", func(t *testing.T) {
+		client.Sent = nil
+		_, err := elementUnderTest.PutRequest(GeneralSettingsRequest{DDNSPeriodicUpdateInterval: 10})
+		var validationError *common.ValidationError
+		assert.ErrorAs(t, err, &validationError)
+		assert.Empty(t, client.Sent)
+	})
+
+	t.Run("puts a response with the deprecated Put", func(t *testing.T) {
+		client.Sent = nil
+		response, err := elementUnderTest.Put(GeneralSettingsResponse{
+			ElementName: "Intel(r) AMT: General Settings",
+			InstanceID:  "Intel(r) AMT: General Settings",
+			HostName:    "Test Host Name",
+		})
+		assert.NoError(t, err)
+		assert.Len(t, client.Sent, 1)
+		assert.Contains(t, client.Sent[0], "<HostName>Test Host Name</HostName>")
+		assert.Equal(t, "Test Host Name", response.Body.GetResponse.HostName)
+	})
+
+	t.Run("converts the Get response into a request", func(t *testing.T) {
+		response, err := elementUnderTest.Get()
+		assert.NoError(t, err)
+		request := response.Body.GetResponse.ToRequest()
+		assert.Equal(t, "Intel(r) AMT: General Settings", request.InstanceID)
+		assert.Empty(t, request.DigestRealm)
+		assert.False(t, request.NetworkInterfaceEnabled)
+	})
+
+	t.Run("puts the mutated settings", func(t *testing.T) {
+		client.Sent = nil
+		_, err := elementUnderTest.Update(func(r *GeneralSettingsRequest) {
+			r.HostName = "Updated Host Name"
+		}, common.WithConcurrentModificationDetection())
+		assert.NoError(t, err)
+		assert.Len(t, client.Sent, 3)
+		assert.Contains(t, client.Sent[2], "<h:HostName>Updated Host Name</h:HostName>")
+		assert.NotContains(t, client.Sent[2], "DigestRealm")
+	})
+}
@@ -13,6 +13,8 @@ import (
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/common"
 )
 
+//go:generate go run ../../../../internal/tools/genconvert -type GeneralSettingsResponse=GeneralSettingsRequest -readonly NetworkInterfaceEnabled,DigestRealm,PrivacyLevel,PowerSource
+
 type Settings struct {
 	base message.Base
 }
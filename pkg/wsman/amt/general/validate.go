@@ -0,0 +1,31 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package general
+
+import (
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/common"
+)
+
+// Validate checks the documented length limits and intervals of the general settings.
+func (r GeneralSettingsRequest) Validate() error {
+	v := common.NewValidationError("GeneralSettingsRequest")
+	v.MaxLength("HostName", r.HostName, 63)
+	v.MaxLength("DomainName", r.DomainName, 191)
+	if r.IdleWakeTimeout != 0 {
+		v.Range("IdleWakeTimeout", r.IdleWakeTimeout, 1, 65535)
+	}
+	if r.DDNSPeriodicUpdateInterval != 0 && r.DDNSPeriodicUpdateInterval < 20 {
+		v.Add("DDNSPeriodicUpdateInterval", "must be 0 or at least 20 minutes, got %d", r.DDNSPeriodicUpdateInterval)
+	}
+	if r.PresenceNotificationInterval != 0 && r.PresenceNotificationInterval < 15 {
+		v.Add("PresenceNotificationInterval", "must be 0 or at least 15 minutes, got %d", r.PresenceNotificationInterval)
+	}
+	if r.DDNSTTL < 0 {
+		v.Add("DDNSTTL", "must not be negative, got %d", r.DDNSTTL)
+	}
+	v.OneOf("PreferredAddressFamily", int(r.PreferredAddressFamily), int(IPv4), int(IPv6))
+	return v.Err()
+}
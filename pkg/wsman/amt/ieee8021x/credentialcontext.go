@@ -11,8 +11,6 @@
 package ieee8021x
 
 import (
-	"encoding/xml"
-
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/internal/message"
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/client"
 )
@@ -44,7 +42,7 @@ func (credentialContext CredentialContext) Enumerate() (response Response, err e
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = credentialContext.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -64,7 +62,7 @@ func (credentialContext CredentialContext) Pull(enumerationContext string) (resp
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = credentialContext.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -6,7 +6,6 @@
 package ieee8021x
 
 import (
-	"encoding/xml"
 	"fmt"
 
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/internal/message"
@@ -37,7 +36,7 @@ func (profile Profile) Get() (response Response, err error) {
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = profile.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -57,7 +56,7 @@ func (profile Profile) Enumerate() (response Response, err error) {
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = profile.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -77,7 +76,7 @@ func (profile Profile) Pull(enumerationContext string) (response Response, err e
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = profile.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -87,6 +86,9 @@ func (profile Profile) Pull(enumerationContext string) (response Response, err e
 // Put will change properties of the selected instance
 func (profile Profile) Put(ieee8021xProfile ProfileRequest) (response Response, err error) {
 	ieee8021xProfile.H = fmt.Sprintf("%s%s", message.AMTSchema, AMT_IEEE8021xProfile)
+	if err = profile.base.Validate(ieee8021xProfile); err != nil {
+		return
+	}
 	response = Response{
 		Message: &client.Message{
 			XMLInput: profile.base.Put(ieee8021xProfile, false, nil),
@@ -98,7 +100,7 @@ func (profile Profile) Put(ieee8021xProfile ProfileRequest) (response Response,
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = profile.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
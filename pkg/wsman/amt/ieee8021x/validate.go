@@ -0,0 +1,27 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package ieee8021x
+
+import (
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/common"
+)
+
+// Validate checks required properties, the documented maximum lengths and the PxeTimeout range.
+func (r ProfileRequest) Validate() error {
+	v := common.NewValidationError("ProfileRequest")
+	v.Required("ElementName", r.ElementName)
+	v.Range("AuthenticationProtocol", int(r.AuthenticationProtocol), int(TLS), int(EAPFAST_TLS))
+	v.OneOf("ServerCertificateNameComparison", int(r.ServerCertificateNameComparison), int(FullName), int(DomainSuffix))
+	v.MaxLength("Username", r.Username, 128)
+	v.MaxLength("Password", r.Password, 32)
+	v.MaxLength("Domain", r.Domain, 128)
+	v.MaxLength("PACPassword", r.PACPassword, 256)
+	v.Range("PxeTimeout", r.PxeTimeout, 0, 86400)
+	if (r.AuthenticationProtocol == TLS || r.AuthenticationProtocol == EAPFAST_TLS) && r.Enabled && r.ClientCertificate == "" {
+		v.Add("ClientCertificate", "is required for TLS based authentication protocols")
+	}
+	return v.Err()
+}
@@ -7,7 +7,6 @@
 package kerberos
 
 import (
-	"encoding/xml"
 	"fmt"
 
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/internal/message"
@@ -35,7 +34,7 @@ func (settingData SettingData) Get() (response Response, err error) {
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = settingData.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -55,7 +54,7 @@ func (settingData SettingData) Enumerate() (response Response, err error) {
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = settingData.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -75,7 +74,7 @@ func (settingData SettingData) Pull(enumerationContext string) (response Respons
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = settingData.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -98,7 +97,7 @@ func (settingData SettingData) GetCredentialCacheState() (response Response, err
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = settingData.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -126,7 +125,7 @@ func (settingData SettingData) SetCredentialCacheState(enabled bool) (response R
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = settingData.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
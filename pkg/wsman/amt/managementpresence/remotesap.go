@@ -7,8 +7,6 @@
 package managementpresence
 
 import (
-	"encoding/xml"
-
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/internal/message"
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/client"
 )
@@ -33,7 +31,7 @@ func (remoteSAP RemoteSAP) Get() (response Response, err error) {
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = remoteSAP.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -53,7 +51,7 @@ func (remoteSAP RemoteSAP) Enumerate() (response Response, err error) {
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = remoteSAP.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -73,7 +71,7 @@ func (remoteSAP RemoteSAP) Pull(enumerationContext string) (response Response, e
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = remoteSAP.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -94,7 +92,7 @@ func (remoteSAP RemoteSAP) Delete(handle string) (response Response, err error)
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = remoteSAP.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
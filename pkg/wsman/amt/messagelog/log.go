@@ -25,8 +25,6 @@
 package messagelog
 
 import (
-	"encoding/xml"
-
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/internal/message"
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/amt/methods"
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/client"
@@ -52,7 +50,7 @@ func (messageLog MessageLog) Get() (response Response, err error) {
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = messageLog.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -72,7 +70,7 @@ func (messageLog MessageLog) Enumerate() (response Response, err error) {
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = messageLog.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -92,7 +90,7 @@ func (messageLog MessageLog) Pull(enumerationContext string) (response Response,
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = messageLog.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -124,7 +122,7 @@ func (messageLog MessageLog) GetRecords(identifier int) (response Response, err
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = messageLog.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -148,7 +146,7 @@ func (messageLog MessageLog) PositionToFirstRecord() (response Response, err err
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = messageLog.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
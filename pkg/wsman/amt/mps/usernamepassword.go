@@ -7,7 +7,6 @@
 package mps
 
 import (
-	"encoding/xml"
 	"fmt"
 
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/internal/message"
@@ -34,7 +33,7 @@ func (usernamePassword UsernamePassword) Get() (response Response, err error) {
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = usernamePassword.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -54,7 +53,7 @@ func (usernamePassword UsernamePassword) Enumerate() (response Response, err err
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = usernamePassword.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -74,7 +73,7 @@ func (usernamePassword UsernamePassword) Pull(enumerationContext string) (respon
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = usernamePassword.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -84,6 +83,9 @@ func (usernamePassword UsernamePassword) Pull(enumerationContext string) (respon
 // Put will change properties of the selected instance
 func (usernamePassword UsernamePassword) Put(mpsUsernamePassword MPSUsernamePasswordRequest) (response Response, err error) {
 	mpsUsernamePassword.H = fmt.Sprintf("%s%s", message.AMTSchema, AMT_MPSUsernamePassword)
+	if err = usernamePassword.base.Validate(mpsUsernamePassword); err != nil {
+		return
+	}
 	response = Response{
 		Message: &client.Message{
 			XMLInput: usernamePassword.base.Put(mpsUsernamePassword, false, nil),
@@ -95,7 +97,7 @@ func (usernamePassword UsernamePassword) Put(mpsUsernamePassword MPSUsernamePass
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = usernamePassword.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -0,0 +1,18 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package mps
+
+import (
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/common"
+)
+
+// Validate checks that the MPS username and password fit within the 16 character limit enforced by the firmware.
+func (r MPSUsernamePasswordRequest) Validate() error {
+	v := common.NewValidationError("MPSUsernamePasswordRequest")
+	v.MaxLength("RemoteID", r.RemoteID, 16)
+	v.MaxLength("Secret", r.Secret, 16)
+	return v.Err()
+}
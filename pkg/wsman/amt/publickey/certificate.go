@@ -13,7 +13,6 @@
 package publickey
 
 import (
-	"encoding/xml"
 	"fmt"
 
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/internal/message"
@@ -44,7 +43,7 @@ func (certificate Certificate) Get(handle int) (response Response, err error) {
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = certificate.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -64,7 +63,7 @@ func (certificate Certificate) Enumerate() (response Response, err error) {
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = certificate.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -84,13 +83,28 @@ func (certificate Certificate) Pull(enumerationContext string) (response Respons
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = certificate.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
 	return
 }
 
+// PullEach streams the certificates returned by a Pull and calls fn for each one instead of collecting them into a Response.
+// Combined with client.Parameters.StreamResponses only a single PublicKeyCertificateResponse is held in memory at a time.
+func (certificate Certificate) PullEach(enumerationContext string, fn func(PublicKeyCertificateResponse) error) (err error) {
+	msg := &client.Message{
+		XMLInput: certificate.base.Pull(enumerationContext),
+	}
+	// send the message to AMT
+	err = certificate.base.Execute(msg)
+	if err != nil {
+		return
+	}
+	// decode the items one at a time
+	return message.DecodeItems(msg, AMT_PublicKeyCertificate, fn)
+}
+
 // Put will change properties of the selected instance
 func (certificate Certificate) Put(handle int, cert string) (response Response, err error) {
 	selector := message.Selector{
@@ -100,6 +114,9 @@ func (certificate Certificate) Put(handle int, cert string) (response Response,
 	publicKeyCertificate := PublicKeyCertificateRequest{}
 	publicKeyCertificate.X509Certificate = cert
 	publicKeyCertificate.H = fmt.Sprintf("%s%s", message.AMTSchema, AMT_PublicKeyCertificate)
+	if err = certificate.base.Validate(publicKeyCertificate); err != nil {
+		return
+	}
 	response = Response{
 		Message: &client.Message{
 			XMLInput: certificate.base.Put(publicKeyCertificate, true, &selector),
@@ -111,7 +128,7 @@ func (certificate Certificate) Put(handle int, cert string) (response Response,
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = certificate.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -132,7 +149,7 @@ func (certificate Certificate) Delete(instanceID string) (response Response, err
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = certificate.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
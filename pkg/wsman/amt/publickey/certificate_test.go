@@ -6,8 +6,11 @@
 package publickey
 
 import (
+	"bytes"
 	"encoding/xml"
 	"fmt"
+	"io"
+	"os"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -343,3 +346,92 @@ func TestNegativeAMT_PublicKeyCertificate(t *testing.T) {
 		}
 	})
 }
+
+// streamingClient answers every request with response, either buffered or as a stream.
+type streamingClient struct {
+	response []byte
+	stream   bool
+}
+
+func (c *streamingClient) Post(msg string) ([]byte, error) {
+	return append([]byte(nil), c.response...), nil
+}
+
+func (c *streamingClient) StreamResponses() bool {
+	return c.stream
+}
+
+func (c *streamingClient) PostStream(msg string) (io.ReadCloser, error) {
+	return io.NopCloser(bytes.NewReader(c.response)), nil
+}
+
+// largePullResponse repeats the first certificate of the recorded Pull response count times.
+func largePullResponse(t testing.TB, count int) []byte {
+	recorded, err := os.ReadFile("../../wsmantesting/responses/amt/publickey/certificate/pull.xml")
+	assert.NoError(t, err)
+	start := bytes.Index(recorded, []byte("<h:AMT_PublicKeyCertificate>"))
+	end := bytes.Index(recorded, []byte("</h:AMT_PublicKeyCertificate>")) + len("</h:AMT_PublicKeyCertificate>")
+	itemsEnd := bytes.Index(recorded, []byte("</g:Items>"))
+	response := append([]byte(nil), recorded[:start]...)
+	response = append(response, bytes.Repeat(recorded[start:end], count)...)
+	return append(response, recorded[itemsEnd:]...)
+}
+
+func TestPullEachAMT_PublicKeyCertificate(t *testing.T) {
+	wsmanMessageCreator := message.NewWSManMessageCreator("http://intel.com/wbem/wscim/1/amt-schema/1/")
+	for _, stream := range []bool{false, true} {
+		client := streamingClient{response: largePullResponse(t, 10), stream: stream}
+		elementUnderTest := NewPublicKeyCertificateWithClient(wsmanMessageCreator, &client)
+		count := 0
+		err := elementUnderTest.PullEach(wsmantesting.EnumerationContext, func(cert PublicKeyCertificateResponse) error {
+			count++
+			assert.Equal(t, "Intel(r) AMT Certificate: Handle: 0", cert.InstanceID)
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 10, count)
+	}
+}
+
+func BenchmarkCertificatePull(b *testing.B) {
+	client := streamingClient{response: largePullResponse(b, 1000)}
+	elementUnderTest := NewPublicKeyCertificateWithClient(message.NewWSManMessageCreator("http://intel.com/wbem/wscim/1/amt-schema/1/"), &client)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		response, err := elementUnderTest.Pull(wsmantesting.EnumerationContext)
+		if err != nil || len(response.Body.PullResponse.PublicKeyCertificateItems) != 1000 {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkCertificatePullStream(b *testing.B) {
+	client := streamingClient{response: largePullResponse(b, 1000), stream: true}
+	elementUnderTest := NewPublicKeyCertificateWithClient(message.NewWSManMessageCreator("http://intel.com/wbem/wscim/1/amt-schema/1/"), &client)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		response, err := elementUnderTest.Pull(wsmantesting.EnumerationContext)
+		if err != nil || len(response.Body.PullResponse.PublicKeyCertificateItems) != 1000 {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkCertificatePullEachStream(b *testing.B) {
+	client := streamingClient{response: largePullResponse(b, 1000), stream: true}
+	elementUnderTest := NewPublicKeyCertificateWithClient(message.NewWSManMessageCreator("http://intel.com/wbem/wscim/1/amt-schema/1/"), &client)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		count := 0
+		err := elementUnderTest.PullEach(wsmantesting.EnumerationContext, func(cert PublicKeyCertificateResponse) error {
+			count++
+			return nil
+		})
+		if err != nil || count != 1000 {
+			b.Fatal(err)
+		}
+	}
+}
@@ -6,7 +6,6 @@
 package publickey
 
 import (
-	"encoding/xml"
 	"errors"
 	"fmt"
 	"strconv"
@@ -37,7 +36,7 @@ func (managementService ManagementService) Get() (response Response, err error)
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = managementService.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -57,7 +56,7 @@ func (managementService ManagementService) Enumerate() (response Response, err e
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = managementService.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -77,7 +76,7 @@ func (managementService ManagementService) Pull(enumerationContext string) (resp
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = managementService.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -98,7 +97,7 @@ func (managementService ManagementService) Delete(instanceID string) (response R
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = managementService.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -146,6 +145,9 @@ func (managementService ManagementService) AddCertificate(certificateBlob string
 		H:               fmt.Sprintf("%s%s", message.AMTSchema, AMT_PublicKeyManagementService),
 		CertificateBlob: certificateBlob,
 	}
+	if err = managementService.base.Validate(certificate); err != nil {
+		return
+	}
 	body := managementService.base.WSManMessageCreator.CreateBody(methods.GenerateInputMethod(AddCertificate), AMT_PublicKeyManagementService, &certificate)
 	response = Response{
 		Message: &client.Message{
@@ -158,7 +160,7 @@ func (managementService ManagementService) AddCertificate(certificateBlob string
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = managementService.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -173,6 +175,9 @@ func (managementService ManagementService) AddTrustedRootCertificate(certificate
 		H:               fmt.Sprintf("%s%s", message.AMTSchema, AMT_PublicKeyManagementService),
 		CertificateBlob: certificateBlob,
 	}
+	if err = managementService.base.Validate(trustedRootCert); err != nil {
+		return
+	}
 	body := managementService.base.WSManMessageCreator.CreateBody(methods.GenerateInputMethod(AddTrustedRootCertificate), AMT_PublicKeyManagementService, &trustedRootCert)
 
 	response = Response{
@@ -186,7 +191,7 @@ func (managementService ManagementService) AddTrustedRootCertificate(certificate
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = managementService.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -203,6 +208,9 @@ func (managementService ManagementService) GenerateKeyPair(keyAlgorithm KeyAlgor
 		KeyAlgorithm: keyAlgorithm,
 		KeyLength:    keyLength,
 	}
+	if err = managementService.base.Validate(generateKeyPair); err != nil {
+		return
+	}
 	body := managementService.base.WSManMessageCreator.CreateBody(methods.GenerateInputMethod(GenerateKeyPair), AMT_PublicKeyManagementService, &generateKeyPair)
 	response = Response{
 		Message: &client.Message{
@@ -215,7 +223,7 @@ func (managementService ManagementService) GenerateKeyPair(keyAlgorithm KeyAlgor
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = managementService.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -246,6 +254,9 @@ func (managementService ManagementService) GeneratePKCS10RequestEx(keyPair, null
 		SigningAlgorithm:             signingAlgorithm,
 		NullSignedCertificateRequest: nullSignedCertificateRequest,
 	}
+	if err = managementService.base.Validate(pkcs10Request); err != nil {
+		return
+	}
 	body := managementService.base.WSManMessageCreator.CreateBody(methods.GenerateInputMethod(GeneratePKCS10RequestEx), AMT_PublicKeyManagementService, &pkcs10Request)
 	response = Response{
 		Message: &client.Message{
@@ -258,7 +269,7 @@ func (managementService ManagementService) GeneratePKCS10RequestEx(keyPair, null
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = managementService.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -275,6 +286,9 @@ func (managementService ManagementService) AddKey(keyBlob string) (response Resp
 		H:       fmt.Sprintf("%s%s", message.AMTSchema, AMT_PublicKeyManagementService),
 		KeyBlob: keyBlob,
 	}
+	if err = managementService.base.Validate(params); err != nil {
+		return
+	}
 	body := managementService.base.WSManMessageCreator.CreateBody(methods.GenerateInputMethod(AddKey), AMT_PublicKeyManagementService, params)
 	response = Response{
 		Message: &client.Message{
@@ -287,7 +301,7 @@ func (managementService ManagementService) AddKey(keyBlob string) (response Resp
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = managementService.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -0,0 +1,60 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package publickey
+
+import (
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/common"
+)
+
+// Validate checks that the certificate blob is present and base64 encoded.
+func (r AddCertificate_INPUT) Validate() error {
+	v := common.NewValidationError("AddCertificate_INPUT")
+	v.Required("CertificateBlob", r.CertificateBlob)
+	v.Base64("CertificateBlob", r.CertificateBlob)
+	return v.Err()
+}
+
+// Validate checks that the certificate blob is present and base64 encoded.
+func (r AddTrustedRootCertificate_INPUT) Validate() error {
+	v := common.NewValidationError("AddTrustedRootCertificate_INPUT")
+	v.Required("CertificateBlob", r.CertificateBlob)
+	v.Base64("CertificateBlob", r.CertificateBlob)
+	return v.Err()
+}
+
+// Validate checks that the key blob is present.
+func (r AddKey_INPUT) Validate() error {
+	v := common.NewValidationError("AddKey_INPUT")
+	v.Required("KeyBlob", r.KeyBlob)
+	return v.Err()
+}
+
+// Validate checks that the key algorithm and length are supported by the firmware key generator.
+func (r GenerateKeyPair_INPUT) Validate() error {
+	v := common.NewValidationError("GenerateKeyPair_INPUT")
+	v.OneOf("KeyAlgorithm", int(r.KeyAlgorithm), int(RSA))
+	v.OneOf("KeyLength", int(r.KeyLength), int(KeyLength2048))
+	return v.Err()
+}
+
+// Validate checks that the key pair reference, signing algorithm and null signed request are provided.
+func (r PKCS10Request) Validate() error {
+	v := common.NewValidationError("PKCS10Request")
+	if len(r.KeyPair.ReferenceParameters.SelectorSet.Selectors) == 0 || r.KeyPair.ReferenceParameters.SelectorSet.Selectors[0].Text == "" {
+		v.Add("KeyPair", "an InstanceID of an AMT_PublicPrivateKeyPair is required")
+	}
+	v.OneOf("SigningAlgorithm", int(r.SigningAlgorithm), int(SHA1RSA), int(SHA256RSA))
+	v.Required("NullSignedCertificateRequest", r.NullSignedCertificateRequest)
+	return v.Err()
+}
+
+// Validate checks that the certificate blob is present and base64 encoded.
+func (r PublicKeyCertificateRequest) Validate() error {
+	v := common.NewValidationError("PublicKeyCertificateRequest")
+	v.Required("X509Certificate", r.X509Certificate)
+	v.Base64("X509Certificate", r.X509Certificate)
+	return v.Err()
+}
@@ -0,0 +1,33 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package publickey
+
+import (
+	"testing"
+
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/internal/message"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/wsmantesting"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateKeyPairValidate(t *testing.T) {
+	assert.NoError(t, GenerateKeyPair_INPUT{KeyAlgorithm: RSA, KeyLength: KeyLength2048}.Validate())
+	assert.EqualError(t, GenerateKeyPair_INPUT{KeyAlgorithm: 1, KeyLength: 1024}.Validate(), "invalid GenerateKeyPair_INPUT: KeyAlgorithm: must be one of [0], got 1; KeyLength: must be one of [2048], got 1024")
+}
+
+func TestCertificateInputValidate(t *testing.T) {
+	assert.NoError(t, AddCertificate_INPUT{CertificateBlob: wsmantesting.TrustedRootCert}.Validate())
+	assert.EqualError(t, AddTrustedRootCertificate_INPUT{}.Validate(), "invalid AddTrustedRootCertificate_INPUT: CertificateBlob: is required")
+	assert.EqualError(t, AddCertificate_INPUT{CertificateBlob: "not base64!"}.Validate(), "invalid AddCertificate_INPUT: CertificateBlob: must be base64 encoded")
+	assert.EqualError(t, PKCS10Request{SigningAlgorithm: 5}.Validate(), "invalid PKCS10Request: KeyPair: an InstanceID of an AMT_PublicPrivateKeyPair is required; SigningAlgorithm: must be one of [0 1], got 5; NullSignedCertificateRequest: is required")
+}
+
+func TestGenerateKeyPairRejectsInvalidKeyLength(t *testing.T) {
+	client := wsmantesting.MockClient{PackageUnderTest: "amt/publickey"}
+	elementUnderTest := NewPublicKeyManagementServiceWithClient(message.NewWSManMessageCreator(message.AMTSchema), &client)
+	_, err := elementUnderTest.GenerateKeyPair(RSA, 4096)
+	assert.EqualError(t, err, "invalid GenerateKeyPair_INPUT: KeyLength: must be one of [2048], got 4096")
+}
@@ -9,7 +9,6 @@
 package publicprivate
 
 import (
-	"encoding/xml"
 	"fmt"
 
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/internal/message"
@@ -40,7 +39,7 @@ func (keyPair KeyPair) Get(handle int) (response Response, err error) {
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = keyPair.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -60,7 +59,7 @@ func (keyPair KeyPair) Enumerate() (response Response, err error) {
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = keyPair.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -80,7 +79,7 @@ func (keyPair KeyPair) Pull(enumerationContext string) (response Response, err e
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = keyPair.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -104,7 +103,7 @@ func (keyPair KeyPair) Delete(handle string) (response Response, err error) {
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = keyPair.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -7,12 +7,12 @@
 package redirection
 
 import (
-	"encoding/xml"
 	"fmt"
 
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/internal/message"
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/amt/methods"
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/client"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/common"
 )
 
 // NewRedirectionServiceWithClient instantiates a new Service
@@ -36,7 +36,7 @@ func (service Service) Get() (response Response, err error) {
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = service.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -56,7 +56,7 @@ func (service Service) Enumerate() (response Response, err error) {
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = service.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -76,7 +76,7 @@ func (service Service) Pull(enumerationContext string) (response Response, err e
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = service.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -97,6 +97,9 @@ func (service Service) Pull(enumerationContext string) (response Response, err e
 // - ListenerEnabled
 func (service Service) Put(redirectionService RedirectionRequest) (response Response, err error) {
 	redirectionService.H = fmt.Sprintf("%s%s", message.AMTSchema, AMT_RedirectionService)
+	if err = service.base.Validate(redirectionService); err != nil {
+		return
+	}
 	response = Response{
 		Message: &client.Message{
 			XMLInput: service.base.Put(redirectionService, false, nil),
@@ -108,7 +111,7 @@ func (service Service) Put(redirectionService RedirectionRequest) (response Resp
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = service.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -133,9 +136,24 @@ func (service Service) RequestStateChange(requestedState RequestedState) (respon
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = service.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
 	return
 }
+
+// Update reads the redirection service, applies mutate to it and writes it back with Put.
+// Pass common.WithConcurrentModificationDetection to abort with common.ErrConcurrentModification when the service changes before the Put.
+func (service Service) Update(mutate func(*RedirectionRequest), opts ...common.UpdateOption) (response Response, err error) {
+	get := func() (RedirectionRequest, error) {
+		current, err := service.Get()
+		return current.Body.GetAndPutResponse.ToRequest(), err
+	}
+	put := func(redirectionService RedirectionRequest) (err error) {
+		response, err = service.Put(redirectionService)
+		return
+	}
+	err = common.Update(get, put, mutate, opts...)
+	return
+}
@@ -330,3 +330,51 @@ func TestNegativeAMT_RedirectionService(t *testing.T) {
 
 	})
 }
+
+func TestUpdateAMT_RedirectionService(t *testing.T) {
+	wsmanMessageCreator := message.NewWSManMessageCreator("http://intel.com/wbem/wscim/1/amt-schema/1/")
+	client := wsmantesting.ActionClient{MockClient: wsmantesting.MockClient{PackageUnderTest: "amt/redirectionservice"}}
+	elementUnderTest := NewRedirectionServiceWithClient(wsmanMessageCreator, &client)
+
+	t.Run("converts the Get response into a request", func(t *testing.T) {
+		response, err := elementUnderTest.Get()
+		assert.NoError(t, err)
+		assert.Equal(t, RedirectionRequest{
+			CreationClassName:       "AMT_RedirectionService",
+			ElementName:             "Intel(r) AMT Redirection Service",
+			EnabledState:            32771,
+			ListenerEnabled:         true,
+			Name:                    "Intel(r) AMT Redirection Service",
+			SystemCreationClassName: "CIM_ComputerSystem",
+			SystemName:              "Intel(r) AMT",
+		}, response.Body.GetAndPutResponse.ToRequest())
+	})
+
+	t.Run("puts the mutated service", func(t *testing.T) {
+		client.Sent = nil
+		_, err := elementUnderTest.Update(func(r *RedirectionRequest) {
+			r.EnabledState = IDERAndSOLAreDisabled
+		}, common.WithConcurrentModificationDetection())
+		assert.NoError(t, err)
+		assert.Len(t, client.Sent, 3)
+		assert.Contains(t, client.Sent[2], "<h:EnabledState>32768</h:EnabledState><h:ListenerEnabled>true</h:ListenerEnabled><h:Name>Intel(r) AMT Redirection Service</h:Name>")
+	})
+}
+
+func TestStrictDecodingAMT_RedirectionService(t *testing.T) {
+	wsmanMessageCreator := message.NewWSManMessageCreator("http://intel.com/wbem/wscim/1/amt-schema/1/")
+	client := wsmantesting.MockClient{PackageUnderTest: "amt/redirectionservice"}
+	elementUnderTest := NewRedirectionServiceWithClient(wsmanMessageCreator, &client)
+
+	client.CurrentMessage = "Get"
+	_, err := elementUnderTest.Get()
+	assert.NoError(t, err)
+
+	client.CurrentMessage = "Pull"
+	_, err = elementUnderTest.Pull(wsmantesting.EnumerationContext)
+	assert.NoError(t, err)
+
+	client.CurrentMessage = "Get"
+	_, err = elementUnderTest.Enumerate()
+	assert.EqualError(t, err, "unexpected response to http://schemas.xmlsoap.org/ws/2004/09/enumeration/Enumerate: action is http://schemas.xmlsoap.org/ws/2004/09/transfer/GetResponse, expected http://schemas.xmlsoap.org/ws/2004/09/enumeration/EnumerateResponse; AMT_RedirectionService is in namespace \"http://intel.com/wbem/wscim/1/amt-schema/1/AMT_RedirectionService\", expected \"http://schemas.xmlsoap.org/ws/2004/09/enumeration\"")
+}
@@ -0,0 +1,17 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package redirection
+
+import (
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/common"
+)
+
+// Validate checks that EnabledState is one of the IDER/SOL combinations accepted by Put.
+func (r RedirectionRequest) Validate() error {
+	v := common.NewValidationError("RedirectionRequest")
+	v.Range("EnabledState", int(r.EnabledState), IDERAndSOLAreDisabled, IDERAndSOLAreEnabled)
+	return v.Err()
+}
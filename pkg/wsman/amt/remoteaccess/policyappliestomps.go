@@ -20,8 +20,6 @@
 package remoteaccess
 
 import (
-	"encoding/xml"
-
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/internal/message"
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/client"
 )
@@ -47,7 +45,7 @@ func (policyAppliesToMPS PolicyAppliesToMPS) Get() (response Response, err error
 	}
 
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = policyAppliesToMPS.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -68,7 +66,7 @@ func (policyAppliesToMPS PolicyAppliesToMPS) Enumerate() (response Response, err
 	}
 
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = policyAppliesToMPS.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -90,7 +88,7 @@ func (policyAppliesToMPS PolicyAppliesToMPS) Pull(enumerationContext string) (re
 	}
 
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = policyAppliesToMPS.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -100,6 +98,9 @@ func (policyAppliesToMPS PolicyAppliesToMPS) Pull(enumerationContext string) (re
 
 // Put will change properties of the selected instance
 func (policyAppliesToMPS PolicyAppliesToMPS) Put(remoteAccessPolicyAppliesToMPS *RemoteAccessPolicyAppliesToMPSRequest) (response Response, err error) {
+	if err = policyAppliesToMPS.base.Validate(remoteAccessPolicyAppliesToMPS); err != nil {
+		return
+	}
 	response = Response{
 		Message: &client.Message{
 			XMLInput: policyAppliesToMPS.base.Put(remoteAccessPolicyAppliesToMPS, false, nil),
@@ -112,7 +113,7 @@ func (policyAppliesToMPS PolicyAppliesToMPS) Put(remoteAccessPolicyAppliesToMPS
 	}
 
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = policyAppliesToMPS.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -135,7 +136,7 @@ func (policyAppliesToMPS PolicyAppliesToMPS) Delete(handle string) (response Res
 	}
 
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = policyAppliesToMPS.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -316,6 +316,7 @@ func TestPositiveAMT_RemoteAccessPolicyAppliesToMPS(t *testing.T) {
 				"",
 				"<w:SelectorSet><w:Selector Name=\"Name\">Instance</w:Selector></w:SelectorSet>",
 				func() (Response, error) {
+					client.CurrentMessage = "Delete"
 					return elementUnderTest.Delete("Instance")
 				},
 				Body{
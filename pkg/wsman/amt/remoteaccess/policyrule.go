@@ -6,7 +6,6 @@
 package remoteaccess
 
 import (
-	"encoding/xml"
 	"fmt"
 
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/internal/message"
@@ -34,7 +33,7 @@ func (policyRule PolicyRule) Get() (response Response, err error) {
 	}
 
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = policyRule.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -55,7 +54,7 @@ func (policyRule PolicyRule) Enumerate() (response Response, err error) {
 	}
 
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = policyRule.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -77,7 +76,7 @@ func (policyRule PolicyRule) Pull(enumerationContext string) (response Response,
 	}
 
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = policyRule.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -88,6 +87,9 @@ func (policyRule PolicyRule) Pull(enumerationContext string) (response Response,
 // Put will change properties of the selected instance
 func (policyRule PolicyRule) Put(remoteAccessPolicyRule RemoteAccessPolicyRuleRequest) (response Response, err error) {
 	remoteAccessPolicyRule.H = fmt.Sprintf("%s%s", message.AMTSchema, AMT_RemoteAccessPolicyRule)
+	if err = policyRule.base.Validate(remoteAccessPolicyRule); err != nil {
+		return
+	}
 	response = Response{
 		Message: &client.Message{
 			XMLInput: policyRule.base.Put(remoteAccessPolicyRule, false, nil),
@@ -99,7 +101,7 @@ func (policyRule PolicyRule) Put(remoteAccessPolicyRule RemoteAccessPolicyRuleRe
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = policyRule.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -120,7 +122,7 @@ func (policyRule PolicyRule) Delete(handle string) (response Response, err error
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = policyRule.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -6,7 +6,6 @@
 package remoteaccess
 
 import (
-	"encoding/xml"
 	"fmt"
 
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/internal/message"
@@ -35,7 +34,7 @@ func (service Service) Get() (response Response, err error) {
 	}
 
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = service.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -56,7 +55,7 @@ func (service Service) Enumerate() (response Response, err error) {
 	}
 
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = service.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -78,7 +77,7 @@ func (service Service) Pull(enumerationContext string) (response Response, err e
 	}
 
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = service.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -92,6 +91,9 @@ func (service Service) Pull(enumerationContext string) (response Response, err e
 // If the created MpServer is configured to use username password authentication, an AMT_MPSUsernamePassword instance is created and used as the associated credential.
 func (service Service) AddMPS(mpServer AddMpServerRequest) (response Response, err error) {
 	mpServer.H = fmt.Sprintf("%s%s", message.AMTSchema, AMT_RemoteAccessService)
+	if err = service.base.Validate(mpServer); err != nil {
+		return
+	}
 	header := service.base.WSManMessageCreator.CreateHeader(methods.GenerateAction(AMT_RemoteAccessService, AddMps), AMT_RemoteAccessService, nil, "", "")
 	body := service.base.WSManMessageCreator.CreateBody(methods.GenerateInputMethod(AddMps), AMT_RemoteAccessService, mpServer)
 	// body := fmt.Sprintf(`<Body><h:AddMpServer_INPUT xmlns:h="%s%s"><h:AccessInfo>%s</h:AccessInfo><h:InfoFormat>%d</h:InfoFormat><h:Port>%d</h:Port><h:AuthMethod>%d</h:AuthMethod><h:Username>%s</h:Username><h:Password>%s</h:Password><h:CN>%s</h:CN></h:AddMpServer_INPUT></Body>`, service.base.WSManMessageCreator.ResourceURIBase, AMT_RemoteAccessService, mpServer.AccessInfo, mpServer.InfoFormat, mpServer.Port, mpServer.AuthMethod, mpServer.Username, mpServer.Password, mpServer.CommonName)
@@ -106,7 +108,7 @@ func (service Service) AddMPS(mpServer AddMpServerRequest) (response Response, e
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = service.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -122,6 +124,9 @@ func (service Service) AddRemoteAccessPolicyRule(remoteAccessPolicyRule RemoteAc
 		Name:  "Name",
 		Value: name,
 	}
+	if err = service.base.Validate(remoteAccessPolicyRule); err != nil {
+		return
+	}
 	header := service.base.WSManMessageCreator.CreateHeader(methods.GenerateAction(AMT_RemoteAccessService, AddRemoteAccessPolicyRule), AMT_RemoteAccessService, nil, "", "")
 	body := fmt.Sprintf(`<Body><h:AddRemoteAccessPolicyRule_INPUT xmlns:h="%s%s"><h:Trigger>%d</h:Trigger><h:TunnelLifeTime>%d</h:TunnelLifeTime><h:ExtendedData>%s</h:ExtendedData><h:MpServer><Address xmlns="http://schemas.xmlsoap.org/ws/2004/08/addressing">http://schemas.xmlsoap.org/ws/2004/08/addressing/role/anonymous</Address><ReferenceParameters xmlns="http://schemas.xmlsoap.org/ws/2004/08/addressing"><ResourceURI xmlns="http://schemas.dmtf.org/wbem/wsman/1/wsman.xsd">%s%s</ResourceURI><SelectorSet xmlns="http://schemas.dmtf.org/wbem/wsman/1/wsman.xsd"><Selector Name="%s">%s</Selector></SelectorSet></ReferenceParameters></h:MpServer></h:AddRemoteAccessPolicyRule_INPUT></Body>`,
 		service.base.WSManMessageCreator.ResourceURIBase,
@@ -142,7 +147,7 @@ func (service Service) AddRemoteAccessPolicyRule(remoteAccessPolicyRule RemoteAc
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = service.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
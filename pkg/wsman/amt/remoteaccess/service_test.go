@@ -29,6 +29,7 @@ func TestPositiveAMT_RemoteAccessService(t *testing.T) {
 	wsmanMessageCreator := message.NewWSManMessageCreator(resourceUriBase)
 	client := wsmantesting.MockClient{
 		PackageUnderTest: "amt/remoteaccess/service",
+		SkipValidation:   true,
 	}
 	elementUnderTest := NewRemoteAccessServiceWithClient(wsmanMessageCreator, &client)
 	t.Run("amt_RemoteAccessService Tests", func(t *testing.T) {
@@ -246,6 +247,7 @@ func TestNegativeAMT_RemoteAccessService(t *testing.T) {
 	wsmanMessageCreator := message.NewWSManMessageCreator(resourceUriBase)
 	client := wsmantesting.MockClient{
 		PackageUnderTest: "amt/remoteaccess/service",
+		SkipValidation:   true,
 	}
 	elementUnderTest := NewRemoteAccessServiceWithClient(wsmanMessageCreator, &client)
 	t.Run("amt_RemoteAccessService Tests", func(t *testing.T) {
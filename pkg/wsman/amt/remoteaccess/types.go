@@ -79,8 +79,9 @@ type (
 		PolicyAppliesItems    []RemoteAccessPolicyAppliesToMPSResponse `xml:"Items>AMT_RemoteAccessPolicyAppliesToMPS"`
 	}
 	AddMpServerResponse struct {
-		XMLName  xml.Name `xml:"AddMpServer_OUTPUT"`
-		MpServer MpServer `xml:"MpServer"` // A reference to the created MPS if the operation succeeded.
+		XMLName     xml.Name `xml:"AddMpServer_OUTPUT"`
+		MpServer    MpServer `xml:"MpServer"`    // A reference to the created MPS if the operation succeeded.
+		ReturnValue int      `xml:"ReturnValue"` // ValueMap={0, 1, 36, 38, 2058} Values={PT_STATUS_SUCCESS, PT_STATUS_INTERNAL_ERROR, PT_STATUS_INVALID_PARAMETER, PT_STATUS_FLASH_WRITE_LIMIT_EXCEEDED, PT_STATUS_DUPLICATE}
 	}
 	AddRemoteAccessPolicyRuleResponse struct {
 		XMLName            xml.Name           `xml:"AddRemoteAccessPolicyRule_OUTPUT"`
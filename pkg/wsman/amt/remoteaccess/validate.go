@@ -0,0 +1,73 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package remoteaccess
+
+import (
+	"encoding/base64"
+	"net"
+
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/common"
+)
+
+// Validate checks the MPS address against its InfoFormat, the port range and the credentials required by the chosen AuthMethod.
+func (r AddMpServerRequest) Validate() error {
+	v := common.NewValidationError("AddMpServerRequest")
+	v.Required("AccessInfo", r.AccessInfo)
+	v.OneOf("InfoFormat", int(r.InfoFormat), int(IPv4Address), int(IPv6Address), int(FQDN))
+	switch r.InfoFormat {
+	case IPv4Address:
+		v.IPv4("AccessInfo", r.AccessInfo)
+	case IPv6Address:
+		if ip := net.ParseIP(r.AccessInfo); r.AccessInfo != "" && (ip == nil || ip.To4() != nil) {
+			v.Add("AccessInfo", "must be an IPv6 address, got %q", r.AccessInfo)
+		}
+	}
+	if r.InfoFormat == IPv4Address || r.InfoFormat == IPv6Address {
+		v.Required("CommonName", r.CommonName)
+	}
+	v.Range("Port", r.Port, 1, 65535)
+	v.OneOf("AuthMethod", int(r.AuthMethod), int(MutualAuthentication), int(UsernamePasswordAuthentication))
+	switch r.AuthMethod {
+	case UsernamePasswordAuthentication:
+		v.Required("Username", r.Username)
+		v.MaxLength("Username", r.Username, 16)
+		v.Required("Password", r.Password)
+		v.MaxLength("Password", r.Password, 16)
+	case MutualAuthentication:
+		v.Required("Certificate", r.Certificate)
+	}
+	return v.Err()
+}
+
+// Validate checks the trigger and that ExtendedData is base64 encoded and no longer than 32 bytes once decoded.
+func (r RemoteAccessPolicyRuleRequest) Validate() error {
+	v := common.NewValidationError("RemoteAccessPolicyRuleRequest")
+	v.Range("Trigger", int(r.Trigger), int(UserInitiated), int(HomeProvisioning))
+	if r.TunnelLifeTime < 0 {
+		v.Add("TunnelLifeTime", "must not be negative, got %d", r.TunnelLifeTime)
+	}
+	if r.ExtendedData != "" {
+		data, err := base64.StdEncoding.DecodeString(r.ExtendedData)
+		if err != nil {
+			v.Add("ExtendedData", "must be base64 encoded")
+		} else if len(data) > 32 {
+			v.Add("ExtendedData", "must be at most 32 bytes, got %d", len(data))
+		}
+	}
+	return v.Err()
+}
+
+// Validate checks the MPS type and that the MpServer and policy references are provided.
+func (r RemoteAccessPolicyAppliesToMPSRequest) Validate() error {
+	v := common.NewValidationError("RemoteAccessPolicyAppliesToMPSRequest")
+	v.Range("MPSType", int(r.MPSType), int(ExternalMPS), int(BothMPS))
+	if r.OrderOfAccess < 0 {
+		v.Add("OrderOfAccess", "must not be negative, got %d", r.OrderOfAccess)
+	}
+	v.Required("ManagedElement.ReferenceParameters.ResourceURI", r.ManagedElement.ReferenceParameters.ResourceURI)
+	v.Required("PolicySet.ReferenceParameters.ResourceURI", r.PolicySet.ReferenceParameters.ResourceURI)
+	return v.Err()
+}
@@ -0,0 +1,54 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package remoteaccess
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/internal/message"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/common"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/wsmantesting"
+)
+
+func TestAddMPSValidation(t *testing.T) {
+	wsmanMessageCreator := message.NewWSManMessageCreator("http://intel.com/wbem/wscim/1/amt-schema/1/")
+	client := wsmantesting.MockClient{
+		PackageUnderTest: "amt/remoteaccess/service",
+		CurrentMessage:   "AddMPSServer",
+	}
+	elementUnderTest := NewRemoteAccessServiceWithClient(wsmanMessageCreator, &client)
+
+	t.Run("sends a valid MPS", func(t *testing.T) {
+		response, err := elementUnderTest.AddMPS(AddMpServerRequest{
+			AccessInfo: "mps.example.com",
+			InfoFormat: FQDN,
+			Port:       4433,
+			AuthMethod: UsernamePasswordAuthentication,
+			Username:   "Username",
+			Password:   "Password",
+			CommonName: "mps.example.com",
+		})
+		assert.NoError(t, err)
+		assert.Contains(t, response.XMLInput, "<h:AccessInfo>mps.example.com</h:AccessInfo>")
+	})
+
+	t.Run("rejects an invalid MPS before sending it", func(t *testing.T) {
+		response, err := elementUnderTest.AddMPS(AddMpServerRequest{
+			AccessInfo: "AccessInfo",
+			InfoFormat: 1,
+			Port:       0,
+			AuthMethod: 3,
+		})
+		var validation *common.ValidationError
+		require.True(t, errors.As(err, &validation))
+		assert.EqualError(t, err, "invalid AddMpServerRequest: InfoFormat: must be one of [3 4 201], got 1; Port: must be between 1 and 65535, got 0; AuthMethod: must be one of [1 2], got 3")
+		assert.Nil(t, response.Message, "nothing is sent")
+	})
+}
@@ -8,7 +8,6 @@ package setupandconfiguration
 
 import (
 	"encoding/base64"
-	"encoding/xml"
 	"errors"
 	"fmt"
 	"strconv"
@@ -61,7 +60,7 @@ func (s Service) Get() (response Response, err error) {
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = s.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -81,7 +80,7 @@ func (s Service) Enumerate() (response Response, err error) {
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = s.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -101,7 +100,7 @@ func (s Service) Pull(enumerationContext string) (response Response, err error)
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = s.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -111,6 +110,9 @@ func (s Service) Pull(enumerationContext string) (response Response, err error)
 // Put will change properties of the selected instance
 func (s Service) Put(setupAndConfigurationService SetupAndConfigurationServiceRequest) (response Response, err error) {
 	setupAndConfigurationService.H = fmt.Sprintf("%s%s", message.AMTSchema, AMT_SetupAndConfigurationService)
+	if err = s.base.Validate(setupAndConfigurationService); err != nil {
+		return
+	}
 	response = Response{
 		Message: &client.Message{
 			XMLInput: s.base.Put(setupAndConfigurationService, false, nil),
@@ -122,13 +124,28 @@ func (s Service) Put(setupAndConfigurationService SetupAndConfigurationServiceRe
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = s.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
 	return
 }
 
+// Update reads the setup and configuration service, applies mutate to it and writes it back with Put.
+// Pass common.WithConcurrentModificationDetection to abort with common.ErrConcurrentModification when the service changes before the Put.
+func (s Service) Update(mutate func(*SetupAndConfigurationServiceRequest), opts ...common.UpdateOption) (response Response, err error) {
+	get := func() (SetupAndConfigurationServiceRequest, error) {
+		current, err := s.Get()
+		return current.Body.GetResponse.ToRequest(), err
+	}
+	put := func(setupAndConfigurationService SetupAndConfigurationServiceRequest) (err error) {
+		response, err = s.Put(setupAndConfigurationService)
+		return
+	}
+	err = common.Update(get, put, mutate, opts...)
+	return
+}
+
 // CommitChanges saves pending configuration commands made to the Intel® AMT device.
 // Completes configuration when in "IN-provisioning" state.
 // This routine commits pending configuration commands which are dependent on an internal restart sequence or a cumulative validity check.
@@ -167,7 +184,7 @@ func (s Service) CommitChanges() (response Response, err error) {
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = s.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -197,7 +214,7 @@ func (s Service) GetUuid() (response Response, err error) {
 	}
 
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = s.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -225,6 +242,9 @@ func (s Service) SetMEBXPassword(password string) (response Response, err error)
 	mebxPassword := MEBXPassword{
 		Password: password,
 	}
+	if err = s.base.Validate(mebxPassword); err != nil {
+		return
+	}
 	body := s.base.WSManMessageCreator.CreateBody(methods.GenerateInputMethod(SetMEBxPassword), AMT_SetupAndConfigurationService, &mebxPassword)
 	// body := fmt.Sprintf(`<Body><h:SetMEBxPassword_INPUT xmlns:h="%s%s"><h:Password>%s</h:Password></h:SetMEBxPassword_INPUT></Body>`, s.base.WSManMessageCreator.ResourceURIBase, AMT_SetupAndConfigurationService, password)
 	response = Response{
@@ -238,7 +258,7 @@ func (s Service) SetMEBXPassword(password string) (response Response, err error)
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = s.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -274,7 +294,7 @@ func (s Service) Unprovision(provisioningMode ProvisioningModeValue) (response R
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = s.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -13,6 +13,8 @@ import (
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/common"
 )
 
+//go:generate go run ../../../../internal/tools/genconvert -type SetupAndConfigurationServiceResponse=SetupAndConfigurationServiceRequest -readonly ProvisioningMode,ProvisioningState,DhcpDNSSuffix,TrustedDNSSuffix
+
 type Service struct {
 	base message.Base
 }
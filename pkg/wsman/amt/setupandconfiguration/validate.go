@@ -0,0 +1,25 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package setupandconfiguration
+
+import (
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/common"
+)
+
+// Validate checks that a password is provided.
+func (r MEBXPassword) Validate() error {
+	v := common.NewValidationError("MEBXPassword")
+	v.Required("Password", r.Password)
+	return v.Err()
+}
+
+// Validate checks that the configuration server FQDN fits in a DNS name.
+func (r SetupAndConfigurationServiceRequest) Validate() error {
+	v := common.NewValidationError("SetupAndConfigurationServiceRequest")
+	v.MaxLength("ConfigurationServerFQDN", r.ConfigurationServerFQDN, 255)
+	v.MaxLength("TrustedDNSSuffix", r.TrustedDNSSuffix, 255)
+	return v.Err()
+}
@@ -7,8 +7,6 @@
 package timesynchronization
 
 import (
-	"encoding/xml"
-
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/internal/message"
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/amt/methods"
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/client"
@@ -34,7 +32,7 @@ func (service Service) Get() (response Response, err error) {
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = service.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -54,7 +52,7 @@ func (service Service) Enumerate() (response Response, err error) {
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = service.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -74,7 +72,7 @@ func (service Service) Pull(enumerationContext string) (response Response, err e
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = service.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -94,12 +92,16 @@ func (service Service) Pull(enumerationContext string) (response Response, err e
 // Values={PT_STATUS_SUCCESS, PT_STATUS_INTERNAL_ERROR, PT_STATUS_INVALID_PARAMETER, PT_STATUS_FLASH_WRITE_LIMIT_EXCEEDED}
 func (service Service) SetHighAccuracyTimeSynch(ta0, tm1, tm2 int64) (response Response, err error) {
 	header := service.base.WSManMessageCreator.CreateHeader(methods.GenerateAction(AMT_TimeSynchronizationService, SetHighAccuracyTimeSynch), AMT_TimeSynchronizationService, nil, "", "")
-	body := service.base.WSManMessageCreator.CreateBody(methods.GenerateInputMethod(SetHighAccuracyTimeSynch), AMT_TimeSynchronizationService, &SetHighAccuracyTimeSynch_INPUT{
+	input := SetHighAccuracyTimeSynch_INPUT{
 		H:   "http://intel.com/wbem/wscim/1/amt-schema/1/AMT_TimeSynchronizationService",
 		Ta0: ta0,
 		Tm1: tm1,
 		Tm2: tm2,
-	})
+	}
+	if err = service.base.Validate(input); err != nil {
+		return
+	}
+	body := service.base.WSManMessageCreator.CreateBody(methods.GenerateInputMethod(SetHighAccuracyTimeSynch), AMT_TimeSynchronizationService, &input)
 	response = Response{
 		Message: &client.Message{
 			XMLInput: service.base.WSManMessageCreator.CreateXML(header, body),
@@ -111,7 +113,7 @@ func (service Service) SetHighAccuracyTimeSynch(ta0, tm1, tm2 int64) (response R
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = service.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -133,7 +135,7 @@ func (service Service) GetLowAccuracyTimeSynch() (response Response, err error)
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = service.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
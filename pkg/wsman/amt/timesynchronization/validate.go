@@ -0,0 +1,29 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package timesynchronization
+
+import (
+	"math"
+
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/common"
+)
+
+// Validate checks that the timestamps are 32-bit seconds and that Tm2 was not taken before Tm1.
+func (r SetHighAccuracyTimeSynch_INPUT) Validate() error {
+	v := common.NewValidationError("SetHighAccuracyTimeSynch_INPUT")
+	for _, t := range []struct {
+		field string
+		value int64
+	}{{"Ta0", r.Ta0}, {"Tm1", r.Tm1}, {"Tm2", r.Tm2}} {
+		if t.value < 0 || t.value > math.MaxUint32 {
+			v.Add(t.field, "must be between 0 and %d, got %d", uint32(math.MaxUint32), t.value)
+		}
+	}
+	if r.Tm2 < r.Tm1 {
+		v.Add("Tm2", "must not be before Tm1, got %d < %d", r.Tm2, r.Tm1)
+	}
+	return v.Err()
+}
@@ -0,0 +1,18 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package timesynchronization
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetHighAccuracyTimeSynchValidate(t *testing.T) {
+	assert.NoError(t, SetHighAccuracyTimeSynch_INPUT{Ta0: 1644240911, Tm1: 1644240943, Tm2: 1644240943}.Validate())
+	assert.EqualError(t, SetHighAccuracyTimeSynch_INPUT{Ta0: -1, Tm1: 1644240943, Tm2: 1 << 32}.Validate(), "invalid SetHighAccuracyTimeSynch_INPUT: Ta0: must be between 0 and 4294967295, got -1; Tm2: must be between 0 and 4294967295, got 4294967296")
+	assert.EqualError(t, SetHighAccuracyTimeSynch_INPUT{Tm1: 20, Tm2: 10}.Validate(), "invalid SetHighAccuracyTimeSynch_INPUT: Tm2: must not be before Tm1, got 10 < 20")
+}
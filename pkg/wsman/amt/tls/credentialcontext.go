@@ -19,7 +19,6 @@
 package tls
 
 import (
-	"encoding/xml"
 	"fmt"
 
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/internal/message"
@@ -46,7 +45,7 @@ func (credentialContext CredentialContext) Get() (response Response, err error)
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = credentialContext.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -66,7 +65,7 @@ func (credentialContext CredentialContext) Enumerate() (response Response, err e
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = credentialContext.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -86,7 +85,7 @@ func (credentialContext CredentialContext) Pull(enumerationContext string) (resp
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = credentialContext.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -107,7 +106,7 @@ func (credentialContext CredentialContext) Delete(handle string) (response Respo
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = credentialContext.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -130,7 +129,7 @@ func (credentialContext CredentialContext) Create(certHandle string) (response R
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = credentialContext.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -6,8 +6,6 @@
 package tls
 
 import (
-	"encoding/xml"
-
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/internal/message"
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/client"
 )
@@ -32,7 +30,7 @@ func (collection ProtocolEndpointCollection) Get() (response Response, err error
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = collection.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -52,7 +50,7 @@ func (collection ProtocolEndpointCollection) Enumerate() (response Response, err
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = collection.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -72,7 +70,7 @@ func (collection ProtocolEndpointCollection) Pull(enumerationContext string) (re
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = collection.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -6,11 +6,11 @@
 package tls
 
 import (
-	"encoding/xml"
 	"fmt"
 
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/internal/message"
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/client"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/common"
 )
 
 // NewTLSSettingDataWithClient instantiates a new SettingData
@@ -37,7 +37,7 @@ func (settingData SettingData) Get(instanceID string) (response Response, err er
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = settingData.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -57,7 +57,7 @@ func (settingData SettingData) Enumerate() (response Response, err error) {
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = settingData.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -77,7 +77,7 @@ func (settingData SettingData) Pull(enumerationContext string) (response Respons
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = settingData.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -95,6 +95,9 @@ func (settingData SettingData) Pull(enumerationContext string) (response Respons
 //
 // This method will not modify the flash ("Enabled" property) until setupandconfiguration.CommitChanges() is issued and performed successfully.
 func (settingData SettingData) Put(instanceID string, tlsSettingData SettingDataRequest) (response Response, err error) {
+	if err = settingData.base.Validate(tlsSettingData); err != nil {
+		return
+	}
 	tlsSettingData.H = fmt.Sprintf("%s%s", message.AMTSchema, AMT_TLSSettingData)
 	selector := message.Selector{
 		Name:  "InstanceID",
@@ -111,9 +114,24 @@ func (settingData SettingData) Put(instanceID string, tlsSettingData SettingData
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = settingData.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
 	return
 }
+
+// Update reads the TLS settings of the instance, applies mutate to them and writes them back with Put.
+// Pass common.WithConcurrentModificationDetection to abort with common.ErrConcurrentModification when the settings change before the Put.
+func (settingData SettingData) Update(instanceID string, mutate func(*SettingDataRequest), opts ...common.UpdateOption) (response Response, err error) {
+	get := func() (SettingDataRequest, error) {
+		current, err := settingData.Get(instanceID)
+		return current.Body.SettingDataGetAndPutResponse.ToRequest(), err
+	}
+	put := func(tlsSettingData SettingDataRequest) (err error) {
+		response, err = settingData.Put(instanceID, tlsSettingData)
+		return
+	}
+	err = common.Update(get, put, mutate, opts...)
+	return
+}
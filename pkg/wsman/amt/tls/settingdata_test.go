@@ -8,6 +8,7 @@ package tls
 import (
 	"encoding/xml"
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/internal/message"
@@ -301,3 +302,49 @@ func TestNegativeAMT_TLSSettingData(t *testing.T) {
 		}
 	})
 }
+
+// writableClient answers like wsmantesting.ActionClient with every writable field of the TLS settings set.
+type writableClient struct {
+	wsmantesting.ActionClient
+}
+
+func (c *writableClient) Post(msg string) ([]byte, error) {
+	response, err := c.ActionClient.Post(msg)
+	return []byte(strings.NewReplacer(
+		"<g:AcceptNonSecureConnections>false", "<g:AcceptNonSecureConnections>true",
+		"<g:Enabled>false", "<g:Enabled>true",
+		"<g:MutualAuthentication>false</g:MutualAuthentication>", "<g:MutualAuthentication>true</g:MutualAuthentication><g:TrustedCN>console.example.com</g:TrustedCN>",
+	).Replace(string(response))), err
+}
+
+func TestUpdateAMT_TLSSettingData(t *testing.T) {
+	wsmanMessageCreator := message.NewWSManMessageCreator("http://intel.com/wbem/wscim/1/amt-schema/1/")
+	client := writableClient{wsmantesting.ActionClient{MockClient: wsmantesting.MockClient{PackageUnderTest: "amt/tls/settingdata"}}}
+	elementUnderTest := NewTLSSettingDataWithClient(wsmanMessageCreator, &client)
+
+	response, err := elementUnderTest.Get("Intel(r) AMT 802.3 TLS Settings")
+	assert.NoError(t, err)
+	assert.Equal(t, SettingDataRequest{
+		ElementName:                "Intel(r) AMT 802.3 TLS Settings",
+		InstanceID:                 "Intel(r) AMT 802.3 TLS Settings",
+		MutualAuthentication:       true,
+		Enabled:                    true,
+		TrustedCN:                  []string{"console.example.com"},
+		AcceptNonSecureConnections: true,
+	}, response.Body.SettingDataGetAndPutResponse.ToRequest(), "every writable field is kept")
+
+	client.Sent = nil
+	_, err = elementUnderTest.Update("Intel(r) AMT 802.3 TLS Settings", func(r *SettingDataRequest) {
+		r.TrustedCN = append(r.TrustedCN, "backup.example.com")
+	})
+	assert.NoError(t, err)
+	assert.Len(t, client.Sent, 2)
+	for _, field := range []string{
+		"<h:MutualAuthentication>true</h:MutualAuthentication>",
+		"<h:Enabled>true</h:Enabled>",
+		"<h:TrustedCN>console.example.com</h:TrustedCN><h:TrustedCN>backup.example.com</h:TrustedCN>",
+		"<h:AcceptNonSecureConnections>true</h:AcceptNonSecureConnections>",
+	} {
+		assert.Contains(t, client.Sent[1], field)
+	}
+}
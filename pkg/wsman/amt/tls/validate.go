@@ -0,0 +1,42 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package tls
+
+import (
+	"strings"
+
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/common"
+)
+
+// Validate checks that ElementName and InstanceID are set, since AMT requires them in every Put, that
+// MutualAuthentication comes with Enabled and at least one TrustedCN, and that each TrustedCN is a valid RFC 1035 name.
+func (r SettingDataRequest) Validate() error {
+	v := common.NewValidationError("SettingDataRequest")
+	v.Required("ElementName", r.ElementName)
+	v.Required("InstanceID", r.InstanceID)
+	if r.MutualAuthentication {
+		if !r.Enabled {
+			v.Add("MutualAuthentication", "requires Enabled to be true")
+		}
+		if len(r.TrustedCN) == 0 {
+			v.Add("TrustedCN", "at least one trusted common name is required when MutualAuthentication is true")
+		}
+	}
+	for i, cn := range r.TrustedCN {
+		field := common.Index("TrustedCN", i)
+		if cn == "" {
+			v.Add(field, "must not be empty")
+			continue
+		}
+		v.MaxLength(field, cn, 255)
+		for _, label := range strings.Split(cn, ".") {
+			if len(label) > 63 {
+				v.Add(field, "label %q is longer than 63 characters", label)
+			}
+		}
+	}
+	return v.Err()
+}
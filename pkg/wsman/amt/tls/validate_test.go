@@ -0,0 +1,33 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package tls
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSettingDataRequestValidate(t *testing.T) {
+	valid := SettingDataRequest{ElementName: "Intel(r) AMT 802.3 TLS Settings", InstanceID: "Intel(r) AMT 802.3 TLS Settings", Enabled: true}
+	assert.NoError(t, valid.Validate())
+
+	mutual := valid
+	mutual.MutualAuthentication = true
+	mutual.TrustedCN = []string{"amt.example.com"}
+	assert.NoError(t, mutual.Validate())
+
+	missingCN := valid
+	missingCN.MutualAuthentication = true
+	missingCN.Enabled = false
+	assert.EqualError(t, missingCN.Validate(), "invalid SettingDataRequest: MutualAuthentication: requires Enabled to be true; TrustedCN: at least one trusted common name is required when MutualAuthentication is true")
+
+	badCN := valid
+	badCN.TrustedCN = []string{"", strings.Repeat("a", 64) + ".com"}
+	badCN.InstanceID = ""
+	assert.EqualError(t, badCN.Validate(), "invalid SettingDataRequest: InstanceID: is required; TrustedCN[0]: must not be empty; TrustedCN[1]: label \""+strings.Repeat("a", 64)+"\" is longer than 63 characters")
+}
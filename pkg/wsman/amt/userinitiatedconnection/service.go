@@ -7,8 +7,6 @@
 package userinitiatedconnection
 
 import (
-	"encoding/xml"
-
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/internal/message"
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/amt/methods"
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/client"
@@ -33,7 +31,7 @@ func (service Service) Get() (response Response, err error) {
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = service.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -53,7 +51,7 @@ func (service Service) Enumerate() (response Response, err error) {
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = service.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -73,7 +71,7 @@ func (service Service) Pull(enumerationContext string) (response Response, err e
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = service.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -110,7 +108,7 @@ func (service Service) RequestStateChange(requestedState RequestedState) (respon
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = service.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
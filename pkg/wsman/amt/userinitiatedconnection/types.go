@@ -40,6 +40,7 @@ type (
 		CreationClassName       string       `xml:"CreationClassName,omitempty"`       // CreationClassName indicates the name of the class or the subclass that is used in the creation of an instance. When used with the other key properties of this class, this property allows all instances of this class and its subclasses to be uniquely identified.
 		ElementName             string       `xml:"ElementName,omitempty"`             // A user-friendly name for the object. This property allows each instance to define a user-friendly name in addition to its key properties, identity data, and description information. Note that the Name property of ManagedSystemElement is also defined as a user-friendly name. But, it is often subclassed to be a Key. It is not reasonable that the same property can convey both identity and a user-friendly name, without inconsistencies. Where Name exists and is not a Key (such as for instances of LogicalDevice), the same information can be present in both the Name and ElementName properties. Note that if there is an associated instance of CIM_EnabledLogicalElementCapabilities, restrictions on this properties may exist as defined in ElementNameMask and MaxElementNameLen properties defined in that class.
 		EnabledState            EnabledState `xml:"EnabledState"`                      // EnabledState is an integer enumeration that indicates the enabled and disabled states of an element.
+		LastConnectionReason    string       `xml:"LastConnectionReason,omitempty"`    // The reason for the last user initiated connection, empty when no connection was initiated.
 		Name                    string       `xml:"Name,omitempty"`                    // The Name property uniquely identifies the Service and provides an indication of the functionality that is managed. This functionality is described in more detail in the Description property of the object.
 		SystemCreationClassName string       `xml:"SystemCreationClassName,omitempty"` // The CreationClassName of the scoping System.
 		SystemName              string       `xml:"SystemName,omitempty"`              // The Name of the scoping System.
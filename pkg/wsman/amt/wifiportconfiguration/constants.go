@@ -11,8 +11,9 @@ const (
 )
 
 const (
-	LocalSyncDisabled LocalProfileSynchronizationEnabled = 0
-	UnrestrictedSync  LocalProfileSynchronizationEnabled = 3
+	LocalSyncDisabled    LocalProfileSynchronizationEnabled = 0
+	LocalUserProfileSync LocalProfileSynchronizationEnabled = 1
+	UnrestrictedSync     LocalProfileSynchronizationEnabled = 3
 )
 
 const (
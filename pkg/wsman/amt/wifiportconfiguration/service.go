@@ -7,7 +7,6 @@
 package wifiportconfiguration
 
 import (
-	"encoding/xml"
 	"errors"
 	"fmt"
 
@@ -16,6 +15,7 @@ import (
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/cim/models"
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/cim/wifi"
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/client"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/common"
 )
 
 // NewWiFiPortConfigurationServiceWithClient instantiates a new Service
@@ -38,7 +38,7 @@ func (service Service) Get() (response Response, err error) {
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = service.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -58,7 +58,7 @@ func (service Service) Enumerate() (response Response, err error) {
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = service.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -78,7 +78,7 @@ func (service Service) Pull(enumerationContext string) (response Response, err e
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = service.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -89,6 +89,9 @@ func (service Service) Pull(enumerationContext string) (response Response, err e
 func (service Service) Put(wiFiPortConfigurationService WiFiPortConfigurationServiceRequest) (response Response, err error) {
 	//wiFiPortConfigurationService.XMLSchema = "http://intel.com/wbem/wscim/1/amt-schema/1/AMT_WiFiPortConfigurationService"
 	wiFiPortConfigurationService.H = fmt.Sprintf("%s%s", message.AMTSchema, AMT_WiFiPortConfigurationService)
+	if err = service.base.Validate(wiFiPortConfigurationService); err != nil {
+		return
+	}
 	response = Response{
 		Message: &client.Message{
 			XMLInput: service.base.Put(wiFiPortConfigurationService, false, nil),
@@ -100,7 +103,7 @@ func (service Service) Put(wiFiPortConfigurationService WiFiPortConfigurationSer
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = service.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -111,6 +114,21 @@ func (service Service) Put(wiFiPortConfigurationService WiFiPortConfigurationSer
 	return
 }
 
+// Update reads the Wi-Fi port configuration service, applies mutate to it and writes it back with Put.
+// Pass common.WithConcurrentModificationDetection to abort with common.ErrConcurrentModification when the service changes before the Put.
+func (service Service) Update(mutate func(*WiFiPortConfigurationServiceRequest), opts ...common.UpdateOption) (response Response, err error) {
+	get := func() (WiFiPortConfigurationServiceRequest, error) {
+		current, err := service.Get()
+		return current.Body.WiFiPortConfigurationService.ToRequest(), err
+	}
+	put := func(wiFiPortConfigurationService WiFiPortConfigurationServiceRequest) (err error) {
+		response, err = service.Put(wiFiPortConfigurationService)
+		return
+	}
+	err = common.Update(get, put, mutate, opts...)
+	return
+}
+
 // AddWiFiSettings atomically creates an instance of CIM_WifiEndpointSettings from the embedded instance parameter
 // and optionally an instance of CIM_IEEE8021xSettings from the embedded instance parameter (if provided),
 // associates the CIM_WiFiEndpointSettings instance with the referenced instance of CIM_WiFiEndpoint using
@@ -195,6 +213,9 @@ func (service Service) AddWiFiSettings(wifiEndpointSettings wifi.WiFiEndpointSet
 		}
 	}
 
+	if err = service.base.Validate(input); err != nil {
+		return
+	}
 	body := service.base.WSManMessageCreator.CreateBody(methods.GenerateInputMethod(AddWiFiSettings), AMT_WiFiPortConfigurationService, &input)
 	response = Response{
 		Message: &client.Message{
@@ -207,7 +228,7 @@ func (service Service) AddWiFiSettings(wifiEndpointSettings wifi.WiFiEndpointSet
 		return
 	}
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = service.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
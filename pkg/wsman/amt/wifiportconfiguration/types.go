@@ -15,6 +15,8 @@ import (
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/common"
 )
 
+//go:generate go run ../../../../internal/tools/genconvert -type WiFiPortConfigurationServiceResponse=WiFiPortConfigurationServiceRequest -readonly HealthState,LastConnectedSsidUnderMeControl
+
 type Service struct {
 	base message.Base
 }
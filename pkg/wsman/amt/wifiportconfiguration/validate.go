@@ -0,0 +1,62 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package wifiportconfiguration
+
+import (
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/cim/wifi"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/common"
+)
+
+// Validate checks the embedded endpoint settings and that IEEE 802.1x profiles reference a CA certificate.
+func (r AddWiFiSettings_INPUT) Validate() error {
+	v := common.NewValidationError("AddWiFiSettings_INPUT")
+	if len(r.WifiEndpoint.ReferenceParameters.SelectorSet.Selector) == 0 || r.WifiEndpoint.ReferenceParameters.SelectorSet.Selector[0].Value == "" {
+		v.Add("WifiEndpoint", "a CIM_WiFiEndpoint name is required")
+	}
+	v.Merge("WiFiEndpointSettings", r.WiFiEndpointSettings.Validate())
+	if r.WiFiEndpointSettings.AuthenticationMethod == wifi.AuthenticationMethod_WPA_IEEE8021x ||
+		r.WiFiEndpointSettings.AuthenticationMethod == wifi.AuthenticationMethod_WPA2_IEEE8021x {
+		if r.CACredential == nil {
+			v.Add("CACredential", "is required for IEEE 802.1x authentication")
+		}
+	}
+	if r.CACredential != nil {
+		v.Merge("CACredential", r.CACredential.Validate())
+	}
+	if r.ClientCredential != nil {
+		v.Merge("ClientCredential", r.ClientCredential.Validate())
+	}
+	return v.Err()
+}
+
+// Validate checks that the reference names an AMT_PublicKeyCertificate by its InstanceID.
+func (r CACredentialRequest) Validate() error {
+	v := common.NewValidationError("CACredentialRequest")
+	validateCertificateReference(v, r.ReferenceParameters)
+	return v.Err()
+}
+
+// Validate checks that the reference names an AMT_PublicKeyCertificate by its InstanceID.
+func (r ClientCredentialRequest) Validate() error {
+	v := common.NewValidationError("ClientCredentialRequest")
+	validateCertificateReference(v, r.ReferenceParameters)
+	return v.Err()
+}
+
+// validateCertificateReference records the violations of a reference to an AMT_PublicKeyCertificate.
+func validateCertificateReference(v *common.ValidationError, reference ReferenceParameters) {
+	v.Required("ReferenceParameters.ResourceURI", reference.ResourceURI)
+	if len(reference.SelectorSet.Selector) == 0 || reference.SelectorSet.Selector[0].Value == "" {
+		v.Add("ReferenceParameters.SelectorSet", "an InstanceID is required")
+	}
+}
+
+// Validate checks that enumerated properties hold supported values.
+func (r WiFiPortConfigurationServiceRequest) Validate() error {
+	v := common.NewValidationError("WiFiPortConfigurationServiceRequest")
+	v.OneOf("LocalProfileSynchronizationEnabled", int(r.LocalProfileSynchronizationEnabled), int(LocalSyncDisabled), int(LocalUserProfileSync), int(UnrestrictedSync))
+	return v.Err()
+}
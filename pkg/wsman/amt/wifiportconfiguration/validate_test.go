@@ -0,0 +1,42 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package wifiportconfiguration
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/cim/wifi"
+)
+
+func TestAddWiFiSettingsValidate(t *testing.T) {
+	reference := func(instanceID string) ReferenceParameters {
+		return ReferenceParameters{
+			ResourceURI: "http://intel.com/wbem/wscim/1/amt-schema/1/AMT_PublicKeyCertificate",
+			SelectorSet: SelectorSet{Selector: []Selector{{Name: "InstanceID", Value: instanceID}}},
+		}
+	}
+	input := AddWiFiSettings_INPUT{
+		WifiEndpoint: WiFiEndpoint{ReferenceParameters: ReferenceParameters{SelectorSet: SelectorSet{Selector: []Selector{{Name: "Name", Value: "WiFi Endpoint 0"}}}}},
+		WiFiEndpointSettings: wifi.WiFiEndpointSettingsRequest{
+			SSID:                 "office",
+			AuthenticationMethod: wifi.AuthenticationMethod_WPA2_IEEE8021x,
+			EncryptionMethod:     wifi.EncryptionMethod_CCMP,
+		},
+		CACredential:     &CACredentialRequest{ReferenceParameters: reference("Intel(r) AMT Certificate: Handle: 1")},
+		ClientCredential: &ClientCredentialRequest{ReferenceParameters: reference("Intel(r) AMT Certificate: Handle: 2")},
+	}
+	assert.NoError(t, input.Validate())
+
+	input.CACredential = &CACredentialRequest{ReferenceParameters: reference("")}
+	input.ClientCredential = &ClientCredentialRequest{}
+	assert.EqualError(t, input.Validate(), "invalid AddWiFiSettings_INPUT: CACredential.ReferenceParameters.SelectorSet: an InstanceID is required; ClientCredential.ReferenceParameters.ResourceURI: is required; ClientCredential.ReferenceParameters.SelectorSet: an InstanceID is required")
+
+	input.CACredential = nil
+	input.ClientCredential = nil
+	assert.EqualError(t, input.Validate(), "invalid AddWiFiSettings_INPUT: CACredential: is required for IEEE 802.1x authentication")
+}
@@ -0,0 +1,377 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+// Package anonymize rewrites captured WS-Man envelopes so they can be shared or added as fixtures without identifying
+// the device or its network.
+//
+// Serial numbers, UUIDs, MAC and IP addresses, host and domain names, user names, certificate subjects, enumeration
+// contexts and secrets are replaced with fake values of the same shape, and certificates and keys with placeholders of
+// the same encoding. An Anonymizer replaces a value with the same fake value wherever it occurs, across every document
+// it rewrites, so the references between responses, such as the enumeration context of a Pull, still hold. Only the
+// text of the rewritten elements changes, the rest of the document is copied byte for byte, so the result decodes into
+// the same response types.
+package anonymize
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/cassette"
+)
+
+// Categories of the values an Anonymizer replaces.
+const (
+	categoryUUID        = "uuid"
+	categoryContext     = "context"
+	categorySerial      = "serial"
+	categoryRealm       = "realm"
+	categoryMAC         = "mac"
+	categoryIP          = "ip"
+	categoryHost        = "host"
+	categoryDomain      = "domain"
+	categoryUser        = "user"
+	categoryNetwork     = "network"
+	categoryName        = "name"
+	categoryCertificate = "certificate"
+	categoryKey         = "key"
+	categorySecret      = "secret"
+)
+
+var (
+	uuidPattern = regexp.MustCompile(`[0-9A-Fa-f]{8}-[0-9A-Fa-f]{4}-[0-9A-Fa-f]{4}-[0-9A-Fa-f]{4}-[0-9A-Fa-f]{12}`)
+	macPattern  = regexp.MustCompile(`^[0-9A-Fa-f]{2}([-:]?)[0-9A-Fa-f]{2}(?:[-:]?[0-9A-Fa-f]{2}){4}$`)
+)
+
+// Anonymizer replaces identifying values with fake ones, consistently across the documents it rewrites. It is not safe
+// for concurrent use.
+type Anonymizer struct {
+	fakes  map[string]string
+	counts map[string]int
+}
+
+// New returns an Anonymizer that has not replaced any value yet.
+func New() *Anonymizer {
+	return &Anonymizer{fakes: map[string]string{}, counts: map[string]int{}}
+}
+
+// Envelope returns doc with the identifying values replaced.
+func (a *Anonymizer) Envelope(doc []byte) ([]byte, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(doc))
+	out := bytes.Buffer{}
+	copied := int64(0)
+	names := []string{}
+	for {
+		start := decoder.InputOffset()
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			names = append(names, t.Name.Local)
+		case xml.EndElement:
+			names = names[:len(names)-1]
+		case xml.CharData:
+			text := string(t)
+			value := strings.TrimSpace(text)
+			if len(names) == 0 || value == "" {
+				continue
+			}
+			fake := a.rewrite(names[len(names)-1], value)
+			if fake == value {
+				continue
+			}
+			lead := text[:strings.Index(text, value)]
+			out.Write(doc[copied:start])
+			out.WriteString(lead)
+			if err := xml.EscapeText(&out, []byte(fake)); err != nil {
+				return nil, err
+			}
+			out.WriteString(text[len(lead)+len(value):])
+			copied = decoder.InputOffset()
+		}
+	}
+	out.Write(doc[copied:])
+	return out.Bytes(), nil
+}
+
+// String is Envelope for a document held in a string.
+func (a *Anonymizer) String(doc string) (string, error) {
+	out, err := a.Envelope([]byte(doc))
+	return string(out), err
+}
+
+// Cassette rewrites the requests and responses of c in place. Responses that are not XML, such as the empty response
+// of a failed request, are left as they are.
+func (a *Anonymizer) Cassette(c *cassette.Cassette) error {
+	for i := range c.Interactions {
+		interaction := &c.Interactions[i]
+		request, err := a.String(interaction.Request)
+		if err != nil {
+			return fmt.Errorf("interaction %d: request: %w", i, err)
+		}
+		interaction.Request = request
+		if strings.TrimSpace(interaction.Response) == "" {
+			continue
+		}
+		response, err := a.String(interaction.Response)
+		if err != nil {
+			return fmt.Errorf("interaction %d: response: %w", i, err)
+		}
+		interaction.Response = response
+	}
+	return nil
+}
+
+// rewrite returns the fake value of the text of the element named name.
+func (a *Anonymizer) rewrite(name, value string) string {
+	switch name {
+	case "MessageID", "RelatesTo":
+		return value
+	}
+	if category := classify(name, value); category != "" {
+		return a.fake(category, value)
+	}
+	return uuidPattern.ReplaceAllStringFunc(value, func(uuid string) string {
+		return a.fake(categoryUUID, uuid)
+	})
+}
+
+// classify returns the category of the text of the element named name, or "" when it is not identifying. Boolean
+// values, such as the flags named after the setting they enable, are never identifying.
+func classify(name, value string) string {
+	if value == "true" || value == "false" {
+		return ""
+	}
+	contains := func(parts ...string) bool {
+		for _, part := range parts {
+			if strings.Contains(name, part) {
+				return true
+			}
+		}
+		return false
+	}
+	switch {
+	case contains("UUID", "GUID"):
+		return categoryUUID
+	case name == "EnumerationContext":
+		return categoryContext
+	case contains("SerialNumber"):
+		return categorySerial
+	case name == "DigestRealm" && strings.HasPrefix(value, "Digest:"):
+		return categoryRealm
+	case contains("MAC", "PermanentAddress") && macPattern.MatchString(value):
+		if strings.Trim(value, "0-:") == "" {
+			return ""
+		}
+		return categoryMAC
+	case contains("IP", "DNS", "Gateway", "Address", "Server", "AccessInfo") && !contains("Mask", "Prefix") && isAddress(value):
+		return categoryIP
+	case contains("HostName", "FQDN", "AccessInfo"):
+		return categoryHost
+	case contains("DomainName"):
+		return categoryDomain
+	case contains("Username", "UserName") && !strings.HasPrefix(value, "$$"):
+		return categoryUser
+	case contains("SSID"):
+		return categoryNetwork
+	case name == "Subject" || name == "Issuer":
+		return categoryName
+	case contains("Certificate") && isBlob(value):
+		return categoryCertificate
+	case contains("Key") && isBlob(value):
+		return categoryKey
+	case contains("Nonce", "OTP", "Password", "PSK", "Passphrase", "Signature") && len(value) >= 8:
+		return categorySecret
+	}
+	return ""
+}
+
+// isAddress reports whether value is an IP address other than an unspecified or loopback one.
+func isAddress(value string) bool {
+	ip := net.ParseIP(value)
+	return ip != nil && !ip.IsUnspecified() && !ip.IsLoopback()
+}
+
+// isBlob reports whether value is base64 data long enough to be a key or a certificate.
+func isBlob(value string) bool {
+	if len(value) < 64 {
+		return false
+	}
+	_, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(value), ""))
+	return err == nil
+}
+
+// fake returns the fake value of value in category, allocating the next one the first time value is seen.
+func (a *Anonymizer) fake(category, value string) string {
+	key := category + "\x00" + value
+	if fake, ok := a.fakes[key]; ok {
+		return fake
+	}
+	a.counts[category]++
+	n := a.counts[category]
+	var fake string
+	switch category {
+	case categoryRealm:
+		fake = "Digest:" + shape(strings.TrimPrefix(value, "Digest:"), n)
+	case categoryMAC:
+		fake = fakeMAC(value, n)
+	case categoryIP:
+		fake = fakeIP(value, n)
+	case categoryHost:
+		if strings.Contains(value, ".") {
+			fake = fmt.Sprintf("host-%d.example.com", n)
+		} else {
+			fake = fmt.Sprintf("host-%d", n)
+		}
+	case categoryDomain:
+		fake = fmt.Sprintf("domain-%d.example.com", n)
+	case categoryUser:
+		fake = fmt.Sprintf("user-%d", n)
+	case categoryNetwork:
+		fake = fmt.Sprintf("network-%d", n)
+	case categoryName:
+		fake = fmt.Sprintf("CN=name-%d", n)
+	case categoryCertificate:
+		fake = base64.StdEncoding.EncodeToString(placeholderCertificate(n))
+	case categoryKey:
+		fake = base64.StdEncoding.EncodeToString(placeholderKey(n))
+	case categorySerial:
+		fake = shape(value, n)
+	default:
+		fake = shapeData(value, n)
+	}
+	a.fakes[key] = fake
+	return fake
+}
+
+// shapeData is shape for values that may be base64 data, which is replaced with data of the same length encoding n.
+func shapeData(value string, n int) string {
+	if !isBase64(value) {
+		return shape(value, n)
+	}
+	decoded, _ := base64.StdEncoding.DecodeString(value)
+	fake := make([]byte, len(decoded))
+	counter := binary.BigEndian.AppendUint64(nil, uint64(n))
+	if len(counter) > len(fake) {
+		counter = counter[len(counter)-len(fake):]
+	}
+	copy(fake[len(fake)-len(counter):], counter)
+	return base64.StdEncoding.EncodeToString(fake)
+}
+
+// shape returns a value shaped like value that encodes n. Hex digits are replaced with the hex digits of n and, in
+// other values, decimal digits with the digits of n and letters with X.
+func shape(value string, n int) string {
+	hex := strings.Trim(value, "0123456789ABCDEFabcdef-:") == ""
+	upper := strings.ToUpper(value) == value
+	digits := fmt.Sprintf("%d", n)
+	if hex {
+		digits = fmt.Sprintf("%x", n)
+		if upper {
+			digits = strings.ToUpper(digits)
+		}
+	}
+	fake := []byte(value)
+	for i := len(fake) - 1; i >= 0; i-- {
+		c := fake[i]
+		isDigit := c >= '0' && c <= '9'
+		isLetter := c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+		switch {
+		case isDigit || hex && isLetter:
+			fake[i] = '0'
+			if len(digits) > 0 {
+				fake[i] = digits[len(digits)-1]
+				digits = digits[:len(digits)-1]
+			}
+		case isLetter && c >= 'a':
+			fake[i] = 'x'
+		case isLetter:
+			fake[i] = 'X'
+		}
+	}
+	return string(fake)
+}
+
+// isBase64 reports whether value is base64 data that is not just hex digits.
+func isBase64(value string) bool {
+	if len(value) < 8 || len(value)%4 != 0 || strings.Trim(value, "0123456789ABCDEFabcdef") == "" {
+		return false
+	}
+	_, err := base64.StdEncoding.DecodeString(value)
+	return err == nil
+}
+
+// fakeMAC returns a locally administered MAC address encoding n, with the separators and case of value.
+func fakeMAC(value string, n int) string {
+	separator := macPattern.FindStringSubmatch(value)[1]
+	octets := []string{"02", "00", "00", fmt.Sprintf("%02x", n>>16&0xff), fmt.Sprintf("%02x", n>>8&0xff), fmt.Sprintf("%02x", n&0xff)}
+	fake := strings.Join(octets, separator)
+	if strings.ToUpper(value) == value {
+		fake = strings.ToUpper(fake)
+	}
+	return fake
+}
+
+// fakeIP returns an address of the documentation ranges encoding n, of the family of value.
+func fakeIP(value string, n int) string {
+	if net.ParseIP(value).To4() == nil {
+		return fmt.Sprintf("2001:db8::%x", n)
+	}
+	networks := []string{"192.0.2", "198.51.100", "203.0.113"}
+	n--
+	return fmt.Sprintf("%s.%d", networks[n/254%len(networks)], n%254+1)
+}
+
+// placeholderKey returns a DER encoded PKCS #1 RSA public key, the encoding AMT uses for its keys, derived from n. The
+// modulus is not the product of two primes, it only has the size and form of one.
+func placeholderKey(n int) []byte {
+	modulus := make([]byte, 0, 256)
+	for block := 0; len(modulus) < 256; block++ {
+		sum := sha256.Sum256([]byte(fmt.Sprintf("anonymized key %d %d", n, block)))
+		modulus = append(modulus, sum[:]...)
+	}
+	modulus[0] |= 0x80
+	modulus[255] |= 1
+	return x509.MarshalPKCS1PublicKey(&rsa.PublicKey{N: new(big.Int).SetBytes(modulus), E: 65537})
+}
+
+// placeholderCertificate returns a DER encoded self-signed certificate named after n. Ed25519 signatures are
+// deterministic, so the certificate of n is always the same.
+func placeholderCertificate(n int) []byte {
+	seed := sha256.Sum256([]byte(fmt.Sprintf("anonymized certificate %d", n)))
+	key := ed25519.NewKeyFromSeed(seed[:])
+	name := pkix.Name{CommonName: fmt.Sprintf("certificate-%d", n)}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(int64(n)),
+		Subject:               name,
+		Issuer:                name,
+		NotBefore:             time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+		NotAfter:              time.Date(2050, 1, 1, 0, 0, 0, 0, time.UTC),
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(nil, template, template, key.Public(), key)
+	if err != nil {
+		panic(err)
+	}
+	return der
+}
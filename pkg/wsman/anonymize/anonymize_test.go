@@ -0,0 +1,149 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package anonymize
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/xml"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/amt/ethernetport"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/amt/publickey"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/cassette"
+)
+
+func readFixture(t *testing.T, path string) []byte {
+	b, err := os.ReadFile("../wsmantesting/responses/" + path)
+	require.NoError(t, err)
+	return b
+}
+
+func TestEthernetPortSettings(t *testing.T) {
+	doc := readFixture(t, "amt/ethernetport/get.xml")
+	anonymized, err := New().Envelope(doc)
+	require.NoError(t, err)
+
+	for _, identifying := range []string{"c8-d9-d2-7a-1e-33", "192.168.0.1", "68.105.28.11", "68.105.29.11"} {
+		assert.NotContains(t, string(anonymized), identifying)
+	}
+	original := ethernetport.Response{}
+	require.NoError(t, xml.Unmarshal(doc, &original))
+	response := ethernetport.Response{}
+	require.NoError(t, xml.Unmarshal(anonymized, &response))
+	settings := response.Body.GetAndPutResponse
+	assert.Equal(t, "02-00-00-00-00-01", settings.MACAddress)
+	assert.Equal(t, "192.0.2.1", settings.DefaultGateway)
+	assert.Equal(t, "192.0.2.2", settings.PrimaryDNS)
+	assert.Equal(t, "192.0.2.3", settings.SecondaryDNS)
+	assert.Equal(t, "255.255.255.0", settings.SubnetMask)
+
+	// everything else is unchanged
+	settings.MACAddress = original.Body.GetAndPutResponse.MACAddress
+	settings.DefaultGateway = original.Body.GetAndPutResponse.DefaultGateway
+	settings.PrimaryDNS = original.Body.GetAndPutResponse.PrimaryDNS
+	settings.SecondaryDNS = original.Body.GetAndPutResponse.SecondaryDNS
+	assert.Equal(t, original.Body.GetAndPutResponse, settings)
+	assert.Equal(t, original.Header, response.Header)
+	assert.Equal(t, len(strings.Split(string(doc), "\n")), len(strings.Split(string(anonymized), "\n")))
+}
+
+func TestPublicKeyCertificates(t *testing.T) {
+	doc := readFixture(t, "amt/publickey/certificate/pull.xml")
+	a := New()
+	anonymized, err := a.Envelope(doc)
+	require.NoError(t, err)
+
+	response := publickey.Response{}
+	require.NoError(t, xml.Unmarshal(anonymized, &response))
+	items := response.Body.PullResponse.PublicKeyCertificateItems
+	require.NotEmpty(t, items)
+	for _, item := range items {
+		assert.Equal(t, item.Subject, item.Issuer)
+		assert.True(t, strings.HasPrefix(item.Subject, "CN=name-"))
+		der, err := base64.StdEncoding.DecodeString(item.X509Certificate)
+		require.NoError(t, err)
+		certificate, err := x509.ParseCertificate(der)
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(certificate.Subject.CommonName, "certificate-"))
+	}
+
+	// the result is deterministic
+	again, err := New().Envelope(doc)
+	require.NoError(t, err)
+	assert.Equal(t, anonymized, again)
+}
+
+func TestConsistentAcrossDocuments(t *testing.T) {
+	a := New()
+	enumerate, err := a.String(`<Envelope><Body><EnumerateResponse><EnumerationContext>D3000000-0000-0000-0000-000000000000</EnumerationContext></EnumerateResponse></Body></Envelope>`)
+	require.NoError(t, err)
+	pull, err := a.String(`<Envelope><Body><Pull><EnumerationContext>D3000000-0000-0000-0000-000000000000</EnumerationContext></Pull></Body></Envelope>`)
+	require.NoError(t, err)
+	assert.Contains(t, enumerate, "<EnumerationContext>00000000-0000-0000-0000-000000000001</EnumerationContext>")
+	assert.Contains(t, pull, "<EnumerationContext>00000000-0000-0000-0000-000000000001</EnumerationContext>")
+}
+
+func TestValues(t *testing.T) {
+	a := New()
+	for _, test := range []struct{ name, value, expected string }{
+		{"SerialNumber", "KNQN0221020W", "XXXX0000001X"},
+		{"SerialNumber", "E0E8D670", "00000002"},
+		{"PlatformGUID", "13AEE355D2BFBB6117A088AEDD7037EA", "00000000000000000000000000000001"},
+		{"UUID", "E67jVdK/u2EXoIiu3XA36g==", "AAAAAAAAAAAAAAAAAAAAAg=="},
+		{"DigestRealm", "Digest:F3EB554784E729164447A89F60B641C5", "Digest:00000000000000000000000000000001"},
+		{"HostName", "amt-nuc", "host-1"},
+		{"HostOSFQDN", "amt-nuc.corp.local", "host-2.example.com"},
+		{"DomainName", "corp.local", "domain-1.example.com"},
+		{"Username", "admin", "user-1"},
+		{"DigestUsername", "$$uns", "$$uns"},
+		{"SharedFQDN", "true", "true"},
+		{"SSID", "office", "network-1"},
+		{"IPAddress", "fe80::1", "2001:db8::1"},
+		{"IPAddress", "0.0.0.0", "0.0.0.0"},
+		{"PermanentAddress", "000000000000", "000000000000"},
+		{"MACAddress", "A4AE111E4653", "020000000001"},
+		{"ConfigurationNonce", "4P3sY7swlhjkhJNxDkEBIUcmpHE=", "AAAAAAAAAAAAAAAAAAAAAAAAAAE="},
+		{"InstanceID", "Intel(r) AMT Ethernet Port Settings 0", "Intel(r) AMT Ethernet Port Settings 0"},
+		{"Name", "uuid:4C4C4544-0054-4810-8052-B9C04F305232", "uuid:00000000-0000-0000-0000-000000000003"},
+		{"MessageID", "uuid:00000000-8086-8086-8086-00000000047B", "uuid:00000000-8086-8086-8086-00000000047B"},
+	} {
+		assert.Equal(t, test.expected, a.rewrite(test.name, test.value), test.name)
+	}
+
+	key, err := base64.StdEncoding.DecodeString(a.rewrite("DERKey", strings.Repeat("MIIBCgKCAQEA", 8)))
+	require.NoError(t, err)
+	_, err = x509.ParsePKCS1PublicKey(key)
+	assert.NoError(t, err)
+}
+
+func TestEscaping(t *testing.T) {
+	anonymized, err := New().String("<Envelope>\n  <HostName> a&amp;b </HostName>\n</Envelope>")
+	require.NoError(t, err)
+	assert.Equal(t, "<Envelope>\n  <HostName> host-1 </HostName>\n</Envelope>", anonymized)
+
+	_, err = New().String("<Envelope><HostName>a</Envelope>")
+	assert.Error(t, err)
+}
+
+func TestCassette(t *testing.T) {
+	c := &cassette.Cassette{Interactions: []cassette.Interaction{
+		{Request: "<Envelope><Body><HostName>amt-nuc</HostName></Body></Envelope>", Response: "<Envelope><Body><HostName>amt-nuc</HostName></Body></Envelope>"},
+		{Request: "<Envelope/>", Error: "connection reset"},
+	}}
+	require.NoError(t, New().Cassette(c))
+	assert.Equal(t, "<Envelope><Body><HostName>host-1</HostName></Body></Envelope>", c.Interactions[0].Request)
+	assert.Equal(t, c.Interactions[0].Request, c.Interactions[0].Response)
+	assert.Equal(t, "connection reset", c.Interactions[1].Error)
+
+	c.Interactions[1].Response = "not xml <"
+	assert.Error(t, New().Cassette(c))
+}
@@ -0,0 +1,399 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+// Package cache provides an optional caching decorator for client.WSMan that serves repeated Get, Enumerate and Pull
+// requests for rarely changing classes from memory.
+//
+// Entries are kept per device and class for a configurable time to live. A Put, Create or Delete invalidates every entry
+// cached for its class on that device. A method invocation invalidates every entry of the device, since methods such as
+// CIM_PowerManagementService.RequestPowerStateChange change the state reported by other classes.
+//
+// An enumeration is cached with all of its pages and is only replayed once the Pull returning EndOfSequence has been
+// cached, so a replay never hands out the context of a page that is not in the cache.
+package cache
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/internal/message"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/client"
+)
+
+// Options configures which classes are cached and for how long.
+type Options struct {
+	DefaultTTL time.Duration            // Time to live of classes not listed in TTLs. Zero disables caching of those classes.
+	TTLs       map[string]time.Duration // Time to live by class name, for example "CIM_BIOSElement". Zero disables caching of the class.
+}
+
+// Stats counts cache lookups and invalidations.
+type Stats struct {
+	Hits          uint64
+	Misses        uint64
+	Invalidations uint64
+}
+
+// Cache holds the cached responses of any number of devices.
+type Cache struct {
+	options Options
+	now     func() time.Time
+
+	mu      sync.Mutex
+	entries map[classKey]*classEntry
+	stats   map[string]*Stats
+}
+
+type classKey struct {
+	device string
+	class  string
+}
+
+type response struct {
+	body    []byte
+	expires time.Time
+}
+
+// classEntry holds the cached responses of one class on one device.
+type classEntry struct {
+	gets        map[string]response
+	enumeration *enumeration
+}
+
+// enumeration holds an Enumerate response and the Pull responses of its pages by the context they were pulled with.
+// All pages expire with the Enumerate response.
+type enumeration struct {
+	enumerate response
+	pulls     map[string][]byte
+	next      string // context of the next page to pull, empty once EndOfSequence was received
+}
+
+func (e *enumeration) complete() bool {
+	return e.next == ""
+}
+
+// New returns an empty cache.
+func New(options Options) *Cache {
+	return &Cache{
+		options: options,
+		now:     time.Now,
+		entries: map[classKey]*classEntry{},
+		stats:   map[string]*Stats{},
+	}
+}
+
+// Client decorates next, the client of device, so that its responses are served from and stored in the cache.
+// device identifies the device within the cache, for example its hostname or GUID.
+func (c *Cache) Client(device string, next client.WSMan) client.WSMan {
+	return &cachingClient{cache: c, device: device, next: next}
+}
+
+// Stats returns the statistics of all classes.
+func (c *Cache) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	total := Stats{}
+	for _, s := range c.stats {
+		total.Hits += s.Hits
+		total.Misses += s.Misses
+		total.Invalidations += s.Invalidations
+	}
+	return total
+}
+
+// ClassStats returns the statistics of a single class across all devices.
+func (c *Cache) ClassStats(class string) Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if s, ok := c.stats[class]; ok {
+		return *s
+	}
+	return Stats{}
+}
+
+// Invalidate removes the entries of class on device.
+func (c *Cache) Invalidate(device, class string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.invalidate(classKey{device: device, class: class})
+}
+
+// Flush removes every entry of device.
+func (c *Cache) Flush(device string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.flush(device)
+}
+
+func (c *Cache) ttl(class string) time.Duration {
+	if ttl, ok := c.options.TTLs[class]; ok {
+		return ttl
+	}
+	return c.options.DefaultTTL
+}
+
+func (c *Cache) classStats(class string) *Stats {
+	s, ok := c.stats[class]
+	if !ok {
+		s = &Stats{}
+		c.stats[class] = s
+	}
+	return s
+}
+
+func (c *Cache) invalidate(key classKey) {
+	if _, ok := c.entries[key]; ok {
+		delete(c.entries, key)
+		c.classStats(key.class).Invalidations++
+	}
+}
+
+func (c *Cache) flush(device string) {
+	for key := range c.entries {
+		if key.device == device {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// invalidateAll removes every entry of device, counting an invalidation for each class.
+func (c *Cache) invalidateAll(device string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key := range c.entries {
+		if key.device == device {
+			c.invalidate(key)
+		}
+	}
+}
+
+// lookup returns the cached response to req, recording a hit or a miss.
+func (c *Cache) lookup(key classKey, req request) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	var body []byte
+	var expires time.Time
+	if entry, ok := c.entries[key]; ok {
+		switch req.action {
+		case message.BaseActionsGet:
+			if r, ok := entry.gets[req.selectors]; ok {
+				body, expires = r.body, r.expires
+			}
+		case message.BaseActionsEnumerate:
+			if e := entry.enumeration; e != nil && e.complete() {
+				body, expires = e.enumerate.body, e.enumerate.expires
+			}
+		case message.BaseActionsPull:
+			if e := entry.enumeration; e != nil && e.complete() {
+				body, expires = e.pulls[req.context], e.enumerate.expires
+			}
+		}
+	}
+	if body == nil || !now.Before(expires) {
+		c.classStats(key.class).Misses++
+		return nil, false
+	}
+	c.classStats(key.class).Hits++
+	return body, true
+}
+
+// store caches the response to req.
+func (c *Cache) store(key classKey, req request, body []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok {
+		entry = &classEntry{gets: map[string]response{}}
+		c.entries[key] = entry
+	}
+	r := response{body: body, expires: c.now().Add(c.ttl(key.class))}
+	switch req.action {
+	case message.BaseActionsGet:
+		entry.gets[req.selectors] = r
+	case message.BaseActionsEnumerate:
+		context := enumerationContext(body)
+		if context == "" {
+			entry.enumeration = nil
+			return
+		}
+		entry.enumeration = &enumeration{enumerate: r, pulls: map[string][]byte{}, next: context}
+	case message.BaseActionsPull:
+		// only the pages of the cached enumeration are kept, in the order they are pulled
+		e := entry.enumeration
+		if e == nil || e.complete() || req.context == "" || req.context != e.next {
+			return
+		}
+		next, end := pullContext(body)
+		if !end && next == "" {
+			entry.enumeration = nil
+			return
+		}
+		e.pulls[req.context] = body
+		e.next = next
+		if end {
+			e.next = ""
+		}
+	}
+}
+
+type cachingClient struct {
+	cache  *Cache
+	device string
+	next   client.WSMan
+}
+
+// Post serves Get, Enumerate and Pull requests of cached classes from the cache and invalidates the cache for any other request.
+func (c *cachingClient) Post(msg string) ([]byte, error) {
+	req, ok := c.route(msg)
+	if !ok {
+		return c.next.Post(msg)
+	}
+	key := classKey{device: c.device, class: req.class}
+	if body, ok := c.cache.lookup(key, req); ok {
+		return append([]byte(nil), body...), nil
+	}
+	body, err := c.next.Post(msg)
+	if err != nil {
+		return body, err
+	}
+	c.cache.store(key, req, append([]byte(nil), body...))
+	return body, nil
+}
+
+// route parses msg and invalidates the entries it changes. It reports whether the response to msg may be served from and
+// stored in the cache.
+func (c *cachingClient) route(msg string) (request, bool) {
+	req, ok := parseRequest(msg)
+	if !ok {
+		return req, false
+	}
+	switch req.action {
+	case message.BaseActionsGet, message.BaseActionsEnumerate, message.BaseActionsPull:
+		return req, c.cache.ttl(req.class) > 0
+	case message.BaseActionsPut, message.BaseActionsCreate, message.BaseActionsDelete:
+		c.cache.Invalidate(c.device, req.class)
+	default:
+		c.cache.invalidateAll(c.device)
+	}
+	return req, false
+}
+
+// StreamResponses forwards the streaming setting of the decorated client.
+func (c *cachingClient) StreamResponses() bool {
+	if s, ok := c.next.(client.Streamer); ok {
+		return s.StreamResponses()
+	}
+	return false
+}
+
+// PostStream streams the responses of the decorated client. Responses of cached classes are read in full to be stored.
+func (c *cachingClient) PostStream(msg string) (io.ReadCloser, error) {
+	s, ok := c.next.(client.Streamer)
+	if !ok {
+		return nil, errors.New("cache: decorated client does not stream responses")
+	}
+	if _, cacheable := c.route(msg); !cacheable {
+		return s.PostStream(msg)
+	}
+	body, err := c.Post(msg)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(body)), nil
+}
+
+// ValidateRequests forwards the request validation setting of the decorated client.
+func (c *cachingClient) ValidateRequests() bool {
+	if rv, ok := c.next.(client.RequestValidator); ok {
+		return rv.ValidateRequests()
+	}
+	return true
+}
+
+// StrictDecoding forwards the strict decoding setting of the decorated client.
+func (c *cachingClient) StrictDecoding() bool {
+	if sd, ok := c.next.(client.StrictDecoder); ok {
+		return sd.StrictDecoding()
+	}
+	return false
+}
+
+type request struct {
+	action    string
+	class     string
+	selectors string
+	context   string
+}
+
+type envelope struct {
+	Header struct {
+		Action      string `xml:"Action"`
+		ResourceURI string `xml:"ResourceURI"`
+		SelectorSet struct {
+			Selectors []struct {
+				Name  string `xml:"Name,attr"`
+				Value string `xml:",innerxml"`
+			} `xml:"Selector"`
+		} `xml:"SelectorSet"`
+	} `xml:"Header"`
+	Body struct {
+		Pull struct {
+			EnumerationContext string `xml:"EnumerationContext"`
+		} `xml:"Pull"`
+	} `xml:"Body"`
+}
+
+func parseRequest(msg string) (request, bool) {
+	e := envelope{}
+	if err := xml.Unmarshal([]byte(msg), &e); err != nil || e.Header.ResourceURI == "" {
+		return request{}, false
+	}
+	selectors := []string{}
+	for _, s := range e.Header.SelectorSet.Selectors {
+		selectors = append(selectors, s.Name+"="+s.Value)
+	}
+	uri := strings.TrimSpace(e.Header.ResourceURI)
+	return request{
+		action:    strings.TrimSpace(e.Header.Action),
+		class:     uri[strings.LastIndex(uri, "/")+1:],
+		selectors: strings.Join(selectors, "&"),
+		context:   strings.TrimSpace(e.Body.Pull.EnumerationContext),
+	}, true
+}
+
+func enumerationContext(body []byte) string {
+	e := struct {
+		Body struct {
+			EnumerateResponse struct {
+				EnumerationContext string `xml:"EnumerationContext"`
+			} `xml:"EnumerateResponse"`
+		} `xml:"Body"`
+	}{}
+	if err := xml.Unmarshal(body, &e); err != nil {
+		return ""
+	}
+	return strings.TrimSpace(e.Body.EnumerateResponse.EnumerationContext)
+}
+
+// pullContext returns the context of the next page of a Pull response and whether it is the last page.
+func pullContext(body []byte) (string, bool) {
+	e := struct {
+		Body struct {
+			PullResponse struct {
+				EnumerationContext string    `xml:"EnumerationContext"`
+				EndOfSequence      *struct{} `xml:"EndOfSequence"`
+			} `xml:"PullResponse"`
+		} `xml:"Body"`
+	}{}
+	if err := xml.Unmarshal(body, &e); err != nil {
+		return "", false
+	}
+	return strings.TrimSpace(e.Body.PullResponse.EnumerationContext), e.Body.PullResponse.EndOfSequence != nil
+}
@@ -0,0 +1,241 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package cache
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/internal/message"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/client"
+	"github.com/stretchr/testify/assert"
+)
+
+// countingClient answers every request with a response naming the action and counts the requests it receives.
+// Enumerations have pages pages, one page when pages is zero.
+type countingClient struct {
+	posts    int
+	contexts int
+	pages    int
+	err      error
+}
+
+func (c *countingClient) Post(msg string) ([]byte, error) {
+	c.posts++
+	if c.err != nil {
+		return nil, c.err
+	}
+	if strings.Contains(msg, message.BaseActionsEnumerate) {
+		c.contexts++
+		return []byte(fmt.Sprintf(`<Envelope><Body><EnumerateResponse><EnumerationContext>context-%d</EnumerationContext></EnumerateResponse></Body></Envelope>`, c.contexts)), nil
+	}
+	if strings.Contains(msg, message.BaseActionsPull) {
+		req, _ := parseRequest(msg)
+		// the context of page n+1 is the context of the enumeration followed by n slashes
+		if page := strings.Count(req.context, "/") + 1; page < c.pages {
+			return []byte(fmt.Sprintf(`<Envelope><Body><PullResponse><Items>page %d</Items><EnumerationContext>%s/</EnumerationContext></PullResponse></Body></Envelope>`, page, req.context)), nil
+		}
+		return []byte(fmt.Sprintf(`<Envelope><Body><PullResponse><Items>response %d</Items><EndOfSequence/></PullResponse></Body></Envelope>`, c.posts)), nil
+	}
+	return []byte(fmt.Sprintf("<Envelope><Body>response %d</Body></Envelope>", c.posts)), nil
+}
+
+// streamingClient is a countingClient that streams its responses.
+type streamingClient struct {
+	countingClient
+	streams int
+}
+
+func (c *streamingClient) StreamResponses() bool {
+	return true
+}
+
+func (c *streamingClient) PostStream(msg string) (io.ReadCloser, error) {
+	c.streams++
+	body, err := c.Post(msg)
+	return io.NopCloser(bytes.NewReader(body)), err
+}
+
+func newBase(class string) message.Base {
+	return message.NewBase(message.NewWSManMessageCreator(message.CIMSchema), class)
+}
+
+func TestCachingClient(t *testing.T) {
+	bios := newBase("CIM_BIOSElement")
+	processor := newBase("CIM_Processor")
+
+	t.Run("serves repeated gets from the cache until the TTL expires", func(t *testing.T) {
+		now := time.Now()
+		cache := New(Options{TTLs: map[string]time.Duration{"CIM_BIOSElement": time.Minute}})
+		cache.now = func() time.Time { return now }
+		next := &countingClient{}
+		c := cache.Client("device", next)
+
+		first, err := c.Post(bios.Get(nil))
+		assert.NoError(t, err)
+		second, err := c.Post(bios.Get(nil))
+		assert.NoError(t, err)
+		assert.Equal(t, first, second)
+		assert.Equal(t, 1, next.posts)
+
+		now = now.Add(time.Minute)
+		_, err = c.Post(bios.Get(nil))
+		assert.NoError(t, err)
+		assert.Equal(t, 2, next.posts)
+		assert.Equal(t, Stats{Hits: 1, Misses: 2}, cache.ClassStats("CIM_BIOSElement"))
+	})
+
+	t.Run("does not cache classes without a TTL", func(t *testing.T) {
+		cache := New(Options{TTLs: map[string]time.Duration{"CIM_BIOSElement": time.Minute}})
+		next := &countingClient{}
+		c := cache.Client("device", next)
+		_, _ = c.Post(processor.Get(nil))
+		_, _ = c.Post(processor.Get(nil))
+		assert.Equal(t, 2, next.posts)
+		assert.Equal(t, Stats{}, cache.Stats())
+	})
+
+	t.Run("keeps devices and selectors apart", func(t *testing.T) {
+		cache := New(Options{DefaultTTL: time.Minute})
+		next := &countingClient{}
+		a := cache.Client("a", next)
+		b := cache.Client("b", next)
+		_, _ = a.Post(bios.Get(nil))
+		_, _ = b.Post(bios.Get(nil))
+		_, _ = a.Post(bios.Get(&message.Selector{Name: "InstanceID", Value: "1"}))
+		_, _ = a.Post(bios.Get(nil))
+		assert.Equal(t, 3, next.posts)
+		assert.Equal(t, Stats{Hits: 1, Misses: 3}, cache.Stats())
+	})
+
+	t.Run("replays an enumeration with its pull", func(t *testing.T) {
+		cache := New(Options{DefaultTTL: time.Minute})
+		next := &countingClient{}
+		c := cache.Client("device", next)
+
+		enumeration, _ := c.Post(processor.Enumerate())
+		pull, _ := c.Post(processor.Pull("context-1"))
+		cachedEnumeration, _ := c.Post(processor.Enumerate())
+		cachedPull, _ := c.Post(processor.Pull("context-1"))
+		assert.Equal(t, enumeration, cachedEnumeration)
+		assert.Equal(t, pull, cachedPull)
+		assert.Equal(t, 2, next.posts)
+	})
+
+	t.Run("replays every page of an enumeration", func(t *testing.T) {
+		cache := New(Options{DefaultTTL: time.Minute})
+		next := &countingClient{pages: 3}
+		c := cache.Client("device", next)
+
+		pages := [][]byte{}
+		enumeration, _ := c.Post(processor.Enumerate())
+		for _, context := range []string{"context-1", "context-1/", "context-1//"} {
+			page, _ := c.Post(processor.Pull(context))
+			pages = append(pages, page)
+		}
+		assert.Contains(t, string(pages[2]), "<EndOfSequence/>")
+		cachedEnumeration, _ := c.Post(processor.Enumerate())
+		assert.Equal(t, enumeration, cachedEnumeration)
+		for i, context := range []string{"context-1", "context-1/", "context-1//"} {
+			page, _ := c.Post(processor.Pull(context))
+			assert.Equal(t, pages[i], page)
+		}
+		assert.Equal(t, 4, next.posts)
+	})
+
+	t.Run("does not replay an enumeration that was not pulled to the end", func(t *testing.T) {
+		cache := New(Options{DefaultTTL: time.Minute})
+		next := &countingClient{pages: 2}
+		c := cache.Client("device", next)
+
+		_, _ = c.Post(processor.Enumerate())
+		_, _ = c.Post(processor.Pull("context-1"))
+		enumeration, _ := c.Post(processor.Enumerate())
+		assert.Contains(t, string(enumeration), "context-2")
+		assert.Equal(t, 3, next.posts)
+	})
+
+	t.Run("invalidates the class on writes", func(t *testing.T) {
+		cache := New(Options{DefaultTTL: time.Minute})
+		next := &countingClient{}
+		c := cache.Client("device", next)
+
+		_, _ = c.Post(bios.Get(nil))
+		_, _ = c.Post(processor.Get(nil))
+		_, _ = c.Post(bios.Put("data", false, nil))
+		_, _ = c.Post(bios.Get(nil))
+		_, _ = c.Post(processor.Get(nil))
+		_, _ = c.Post(bios.RequestStateChange(message.CIMSchema+"CIM_BIOSElement/RequestStateChange", 2))
+		_, _ = c.Post(bios.Get(nil))
+		assert.Equal(t, 6, next.posts)
+		assert.Equal(t, Stats{Misses: 3, Invalidations: 2}, cache.ClassStats("CIM_BIOSElement"))
+		assert.Equal(t, Stats{Hits: 1, Misses: 1, Invalidations: 1}, cache.ClassStats("CIM_Processor"))
+	})
+
+	t.Run("invalidates every class of the device on method invocations", func(t *testing.T) {
+		cache := New(Options{DefaultTTL: time.Minute})
+		next := &countingClient{}
+		c := cache.Client("device", next)
+		other := cache.Client("other", next)
+		service := newBase("CIM_PowerManagementService")
+		associated := newBase("CIM_AssociatedPowerManagementService")
+
+		_, _ = c.Post(associated.Get(nil))
+		_, _ = other.Post(associated.Get(nil))
+		_, _ = c.Post(service.RequestStateChange(message.CIMSchema+"CIM_PowerManagementService/RequestPowerStateChange", 8))
+		_, _ = c.Post(associated.Get(nil))
+		_, _ = other.Post(associated.Get(nil))
+		assert.Equal(t, 4, next.posts)
+		assert.Equal(t, Stats{Hits: 1, Misses: 3, Invalidations: 1}, cache.ClassStats("CIM_AssociatedPowerManagementService"))
+	})
+
+	t.Run("forwards streaming to the decorated client", func(t *testing.T) {
+		cache := New(Options{TTLs: map[string]time.Duration{"CIM_BIOSElement": time.Minute}})
+		next := &streamingClient{}
+		c := cache.Client("device", next)
+		s, ok := c.(client.Streamer)
+		assert.True(t, ok)
+		assert.True(t, s.StreamResponses())
+
+		for i := 0; i < 2; i++ {
+			body, err := s.PostStream(bios.Get(nil))
+			assert.NoError(t, err)
+			data, _ := io.ReadAll(body)
+			assert.Equal(t, "<Envelope><Body>response 1</Body></Envelope>", string(data))
+		}
+		_, err := s.PostStream(processor.Get(nil))
+		assert.NoError(t, err)
+		assert.Equal(t, 1, next.streams)
+		assert.Equal(t, 2, next.posts)
+	})
+
+	t.Run("does not cache errors", func(t *testing.T) {
+		cache := New(Options{DefaultTTL: time.Minute})
+		next := &countingClient{err: errors.New("unreachable")}
+		c := cache.Client("device", next)
+		_, err := c.Post(bios.Get(nil))
+		assert.EqualError(t, err, "unreachable")
+		next.err = nil
+		_, err = c.Post(bios.Get(nil))
+		assert.NoError(t, err)
+		assert.Equal(t, 2, next.posts)
+	})
+
+	t.Run("flushes a device", func(t *testing.T) {
+		cache := New(Options{DefaultTTL: time.Minute})
+		next := &countingClient{}
+		c := cache.Client("device", next)
+		_, _ = c.Post(bios.Get(nil))
+		cache.Flush("device")
+		_, _ = c.Post(bios.Get(nil))
+		assert.Equal(t, 2, next.posts)
+	})
+}
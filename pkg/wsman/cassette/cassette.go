@@ -0,0 +1,246 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+// Package cassette records the WS-Man exchanges of a client.WSMan with a real device to a cassette file and replays
+// them offline.
+//
+// A Recorder decorates the client of the device and records every request with its response. A Replayer answers
+// requests from a cassette, matching them semantically: two requests match when they have the same action, resource
+// URI and selectors and their bodies are equal regardless of namespace prefixes, attribute order and whitespace. The
+// message identifiers and the other addressing headers are ignored.
+package cassette
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/internal/envelope"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/client"
+)
+
+// Version is the version of the cassette format written by Save.
+const Version = 1
+
+// ErrNotRecorded is returned by a Replayer for a request that matches no interaction of its cassette.
+var ErrNotRecorded = errors.New("cassette: request not recorded")
+
+// Interaction is a request and the response the device answered it with.
+type Interaction struct {
+	Request  string `json:"request"`
+	Response string `json:"response"`
+	// Error is the error the client returned instead of a response, if any.
+	Error string `json:"error,omitempty"`
+}
+
+// Cassette is a sequence of interactions with a device.
+type Cassette struct {
+	Version      int           `json:"version"`
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Load reads the cassette stored at path.
+func Load(path string) (*Cassette, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	c := &Cassette{}
+	if err := json.Unmarshal(b, c); err != nil {
+		return nil, fmt.Errorf("cassette: %s: %w", path, err)
+	}
+	if c.Version != Version {
+		return nil, fmt.Errorf("cassette: %s: unsupported version %d", path, c.Version)
+	}
+	return c, nil
+}
+
+// Save writes the cassette to path.
+func (c *Cassette) Save(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := c.Write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Write writes the cassette to w in the format read by Load.
+func (c *Cassette) Write(w io.Writer) error {
+	saved := Cassette{Version: Version, Interactions: c.Interactions}
+	if saved.Interactions == nil {
+		saved.Interactions = []Interaction{}
+	}
+	b, err := json.MarshalIndent(saved, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(b, '\n'))
+	return err
+}
+
+// Recorder is a client.WSMan that records the exchanges of the client it decorates.
+type Recorder struct {
+	next client.WSMan
+
+	mu           sync.Mutex
+	interactions []Interaction
+}
+
+// NewRecorder returns a Recorder decorating next, the client of the recorded device.
+func NewRecorder(next client.WSMan) *Recorder {
+	return &Recorder{next: next}
+}
+
+// Post sends msg with the decorated client and records the exchange.
+func (r *Recorder) Post(msg string) ([]byte, error) {
+	response, err := r.next.Post(msg)
+	interaction := Interaction{Request: msg, Response: string(response)}
+	if err != nil {
+		interaction.Error = err.Error()
+	}
+	r.mu.Lock()
+	r.interactions = append(r.interactions, interaction)
+	r.mu.Unlock()
+	return response, err
+}
+
+// Cassette returns the interactions recorded so far.
+func (r *Recorder) Cassette() *Cassette {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return &Cassette{Version: Version, Interactions: append([]Interaction(nil), r.interactions...)}
+}
+
+// Save writes the interactions recorded so far to path.
+func (r *Recorder) Save(path string) error {
+	return r.Cassette().Save(path)
+}
+
+// ValidateRequests forwards the request validation setting of the decorated client.
+func (r *Recorder) ValidateRequests() bool {
+	if rv, ok := r.next.(client.RequestValidator); ok {
+		return rv.ValidateRequests()
+	}
+	return true
+}
+
+// StrictDecoding forwards the strict decoding setting of the decorated client.
+func (r *Recorder) StrictDecoding() bool {
+	if sd, ok := r.next.(client.StrictDecoder); ok {
+		return sd.StrictDecoding()
+	}
+	return false
+}
+
+// Replayer is a client.WSMan answering requests with the responses recorded in a cassette.
+//
+// A request is answered by the first matching interaction that was not replayed yet, so a sequence of identical
+// requests, such as a poll of the power state, gets the recorded responses in order. Once every matching interaction
+// was replayed, the last one is replayed again.
+type Replayer struct {
+	// Strict is returned by StrictDecoding.
+	Strict bool
+
+	mu           sync.Mutex
+	interactions []recorded
+}
+
+type recorded struct {
+	Interaction
+	key      string
+	replayed bool
+}
+
+// NewReplayer returns a Replayer answering from c. It fails when a recorded request is not a WS-Man envelope.
+func NewReplayer(c *Cassette) (*Replayer, error) {
+	r := &Replayer{}
+	for i, interaction := range c.Interactions {
+		key, err := requestKey(interaction.Request)
+		if err != nil {
+			return nil, fmt.Errorf("cassette: interaction %d: %w", i, err)
+		}
+		r.interactions = append(r.interactions, recorded{Interaction: interaction, key: key})
+	}
+	return r, nil
+}
+
+// Post returns the response recorded for the request matching msg, or an error wrapping ErrNotRecorded.
+func (r *Replayer) Post(msg string) ([]byte, error) {
+	key, err := requestKey(msg)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNotRecorded, err)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var match *recorded
+	for i := range r.interactions {
+		candidate := &r.interactions[i]
+		if candidate.key != key {
+			continue
+		}
+		match = candidate
+		if !candidate.replayed {
+			break
+		}
+	}
+	if match == nil {
+		return nil, fmt.Errorf("%w:\n%s", ErrNotRecorded, key)
+	}
+	match.replayed = true
+	if match.Error != "" {
+		return []byte(match.Response), errors.New(match.Error)
+	}
+	return []byte(match.Response), nil
+}
+
+// Unplayed returns the interactions that were not replayed, which tests can check to verify that a workflow sent every
+// recorded request.
+func (r *Replayer) Unplayed() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	unplayed := []Interaction{}
+	for _, i := range r.interactions {
+		if !i.replayed {
+			unplayed = append(unplayed, i.Interaction)
+		}
+	}
+	return unplayed
+}
+
+// StrictDecoding reports whether responses are decoded in strict mode
+func (r *Replayer) StrictDecoding() bool {
+	return r.Strict
+}
+
+// requestKey returns the canonical form of the parts of a request that identify it.
+func requestKey(msg string) (string, error) {
+	e, err := envelope.Parse(msg)
+	if err != nil {
+		return "", err
+	}
+	names := make([]string, 0, len(e.Selectors))
+	for name := range e.Selectors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	sb := strings.Builder{}
+	fmt.Fprintf(&sb, "action %s\nresource %s\n", e.Action, e.ResourceURI)
+	for _, name := range names {
+		fmt.Fprintf(&sb, "selector %s=%q\n", name, e.Selectors[name])
+	}
+	if e.Body != nil {
+		sb.WriteString(e.Body.String())
+	}
+	return sb.String(), nil
+}
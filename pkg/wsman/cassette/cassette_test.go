@@ -0,0 +1,147 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package cassette
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/internal/message"
+)
+
+// deviceClient answers every request with a response counting the requests, or with err.
+type deviceClient struct {
+	posts int
+	err   error
+}
+
+func (c *deviceClient) Post(msg string) ([]byte, error) {
+	c.posts++
+	if c.err != nil {
+		return nil, c.err
+	}
+	return []byte(fmt.Sprintf("<Envelope><Body>response %d</Body></Envelope>", c.posts)), nil
+}
+
+func (c *deviceClient) ValidateRequests() bool {
+	return false
+}
+
+func newBase(class string) message.Base {
+	return message.NewBase(message.NewWSManMessageCreator(message.CIMSchema), class)
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	bios := newBase("CIM_BIOSElement")
+	chassis := newBase("CIM_Chassis")
+	device := &deviceClient{}
+	recorder := NewRecorder(device)
+	assert.False(t, recorder.ValidateRequests())
+	assert.False(t, recorder.StrictDecoding())
+
+	for _, msg := range []string{bios.Get(nil), bios.Get(nil), chassis.Enumerate()} {
+		_, err := recorder.Post(msg)
+		require.NoError(t, err)
+	}
+	device.err = errors.New("connection reset")
+	_, err := recorder.Post(chassis.Pull("context"))
+	assert.EqualError(t, err, "connection reset")
+
+	path := filepath.Join(t.TempDir(), "device.json")
+	require.NoError(t, recorder.Save(path))
+	loaded, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, recorder.Cassette(), loaded)
+
+	replayer, err := NewReplayer(loaded)
+	require.NoError(t, err)
+	replayer.Strict = true
+	assert.True(t, replayer.StrictDecoding())
+
+	// a new message creator numbers its messages differently
+	bios = newBase("CIM_BIOSElement")
+	chassis = newBase("CIM_Chassis")
+	chassis.Get(nil)
+
+	response, err := replayer.Post(chassis.Enumerate())
+	require.NoError(t, err)
+	assert.Equal(t, "<Envelope><Body>response 3</Body></Envelope>", string(response))
+	assert.Len(t, replayer.Unplayed(), 3)
+
+	// identical requests are answered in the recorded order, then with the last response
+	for _, expected := range []string{"response 1", "response 2", "response 2"} {
+		response, err = replayer.Post(bios.Get(nil))
+		require.NoError(t, err)
+		assert.Contains(t, string(response), expected)
+	}
+
+	_, err = replayer.Post(chassis.Pull("context"))
+	assert.EqualError(t, err, "connection reset")
+	assert.Empty(t, replayer.Unplayed())
+
+	_, err = replayer.Post(chassis.Pull("other context"))
+	assert.ErrorIs(t, err, ErrNotRecorded)
+	_, err = replayer.Post("not xml")
+	assert.ErrorIs(t, err, ErrNotRecorded)
+}
+
+func TestReplayMatchesSemantically(t *testing.T) {
+	recorded := `<Envelope xmlns="http://www.w3.org/2003/05/soap-envelope" xmlns:a="http://schemas.xmlsoap.org/ws/2004/08/addressing" xmlns:w="http://schemas.dmtf.org/wbem/wsman/1/wsman.xsd"><Header><a:Action>http://schemas.xmlsoap.org/ws/2004/09/transfer/Put</a:Action><a:MessageID>1</a:MessageID><w:ResourceURI>http://intel.com/wbem/wscim/1/amt-schema/1/AMT_GeneralSettings</w:ResourceURI><w:SelectorSet><w:Selector Name="A">1</w:Selector><w:Selector Name="B">2</w:Selector></w:SelectorSet></Header><Body><h:AMT_GeneralSettings xmlns:h="http://intel.com/wbem/wscim/1/amt-schema/1/AMT_GeneralSettings"><h:HostName>host</h:HostName><h:PingResponseEnabled>true</h:PingResponseEnabled></h:AMT_GeneralSettings></Body></Envelope>`
+	replayer, err := NewReplayer(&Cassette{Version: Version, Interactions: []Interaction{{Request: recorded, Response: "<Envelope/>"}}})
+	require.NoError(t, err)
+
+	reformatted := `<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope" xmlns:wsa="http://schemas.xmlsoap.org/ws/2004/08/addressing" xmlns:wsman="http://schemas.dmtf.org/wbem/wsman/1/wsman.xsd">
+  <s:Header>
+    <wsa:MessageID>42</wsa:MessageID>
+    <wsa:Action>http://schemas.xmlsoap.org/ws/2004/09/transfer/Put</wsa:Action>
+    <wsman:ResourceURI>http://intel.com/wbem/wscim/1/amt-schema/1/AMT_GeneralSettings</wsman:ResourceURI>
+    <wsman:SelectorSet><wsman:Selector Name="B">2</wsman:Selector><wsman:Selector Name="A">1</wsman:Selector></wsman:SelectorSet>
+  </s:Header>
+  <s:Body>
+    <AMT_GeneralSettings xmlns="http://intel.com/wbem/wscim/1/amt-schema/1/AMT_GeneralSettings">
+      <HostName>host</HostName>
+      <PingResponseEnabled>true</PingResponseEnabled>
+    </AMT_GeneralSettings>
+  </s:Body>
+</s:Envelope>`
+	response, err := replayer.Post(reformatted)
+	require.NoError(t, err)
+	assert.Equal(t, "<Envelope/>", string(response))
+
+	for name, changed := range map[string]string{
+		"action":   strings.Replace(reformatted, "transfer/Put", "transfer/Get", 1),
+		"selector": strings.Replace(reformatted, `Name="B">2`, `Name="B">3`, 1),
+		"body":     strings.Replace(reformatted, "<HostName>host", "<HostName>other", 1),
+	} {
+		_, err := replayer.Post(changed)
+		assert.ErrorIs(t, err, ErrNotRecorded, name)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	_, err := Load(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+
+	path := filepath.Join(t.TempDir(), "empty.json")
+	require.NoError(t, (&Cassette{}).Save(path))
+	c, err := Load(path)
+	require.NoError(t, err)
+	assert.Empty(t, c.Interactions)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"version":2,"interactions":[]}`), 0o644))
+	_, err = Load(path)
+	assert.EqualError(t, err, "cassette: "+path+": unsupported version 2")
+
+	_, err = NewReplayer(&Cassette{Interactions: []Interaction{{Request: "<NotAnEnvelope/>"}}})
+	assert.Error(t, err)
+}
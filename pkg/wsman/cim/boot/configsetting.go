@@ -23,7 +23,6 @@
 package boot
 
 import (
-	"encoding/xml"
 	"fmt"
 
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/internal/message"
@@ -52,7 +51,7 @@ func (configSetting ConfigSetting) Get() (response Response, err error) {
 		return
 	}
 
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = configSetting.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -72,7 +71,7 @@ func (configSetting ConfigSetting) Enumerate() (response Response, err error) {
 		return
 	}
 
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = configSetting.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -90,7 +89,7 @@ func (configSetting ConfigSetting) Pull(enumerationContext string) (response Res
 	if err != nil {
 		return
 	}
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = configSetting.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -127,7 +126,7 @@ func (configSetting ConfigSetting) ChangeBootOrder(source Source) (response Resp
 		return
 	}
 
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = configSetting.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -6,8 +6,6 @@
 package boot
 
 import (
-	"encoding/xml"
-
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/internal/message"
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/client"
 )
@@ -37,7 +35,7 @@ func (sourceSetting SourceSetting) Get(instanceID string) (response Response, er
 		return
 	}
 
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = sourceSetting.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -57,7 +55,7 @@ func (sourceSetting SourceSetting) Enumerate() (response Response, err error) {
 		return
 	}
 
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = sourceSetting.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -75,7 +73,7 @@ func (sourceSetting SourceSetting) Pull(enumerationContext string) (response Res
 	if err != nil {
 		return
 	}
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = sourceSetting.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
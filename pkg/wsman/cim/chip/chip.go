@@ -7,8 +7,6 @@
 package chip
 
 import (
-	"encoding/xml"
-
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/internal/message"
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/client"
 )
@@ -34,7 +32,7 @@ func (chip Package) Get() (response Response, err error) {
 		return
 	}
 
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = chip.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -55,7 +53,7 @@ func (chip Package) Enumerate() (response Response, err error) {
 		return
 	}
 
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = chip.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -74,7 +72,7 @@ func (chip Package) Pull(enumerationContext string) (response Response, err erro
 	if err != nil {
 		return
 	}
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = chip.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
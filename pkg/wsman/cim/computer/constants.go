@@ -5,4 +5,13 @@
 
 package computer
 
-const CIM_ComputerSystemPackage string = "CIM_ComputerSystemPackage"
+const (
+	CIM_ComputerSystemPackage string = "CIM_ComputerSystemPackage"
+	CIM_ComputerSystem        string = "CIM_ComputerSystem"
+)
+
+// Names of the CIM_ComputerSystem instances of an AMT device
+const (
+	ManagedSystem string = "ManagedSystem" // The host system managed by AMT, whose power state CIM_AssociatedPowerManagementService holds.
+	AMTSubsystem  string = "Intel(r) AMT"  // The AMT subsystem itself.
+)
@@ -0,0 +1,78 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package computer
+
+import (
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/internal/message"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/client"
+)
+
+// NewComputerSystemWithClient returns a new instance of the System struct.
+func NewComputerSystemWithClient(wsmanMessageCreator *message.WSManMessageCreator, client client.WSMan) System {
+	return System{
+		base:   message.NewBaseWithClient(wsmanMessageCreator, CIM_ComputerSystem, client),
+		client: client,
+	}
+}
+
+// Get retrieves the representation of the computer system named name, ManagedSystem or AMTSubsystem
+func (system System) Get(name string) (response Response, err error) {
+	selector := message.Selector{Name: "Name", Value: name}
+	response = Response{
+		Message: &client.Message{
+			XMLInput: system.base.Get(&selector),
+		},
+	}
+
+	err = system.base.Execute(response.Message)
+	if err != nil {
+		return
+	}
+
+	err = system.base.Decode(response.Message, &response)
+	if err != nil {
+		return
+	}
+	return
+}
+
+// Enumerate returns an enumeration context which is used in a subsequent Pull call
+func (system System) Enumerate() (response Response, err error) {
+	response = Response{
+		Message: &client.Message{
+			XMLInput: system.base.Enumerate(),
+		},
+	}
+
+	err = system.base.Execute(response.Message)
+	if err != nil {
+		return
+	}
+
+	err = system.base.Decode(response.Message, &response)
+	if err != nil {
+		return
+	}
+	return
+}
+
+// Pull returns the instances of this class.  An enumeration context provided by the Enumerate call is used as input.
+func (system System) Pull(enumerationContext string) (response Response, err error) {
+	response = Response{
+		Message: &client.Message{
+			XMLInput: system.base.Pull(enumerationContext),
+		},
+	}
+	err = system.base.Execute(response.Message)
+	if err != nil {
+		return
+	}
+	err = system.base.Decode(response.Message, &response)
+	if err != nil {
+		return
+	}
+	return
+}
@@ -0,0 +1,197 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package computer
+
+import (
+	"encoding/xml"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/internal/message"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/common"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/wsmantesting"
+)
+
+var managedSystem = ComputerSystem{
+	XMLName:           xml.Name{Space: "http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_ComputerSystem", Local: "CIM_ComputerSystem"},
+	CreationClassName: "CIM_ComputerSystem",
+	Dedicated:         []int{32},
+	ElementName:       "Managed System",
+	EnabledState:      2,
+	HealthState:       5,
+	Name:              ManagedSystem,
+	NameFormat:        "Other",
+	OperationalStatus: []int{0},
+	RequestedState:    12,
+}
+
+func TestPositiveSystem(t *testing.T) {
+	messageID := 0
+	resourceUriBase := "http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/"
+	wsmanMessageCreator := message.NewWSManMessageCreator(resourceUriBase)
+	client := wsmantesting.MockClient{
+		PackageUnderTest: "cim/computer/system",
+	}
+	elementUnderTest := NewComputerSystemWithClient(wsmanMessageCreator, &client)
+
+	t.Run("cim_ComputerSystem Tests", func(t *testing.T) {
+		tests := []struct {
+			name             string
+			method           string
+			action           string
+			extraHeader      string
+			body             string
+			responseFunc     func() (Response, error)
+			expectedResponse interface{}
+		}{
+			//GETS
+			{
+				"should create and parse a valid cim_ComputerSystem Get call",
+				CIM_ComputerSystem,
+				wsmantesting.GET,
+				"<w:SelectorSet><w:Selector Name=\"Name\">ManagedSystem</w:Selector></w:SelectorSet>",
+				"",
+				func() (Response, error) {
+					client.CurrentMessage = "Get"
+					return elementUnderTest.Get(ManagedSystem)
+				},
+				Body{
+					XMLName:           xml.Name{Space: message.XMLBodySpace, Local: "Body"},
+					SystemGetResponse: managedSystem,
+				},
+			},
+			//ENUMERATES
+			{
+				"should create and parse a valid cim_ComputerSystem Enumerate call",
+				CIM_ComputerSystem,
+				wsmantesting.ENUMERATE,
+				"",
+				wsmantesting.ENUMERATE_BODY,
+				func() (Response, error) {
+					client.CurrentMessage = "Enumerate"
+					return elementUnderTest.Enumerate()
+				},
+				Body{
+					XMLName: xml.Name{Space: message.XMLBodySpace, Local: "Body"},
+					EnumerateResponse: common.EnumerateResponse{
+						EnumerationContext: "E2020000-0000-0000-0000-000000000000",
+					},
+				},
+			},
+			//PULLS
+			{
+				"should create and parse a valid cim_ComputerSystem Pull call",
+				CIM_ComputerSystem,
+				wsmantesting.PULL,
+				"",
+				wsmantesting.PULL_BODY,
+				func() (Response, error) {
+					client.CurrentMessage = "Pull"
+					return elementUnderTest.Pull(wsmantesting.EnumerationContext)
+				},
+				Body{
+					XMLName: xml.Name{Space: message.XMLBodySpace, Local: "Body"},
+					PullResponse: PullResponse{
+						SystemItems: []ComputerSystem{
+							managedSystem,
+							{
+								XMLName:                 xml.Name{Space: "http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_ComputerSystem", Local: "CIM_ComputerSystem"},
+								CreationClassName:       "CIM_ComputerSystem",
+								Dedicated:               []int{14},
+								ElementName:             "Intel(r) AMT Subsystem",
+								EnabledState:            5,
+								HealthState:             5,
+								IdentifyingDescriptions: []string{"CIM:GUID", "CIM:Hardware:Host name"},
+								Name:                    AMTSubsystem,
+								NameFormat:              "Other",
+								OperationalStatus:       []int{0},
+								OtherIdentifyingInfo:    []string{"4C4C4544-0046-3810-8052-B3C04F4B4E33", "dev.example.com"},
+								RequestedState:          12,
+							},
+						},
+					},
+				},
+			},
+		}
+
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				expectedXMLInput := wsmantesting.ExpectedResponse(messageID, resourceUriBase, test.method, test.action, test.extraHeader, test.body)
+				messageID++
+				response, err := test.responseFunc()
+				assert.NoError(t, err)
+				assert.Equal(t, expectedXMLInput, response.XMLInput)
+				assert.Equal(t, test.expectedResponse, response.Body)
+			})
+		}
+	})
+}
+
+func TestNegativeSystem(t *testing.T) {
+	messageID := 0
+	resourceUriBase := "http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/"
+	wsmanMessageCreator := message.NewWSManMessageCreator(resourceUriBase)
+	client := wsmantesting.MockClient{
+		PackageUnderTest: "cim/computer/system",
+	}
+	elementUnderTest := NewComputerSystemWithClient(wsmanMessageCreator, &client)
+
+	t.Run("cim_ComputerSystem Tests", func(t *testing.T) {
+		tests := []struct {
+			name         string
+			method       string
+			action       string
+			extraHeader  string
+			body         string
+			responseFunc func() (Response, error)
+		}{
+			{
+				"should handle error when cim_ComputerSystem Get call",
+				CIM_ComputerSystem,
+				wsmantesting.GET,
+				"<w:SelectorSet><w:Selector Name=\"Name\">ManagedSystem</w:Selector></w:SelectorSet>",
+				"",
+				func() (Response, error) {
+					client.CurrentMessage = "Error"
+					return elementUnderTest.Get(ManagedSystem)
+				},
+			},
+			{
+				"should handle error when cim_ComputerSystem Enumerate call",
+				CIM_ComputerSystem,
+				wsmantesting.ENUMERATE,
+				"",
+				wsmantesting.ENUMERATE_BODY,
+				func() (Response, error) {
+					client.CurrentMessage = "Error"
+					return elementUnderTest.Enumerate()
+				},
+			},
+			{
+				"should handle error when cim_ComputerSystem Pull call",
+				CIM_ComputerSystem,
+				wsmantesting.PULL,
+				"",
+				wsmantesting.PULL_BODY,
+				func() (Response, error) {
+					client.CurrentMessage = "Error"
+					return elementUnderTest.Pull(wsmantesting.EnumerationContext)
+				},
+			},
+		}
+
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				expectedXMLInput := wsmantesting.ExpectedResponse(messageID, resourceUriBase, test.method, test.action, test.extraHeader, test.body)
+				messageID++
+				response, err := test.responseFunc()
+				assert.Error(t, err)
+				assert.Equal(t, expectedXMLInput, response.XMLInput)
+			})
+		}
+	})
+}
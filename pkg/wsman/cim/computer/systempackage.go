@@ -9,8 +9,6 @@
 package computer
 
 import (
-	"encoding/xml"
-
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/internal/message"
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/client"
 )
@@ -36,7 +34,7 @@ func (systemPackage SystemPackage) Get() (response Response, err error) {
 		return
 	}
 
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = systemPackage.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -57,7 +55,7 @@ func (systemPackage SystemPackage) Enumerate() (response Response, err error) {
 		return
 	}
 
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = systemPackage.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -76,7 +74,7 @@ func (systemPackage SystemPackage) Pull(enumerationContext string) (response Res
 	if err != nil {
 		return
 	}
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = systemPackage.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -18,6 +18,11 @@ type SystemPackage struct {
 	client client.WSMan
 }
 
+type System struct {
+	base   message.Base
+	client client.WSMan
+}
+
 // Response Types
 type (
 	Response struct {
@@ -30,12 +35,14 @@ type (
 	Body struct {
 		XMLName           xml.Name              `xml:"Body"`
 		GetResponse       ComputerSystemPackage `xml:"CIM_ComputerSystemPackage"`
+		SystemGetResponse ComputerSystem        `xml:"CIM_ComputerSystem"`
 		EnumerateResponse common.EnumerateResponse
 		PullResponse      PullResponse `xml:"PullResponse"`
 	}
 
 	PullResponse struct {
-		Items []ComputerSystemPackage `xml:"Items>CIM_ComputerSystemPackage"`
+		Items       []ComputerSystemPackage `xml:"Items>CIM_ComputerSystemPackage"`
+		SystemItems []ComputerSystem        `xml:"Items>CIM_ComputerSystem"`
 	}
 
 	Antecedent struct {
@@ -53,6 +60,20 @@ type (
 		Dependent    Dependent  // The UnitaryComputerSystem.
 		PlatformGUID string     `xml:"PlatformGUID,omitempty"` // A Gloabally Unique Identifier for the System's Package.
 	}
+	ComputerSystem struct {
+		XMLName                 xml.Name `xml:"CIM_ComputerSystem"`
+		CreationClassName       string   `xml:"CreationClassName,omitempty"`       // CreationClassName indicates the name of the class or the subclass used in the creation of an instance.
+		Dedicated               []int    `xml:"Dedicated,omitempty"`               // Enumeration indicating whether the ComputerSystem is a special-purpose System (ie, dedicated to a particular use), versus being 'general purpose'.
+		ElementName             string   `xml:"ElementName,omitempty"`             // A user-friendly name for the object.
+		EnabledState            int      `xml:"EnabledState,omitempty"`            // EnabledState is an integer enumeration that indicates the enabled and disabled states of an element.
+		HealthState             int      `xml:"HealthState,omitempty"`             // Indicates the current health of the element.
+		IdentifyingDescriptions []string `xml:"IdentifyingDescriptions,omitempty"` // An array of free-form strings providing explanations and details behind the entries in the OtherIdentifyingInfo array.
+		Name                    string   `xml:"Name,omitempty"`                    // The Name property defines the label by which the object is known, ManagedSystem or Intel(r) AMT.
+		NameFormat              string   `xml:"NameFormat,omitempty"`              // The ComputerSystem object and its derivatives are Top Level Objects of CIM. This property identifies how the Name of the system was generated.
+		OperationalStatus       []int    `xml:"OperationalStatus,omitempty"`       // Indicates the current statuses of the element.
+		OtherIdentifyingInfo    []string `xml:"OtherIdentifyingInfo,omitempty"`    // OtherIdentifyingInfo captures additional data, beyond System Name information, that could be used to identify a ComputerSystem.
+		RequestedState          int      `xml:"RequestedState,omitempty"`          // RequestedState is an integer enumeration that indicates the last requested or desired state for the element.
+	}
 	ReferenceParameters struct {
 		XMLName     xml.Name    `xml:"ReferenceParameters"`
 		ResourceURI string      `xml:"ResourceURI,omitempty"`
@@ -12,8 +12,6 @@
 package concrete
 
 import (
-	"encoding/xml"
-
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/internal/message"
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/client"
 )
@@ -42,7 +40,7 @@ func (dependency Dependency) Enumerate() (response Response, err error) {
 		return
 	}
 
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = dependency.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -61,7 +59,7 @@ func (dependency Dependency) Pull(enumerationContext string) (response Response,
 	if err != nil {
 		return
 	}
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = dependency.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -18,8 +18,6 @@
 package credential
 
 import (
-	"encoding/xml"
-
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/internal/message"
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/client"
 )
@@ -47,7 +45,7 @@ func (context Context) Enumerate() (response Response, err error) {
 		return
 	}
 
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = context.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -66,7 +64,7 @@ func (context Context) Pull(enumerationContext string) (response Response, err e
 	if err != nil {
 		return
 	}
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = context.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -7,8 +7,6 @@
 package ieee8021x
 
 import (
-	"encoding/xml"
-
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/internal/message"
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/client"
 )
@@ -36,7 +34,7 @@ func (settings Settings) Enumerate() (response Response, err error) {
 		return
 	}
 
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = settings.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -55,7 +53,7 @@ func (settings Settings) Pull(enumerationContext string) (response Response, err
 	if err != nil {
 		return
 	}
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = settings.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -7,8 +7,6 @@
 package kvm
 
 import (
-	"encoding/xml"
-
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/internal/message"
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/cim/methods"
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/client"
@@ -40,7 +38,7 @@ func (redirectionSAP RedirectionSAP) Get() (response Response, err error) {
 		return
 	}
 
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = redirectionSAP.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -61,7 +59,7 @@ func (redirectionSAP RedirectionSAP) Enumerate() (response Response, err error)
 		return
 	}
 
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = redirectionSAP.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -80,7 +78,7 @@ func (redirectionSAP RedirectionSAP) Pull(enumerationContext string) (response R
 	if err != nil {
 		return
 	}
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = redirectionSAP.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
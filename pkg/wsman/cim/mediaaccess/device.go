@@ -7,8 +7,6 @@
 package mediaaccess
 
 import (
-	"encoding/xml"
-
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/internal/message"
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/client"
 )
@@ -37,7 +35,7 @@ func (device Device) Enumerate() (response Response, err error) {
 		return
 	}
 
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = device.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -56,7 +54,7 @@ func (device Device) Pull(enumerationContext string) (response Response, err err
 	if err != nil {
 		return
 	}
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = device.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -29,29 +29,31 @@ import (
 )
 
 type Messages struct {
-	wsmanMessageCreator       *message.WSManMessageCreator
-	BIOSElement               bios.Element
-	BootConfigSetting         boot.ConfigSetting
-	BootService               boot.Service
-	BootSourceSetting         boot.SourceSetting
-	Card                      card.Package
-	Chassis                   chassis.Package
-	Chip                      chip.Package
-	ComputerSystemPackage     computer.SystemPackage
-	ConcreteDependency        concrete.Dependency
-	CredentialContext         credential.Context
-	IEEE8021xSettings         ieee8021x.Settings
-	KVMRedirectionSAP         kvm.RedirectionSAP
-	MediaAccessDevice         mediaaccess.Device
-	PhysicalMemory            physical.Memory
-	PhysicalPackage           physical.Package
-	PowerManagementService    power.ManagementService
-	Processor                 processor.Package
-	ServiceAvailableToElement service.AvailableToElement
-	SoftwareIdentity          software.Identity
-	SystemPackaging           system.Package
-	WiFiEndpointSettings      wifi.EndpointSettings
-	WiFiPort                  wifi.Port
+	wsmanMessageCreator              *message.WSManMessageCreator
+	AssociatedPowerManagementService power.AssociatedManagementService
+	BIOSElement                      bios.Element
+	BootConfigSetting                boot.ConfigSetting
+	BootService                      boot.Service
+	BootSourceSetting                boot.SourceSetting
+	Card                             card.Package
+	Chassis                          chassis.Package
+	Chip                             chip.Package
+	ComputerSystem                   computer.System
+	ComputerSystemPackage            computer.SystemPackage
+	ConcreteDependency               concrete.Dependency
+	CredentialContext                credential.Context
+	IEEE8021xSettings                ieee8021x.Settings
+	KVMRedirectionSAP                kvm.RedirectionSAP
+	MediaAccessDevice                mediaaccess.Device
+	PhysicalMemory                   physical.Memory
+	PhysicalPackage                  physical.Package
+	PowerManagementService           power.ManagementService
+	Processor                        processor.Package
+	ServiceAvailableToElement        service.AvailableToElement
+	SoftwareIdentity                 software.Identity
+	SystemPackaging                  system.Package
+	WiFiEndpointSettings             wifi.EndpointSettings
+	WiFiPort                         wifi.Port
 }
 
 func NewMessages(client client.WSMan) Messages {
@@ -60,6 +62,7 @@ func NewMessages(client client.WSMan) Messages {
 	m := Messages{
 		wsmanMessageCreator: wsmanMessageCreator,
 	}
+	m.AssociatedPowerManagementService = power.NewAssociatedPowerManagementServiceWithClient(wsmanMessageCreator, client)
 	m.BIOSElement = bios.NewBIOSElementWithClient(wsmanMessageCreator, client)
 	m.BootConfigSetting = boot.NewBootConfigSettingWithClient(wsmanMessageCreator, client)
 	m.BootService = boot.NewBootServiceWithClient(wsmanMessageCreator, client)
@@ -67,6 +70,7 @@ func NewMessages(client client.WSMan) Messages {
 	m.Card = card.NewCardWithClient(wsmanMessageCreator, client)
 	m.Chassis = chassis.NewChassisWithClient(wsmanMessageCreator, client)
 	m.Chip = chip.NewChipWithClient(wsmanMessageCreator, client)
+	m.ComputerSystem = computer.NewComputerSystemWithClient(wsmanMessageCreator, client)
 	m.ComputerSystemPackage = computer.NewComputerSystemPackageWithClient(wsmanMessageCreator, client)
 	m.ConcreteDependency = concrete.NewDependencyWithClient(wsmanMessageCreator, client)
 	m.CredentialContext = credential.NewContextWithClient(wsmanMessageCreator, client)
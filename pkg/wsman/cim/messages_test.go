@@ -36,6 +36,9 @@ func TestNewMessages(t *testing.T) {
 	if m.wsmanMessageCreator == nil {
 		t.Error("wsmanMessageCreator is not initialized")
 	}
+	if reflect.DeepEqual(m.AssociatedPowerManagementService, power.AssociatedManagementService{}) {
+		t.Error("AssociatedPowerManagementService is not initialized")
+	}
 	if reflect.DeepEqual(m.BIOSElement, bios.Element{}) {
 		t.Error("BIOSElement is not initialized")
 	}
@@ -57,6 +60,9 @@ func TestNewMessages(t *testing.T) {
 	if reflect.DeepEqual(m.Chip, chip.Package{}) {
 		t.Error("Chip is not initialized")
 	}
+	if reflect.DeepEqual(m.ComputerSystem, computer.System{}) {
+		t.Error("ComputerSystem is not initialized")
+	}
 	if reflect.DeepEqual(m.ComputerSystemPackage, computer.SystemPackage{}) {
 		t.Error("ComputerSystemPackage is not initialized")
 	}
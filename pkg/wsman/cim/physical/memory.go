@@ -7,8 +7,6 @@
 package physical
 
 import (
-	"encoding/xml"
-
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/internal/message"
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/client"
 )
@@ -37,7 +35,7 @@ func (memory Memory) Enumerate() (response Response, err error) {
 		return
 	}
 
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = memory.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -56,9 +54,24 @@ func (memory Memory) Pull(enumerationContext string) (response Response, err err
 	if err != nil {
 		return
 	}
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = memory.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
 	return
 }
+
+// PullEach streams the memory modules returned by a Pull and calls fn for each one instead of collecting them into a Response.
+// Combined with client.Parameters.StreamResponses only a single PhysicalMemory is held in memory at a time.
+func (memory Memory) PullEach(enumerationContext string, fn func(PhysicalMemory) error) (err error) {
+	msg := &client.Message{
+		XMLInput: memory.base.Pull(enumerationContext),
+	}
+	// send the message to AMT
+	err = memory.base.Execute(msg)
+	if err != nil {
+		return
+	}
+	// decode the items one at a time
+	return message.DecodeItems(msg, CIM_PhysicalMemory, fn)
+}
@@ -6,8 +6,6 @@
 package physical
 
 import (
-	"encoding/xml"
-
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/internal/message"
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/client"
 )
@@ -36,7 +34,7 @@ func (physicalPackage Package) Enumerate() (response Response, err error) {
 		return
 	}
 
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = physicalPackage.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -55,7 +53,7 @@ func (physicalPackage Package) Pull(enumerationContext string) (response Respons
 	if err != nil {
 		return
 	}
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = physicalPackage.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
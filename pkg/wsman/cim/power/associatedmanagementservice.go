@@ -0,0 +1,124 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package power
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/internal/message"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/cim/computer"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/client"
+)
+
+// NewAssociatedPowerManagementServiceWithClient returns a new instance of the AssociatedManagementService struct.
+func NewAssociatedPowerManagementServiceWithClient(wsmanMessageCreator *message.WSManMessageCreator, client client.WSMan) AssociatedManagementService {
+	return AssociatedManagementService{
+		base:   message.NewBaseWithClient(wsmanMessageCreator, CIM_AssociatedPowerManagementService, client),
+		client: client,
+	}
+}
+
+// Enumerate returns an enumeration context which is used in a subsequent Pull call
+func (associatedService AssociatedManagementService) Enumerate() (response Response, err error) {
+	response = Response{
+		Message: &client.Message{
+			XMLInput: associatedService.base.Enumerate(),
+		},
+	}
+
+	err = associatedService.base.Execute(response.Message)
+	if err != nil {
+		return
+	}
+
+	err = associatedService.base.Decode(response.Message, &response)
+	if err != nil {
+		return
+	}
+	return
+}
+
+// Pull returns the instances of this class.  An enumeration context provided by the Enumerate call is used as input.
+func (associatedService AssociatedManagementService) Pull(enumerationContext string) (response Response, err error) {
+	response = Response{
+		Message: &client.Message{
+			XMLInput: associatedService.base.Pull(enumerationContext),
+		},
+	}
+	err = associatedService.base.Execute(response.Message)
+	if err != nil {
+		return
+	}
+	err = associatedService.base.Decode(response.Message, &response)
+	if err != nil {
+		return
+	}
+	return
+}
+
+// GetPowerState enumerates the associations and returns the one of the managed system, which holds its current power
+// state and the power states that can be requested in it.
+func (associatedService AssociatedManagementService) GetPowerState() (AssociatedPowerManagementService, error) {
+	enumerated, err := associatedService.Enumerate()
+	if err != nil {
+		return AssociatedPowerManagementService{}, err
+	}
+	pulled, err := associatedService.Pull(enumerated.Body.EnumerateResponse.EnumerationContext)
+	if err != nil {
+		return AssociatedPowerManagementService{}, err
+	}
+	for _, association := range pulled.Body.PullResponse.AssociatedPowerManagementServiceItems {
+		if association.UserOfService.Selector("Name") == computer.ManagedSystem {
+			return association, nil
+		}
+	}
+	return AssociatedPowerManagementService{}, fmt.Errorf("no %s of the managed system", CIM_AssociatedPowerManagementService)
+}
+
+// WaitForPowerState reads the power state every interval until it is state, and returns the association holding it.
+// A read that fails is retried at the next interval. When ctx is done first it returns the last association read with
+// an error wrapping the error of ctx, and the error of the last read when it failed. interval must be positive.
+//
+// Use ResultingPowerState for the state a requested change ends in. Resets and power cycles end in PowerOn, which the
+// system may already report before the change started, so waiting for them only checks that the system is back on.
+func (associatedService AssociatedManagementService) WaitForPowerState(ctx context.Context, state PowerState, interval time.Duration) (AssociatedPowerManagementService, error) {
+	if interval <= 0 {
+		return AssociatedPowerManagementService{}, errors.New("interval must be positive")
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	var last AssociatedPowerManagementService
+	for {
+		association, err := associatedService.GetPowerState()
+		if err == nil {
+			if association.PowerState == state {
+				return association, nil
+			}
+			last = association
+		}
+		select {
+		case <-ctx.Done():
+			if err != nil {
+				return last, fmt.Errorf("power state is unknown while waiting for %d: %w: %w", state, ctx.Err(), err)
+			}
+			return last, fmt.Errorf("power state is %d while waiting for %d: %w", last.PowerState, state, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// Selector returns the value of the selector name of the reference, or "" when it has none.
+func (reference EndpointReference) Selector(name string) string {
+	for _, selector := range reference.ReferenceParameters.Selectors {
+		if selector.Name == name {
+			return selector.Value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,259 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package power
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/internal/message"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/common"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/wsmantesting"
+)
+
+var associatedPowerManagementService = AssociatedPowerManagementService{
+	XMLName:                       xml.Name{Space: "http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_AssociatedPowerManagementService", Local: "CIM_AssociatedPowerManagementService"},
+	AvailableRequestedPowerStates: []PowerState{MasterBusReset, PowerOffHard, PowerCycleOffHard, DiagnosticInterruptNMI, SleepDeep, Hibernate, MasterBusResetGraceful, PowerOffSoftGraceful},
+	PowerState:                    PowerOn,
+	ServiceProvided: EndpointReference{
+		Address: "http://schemas.xmlsoap.org/ws/2004/08/addressing/role/anonymous",
+		ReferenceParameters: ReferenceParameters{
+			ResourceURI: "http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_PowerManagementService",
+			Selectors: []Selector{
+				{Name: "CreationClassName", Value: "CIM_PowerManagementService"},
+				{Name: "Name", Value: "Intel(r) AMT Power Management Service"},
+				{Name: "SystemCreationClassName", Value: "CIM_ComputerSystem"},
+				{Name: "SystemName", Value: "Intel(r) AMT"},
+			},
+		},
+	},
+	UserOfService: EndpointReference{
+		Address: "http://schemas.xmlsoap.org/ws/2004/08/addressing/role/anonymous",
+		ReferenceParameters: ReferenceParameters{
+			ResourceURI: "http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_ComputerSystem",
+			Selectors: []Selector{
+				{Name: "CreationClassName", Value: "CIM_ComputerSystem"},
+				{Name: "Name", Value: "ManagedSystem"},
+			},
+		},
+	},
+}
+
+// powerStateClient answers the pulls of CIM_AssociatedPowerManagementService with the power states in turn, then
+// with the last one. The first failures enumerations fail.
+type powerStateClient struct {
+	wsmantesting.MockClient
+	states   []PowerState
+	pulls    int
+	failures int
+}
+
+func (c *powerStateClient) Post(msg string) ([]byte, error) {
+	if !strings.Contains(msg, message.BaseActionsPull) {
+		if c.failures > 0 {
+			c.failures--
+			return nil, errors.New("connection reset")
+		}
+		c.CurrentMessage = "Enumerate"
+		return c.MockClient.Post(msg)
+	}
+	c.CurrentMessage = "Pull"
+	response, err := c.MockClient.Post(msg)
+	state := c.states[len(c.states)-1]
+	if c.pulls < len(c.states) {
+		state = c.states[c.pulls]
+	}
+	c.pulls++
+	return []byte(strings.Replace(string(response), "<h:PowerState>2</h:PowerState>", "<h:PowerState>"+string(rune('0'+state))+"</h:PowerState>", 1)), err
+}
+
+func TestPositiveCIMAssociatedPowerManagementService(t *testing.T) {
+	messageID := 0
+	resourceUriBase := "http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/"
+	wsmanMessageCreator := message.NewWSManMessageCreator(resourceUriBase)
+	client := wsmantesting.MockClient{
+		PackageUnderTest: "cim/power/associatedmanagementservice",
+	}
+	elementUnderTest := NewAssociatedPowerManagementServiceWithClient(wsmanMessageCreator, &client)
+
+	t.Run("cim_AssociatedPowerManagementService Tests", func(t *testing.T) {
+		tests := []struct {
+			name             string
+			method           string
+			action           string
+			body             string
+			responseFunc     func() (Response, error)
+			expectedResponse interface{}
+		}{
+			{
+				"Should issue a valid cim_AssociatedPowerManagementService Enumerate call",
+				CIM_AssociatedPowerManagementService,
+				wsmantesting.ENUMERATE,
+				wsmantesting.ENUMERATE_BODY,
+				func() (Response, error) {
+					client.CurrentMessage = "Enumerate"
+					return elementUnderTest.Enumerate()
+				},
+				Body{
+					XMLName: xml.Name{Space: message.XMLBodySpace, Local: "Body"},
+					EnumerateResponse: common.EnumerateResponse{
+						EnumerationContext: "DE020000-0000-0000-0000-000000000000",
+					},
+				},
+			},
+			{
+				"Should issue a valid cim_AssociatedPowerManagementService Pull call",
+				CIM_AssociatedPowerManagementService,
+				wsmantesting.PULL,
+				wsmantesting.PULL_BODY,
+				func() (Response, error) {
+					client.CurrentMessage = "Pull"
+					return elementUnderTest.Pull(wsmantesting.EnumerationContext)
+				},
+				Body{
+					XMLName: xml.Name{Space: message.XMLBodySpace, Local: "Body"},
+					PullResponse: PullResponse{
+						XMLName:                               xml.Name{Space: "http://schemas.xmlsoap.org/ws/2004/09/enumeration", Local: "PullResponse"},
+						AssociatedPowerManagementServiceItems: []AssociatedPowerManagementService{associatedPowerManagementService},
+					},
+				},
+			},
+		}
+
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				expectedXMLInput := wsmantesting.ExpectedResponse(messageID, resourceUriBase, test.method, test.action, "", test.body)
+				messageID++
+				response, err := test.responseFunc()
+				assert.NoError(t, err)
+				assert.Equal(t, expectedXMLInput, response.XMLInput)
+				assert.Equal(t, test.expectedResponse, response.Body)
+			})
+		}
+	})
+}
+
+func TestNegativeCIMAssociatedPowerManagementService(t *testing.T) {
+	wsmanMessageCreator := message.NewWSManMessageCreator("http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/")
+	client := wsmantesting.MockClient{
+		PackageUnderTest: "cim/power/associatedmanagementservice",
+		CurrentMessage:   "Error",
+	}
+	elementUnderTest := NewAssociatedPowerManagementServiceWithClient(wsmanMessageCreator, &client)
+
+	_, err := elementUnderTest.Enumerate()
+	assert.Error(t, err)
+	_, err = elementUnderTest.Pull(wsmantesting.EnumerationContext)
+	assert.Error(t, err)
+	_, err = elementUnderTest.GetPowerState()
+	assert.Error(t, err)
+}
+
+func TestGetPowerState(t *testing.T) {
+	wsmanMessageCreator := message.NewWSManMessageCreator("http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/")
+	client := &powerStateClient{
+		MockClient: wsmantesting.MockClient{PackageUnderTest: "cim/power/associatedmanagementservice"},
+		states:     []PowerState{PowerOn},
+	}
+	elementUnderTest := NewAssociatedPowerManagementServiceWithClient(wsmanMessageCreator, client)
+
+	association, err := elementUnderTest.GetPowerState()
+	require.NoError(t, err)
+	assert.Equal(t, associatedPowerManagementService, association)
+	assert.Equal(t, "Intel(r) AMT Power Management Service", association.ServiceProvided.Selector("Name"))
+	assert.Empty(t, association.ServiceProvided.Selector("Tag"))
+}
+
+func TestWaitForPowerState(t *testing.T) {
+	wsmanMessageCreator := message.NewWSManMessageCreator("http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/")
+
+	t.Run("returns once the power state is reached", func(t *testing.T) {
+		client := &powerStateClient{
+			MockClient: wsmantesting.MockClient{PackageUnderTest: "cim/power/associatedmanagementservice"},
+			states:     []PowerState{PowerOn, PowerOn, PowerOffHard},
+		}
+		elementUnderTest := NewAssociatedPowerManagementServiceWithClient(wsmanMessageCreator, client)
+
+		association, err := elementUnderTest.WaitForPowerState(context.Background(), PowerOffHard, time.Millisecond)
+		require.NoError(t, err)
+		assert.Equal(t, PowerOffHard, association.PowerState)
+		assert.Equal(t, 3, client.pulls)
+	})
+
+	t.Run("times out with the last power state", func(t *testing.T) {
+		client := &powerStateClient{
+			MockClient: wsmantesting.MockClient{PackageUnderTest: "cim/power/associatedmanagementservice"},
+			states:     []PowerState{PowerOn},
+		}
+		elementUnderTest := NewAssociatedPowerManagementServiceWithClient(wsmanMessageCreator, client)
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		association, err := elementUnderTest.WaitForPowerState(ctx, PowerOffHard, time.Millisecond)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.EqualError(t, err, "power state is 2 while waiting for 8: context deadline exceeded")
+		assert.Equal(t, PowerOn, association.PowerState)
+	})
+
+	t.Run("retries the reads that fail", func(t *testing.T) {
+		client := &powerStateClient{
+			MockClient: wsmantesting.MockClient{PackageUnderTest: "cim/power/associatedmanagementservice"},
+			states:     []PowerState{PowerOffHard},
+			failures:   2,
+		}
+		elementUnderTest := NewAssociatedPowerManagementServiceWithClient(wsmanMessageCreator, client)
+
+		association, err := elementUnderTest.WaitForPowerState(context.Background(), PowerOffHard, time.Millisecond)
+		require.NoError(t, err)
+		assert.Equal(t, PowerOffHard, association.PowerState)
+	})
+
+	t.Run("times out with the error of the last read", func(t *testing.T) {
+		client := &powerStateClient{
+			MockClient: wsmantesting.MockClient{PackageUnderTest: "cim/power/associatedmanagementservice"},
+			states:     []PowerState{PowerOn},
+			failures:   math.MaxInt,
+		}
+		elementUnderTest := NewAssociatedPowerManagementServiceWithClient(wsmanMessageCreator, client)
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		_, err := elementUnderTest.WaitForPowerState(ctx, PowerOffHard, time.Millisecond)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.EqualError(t, err, "power state is unknown while waiting for 8: context deadline exceeded: connection reset")
+	})
+
+	t.Run("rejects an interval that is not positive", func(t *testing.T) {
+		client := &powerStateClient{
+			MockClient: wsmantesting.MockClient{PackageUnderTest: "cim/power/associatedmanagementservice"},
+			states:     []PowerState{PowerOn},
+		}
+		elementUnderTest := NewAssociatedPowerManagementServiceWithClient(wsmanMessageCreator, client)
+
+		_, err := elementUnderTest.WaitForPowerState(context.Background(), PowerOn, 0)
+		assert.EqualError(t, err, "interval must be positive")
+		assert.Zero(t, client.pulls)
+	})
+}
+
+func TestResultingPowerState(t *testing.T) {
+	state, ok := ResultingPowerState(PowerCycleOffHard)
+	assert.True(t, ok)
+	assert.Equal(t, PowerOn, state)
+	state, ok = ResultingPowerState(PowerOffSoftGraceful)
+	assert.True(t, ok)
+	assert.Equal(t, PowerOffHard, state)
+	_, ok = ResultingPowerState(PowerState(1))
+	assert.False(t, ok)
+}
@@ -6,8 +6,9 @@
 package power
 
 const (
-	CIM_PowerManagementService string = "CIM_PowerManagementService"
-	RequestPowerStateChange    string = "RequestPowerStateChange"
+	CIM_PowerManagementService           string = "CIM_PowerManagementService"
+	CIM_AssociatedPowerManagementService string = "CIM_AssociatedPowerManagementService"
+	RequestPowerStateChange              string = "RequestPowerStateChange"
 )
 
 const (
@@ -56,3 +57,52 @@ const (
 	// Power Cycle (Off - Hard Graceful)
 	PowerCycleOffHardGraceful PowerState = 16 // ?
 )
+
+// Return values of RequestPowerStateChange.
+const (
+	ReturnValueCompletedWithNoError ReturnValue = iota
+	ReturnValueNotSupported
+	ReturnValueUnknownOrUnspecifiedError
+	ReturnValueCannotCompleteWithinTimeoutPeriod
+	ReturnValueFailed
+	ReturnValueInvalidParameter
+	ReturnValueInUse
+)
+
+const (
+	// ReturnValueJobStarted means the change was accepted and runs in a CIM_ConcreteJob, for example when it is
+	// scheduled with a Time.
+	ReturnValueJobStarted ReturnValue = iota + 4096
+	ReturnValueInvalidStateTransition
+	ReturnValueUseOfTimeoutParameterNotSupported
+	ReturnValueBusy
+)
+
+const addressingNamespace = "http://schemas.xmlsoap.org/ws/2004/08/addressing"
+
+// resultingPowerStates maps the power states RequestPowerStateChange accepts to the PowerState of the system once the
+// change completed: on after a power on, reset or power cycle, off after a power off.
+var resultingPowerStates = map[PowerState]PowerState{
+	PowerOn:                   PowerOn,
+	SleepLight:                SleepLight,
+	SleepDeep:                 SleepDeep,
+	Hibernate:                 Hibernate,
+	PowerCycleOffSoft:         PowerOn,
+	PowerCycleOffHard:         PowerOn,
+	PowerCycleOffSoftGraceful: PowerOn,
+	PowerCycleOffHardGraceful: PowerOn,
+	MasterBusReset:            PowerOn,
+	MasterBusResetGraceful:    PowerOn,
+	DiagnosticInterruptNMI:    PowerOn,
+	PowerOffHard:              PowerOffHard,
+	PowerOffSoft:              PowerOffHard,
+	PowerOffSoftGraceful:      PowerOffHard,
+	PowerOffHardGraceful:      PowerOffHard,
+}
+
+// ResultingPowerState returns the PowerState CIM_AssociatedPowerManagementService reports once the change to requested
+// completed, and false when requested is not a power state that can be requested.
+func ResultingPowerState(requested PowerState) (PowerState, bool) {
+	state, ok := resultingPowerStates[requested]
+	return state, ok
+}
@@ -11,8 +11,11 @@ package power
 import (
 	"encoding/xml"
 	"fmt"
+	"strconv"
+	"time"
 
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/internal/message"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/cim/computer"
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/cim/methods"
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/client"
 )
@@ -27,8 +30,31 @@ func NewPowerManagementServiceWithClient(wsmanMessageCreator *message.WSManMessa
 
 // RequestPowerStateChange defines the desired power state of the managed element, and when the element should be put into that state.
 func (managementService ManagementService) RequestPowerStateChange(powerState PowerState) (response Response, err error) {
+	return managementService.RequestPowerStateChangeWithParameters(PowerStateChangeRequest{PowerState: powerState})
+}
+
+// RequestPowerStateChangeWithParameters changes the power state of request.ManagedElement, possibly later or within a
+// timeout. The ReturnValue of the response tells whether the change completed, ReturnValueCompletedWithNoError, was
+// accepted as a job, ReturnValueJobStarted, or is not supported in the current power state, ReturnValueNotSupported.
+func (managementService ManagementService) RequestPowerStateChangeWithParameters(request PowerStateChangeRequest) (response Response, err error) {
+	if err = managementService.base.Validate(request); err != nil {
+		return
+	}
+	input := RequestPowerStateChange_INPUT{
+		PowerState:     request.PowerState,
+		ManagedElement: request.ManagedElement,
+	}
+	if len(input.ManagedElement.ReferenceParameters.Selectors) == 0 {
+		input.ManagedElement = managedSystemReference()
+	}
+	if !request.Time.IsZero() {
+		input.Time = &DateTime{Datetime: request.Time.UTC().Format(time.RFC3339)}
+	}
+	if request.TimeoutPeriod > 0 {
+		input.TimeoutPeriod = &Interval{Interval: formatDuration(request.TimeoutPeriod)}
+	}
 	header := managementService.base.WSManMessageCreator.CreateHeader(methods.GenerateAction(CIM_PowerManagementService, RequestPowerStateChange), CIM_PowerManagementService, nil, "", "")
-	body := fmt.Sprintf(`<Body><h:RequestPowerStateChange_INPUT xmlns:h="http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_PowerManagementService"><h:PowerState>%d</h:PowerState><h:ManagedElement><Address xmlns="http://schemas.xmlsoap.org/ws/2004/08/addressing">http://schemas.xmlsoap.org/ws/2004/08/addressing</Address><ReferenceParameters xmlns="http://schemas.xmlsoap.org/ws/2004/08/addressing"><ResourceURI xmlns="http://schemas.dmtf.org/wbem/wsman/1/wsman.xsd">http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_ComputerSystem</ResourceURI><SelectorSet xmlns="http://schemas.dmtf.org/wbem/wsman/1/wsman.xsd"><Selector Name="CreationClassName">CIM_ComputerSystem</Selector><Selector Name="Name">ManagedSystem</Selector></SelectorSet></ReferenceParameters></h:ManagedElement></h:RequestPowerStateChange_INPUT></Body>`, powerState)
+	body := managementService.base.WSManMessageCreator.CreateBody(methods.GenerateInputMethod(RequestPowerStateChange), CIM_PowerManagementService, &input)
 	response = Response{
 		Message: &client.Message{
 			XMLInput: managementService.base.WSManMessageCreator.CreateXML(header, body),
@@ -41,7 +67,7 @@ func (managementService ManagementService) RequestPowerStateChange(powerState Po
 	}
 
 	// put the xml response into the go struct
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = managementService.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -49,6 +75,54 @@ func (managementService ManagementService) RequestPowerStateChange(powerState Po
 	return
 }
 
+// managedSystemReference returns the reference to the CIM_ComputerSystem of the host managed by AMT.
+func managedSystemReference() EndpointReference {
+	return EndpointReference{
+		Address: addressingNamespace,
+		ReferenceParameters: ReferenceParameters{
+			ResourceURI: "http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_ComputerSystem",
+			Selectors: []Selector{
+				{Name: "CreationClassName", Value: "CIM_ComputerSystem"},
+				{Name: "Name", Value: computer.ManagedSystem},
+			},
+		},
+	}
+}
+
+// formatDuration returns d as an xs:duration in days, hours, minutes and seconds, the format of AMT.
+func formatDuration(d time.Duration) string {
+	days := d / (24 * time.Hour)
+	d -= days * 24 * time.Hour
+	hours := d / time.Hour
+	d -= hours * time.Hour
+	minutes := d / time.Minute
+	d -= minutes * time.Minute
+	return fmt.Sprintf("P%dDT%dH%dM%sS", days, hours, minutes, strconv.FormatFloat(d.Seconds(), 'f', -1, 64))
+}
+
+// MarshalXML encodes the reference in the WS-Addressing and WS-Management namespaces, as AMT expects it in method
+// parameters.
+func (reference EndpointReference) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	type selectorSet struct {
+		Selectors []Selector `xml:"Selector"`
+	}
+	type referenceParameters struct {
+		ResourceURI string      `xml:"http://schemas.dmtf.org/wbem/wsman/1/wsman.xsd ResourceURI"`
+		SelectorSet selectorSet `xml:"http://schemas.dmtf.org/wbem/wsman/1/wsman.xsd SelectorSet"`
+	}
+	type endpointReference struct {
+		Address             string              `xml:"http://schemas.xmlsoap.org/ws/2004/08/addressing Address"`
+		ReferenceParameters referenceParameters `xml:"http://schemas.xmlsoap.org/ws/2004/08/addressing ReferenceParameters"`
+	}
+	return e.EncodeElement(endpointReference{
+		Address: reference.Address,
+		ReferenceParameters: referenceParameters{
+			ResourceURI: reference.ReferenceParameters.ResourceURI,
+			SelectorSet: selectorSet{Selectors: reference.ReferenceParameters.Selectors},
+		},
+	}, start)
+}
+
 // Get retrieves the representation of the instance
 func (managementService ManagementService) Get() (response Response, err error) {
 	response = Response{
@@ -62,7 +136,7 @@ func (managementService ManagementService) Get() (response Response, err error)
 		return
 	}
 
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = managementService.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -83,7 +157,7 @@ func (managementService ManagementService) Enumerate() (response Response, err e
 		return
 	}
 
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = managementService.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -101,7 +175,7 @@ func (managementService ManagementService) Pull(enumerationContext string) (resp
 	if err != nil {
 		return
 	}
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = managementService.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
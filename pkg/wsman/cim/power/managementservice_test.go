@@ -8,6 +8,7 @@ package power
 import (
 	"encoding/xml"
 	"testing"
+	"time"
 
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/internal/message"
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/cim/methods"
@@ -17,7 +18,8 @@ import (
 )
 
 const (
-	RequestPowerStateChange_BODY = "<h:RequestPowerStateChange_INPUT xmlns:h=\"http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_PowerManagementService\"><h:PowerState>8</h:PowerState><h:ManagedElement><Address xmlns=\"http://schemas.xmlsoap.org/ws/2004/08/addressing\">http://schemas.xmlsoap.org/ws/2004/08/addressing</Address><ReferenceParameters xmlns=\"http://schemas.xmlsoap.org/ws/2004/08/addressing\"><ResourceURI xmlns=\"http://schemas.dmtf.org/wbem/wsman/1/wsman.xsd\">http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_ComputerSystem</ResourceURI><SelectorSet xmlns=\"http://schemas.dmtf.org/wbem/wsman/1/wsman.xsd\"><Selector Name=\"CreationClassName\">CIM_ComputerSystem</Selector><Selector Name=\"Name\">ManagedSystem</Selector></SelectorSet></ReferenceParameters></h:ManagedElement></h:RequestPowerStateChange_INPUT>"
+	RequestPowerStateChange_BODY          = "<h:RequestPowerStateChange_INPUT xmlns:h=\"http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_PowerManagementService\"><h:PowerState>8</h:PowerState><h:ManagedElement><Address xmlns=\"http://schemas.xmlsoap.org/ws/2004/08/addressing\">http://schemas.xmlsoap.org/ws/2004/08/addressing</Address><ReferenceParameters xmlns=\"http://schemas.xmlsoap.org/ws/2004/08/addressing\"><ResourceURI xmlns=\"http://schemas.dmtf.org/wbem/wsman/1/wsman.xsd\">http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_ComputerSystem</ResourceURI><SelectorSet xmlns=\"http://schemas.dmtf.org/wbem/wsman/1/wsman.xsd\"><Selector Name=\"CreationClassName\">CIM_ComputerSystem</Selector><Selector Name=\"Name\">ManagedSystem</Selector></SelectorSet></ReferenceParameters></h:ManagedElement></h:RequestPowerStateChange_INPUT>"
+	RequestPowerStateChangeScheduled_BODY = "<h:RequestPowerStateChange_INPUT xmlns:h=\"http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_PowerManagementService\"><h:PowerState>12</h:PowerState><h:ManagedElement><Address xmlns=\"http://schemas.xmlsoap.org/ws/2004/08/addressing\">http://schemas.xmlsoap.org/ws/2004/08/addressing</Address><ReferenceParameters xmlns=\"http://schemas.xmlsoap.org/ws/2004/08/addressing\"><ResourceURI xmlns=\"http://schemas.dmtf.org/wbem/wsman/1/wsman.xsd\">http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_ComputerSystem</ResourceURI><SelectorSet xmlns=\"http://schemas.dmtf.org/wbem/wsman/1/wsman.xsd\"><Selector Name=\"CreationClassName\">CIM_ComputerSystem</Selector><Selector Name=\"Name\">ManagedSystem</Selector></SelectorSet></ReferenceParameters></h:ManagedElement><h:Time><Datetime xmlns=\"http://schemas.dmtf.org/wbem/wscim/1/common\">2024-01-31T20:00:00Z</Datetime></h:Time><h:TimeoutPeriod><Interval xmlns=\"http://schemas.dmtf.org/wbem/wscim/1/common\">P0DT0H1M30S</Interval></h:TimeoutPeriod></h:RequestPowerStateChange_INPUT>"
 )
 
 func TestPositiveCIMPowerManagementService(t *testing.T) {
@@ -55,6 +57,33 @@ func TestPositiveCIMPowerManagementService(t *testing.T) {
 					},
 				},
 			},
+			{
+				"Should issue a valid cim_PowerManagementService scheduled RequestPowerStateChange call",
+				CIM_PowerManagementService,
+				methods.GenerateAction(CIM_PowerManagementService, RequestPowerStateChange),
+				RequestPowerStateChangeScheduled_BODY,
+				func() (Response, error) {
+					client.CurrentMessage = "RequestPowerStateChangeJob"
+					return elementUnderTest.RequestPowerStateChangeWithParameters(PowerStateChangeRequest{
+						PowerState:    PowerOffSoftGraceful,
+						Time:          time.Date(2024, 1, 31, 21, 0, 0, 0, time.FixedZone("CET", 3600)),
+						TimeoutPeriod: 90 * time.Second,
+					})
+				},
+				Body{
+					XMLName: xml.Name{Space: message.XMLBodySpace, Local: "Body"},
+					RequestPowerStateChangeResponse: PowerActionResponse{
+						ReturnValue: ReturnValueJobStarted,
+						Job: &EndpointReference{
+							Address: "http://schemas.xmlsoap.org/ws/2004/08/addressing/role/anonymous",
+							ReferenceParameters: ReferenceParameters{
+								ResourceURI: "http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_ConcreteJob",
+								Selectors:   []Selector{{Name: "InstanceID", Value: "Intel(r) AMT:PowerStateChange 1"}},
+							},
+						},
+					},
+				},
+			},
 			{
 				"Should issue a valid cim_PowerManagementService Get call",
 				CIM_PowerManagementService,
@@ -255,3 +284,39 @@ func TestNegativeCIMPowerManagementService(t *testing.T) {
 		}
 	})
 }
+
+func TestRequestPowerStateChangeWithParameters(t *testing.T) {
+	wsmanMessageCreator := message.NewWSManMessageCreator("http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/")
+	client := wsmantesting.MockClient{
+		PackageUnderTest: "cim/power/managementservice",
+		CurrentMessage:   "RequestPowerStateChange",
+	}
+	elementUnderTest := NewPowerManagementServiceWithClient(wsmanMessageCreator, &client)
+
+	response, err := elementUnderTest.RequestPowerStateChangeWithParameters(PowerStateChangeRequest{
+		PowerState: MasterBusReset,
+		ManagedElement: EndpointReference{
+			Address: "http://schemas.xmlsoap.org/ws/2004/08/addressing",
+			ReferenceParameters: ReferenceParameters{
+				ResourceURI: "http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_ComputerSystem",
+				Selectors:   []Selector{{Name: "CreationClassName", Value: "CIM_ComputerSystem"}, {Name: "Name", Value: "Other"}},
+			},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, ReturnValueCompletedWithNoError, response.Body.RequestPowerStateChangeResponse.ReturnValue)
+	assert.Contains(t, response.XMLInput, "<h:PowerState>10</h:PowerState>")
+	assert.Contains(t, response.XMLInput, `<Selector Name="Name">Other</Selector>`)
+	assert.NotContains(t, response.XMLInput, "h:Time")
+	assert.NotContains(t, response.XMLInput, "h:TimeoutPeriod")
+
+	_, err = elementUnderTest.RequestPowerStateChangeWithParameters(PowerStateChangeRequest{PowerState: PowerOffSoftGraceful, TimeoutPeriod: -time.Second})
+	assert.EqualError(t, err, "invalid PowerStateChangeRequest: TimeoutPeriod: must not be negative, got -1s")
+	_, err = elementUnderTest.RequestPowerStateChange(PowerState(1))
+	assert.EqualError(t, err, "invalid PowerStateChangeRequest: PowerState: must be between 2 and 16, got 1")
+}
+
+func TestFormatDuration(t *testing.T) {
+	assert.Equal(t, "P0DT0H0M30S", formatDuration(30*time.Second))
+	assert.Equal(t, "P1DT2H3M4.5S", formatDuration(26*time.Hour+3*time.Minute+4500*time.Millisecond))
+}
@@ -7,6 +7,7 @@ package power
 
 import (
 	"encoding/xml"
+	"time"
 
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/internal/message"
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/client"
@@ -18,8 +19,29 @@ type ManagementService struct {
 	client client.WSMan
 }
 
+type AssociatedManagementService struct {
+	base   message.Base
+	client client.WSMan
+}
+
 type PowerState int
 
+// ReturnValue is the return code of RequestPowerStateChange.
+type ReturnValue int
+
+// PowerStateChangeRequest holds the parameters of RequestPowerStateChange.
+type PowerStateChangeRequest struct {
+	// PowerState is the power state to put ManagedElement in.
+	PowerState PowerState
+	// ManagedElement is the system whose power state changes, the managed system when it has no selectors.
+	ManagedElement EndpointReference
+	// Time is when the change happens, right away when zero.
+	Time time.Time
+	// TimeoutPeriod is how long the change may take, as long as the device needs when zero. A graceful change that
+	// does not complete in time is forced.
+	TimeoutPeriod time.Duration
+}
+
 // Response Types
 type (
 	Response struct {
@@ -37,8 +59,9 @@ type (
 	}
 
 	PullResponse struct {
-		XMLName                     xml.Name                 `xml:"PullResponse"`
-		PowerManagementServiceItems []PowerManagementService `xml:"Items>CIM_PowerManagementService"`
+		XMLName                               xml.Name                           `xml:"PullResponse"`
+		PowerManagementServiceItems           []PowerManagementService           `xml:"Items>CIM_PowerManagementService"`
+		AssociatedPowerManagementServiceItems []AssociatedPowerManagementService `xml:"Items>CIM_AssociatedPowerManagementService"`
 	}
 
 	PowerManagementService struct {
@@ -52,7 +75,50 @@ type (
 		SystemName              string   `xml:"SystemName,omitempty"`              // The Name of the scoping System.
 	}
 
+	AssociatedPowerManagementService struct {
+		XMLName                       xml.Name          `xml:"CIM_AssociatedPowerManagementService"`
+		AvailableRequestedPowerStates []PowerState      `xml:"AvailableRequestedPowerStates,omitempty"` // AvailableRequestedPowerStates indicates the possible values for the PowerState parameter of the method RequestPowerStateChange, as a function of the current power state of the system.
+		PowerState                    PowerState        `xml:"PowerState,omitempty"`                    // The current power state of the associated Managed System Element.
+		ServiceProvided               EndpointReference `xml:"ServiceProvided"`                         // The Service that is available.
+		UserOfService                 EndpointReference `xml:"UserOfService"`                           // The ManagedElement that can use the Service.
+	}
+
+	EndpointReference struct {
+		Address             string              `xml:"Address,omitempty"`
+		ReferenceParameters ReferenceParameters `xml:"ReferenceParameters"`
+	}
+
+	ReferenceParameters struct {
+		ResourceURI string     `xml:"ResourceURI,omitempty"`
+		Selectors   []Selector `xml:"SelectorSet>Selector,omitempty"`
+	}
+
+	Selector struct {
+		Name  string `xml:"Name,attr"`
+		Value string `xml:",chardata"`
+	}
+
+	RequestPowerStateChange_INPUT struct {
+		XMLName        xml.Name          `xml:"h:RequestPowerStateChange_INPUT"`
+		H              string            `xml:"xmlns:h,attr"`
+		PowerState     PowerState        `xml:"h:PowerState"`
+		ManagedElement EndpointReference `xml:"h:ManagedElement"`
+		Time           *DateTime         `xml:"h:Time,omitempty"`
+		TimeoutPeriod  *Interval         `xml:"h:TimeoutPeriod,omitempty"`
+	}
+
+	// DateTime is a CIM datetime holding a point in time, an xs:dateTime such as 2024-01-31T20:00:00Z.
+	DateTime struct {
+		Datetime string `xml:"http://schemas.dmtf.org/wbem/wscim/1/common Datetime"`
+	}
+
+	// Interval is a CIM datetime holding a duration, an xs:duration such as P0DT0H1M30S.
+	Interval struct {
+		Interval string `xml:"http://schemas.dmtf.org/wbem/wscim/1/common Interval"`
+	}
+
 	PowerActionResponse struct {
-		ReturnValue int `xml:"ReturnValue"`
+		ReturnValue ReturnValue        `xml:"ReturnValue"`
+		Job         *EndpointReference `xml:"Job,omitempty"` // The CIM_ConcreteJob tracking the change when ReturnValue is ReturnValueJobStarted.
 	}
 )
@@ -0,0 +1,20 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package power
+
+import (
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/common"
+)
+
+// Validate checks that the power state is a known one and that the timeout is not negative.
+func (r PowerStateChangeRequest) Validate() error {
+	v := common.NewValidationError("PowerStateChangeRequest")
+	v.Range("PowerState", int(r.PowerState), int(PowerOn), int(PowerCycleOffHardGraceful))
+	if r.TimeoutPeriod < 0 {
+		v.Add("TimeoutPeriod", "must not be negative, got %v", r.TimeoutPeriod)
+	}
+	return v.Err()
+}
@@ -13,8 +13,6 @@
 package service
 
 import (
-	"encoding/xml"
-
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/internal/message"
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/client"
 )
@@ -43,7 +41,7 @@ func (availableToElement AvailableToElement) Enumerate() (response Response, err
 		return
 	}
 
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = availableToElement.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -62,7 +60,7 @@ func (availableToElement AvailableToElement) Pull(enumerationContext string) (re
 	if err != nil {
 		return
 	}
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = availableToElement.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -11,8 +11,6 @@
 package software
 
 import (
-	"encoding/xml"
-
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/internal/message"
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/client"
 )
@@ -42,7 +40,7 @@ func (identity Identity) Get(instanceID string) (response Response, err error) {
 		return
 	}
 
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = identity.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -63,7 +61,7 @@ func (identity Identity) Enumerate() (response Response, err error) {
 		return
 	}
 
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = identity.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -82,9 +80,24 @@ func (identity Identity) Pull(enumerationContext string) (response Response, err
 	if err != nil {
 		return
 	}
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = identity.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
 	return
 }
+
+// PullEach streams the software identities returned by a Pull and calls fn for each one instead of collecting them into a Response.
+// Combined with client.Parameters.StreamResponses only a single SoftwareIdentity is held in memory at a time.
+func (identity Identity) PullEach(enumerationContext string, fn func(SoftwareIdentity) error) (err error) {
+	msg := &client.Message{
+		XMLInput: identity.base.Pull(enumerationContext),
+	}
+	// send the message to AMT
+	err = identity.base.Execute(msg)
+	if err != nil {
+		return
+	}
+	// decode the items one at a time
+	return message.DecodeItems(msg, CIM_SoftwareIdentity, fn)
+}
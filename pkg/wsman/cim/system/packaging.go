@@ -9,8 +9,6 @@
 package system
 
 import (
-	"encoding/xml"
-
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/internal/message"
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/client"
 )
@@ -38,7 +36,7 @@ func (packaging Package) Enumerate() (response Response, err error) {
 		return
 	}
 
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = packaging.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -57,7 +55,7 @@ func (packaging Package) Pull(enumerationContext string) (response Response, err
 	if err != nil {
 		return
 	}
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = packaging.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
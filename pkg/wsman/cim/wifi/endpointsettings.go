@@ -14,8 +14,6 @@
 package wifi
 
 import (
-	"encoding/xml"
-
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/internal/message"
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/client"
 )
@@ -43,7 +41,7 @@ func (endpointSettings EndpointSettings) Enumerate() (response Response, err err
 		return
 	}
 
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = endpointSettings.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -62,7 +60,7 @@ func (endpointSettings EndpointSettings) Pull(enumerationContext string) (respon
 	if err != nil {
 		return
 	}
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = endpointSettings.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -83,7 +81,7 @@ func (endpointSettings EndpointSettings) Delete(handle string) (response Respons
 		return
 	}
 
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = endpointSettings.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -6,7 +6,6 @@
 package wifi
 
 import (
-	"encoding/xml"
 	"errors"
 	"strconv"
 
@@ -36,7 +35,7 @@ func (port Port) RequestStateChange(requestedState int) (response Response, err
 		return
 	}
 
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = port.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -59,7 +58,7 @@ func (port Port) Get() (response Response, err error) {
 		return
 	}
 
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = port.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -80,7 +79,7 @@ func (port Port) Enumerate() (response Response, err error) {
 		return
 	}
 
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = port.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -99,7 +98,7 @@ func (port Port) Pull(enumerationContext string) (response Response, err error)
 	if err != nil {
 		return
 	}
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = port.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -0,0 +1,68 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package wifi
+
+import (
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/common"
+)
+
+// Validate checks that the SSID is set and at most 32 bytes, that a PSK profile has a PSKValue or a passphrase of 8 to
+// 63 characters while an IEEE 802.1x profile has no passphrase, and that the encryption method is one the
+// authentication method allows.
+func (r WiFiEndpointSettingsRequest) Validate() error {
+	v := common.NewValidationError("WiFiEndpointSettingsRequest")
+	v.Required("SSID", r.SSID)
+	v.MaxLength("SSID", r.SSID, 32)
+	switch r.AuthenticationMethod {
+	case AuthenticationMethod_WPA_PSK, AuthenticationMethod_WPA2_PSK:
+		if r.PSKPassPhrase == "" && r.PSKValue == 0 {
+			v.Add("PSKPassPhrase", "is required for PSK authentication")
+		}
+		if r.PSKPassPhrase != "" && (len(r.PSKPassPhrase) < 8 || len(r.PSKPassPhrase) > 63) {
+			v.Add("PSKPassPhrase", "must be between 8 and 63 characters, got %d", len(r.PSKPassPhrase))
+		}
+	case AuthenticationMethod_WPA_IEEE8021x, AuthenticationMethod_WPA2_IEEE8021x:
+		if r.PSKPassPhrase != "" {
+			v.Add("PSKPassPhrase", "must not be set for IEEE 802.1x authentication")
+		}
+	}
+	validateEncryption(v, r.AuthenticationMethod, r.EncryptionMethod, r.BSSType)
+	for i, key := range r.Keys {
+		v.MaxLength(common.Index("Keys", i), key, 256)
+	}
+	return v.Err()
+}
+
+// Validate checks the SSID, the BSS type and that the encryption method is one the authentication method allows.
+func (r WiFiEndpointSettings_INPUT) Validate() error {
+	v := common.NewValidationError("WiFiEndpointSettings_INPUT")
+	v.Required("SSID", r.SSID)
+	v.MaxLength("SSID", r.SSID, 32)
+	validateEncryption(v, r.AuthenticationMethod, r.EncryptionMethod, r.BSSType)
+	if r.Priority < 0 {
+		v.Add("Priority", "must not be negative, got %d", r.Priority)
+	}
+	return v.Err()
+}
+
+// validateEncryption records a violation when the encryption method is not one the authentication method allows, or
+// the BSS type is not supported.
+func validateEncryption(v *common.ValidationError, authenticationMethod AuthenticationMethod, encryptionMethod EncryptionMethod, bssType BSSType) {
+	switch authenticationMethod {
+	case AuthenticationMethod_WPA_PSK, AuthenticationMethod_WPA2_PSK, AuthenticationMethod_WPA_IEEE8021x, AuthenticationMethod_WPA2_IEEE8021x:
+		v.OneOf("EncryptionMethod", int(encryptionMethod), int(EncryptionMethod_TKIP), int(EncryptionMethod_CCMP))
+	case AuthenticationMethod_WPA3_SAE, AuthenticationMethod_WPA3_OWE:
+		v.OneOf("EncryptionMethod", int(encryptionMethod), int(EncryptionMethod_CCMP))
+	case AuthenticationMethod_OpenSystem, AuthenticationMethod_SharedKey:
+		v.OneOf("EncryptionMethod", int(encryptionMethod), int(EncryptionMethod_WEP), int(EncryptionMethod_None))
+	case AuthenticationMethod_Other:
+	default:
+		v.Add("AuthenticationMethod", "unsupported value %d", authenticationMethod)
+	}
+	if bssType != 0 {
+		v.OneOf("BSSType", int(bssType), int(BSSType_Independent), int(BSSType_Infrastructure))
+	}
+}
@@ -0,0 +1,54 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package wifi
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWiFiEndpointSettingsRequestValidate(t *testing.T) {
+	psk := WiFiEndpointSettingsRequest{
+		SSID:                 "home",
+		AuthenticationMethod: AuthenticationMethod_WPA2_PSK,
+		EncryptionMethod:     EncryptionMethod_CCMP,
+		PSKPassPhrase:        "passphrase",
+	}
+	assert.NoError(t, psk.Validate())
+
+	shortPSK := psk
+	shortPSK.PSKPassPhrase = "short"
+	assert.EqualError(t, shortPSK.Validate(), "invalid WiFiEndpointSettingsRequest: PSKPassPhrase: must be between 8 and 63 characters, got 5")
+
+	missingPSK := psk
+	missingPSK.PSKPassPhrase = ""
+	missingPSK.EncryptionMethod = EncryptionMethod_WEP
+	assert.EqualError(t, missingPSK.Validate(), "invalid WiFiEndpointSettingsRequest: PSKPassPhrase: is required for PSK authentication; EncryptionMethod: must be one of [3 4], got 2")
+
+	ieee8021x := WiFiEndpointSettingsRequest{
+		SSID:                 strings.Repeat("s", 33),
+		AuthenticationMethod: AuthenticationMethod_WPA2_IEEE8021x,
+		EncryptionMethod:     EncryptionMethod_CCMP,
+		PSKPassPhrase:        "passphrase",
+	}
+	assert.EqualError(t, ieee8021x.Validate(), "invalid WiFiEndpointSettingsRequest: SSID: must be at most 32 characters, got 33; PSKPassPhrase: must not be set for IEEE 802.1x authentication")
+}
+
+func TestWiFiEndpointSettingsInputValidate(t *testing.T) {
+	input := WiFiEndpointSettings_INPUT{
+		SSID:                 "office",
+		AuthenticationMethod: AuthenticationMethod_WPA3_SAE,
+		EncryptionMethod:     EncryptionMethod_CCMP,
+		BSSType:              BSSType_Infrastructure,
+	}
+	assert.NoError(t, input.Validate())
+
+	input.EncryptionMethod = EncryptionMethod_TKIP
+	input.Priority = -1
+	assert.EqualError(t, input.Validate(), "invalid WiFiEndpointSettings_INPUT: EncryptionMethod: must be one of [4], got 3; Priority: must not be negative, got -1")
+}
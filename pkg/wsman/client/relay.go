@@ -7,75 +7,217 @@ package client
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"crypto/tls"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httputil"
 	"net/url"
+	"regexp"
 	"strconv"
-	"strings"
 	"sync"
 	"time"
 
 	"github.com/gorilla/websocket"
 )
 
+// Defaults of the connection settings of a WsTransport.
+const (
+	DefaultPingInterval        = 30 * time.Second
+	DefaultPongTimeout         = 10 * time.Second
+	DefaultReconnectAttempts   = 3
+	DefaultReconnectBackoff    = 250 * time.Millisecond
+	DefaultMaxReconnectBackoff = 5 * time.Second
+)
+
+// ErrTransportClosed is returned by the round trips of a WsTransport after Close.
+var ErrTransportClosed = errors.New("relay transport closed")
+
 // WsTransport is an implementation of http.Transport which uses websocket relay
+//
+// The websocket is opened by the first round trip and reused until it fails, it is then reopened by the next round
+// trip. A WsTransport can be used concurrently, round trips are sent one at a time over the websocket. The connection
+// settings must be set before the first round trip.
 type WsTransport struct {
 	wsurl     string
 	protocol  int
 	host      string
-	username  string
-	password  string
 	port      int
 	tls       bool
 	tls1only  bool
 	token     string
-	conn      *websocket.Conn
 	tlsconfig *tls.Config
-	buf_mutex sync.Mutex
-	messages  []byte
+
+	// Auth authenticates the websocket with the relay, see RelayAuth.
+	Auth RelayAuth
+
+	// PingInterval is the interval of the pings that monitor the websocket, which fails when no pong arrives within
+	// PongTimeout. Pings are disabled when PingInterval is zero.
+	PingInterval time.Duration
+	PongTimeout  time.Duration
+	// ReconnectAttempts bounds the attempts to open the websocket for a round trip. The delay between attempts starts
+	// at ReconnectBackoff and doubles up to MaxReconnectBackoff.
+	ReconnectAttempts   int
+	ReconnectBackoff    time.Duration
+	MaxReconnectBackoff time.Duration
+
+	// mu serializes round trips, the responses are read from the stream in the order of the requests
+	mu sync.Mutex
+
+	// connMu guards the current websocket, Close takes it without waiting for a round trip
+	connMu sync.Mutex
+	conn   *websocket.Conn
+	stream *wsStream
+	done   chan struct{}
+	closed bool
 }
 
+// unframedEnd matches the end of a response body that has neither a Content-Length nor chunked encoding.
+var unframedEnd = regexp.MustCompile(`</([A-Za-z_][\w.-]*:)?Envelope>|</html>`)
+
 // NewTransport creates a new Websocket RoundTripper.
+//
+// The credentials are passed to the relay in the websocket URL, see QueryCredentials, where proxies and the access logs
+// of the relay can record them.
+//
+// Deprecated: use NewWsTransportWithAuth, which keeps the credentials out of the URL.
 func NewWsTransport(wsurl string, protocol int, host, username, password string, port int, tls, tls1only bool, token string, tlsconfig *tls.Config) *WsTransport {
+	return NewWsTransportWithAuth(wsurl, protocol, host, port, tls, tls1only, token, tlsconfig, QueryCredentials{Username: username, Password: password})
+}
+
+// NewWsTransportWithAuth creates a new Websocket RoundTripper that authenticates with the relay using auth, for
+// example a BearerToken, a TokenExchange or a TunnelDigest. It never falls back to QueryCredentials: round trips fail
+// when auth is nil.
+func NewWsTransportWithAuth(wsurl string, protocol int, host string, port int, tls, tls1only bool, token string, tlsconfig *tls.Config, auth RelayAuth) *WsTransport {
 	t := &WsTransport{
 		wsurl:     wsurl,
 		protocol:  protocol,
 		host:      host,
-		username:  username,
-		password:  password,
 		port:      port,
 		tls:       tls,
 		tls1only:  tls1only,
 		token:     token,
 		tlsconfig: tlsconfig,
-		buf_mutex: sync.Mutex{},
+
+		Auth:                auth,
+		PingInterval:        DefaultPingInterval,
+		PongTimeout:         DefaultPongTimeout,
+		ReconnectAttempts:   DefaultReconnectAttempts,
+		ReconnectBackoff:    DefaultReconnectBackoff,
+		MaxReconnectBackoff: DefaultMaxReconnectBackoff,
 	}
 	return t
 }
 
-func (t *WsTransport) timedReadMessage(ms int) (b []byte) {
-	timer := time.NewTimer(time.Duration(ms) * time.Millisecond)
-	<-timer.C
-	t.buf_mutex.Lock()
-	b = append(b, t.messages...)
-	t.messages = []byte{}
-	t.buf_mutex.Unlock()
-	return b
+// wsStream buffers the data of the websocket messages read in the background until a round trip consumes it.
+type wsStream struct {
+	mu     sync.Mutex
+	buf    []byte
+	err    error
+	signal chan struct{}
+}
+
+func newWsStream() *wsStream {
+	return &wsStream{signal: make(chan struct{}, 1)}
+}
+
+func (s *wsStream) write(p []byte) {
+	s.mu.Lock()
+	s.buf = append(s.buf, p...)
+	s.mu.Unlock()
+	s.notify()
+}
+
+// failed reports whether the websocket of the stream has failed.
+func (s *wsStream) failed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err != nil
+}
+
+// fail ends the stream with err once the buffered data is consumed.
+func (s *wsStream) fail(err error) {
+	s.mu.Lock()
+	if s.err == nil {
+		s.err = err
+	}
+	s.mu.Unlock()
+	s.notify()
+}
+
+// discard drops the buffered data and ends the stream with err.
+func (s *wsStream) discard(err error) {
+	s.mu.Lock()
+	s.buf = nil
+	s.err = err
+	s.mu.Unlock()
+	s.notify()
+}
+
+// unread puts p back in front of the buffered data.
+func (s *wsStream) unread(p []byte) {
+	if len(p) == 0 {
+		return
+	}
+	s.mu.Lock()
+	s.buf = append(append([]byte{}, p...), s.buf...)
+	s.mu.Unlock()
+	s.notify()
+}
+
+func (s *wsStream) notify() {
+	select {
+	case s.signal <- struct{}{}:
+	default:
+	}
+}
+
+// reader returns a reader of the stream that gives up when ctx is done.
+func (s *wsStream) reader(ctx context.Context) io.Reader {
+	return readerFunc(func(b []byte) (int, error) {
+		for {
+			s.mu.Lock()
+			if len(s.buf) > 0 {
+				n := copy(b, s.buf)
+				s.buf = s.buf[n:]
+				s.mu.Unlock()
+				return n, nil
+			}
+			err := s.err
+			s.mu.Unlock()
+			if err != nil {
+				return 0, err
+			}
+			select {
+			case <-s.signal:
+			case <-ctx.Done():
+				return 0, ctx.Err()
+			}
+		}
+	})
+}
+
+type readerFunc func([]byte) (int, error)
+
+func (f readerFunc) Read(b []byte) (int, error) {
+	return f(b)
 }
 
 func (t *WsTransport) buildUrl() string {
+	return t.relayUrl(t.protocol, t.port)
+}
+
+// relayUrl returns the URL of the relay to the AMT port using protocol.
+func (t *WsTransport) relayUrl(protocol, port int) string {
 	// Use net/url to construct the URL
 	u, _ := url.Parse(t.wsurl)
 	// Prepare query parameters
 	q := u.Query()
-	q.Set("p", strconv.Itoa(t.protocol))
+	q.Set("p", strconv.Itoa(protocol))
 	q.Set("host", t.host)
-	q.Set("user", t.username)
-	q.Set("pass", t.password)
-	q.Set("port", strconv.Itoa(t.port))
+	q.Set("port", strconv.Itoa(port))
 	q.Set("tls", strconv.FormatBool(t.tls))
 	q.Set("tls1only", strconv.FormatBool(t.tls1only))
 	// Set query string to URL
@@ -83,95 +225,297 @@ func (t *WsTransport) buildUrl() string {
 	return u.String()
 }
 
-func (t *WsTransport) connectWebsocket() (conn *websocket.Conn, err error) {
-	url := t.buildUrl()
+// dial opens a websocket to the relay, retrying with backoff until ctx is done or ReconnectAttempts is reached. The
+// relay rejecting the websocket with a client error is not retried.
+func (t *WsTransport) dial(ctx context.Context, protocol, port int) (*websocket.Conn, error) {
+	backoff := t.ReconnectBackoff
+	for attempt := 1; ; attempt++ {
+		conn, err := t.dialOnce(ctx, protocol, port)
+		if err == nil || attempt >= t.ReconnectAttempts || ctx.Err() != nil {
+			return conn, err
+		}
+		var rejected *relayRejectedError
+		if errors.As(err, &rejected) && rejected.status < 500 {
+			return nil, err
+		}
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if backoff *= 2; backoff > t.MaxReconnectBackoff {
+			backoff = t.MaxReconnectBackoff
+		}
+	}
+}
+
+// relayRejectedError is returned when the relay answers the websocket handshake with an HTTP error, or with a zero
+// status when the credentials of the handshake cannot be obtained.
+type relayRejectedError struct {
+	status int
+	err    error
+}
+
+func (e *relayRejectedError) Error() string {
+	if e.status == 0 {
+		return fmt.Sprintf("relay authentication failed: %v", e.err)
+	}
+	return fmt.Sprintf("%v: relay responded %d %s", e.err, e.status, http.StatusText(e.status))
+}
+
+func (e *relayRejectedError) Unwrap() error {
+	return e.err
+}
+
+func (t *WsTransport) dialOnce(ctx context.Context, protocol, port int) (*websocket.Conn, error) {
+	u, err := url.Parse(t.relayUrl(protocol, port))
+	if err != nil {
+		return nil, err
+	}
 	// Attempt to establish websocket connection
 	var hdr = http.Header{}
 	if t.token != "" {
 		hdr.Set("Sec-Websocket-Protocol", t.token)
 	}
+	q := u.Query()
+	if err := t.Auth.AuthorizeRelay(ctx, q, hdr); err != nil {
+		return nil, &relayRejectedError{err: err}
+	}
+	u.RawQuery = q.Encode()
 	wsdialer := websocket.Dialer{}
 	wsdialer.TLSClientConfig = t.tlsconfig
-	conn, _, err = wsdialer.Dial(url, hdr)
+	conn, resp, err := wsdialer.DialContext(ctx, u.String(), hdr)
 	if err != nil {
+		if resp != nil && resp.StatusCode >= 400 {
+			return nil, &relayRejectedError{status: resp.StatusCode, err: err}
+		}
 		return nil, err
-	} else {
-		t.conn = conn
-		go func() {
-			for {
-				//Trying to read.
-				_, p, err := t.conn.ReadMessage()
-				if err != nil {
-					return
-				}
-				t.buf_mutex.Lock()
-				t.messages = append(t.messages, p...)
-				t.buf_mutex.Unlock()
+	}
+	return conn, nil
+}
+
+// connect opens the websocket of the WS-Man round trips.
+func (t *WsTransport) connect(ctx context.Context) error {
+	conn, err := t.dial(ctx, t.protocol, t.port)
+	if err != nil {
+		return err
+	}
+	t.connMu.Lock()
+	defer t.connMu.Unlock()
+	if t.closed {
+		conn.Close()
+		return ErrTransportClosed
+	}
+	t.conn = conn
+	t.stream = newWsStream()
+	t.done = make(chan struct{})
+	go t.read(conn, t.stream)
+	if t.PingInterval > 0 {
+		go t.ping(conn, t.stream, t.done)
+	}
+	return nil
+}
+
+// read feeds the messages of conn to stream until conn fails, which fails the round trip in progress.
+func (t *WsTransport) read(conn *websocket.Conn, stream *wsStream) {
+	if t.PingInterval > 0 {
+		deadline := t.PingInterval + t.PongTimeout
+		_ = conn.SetReadDeadline(time.Now().Add(deadline))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(deadline))
+		})
+	}
+	for {
+		_, p, err := conn.ReadMessage()
+		if err != nil {
+			// the relay closing the websocket ends the stream like a closed HTTP connection
+			var closeErr *websocket.CloseError
+			if errors.As(err, &closeErr) {
+				err = io.EOF
 			}
-		}()
+			stream.fail(err)
+			return
+		}
+		stream.write(p)
+	}
+}
+
+// ping sends pings over conn until done is closed or a ping cannot be sent.
+func (t *WsTransport) ping(conn *websocket.Conn, stream *wsStream, done chan struct{}) {
+	ticker := time.NewTicker(t.PingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(t.PongTimeout)); err != nil {
+				stream.fail(err)
+				conn.Close()
+				return
+			}
+		}
 	}
-	return conn, err
 }
 
 func (t *WsTransport) disconnectWebsocket() {
+	t.connMu.Lock()
+	defer t.connMu.Unlock()
 	if t.conn != nil {
+		close(t.done)
 		_ = t.conn.Close()
 		t.conn = nil
+		t.stream = nil
 	}
 }
 
+// current returns the websocket and its stream, or ErrTransportClosed after Close.
+func (t *WsTransport) current() (*websocket.Conn, *wsStream, error) {
+	t.connMu.Lock()
+	defer t.connMu.Unlock()
+	if t.closed {
+		return nil, nil, ErrTransportClosed
+	}
+	return t.conn, t.stream, nil
+}
+
+// Close closes the websocket, failing the round trip in progress, and makes later round trips fail with ErrTransportClosed.
+func (t *WsTransport) Close() error {
+	t.connMu.Lock()
+	t.closed = true
+	t.connMu.Unlock()
+	t.disconnectWebsocket()
+	return nil
+}
+
 // RoundTrip makes a low level text exchange over websocket. This is supposed to be used by high level round tripper
+//
+// The response is parsed from the websocket stream as it arrives and is complete as soon as the body delimited by its
+// Content-Length or chunked encoding has been received. A body without either ends with the websocket connection, or
+// with a SOAP envelope or HTML document, after which the connection is not reused. When Auth implements TunnelAuth, a
+// request AMT challenges is sent once more with the credentials.
 func (t *WsTransport) RoundTrip(r *http.Request) (resp *http.Response, err error) {
 	// Sanity check
-	if t.wsurl == "" || t.protocol == 0 || t.host == "" || t.username == "" || t.password == "" || t.port == 0 {
+	if t.wsurl == "" || t.protocol == 0 || t.host == "" || t.port == 0 || t.Auth == nil {
 		return nil, errors.New("invalid transport data")
 	}
-	// Check if we had already established websocket for this transport object, if not create
-	if t.conn == nil || t.conn.UnderlyingConn() == nil {
-		_, err := t.connectWebsocket()
-		if err != nil {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	// be careful when working with request Body.. make a copy
+	buf := []byte{}
+	if r.Body != nil {
+		if buf, err = io.ReadAll(r.Body); err != nil {
 			return nil, err
 		}
 	}
-	// t.conn should be established
-	// be careful when working with request Body.. make a copy
-	buf, _ := io.ReadAll(r.Body)
-	bd1 := io.NopCloser(bytes.NewBuffer(buf))
-	bd2 := io.NopCloser(bytes.NewBuffer(buf))
-	l, _ := io.Copy(io.Discard, bd1)
-	r.Body = bd2
-	r.Header.Add("Content-Length", strconv.FormatInt(l, 10))
-
-	bytes_to_send, _ := httputil.DumpRequest(r, true)
-	// write and ignore error status, proper error handling is at read go routine
-	_ = t.conn.WriteMessage(websocket.TextMessage, bytes_to_send)
-
-	b := t.timedReadMessage(100)
-	bf := []byte{}
-	bf = append(bf, b[:]...)
-	// this is to check if we finished reading the whole body, otherwise read again
-	// this will try to read data 10 times with 300 ms delay (3 seconds) if previous read yields empty data
-	max_count := 10
-	count := 0
-	for {
-		b = t.timedReadMessage(300)
-		// how many zero read
-		if len(b) == 0 {
-			count = count + 1
-			if count >= max_count {
-				break
-			}
+	r.Header.Set("Content-Length", strconv.Itoa(len(buf)))
+	tunnelAuth, ok := t.Auth.(TunnelAuth)
+	if !ok {
+		r.Body = io.NopCloser(bytes.NewReader(buf))
+		return t.exchange(r)
+	}
+	// the request is sent again with the credentials once AMT challenges it
+	for attempt := 0; ; attempt++ {
+		req := r.Clone(r.Context())
+		req.Body = io.NopCloser(bytes.NewReader(buf))
+		if err := tunnelAuth.AuthorizeRequest(req); err != nil {
+			return nil, err
+		}
+		resp, err := t.exchange(req)
+		if err != nil || resp.StatusCode != http.StatusUnauthorized || attempt > 0 {
+			return resp, err
 		}
-		bf = append(bf, b[:]...)
-		if strings.Index(string(bf), "</a:Envelope>") > 0 {
-			break
+		if retry, err := tunnelAuth.Challenge(resp); err != nil || !retry {
+			return resp, err
 		}
-		if strings.Index(string(bf), "</html>") > 0 {
-			t.disconnectWebsocket()
-			break
+	}
+}
+
+// exchange sends r over the websocket, opening it when needed, and reads the response.
+func (t *WsTransport) exchange(r *http.Request) (*http.Response, error) {
+	conn, stream, err := t.current()
+	if err != nil {
+		return nil, err
+	}
+	// a websocket that failed while idle is replaced before sending
+	if conn == nil || stream.failed() {
+		t.disconnectWebsocket()
+		if err := t.connect(r.Context()); err != nil {
+			return nil, err
+		}
+		if conn, stream, err = t.current(); err != nil {
+			return nil, err
+		}
+	}
+	bytes_to_send, err := httputil.DumpRequest(r, true)
+	if err != nil {
+		return nil, err
+	}
+	if err := conn.WriteMessage(websocket.TextMessage, bytes_to_send); err != nil {
+		t.disconnectWebsocket()
+		return nil, t.closedOr(err)
+	}
+	resp, reusable, err := readResponse(stream, r)
+	if err != nil || !reusable {
+		t.disconnectWebsocket()
+	}
+	if err != nil {
+		return nil, t.closedOr(err)
+	}
+	return resp, nil
+}
+
+// closedOr returns ErrTransportClosed when the transport was closed, err otherwise.
+func (t *WsTransport) closedOr(err error) error {
+	if _, _, closed := t.current(); closed != nil {
+		return closed
+	}
+	return err
+}
+
+// readResponse reads the response to r from the stream, buffering its body. It reports whether the stream is still
+// positioned at the start of the next response.
+func readResponse(stream *wsStream, r *http.Request) (*http.Response, bool, error) {
+	br := bufio.NewReader(stream.reader(r.Context()))
+	resp, err := http.ReadResponse(br, r)
+	if err != nil {
+		return nil, false, err
+	}
+	defer resp.Body.Close()
+	framed := resp.ContentLength >= 0 || len(resp.TransferEncoding) > 0 || resp.Body == http.NoBody
+	var body []byte
+	reusable := framed
+	if framed {
+		body, err = io.ReadAll(resp.Body)
+	} else {
+		body, err = readUnframedBody(resp.Body)
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	if reusable {
+		rest, _ := br.Peek(br.Buffered())
+		stream.unread(rest)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	resp.ContentLength = int64(len(body))
+	resp.TransferEncoding = nil
+	return resp, reusable, nil
+}
+
+// readUnframedBody reads a body without Content-Length or chunked encoding until the end of the stream or of the SOAP
+// envelope or HTML document it carries.
+func readUnframedBody(r io.Reader) ([]byte, error) {
+	body := []byte{}
+	b := make([]byte, 4096)
+	for {
+		n, err := r.Read(b)
+		body = append(body, b[:n]...)
+		if err == io.EOF || err == nil && unframedEnd.Match(body) {
+			return body, nil
+		}
+		if err != nil {
+			return nil, err
 		}
 	}
-	read_buffer := bytes.NewReader(bf)
-	resp, err = http.ReadResponse(bufio.NewReader(read_buffer), r)
-	return resp, err
 }
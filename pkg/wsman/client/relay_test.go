@@ -5,10 +5,15 @@
 package client
 
 import (
+	"context"
 	"crypto/tls"
+	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -263,3 +268,294 @@ func TestNewWsTransportDelay(t *testing.T) {
 	}
 	defer trans.disconnectWebsocket()
 }
+
+// framed_tester answers every request with the websocket messages listed for the path of the relay URL.
+func framed_tester(w http.ResponseWriter, r *http.Request) {
+	envelope := "<s:Envelope><s:Body>ok</s:Body></s:Envelope>"
+	responses := map[string][]string{
+		"/length": {
+			"HTTP/1.1 200 OK\r\nContent-Type: application/soap+xml\r\nContent-Length: " + strconv.Itoa(len(envelope)) + "\r\n\r\n" + envelope[:10],
+			envelope[10:],
+		},
+		"/chunked": {
+			"HTTP/1.1 200 OK\r\nTransfer-Encoding: chunked\r\n\r\n" + strconv.FormatInt(int64(len(envelope[:20])), 16) + "\r\n" + envelope[:20] + "\r\n",
+			strconv.FormatInt(int64(len(envelope[20:])), 16) + "\r\n" + envelope[20:] + "\r\n0\r\n\r\n",
+		},
+		"/prefix": {"HTTP/1.1 200 OK\r\n\r\n" + envelope},
+		"/stall":  {"HTTP/1.1 200 OK\r\nContent-Length: 100\r\n\r\npartial"},
+	}[r.URL.Path]
+	c, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer c.Close()
+	for {
+		_, _, err := c.ReadMessage()
+		if err != nil {
+			return
+		}
+		for _, response := range responses {
+			if err := c.WriteMessage(websocket.TextMessage, []byte(response)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func roundTripFramed(t *testing.T, path string, requests int) (*WsTransport, []string) {
+	s := httptest.NewServer(http.HandlerFunc(framed_tester))
+	t.Cleanup(s.Close)
+	trans := NewWsTransport("ws"+strings.TrimPrefix(s.URL, "http")+path, 1, "9b3ee6a0-c1dc-5546-f7f3-54b2039edfb9", "user", "pass", 16992, false, false, "", tlsconfig)
+	t.Cleanup(trans.disconnectWebsocket)
+	bodies := []string{}
+	for i := 0; i < requests; i++ {
+		req := httptest.NewRequest("POST", "http://localhost/wsman", strings.NewReader("<Envelope/>"))
+		resp, err := trans.RoundTrip(req)
+		if err != nil {
+			t.Fatalf("round trip %d failed: %v", i, err)
+		}
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		bodies = append(bodies, string(body))
+	}
+	return trans, bodies
+}
+
+func TestWsTransportContentLength(t *testing.T) {
+	start := time.Now()
+	trans, bodies := roundTripFramed(t, "/length", 3)
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("round trips took %v", elapsed)
+	}
+	for _, body := range bodies {
+		if body != "<s:Envelope><s:Body>ok</s:Body></s:Envelope>" {
+			t.Errorf("unexpected body %q", body)
+		}
+	}
+	if trans.conn == nil {
+		t.Error("websocket should be reused")
+	}
+}
+
+func TestWsTransportChunked(t *testing.T) {
+	_, bodies := roundTripFramed(t, "/chunked", 2)
+	for _, body := range bodies {
+		if body != "<s:Envelope><s:Body>ok</s:Body></s:Envelope>" {
+			t.Errorf("unexpected body %q", body)
+		}
+	}
+}
+
+func TestWsTransportUnframedEnvelopePrefix(t *testing.T) {
+	_, bodies := roundTripFramed(t, "/prefix", 1)
+	if bodies[0] != "<s:Envelope><s:Body>ok</s:Body></s:Envelope>" {
+		t.Errorf("unexpected body %q", bodies[0])
+	}
+}
+
+func TestWsTransportRequestTimeout(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(framed_tester))
+	defer s.Close()
+	trans := NewWsTransport("ws"+strings.TrimPrefix(s.URL, "http")+"/stall", 1, "9b3ee6a0-c1dc-5546-f7f3-54b2039edfb9", "user", "pass", 16992, false, false, "", tlsconfig)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req := httptest.NewRequest("POST", "http://localhost/wsman", nil).WithContext(ctx)
+	_, err := trans.RoundTrip(req)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the deadline to be exceeded, got %v", err)
+	}
+	if trans.conn != nil {
+		t.Error("websocket with a partial response should be closed")
+	}
+}
+
+// relayServer is a relay that counts websockets, rejects the first upgrade attempts with reject and then behaves as
+// configured.
+type relayServer struct {
+	mu         sync.Mutex
+	attempts   int
+	reject     []int
+	upgrades   int
+	silent     bool // neither answers requests nor reads pings
+	closeAfter bool // closes the websocket after each response
+}
+
+func (s *relayServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.attempts++
+	if len(s.reject) > 0 {
+		status := s.reject[0]
+		s.reject = s.reject[1:]
+		s.mu.Unlock()
+		w.WriteHeader(status)
+		return
+	}
+	s.upgrades++
+	s.mu.Unlock()
+	c, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer c.Close()
+	if s.silent {
+		time.Sleep(time.Second)
+		return
+	}
+	for {
+		if _, _, err := c.ReadMessage(); err != nil {
+			return
+		}
+		if err := c.WriteMessage(websocket.TextMessage, []byte("HTTP/1.1 200 OK\r\nContent-Length: 2\r\n\r\nok")); err != nil {
+			return
+		}
+		if s.closeAfter {
+			c.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+			return
+		}
+	}
+}
+
+func (s *relayServer) counts() (int, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.attempts, s.upgrades
+}
+
+func newRelayTransport(t *testing.T, relay *relayServer) *WsTransport {
+	s := httptest.NewServer(relay)
+	t.Cleanup(s.Close)
+	trans := NewWsTransport("ws"+strings.TrimPrefix(s.URL, "http"), 1, "9b3ee6a0-c1dc-5546-f7f3-54b2039edfb9", "user", "pass", 16992, false, false, "", tlsconfig)
+	trans.ReconnectBackoff = time.Millisecond
+	t.Cleanup(func() { trans.Close() })
+	return trans
+}
+
+func post(trans *WsTransport) error {
+	resp, err := trans.RoundTrip(httptest.NewRequest("POST", "http://localhost/wsman", strings.NewReader("<Envelope/>")))
+	if err != nil {
+		return err
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != "ok" {
+		return errors.New("unexpected body " + string(body))
+	}
+	return nil
+}
+
+func TestWsTransportReconnects(t *testing.T) {
+	relay := &relayServer{closeAfter: true}
+	trans := newRelayTransport(t, relay)
+	for i := 0; i < 3; i++ {
+		if err := post(trans); err != nil {
+			t.Fatalf("round trip %d failed: %v", i, err)
+		}
+		// let the reader see the close before the next round trip
+		time.Sleep(20 * time.Millisecond)
+	}
+	if _, upgrades := relay.counts(); upgrades != 3 {
+		t.Errorf("expected a websocket per round trip, got %d", upgrades)
+	}
+}
+
+func TestWsTransportReconnectBackoff(t *testing.T) {
+	relay := &relayServer{reject: []int{http.StatusServiceUnavailable, http.StatusBadGateway}}
+	trans := newRelayTransport(t, relay)
+	if err := post(trans); err != nil {
+		t.Fatal(err)
+	}
+	if attempts, _ := relay.counts(); attempts != 3 {
+		t.Errorf("expected 3 attempts, got %d", attempts)
+	}
+
+	relay = &relayServer{reject: []int{http.StatusUnauthorized, http.StatusUnauthorized}}
+	trans = newRelayTransport(t, relay)
+	if err := post(trans); err == nil {
+		t.Error("round trip should fail when the relay rejects the credentials")
+	}
+	if attempts, _ := relay.counts(); attempts != 1 {
+		t.Errorf("client errors should not be retried, got %d attempts", attempts)
+	}
+}
+
+func TestWsTransportClose(t *testing.T) {
+	relay := &relayServer{silent: true}
+	trans := newRelayTransport(t, relay)
+	trans.PingInterval = 0
+	result := make(chan error, 1)
+	go func() {
+		result <- post(trans)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	if err := trans.Close(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-result:
+		if !errors.Is(err, ErrTransportClosed) {
+			t.Errorf("in-flight round trip should fail with ErrTransportClosed, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close did not fail the round trip in progress")
+	}
+	if err := post(trans); !errors.Is(err, ErrTransportClosed) {
+		t.Errorf("round trip after Close should fail with ErrTransportClosed, got %v", err)
+	}
+}
+
+func TestWsTransportPingTimeout(t *testing.T) {
+	relay := &relayServer{silent: true}
+	trans := newRelayTransport(t, relay)
+	trans.PingInterval = 20 * time.Millisecond
+	trans.PongTimeout = 20 * time.Millisecond
+	start := time.Now()
+	err := post(trans)
+	if err == nil {
+		t.Fatal("round trip should fail without pongs")
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("dead websocket detected after %v", elapsed)
+	}
+}
+
+func TestWsTransportKeepsAlive(t *testing.T) {
+	relay := &relayServer{}
+	trans := newRelayTransport(t, relay)
+	trans.PingInterval = 10 * time.Millisecond
+	trans.PongTimeout = 50 * time.Millisecond
+	if err := post(trans); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(150 * time.Millisecond)
+	if err := post(trans); err != nil {
+		t.Fatal(err)
+	}
+	if _, upgrades := relay.counts(); upgrades != 1 {
+		t.Errorf("answered pings should keep the websocket open, got %d websockets", upgrades)
+	}
+}
+
+func TestWsTransportConcurrentRoundTrips(t *testing.T) {
+	relay := &relayServer{}
+	trans := newRelayTransport(t, relay)
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- post(trans)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Error(err)
+		}
+	}
+	if _, upgrades := relay.counts(); upgrades != 1 {
+		t.Errorf("round trips should share the websocket, got %d websockets", upgrades)
+	}
+}
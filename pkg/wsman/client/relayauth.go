@@ -0,0 +1,166 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RelayAuth authenticates the websocket of a WsTransport with the relay.
+type RelayAuth interface {
+	// AuthorizeRelay adds the credentials of a websocket handshake to its query and header.
+	AuthorizeRelay(ctx context.Context, query url.Values, header http.Header) error
+}
+
+// TunnelAuth is implemented by a RelayAuth that also authenticates the HTTP requests sent through the websocket, so
+// that AMT checks the credentials instead of the relay.
+type TunnelAuth interface {
+	// AuthorizeRequest sets the credentials of r before it is sent.
+	AuthorizeRequest(r *http.Request) error
+	// Challenge takes the challenge of a 401 response to a request and reports whether the request should be sent again.
+	Challenge(resp *http.Response) (bool, error)
+}
+
+// QueryCredentials passes the AMT credentials to the relay in the user and pass parameters of the websocket URL, which
+// is what the deprecated NewWsTransport configures. Proxies and the access logs of the relay can record them, prefer
+// another RelayAuth when the relay supports it.
+type QueryCredentials struct {
+	Username string
+	Password string
+}
+
+// AuthorizeRelay implements RelayAuth.
+func (c QueryCredentials) AuthorizeRelay(ctx context.Context, query url.Values, header http.Header) error {
+	if c.Username == "" || c.Password == "" {
+		return errors.New("relay credentials are missing")
+	}
+	query.Set("user", c.Username)
+	query.Set("pass", c.Password)
+	return nil
+}
+
+// BearerToken authenticates with the relay by a token in the Authorization header of the websocket handshake.
+type BearerToken struct {
+	Token string
+}
+
+// AuthorizeRelay implements RelayAuth.
+func (b BearerToken) AuthorizeRelay(ctx context.Context, query url.Values, header http.Header) error {
+	if b.Token == "" {
+		return errors.New("relay token is missing")
+	}
+	header.Set("Authorization", "Bearer "+b.Token)
+	return nil
+}
+
+// Token is a bearer token obtained by a TokenExchange.
+type Token struct {
+	Value string
+	// Expiry is the time the token expires, the token never expires when it is zero.
+	Expiry time.Time
+}
+
+// TokenExchange authenticates with the relay by a bearer token obtained from Exchange, which is called again when the
+// token expires within RefreshBefore.
+type TokenExchange struct {
+	Exchange      func(ctx context.Context) (Token, error)
+	RefreshBefore time.Duration
+
+	mu    sync.Mutex
+	token Token
+	now   func() time.Time
+}
+
+// NewTokenExchange returns a TokenExchange obtaining its tokens from exchange.
+func NewTokenExchange(exchange func(ctx context.Context) (Token, error), refreshBefore time.Duration) *TokenExchange {
+	return &TokenExchange{Exchange: exchange, RefreshBefore: refreshBefore}
+}
+
+// AuthorizeRelay implements RelayAuth.
+func (e *TokenExchange) AuthorizeRelay(ctx context.Context, query url.Values, header http.Header) error {
+	token, err := e.current(ctx)
+	if err != nil {
+		return err
+	}
+	header.Set("Authorization", "Bearer "+token.Value)
+	return nil
+}
+
+// current returns the cached token, exchanging a new one when there is none or it is about to expire.
+func (e *TokenExchange) current(ctx context.Context) (Token, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	now := time.Now
+	if e.now != nil {
+		now = e.now
+	}
+	if e.token.Value != "" && (e.token.Expiry.IsZero() || now().Add(e.RefreshBefore).Before(e.token.Expiry)) {
+		return e.token, nil
+	}
+	token, err := e.Exchange(ctx)
+	if err != nil {
+		return Token{}, fmt.Errorf("relay token exchange failed: %w", err)
+	}
+	if token.Value == "" {
+		return Token{}, errors.New("relay token exchange returned no token")
+	}
+	e.token = token
+	return token, nil
+}
+
+// TunnelDigest passes no credentials to the relay and answers the digest challenges of AMT to the requests sent
+// through the websocket, so the relay never sees the AMT credentials. It suits relays that forward the traffic of
+// unauthenticated websockets, or that authenticate them by the token of NewWsTransport.
+type TunnelDigest struct {
+	mu        sync.Mutex
+	challenge *authChallenge
+}
+
+// NewTunnelDigest returns a TunnelDigest authenticating with AMT as username.
+func NewTunnelDigest(username, password string) *TunnelDigest {
+	return &TunnelDigest{challenge: &authChallenge{Username: username, Password: password}}
+}
+
+// AuthorizeRelay implements RelayAuth, it adds nothing to the handshake.
+func (d *TunnelDigest) AuthorizeRelay(ctx context.Context, query url.Values, header http.Header) error {
+	return nil
+}
+
+// AuthorizeRequest implements TunnelAuth, it sets the Authorization header once a challenge was received.
+func (d *TunnelDigest) AuthorizeRequest(r *http.Request) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.challenge.Realm == "" {
+		return nil
+	}
+	auth, err := d.challenge.authorize(r.Method, r.URL.RequestURI())
+	if err != nil {
+		return fmt.Errorf("failed digest auth %v", err)
+	}
+	r.Header.Set("Authorization", auth)
+	return nil
+}
+
+// Challenge implements TunnelAuth, it takes a digest challenge from the WWW-Authenticate header of resp.
+func (d *TunnelDigest) Challenge(resp *http.Response) (bool, error) {
+	header := resp.Header.Get("WWW-Authenticate")
+	if !strings.HasPrefix(strings.TrimSpace(header), "Digest ") {
+		return false, nil
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := d.challenge.parseChallenge(header); err != nil {
+		return false, err
+	}
+	d.challenge.NonceCount = 0
+	return true, nil
+}
@@ -0,0 +1,195 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// authRelay records the websocket handshakes and answers the tunneled requests like AMT using digest authentication.
+type authRelay struct {
+	mu         sync.Mutex
+	handshakes []*http.Request
+	tunneled   []string
+}
+
+func (s *authRelay) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.handshakes = append(s.handshakes, r)
+	s.mu.Unlock()
+	c, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer c.Close()
+	for {
+		_, message, err := c.ReadMessage()
+		if err != nil {
+			return
+		}
+		req, err := http.ReadRequest(bufio.NewReader(bytes.NewReader(message)))
+		if err != nil {
+			return
+		}
+		auth := req.Header.Get("Authorization")
+		s.mu.Lock()
+		s.tunneled = append(s.tunneled, auth)
+		s.mu.Unlock()
+		response := "HTTP/1.1 200 OK\r\nContent-Length: 2\r\n\r\nok"
+		if !strings.HasPrefix(auth, `Digest username="admin"`) {
+			response = "HTTP/1.1 401 Unauthorized\r\nWWW-Authenticate: Digest realm=\"Digest:AMT\", nonce=\"abc\", qop=\"auth\"\r\nContent-Length: 0\r\n\r\n"
+		}
+		if err := c.WriteMessage(websocket.TextMessage, []byte(response)); err != nil {
+			return
+		}
+	}
+}
+
+func newAuthTransport(t *testing.T, relay *authRelay, auth RelayAuth) *WsTransport {
+	s := httptest.NewServer(relay)
+	t.Cleanup(s.Close)
+	trans := NewWsTransport("ws"+strings.TrimPrefix(s.URL, "http"), 1, "9b3ee6a0-c1dc-5546-f7f3-54b2039edfb9", "admin", "P@ssw0rd", 16992, false, false, "", tlsconfig)
+	if auth != nil {
+		trans.Auth = auth
+	}
+	t.Cleanup(func() { trans.Close() })
+	return trans
+}
+
+func authPost(trans *WsTransport) (*http.Response, error) {
+	return trans.RoundTrip(httptest.NewRequest("POST", "http://localhost/wsman", strings.NewReader("<Envelope/>")))
+}
+
+func TestQueryCredentials(t *testing.T) {
+	relay := &authRelay{}
+	trans := newAuthTransport(t, relay, nil)
+	if _, err := authPost(trans); err != nil {
+		t.Fatal(err)
+	}
+	q := relay.handshakes[0].URL.Query()
+	if q.Get("user") != "admin" || q.Get("pass") != "P@ssw0rd" {
+		t.Errorf("expected the credentials in the query, got %v", q)
+	}
+}
+
+func TestBearerToken(t *testing.T) {
+	relay := &authRelay{}
+	trans := newAuthTransport(t, relay, BearerToken{Token: "secret"})
+	if _, err := authPost(trans); err != nil {
+		t.Fatal(err)
+	}
+	handshake := relay.handshakes[0]
+	if got := handshake.Header.Get("Authorization"); got != "Bearer secret" {
+		t.Errorf("unexpected Authorization header %q", got)
+	}
+	if strings.Contains(handshake.URL.RawQuery, "P@ssw0rd") || handshake.URL.Query().Has("user") {
+		t.Errorf("credentials leaked into the query %q", handshake.URL.RawQuery)
+	}
+	if err := (BearerToken{}).AuthorizeRelay(context.Background(), url.Values{}, http.Header{}); err == nil {
+		t.Error("an empty token should be rejected")
+	}
+}
+
+func TestNewWsTransportWithAuth(t *testing.T) {
+	relay := &authRelay{}
+	s := httptest.NewServer(relay)
+	t.Cleanup(s.Close)
+	trans := NewWsTransportWithAuth("ws"+strings.TrimPrefix(s.URL, "http"), 1, "9b3ee6a0-c1dc-5546-f7f3-54b2039edfb9", 16992, false, false, "", tlsconfig, BearerToken{Token: "secret"})
+	t.Cleanup(func() { trans.Close() })
+	if _, err := authPost(trans); err != nil {
+		t.Fatal(err)
+	}
+	q := relay.handshakes[0].URL.Query()
+	if q.Has("user") || q.Has("pass") {
+		t.Errorf("credentials leaked into the query %v", q)
+	}
+
+	trans = NewWsTransportWithAuth("ws"+strings.TrimPrefix(s.URL, "http"), 1, "9b3ee6a0-c1dc-5546-f7f3-54b2039edfb9", 16992, false, false, "", tlsconfig, nil)
+	if _, err := authPost(trans); err == nil {
+		t.Error("a transport without auth should not fall back to the query credentials")
+	}
+}
+
+func TestTokenExchange(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	exchanges := 0
+	exchange := NewTokenExchange(func(ctx context.Context) (Token, error) {
+		exchanges++
+		return Token{Value: "token" + strconv.Itoa(exchanges), Expiry: now.Add(time.Minute)}, nil
+	}, 10*time.Second)
+	exchange.now = func() time.Time { return now }
+
+	header := http.Header{}
+	for i := 0; i < 2; i++ {
+		if err := exchange.AuthorizeRelay(context.Background(), url.Values{}, header); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if exchanges != 1 || header.Get("Authorization") != "Bearer token1" {
+		t.Errorf("the token should be reused before it expires, %d exchanges, header %q", exchanges, header.Get("Authorization"))
+	}
+	// within RefreshBefore of the expiry the token is refreshed
+	now = now.Add(55 * time.Second)
+	if err := exchange.AuthorizeRelay(context.Background(), url.Values{}, header); err != nil {
+		t.Fatal(err)
+	}
+	if exchanges != 2 || header.Get("Authorization") != "Bearer token2" {
+		t.Errorf("the token should be refreshed before it expires, %d exchanges, header %q", exchanges, header.Get("Authorization"))
+	}
+}
+
+func TestTokenExchangeFailure(t *testing.T) {
+	relay := &authRelay{}
+	failure := errors.New("identity provider unavailable")
+	trans := newAuthTransport(t, relay, NewTokenExchange(func(ctx context.Context) (Token, error) {
+		return Token{}, failure
+	}, 0))
+	if _, err := authPost(trans); !errors.Is(err, failure) {
+		t.Errorf("expected the exchange error, got %v", err)
+	}
+	if len(relay.handshakes) != 0 {
+		t.Error("no handshake should be attempted without a token")
+	}
+}
+
+func TestTunnelDigest(t *testing.T) {
+	relay := &authRelay{}
+	trans := newAuthTransport(t, relay, NewTunnelDigest("admin", "P@ssw0rd"))
+	for i := 0; i < 2; i++ {
+		resp, err := authPost(trans)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != http.StatusOK || string(body) != "ok" {
+			t.Fatalf("unexpected response %d %q", resp.StatusCode, body)
+		}
+	}
+	handshake := relay.handshakes[0]
+	if handshake.URL.Query().Has("user") || handshake.URL.Query().Has("pass") || handshake.Header.Get("Authorization") != "" {
+		t.Errorf("credentials passed to the relay: %v", handshake.URL.Query())
+	}
+	// the first request is challenged, the later ones are authorized up front
+	if len(relay.tunneled) != 3 || relay.tunneled[0] != "" || relay.tunneled[1] == "" || relay.tunneled[2] == "" {
+		t.Errorf("unexpected tunneled authorizations %q", relay.tunneled)
+	}
+	if !strings.Contains(relay.tunneled[2], `nc="00000002"`) {
+		t.Errorf("the nonce count should increase, got %q", relay.tunneled[2])
+	}
+}
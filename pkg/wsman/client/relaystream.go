@@ -0,0 +1,101 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Protocols of the relay, the p parameter of its URL.
+const (
+	RelayProtocolWSMan       = 1 // HTTP requests to the WS-Man port, see WsTransport.RoundTrip
+	RelayProtocolRedirection = 2 // the byte stream of a redirection session, see WsTransport.OpenStream
+)
+
+// Ports of the AMT redirection service, used by SOL, IDE-R and KVM sessions.
+const (
+	RedirectionPort    = 16994
+	RedirectionTLSPort = 16995
+)
+
+// RelayStream is a bidirectional byte stream to AMT through the relay. Every Write is sent as a binary websocket message
+// and Read returns the data of the messages received in order, regardless of how they were split.
+type RelayStream struct {
+	conn   *websocket.Conn
+	stream *wsStream
+	reader io.Reader
+	done   chan struct{}
+
+	writeMu   sync.Mutex
+	closeOnce sync.Once
+}
+
+// OpenStream opens a websocket through the relay to the AMT port using protocol, for example RelayProtocolRedirection
+// and RedirectionPort, with the same relay URL, TLS settings and Auth as the round trips of t. The redirection session
+// itself, including its own authentication with AMT, is up to the caller. The stream is independent of the round trips
+// and of Close, it lives until it is closed or the websocket fails.
+func (t *WsTransport) OpenStream(ctx context.Context, protocol, port int) (*RelayStream, error) {
+	if t.wsurl == "" || t.host == "" || protocol == 0 || port == 0 || t.Auth == nil {
+		return nil, errors.New("invalid transport data")
+	}
+	conn, err := t.dial(ctx, protocol, port)
+	if err != nil {
+		return nil, err
+	}
+	s := &RelayStream{
+		conn:   conn,
+		stream: newWsStream(),
+		done:   make(chan struct{}),
+	}
+	s.reader = s.stream.reader(context.Background())
+	go t.read(conn, s.stream)
+	if t.PingInterval > 0 {
+		go t.ping(conn, s.stream, s.done)
+	}
+	return s, nil
+}
+
+// Read reads the data received from AMT. It returns io.EOF once the relay closed the websocket and the data received
+// before was read.
+func (s *RelayStream) Read(b []byte) (int, error) {
+	return s.reader.Read(b)
+}
+
+// Write sends b to AMT in a single binary message.
+func (s *RelayStream) Write(b []byte) (int, error) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	select {
+	case <-s.done:
+		return 0, net.ErrClosed
+	default:
+	}
+	if err := s.conn.WriteMessage(websocket.BinaryMessage, b); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// Close closes the websocket, after which Read and Write fail with net.ErrClosed.
+func (s *RelayStream) Close() error {
+	err := net.ErrClosed
+	s.closeOnce.Do(func() {
+		close(s.done)
+		s.stream.discard(net.ErrClosed)
+		// The close frame is best-effort and does not take writeMu: a Write stalled on the relay would hold it forever.
+		// WriteControl may run concurrently with a Write, and its deadline bounds the wait for the stalled one, which
+		// fails once the websocket is closed.
+		_ = s.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+		err = s.conn.Close()
+	})
+	return err
+}
@@ -0,0 +1,173 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// redirection_tester echoes binary messages in upper case until it receives "bye", then sends a last message and
+// closes the websocket.
+func redirection_tester(queries chan url.Values) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		queries <- r.URL.Query()
+		c, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer c.Close()
+		for {
+			mt, message, err := c.ReadMessage()
+			if err != nil {
+				return
+			}
+			if mt != websocket.BinaryMessage {
+				_ = c.WriteMessage(websocket.TextMessage, []byte("expected binary message"))
+				return
+			}
+			if string(message) == "bye" {
+				_ = c.WriteMessage(websocket.BinaryMessage, []byte("BYE"))
+				_ = c.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+				return
+			}
+			_ = c.WriteMessage(websocket.BinaryMessage, []byte(strings.ToUpper(string(message))))
+		}
+	}
+}
+
+func openRedirection(t *testing.T) (*RelayStream, chan url.Values) {
+	queries := make(chan url.Values, 1)
+	s := httptest.NewServer(redirection_tester(queries))
+	t.Cleanup(s.Close)
+	trans := NewWsTransport("ws"+strings.TrimPrefix(s.URL, "http"), RelayProtocolWSMan, "9b3ee6a0-c1dc-5546-f7f3-54b2039edfb9", "", "", 16992, false, false, "token", tlsconfig)
+	trans.Auth = BearerToken{Token: "secret"}
+	stream, err := trans.OpenStream(context.Background(), RelayProtocolRedirection, RedirectionPort)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { stream.Close() })
+	return stream, queries
+}
+
+func TestRelayStream(t *testing.T) {
+	stream, queries := openRedirection(t)
+	q := <-queries
+	if q.Get("p") != "2" || q.Get("port") != "16994" {
+		t.Errorf("unexpected relay query %v", q)
+	}
+	for _, m := range []string{"sol", "ider"} {
+		if _, err := stream.Write([]byte(m)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the messages are read as one stream
+	b := make([]byte, 7)
+	if _, err := io.ReadFull(stream, b); err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "SOLIDER" {
+		t.Errorf("unexpected data %q", b)
+	}
+	if _, err := stream.Write([]byte("bye")); err != nil {
+		t.Fatal(err)
+	}
+	rest, err := io.ReadAll(stream)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(rest) != "BYE" {
+		t.Errorf("the data before the relay closed should be read, got %q", rest)
+	}
+}
+
+func TestRelayStreamClose(t *testing.T) {
+	stream, _ := openRedirection(t)
+	if err := stream.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stream.Read(make([]byte, 1)); !errors.Is(err, net.ErrClosed) {
+		t.Errorf("Read after Close should fail with net.ErrClosed, got %v", err)
+	}
+	if _, err := stream.Write([]byte("sol")); !errors.Is(err, net.ErrClosed) {
+		t.Errorf("Write after Close should fail with net.ErrClosed, got %v", err)
+	}
+	if err := stream.Close(); !errors.Is(err, net.ErrClosed) {
+		t.Errorf("second Close should fail with net.ErrClosed, got %v", err)
+	}
+}
+
+func TestRelayStreamCloseStalledWrite(t *testing.T) {
+	// the relay accepts the websocket but never reads, so the writes stall once the buffers are full
+	release := make(chan struct{})
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer c.Close()
+		<-release
+	}))
+	t.Cleanup(s.Close)
+	t.Cleanup(func() { close(release) })
+	trans := NewWsTransportWithAuth("ws"+strings.TrimPrefix(s.URL, "http"), RelayProtocolWSMan, "9b3ee6a0-c1dc-5546-f7f3-54b2039edfb9", 16992, false, false, "", tlsconfig, BearerToken{Token: "secret"})
+	trans.PingInterval = 0
+	stream, err := trans.OpenStream(context.Background(), RelayProtocolRedirection, RedirectionPort)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	written := make(chan error, 1)
+	go func() {
+		b := make([]byte, 1<<20)
+		for {
+			if _, err := stream.Write(b); err != nil {
+				written <- err
+				return
+			}
+		}
+	}()
+	select {
+	case err := <-written:
+		t.Fatalf("the writes should stall, got %v", err)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	closed := make(chan error, 1)
+	go func() { closed <- stream.Close() }()
+	select {
+	case err := <-closed:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close blocked on the stalled Write")
+	}
+	select {
+	case err := <-written:
+		if err == nil {
+			t.Error("the stalled Write should fail once the stream is closed")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the stalled Write did not return after Close")
+	}
+}
+
+func TestRelayStreamInvalid(t *testing.T) {
+	trans := NewWsTransport("ws://localhost/relay", RelayProtocolWSMan, "", "user", "pass", 16992, false, false, "", tlsconfig)
+	if _, err := trans.OpenStream(context.Background(), RelayProtocolRedirection, RedirectionPort); err == nil {
+		t.Error("OpenStream should fail without a host")
+	}
+}
@@ -9,4 +9,10 @@ type Parameters struct {
 	UseTLS            bool
 	SelfSignedAllowed bool
 	LogAMTMessages    bool
+	// SkipRequestValidation disables the Validate() check performed on request inputs before they are sent.
+	SkipRequestValidation bool
+	// StrictDecoding makes responses fail to decode when their action, namespace or properties do not match the expected class.
+	StrictDecoding bool
+	// StreamResponses decodes responses directly from the HTTP body instead of keeping a copy in Message.XMLOutput.
+	StreamResponses bool
 }
@@ -13,6 +13,7 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -27,6 +28,19 @@ const NonTLSPort = "16992"
 type Message struct {
 	XMLInput  string
 	XMLOutput string
+	body      io.ReadCloser
+}
+
+// SetBody stores a response body that is decoded as it is read, in place of XMLOutput.
+func (m *Message) SetBody(body io.ReadCloser) {
+	m.body = body
+}
+
+// TakeBody returns the response body stored by SetBody, if any, and removes it from the message. The caller must close it.
+func (m *Message) TakeBody() io.ReadCloser {
+	body := m.body
+	m.body = nil
+	return body
 }
 
 // WSMan is an interface for the wsman.Client.
@@ -34,6 +48,34 @@ type WSMan interface {
 	Post(msg string) (response []byte, err error)
 }
 
+// RequestValidator is implemented by clients that control whether request inputs are validated before they are sent.
+// Clients that do not implement it always have their requests validated.
+type RequestValidator interface {
+	ValidateRequests() bool
+}
+
+// StrictDecoder is implemented by clients that control whether responses are decoded in strict mode.
+// Clients that do not implement it have their responses decoded leniently.
+type StrictDecoder interface {
+	StrictDecoding() bool
+}
+
+// Streamer is implemented by clients that can return the response body as it is received instead of buffering it.
+type Streamer interface {
+	StreamResponses() bool
+	PostStream(msg string) (io.ReadCloser, error)
+}
+
+// DecodeError is returned in strict decoding mode when a response does not match the message that was expected.
+type DecodeError struct {
+	Action        string   // Action of the request the response belongs to.
+	Discrepancies []string // Every difference found between the response and the expected message.
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("unexpected response to %s: %s", e.Action, strings.Join(e.Discrepancies, "; "))
+}
+
 // Target is a thin wrapper around http.Target.
 type Target struct {
 	http.Client
@@ -44,6 +86,9 @@ type Target struct {
 	OptimizeEnum   bool
 	logAMTMessages bool
 	challenge      *authChallenge
+	skipValidation bool
+	strictDecoding bool
+	stream         bool
 }
 
 func NewWsman(cp Parameters) *Target {
@@ -62,6 +107,9 @@ func NewWsman(cp Parameters) *Target {
 		password:       cp.Password,
 		useDigest:      cp.UseDigest,
 		logAMTMessages: cp.LogAMTMessages,
+		skipValidation: cp.SkipRequestValidation,
+		strictDecoding: cp.StrictDecoding,
+		stream:         cp.StreamResponses,
 	}
 
 	res.Timeout = 10 * time.Second
@@ -76,6 +124,43 @@ func NewWsman(cp Parameters) *Target {
 
 // Post overrides http.Client's Post method
 func (c *Target) Post(msg string) (response []byte, err error) {
+	res, err := c.send(msg)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	response, err = io.ReadAll(res.Body)
+	if c.logAMTMessages {
+		logrus.Trace(string(response))
+	}
+
+	if err != nil && err.Error() != io.EOF.Error() {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// PostStream sends msg like Post but returns the response body without reading it. The caller must close the body.
+// When AMT messages are logged the body is read first so it can be logged.
+func (c *Target) PostStream(msg string) (io.ReadCloser, error) {
+	if c.logAMTMessages {
+		response, err := c.Post(msg)
+		if err != nil {
+			return nil, err
+		}
+		return io.NopCloser(bytes.NewReader(response)), nil
+	}
+	res, err := c.send(msg)
+	if err != nil {
+		return nil, err
+	}
+	return res.Body, nil
+}
+
+// send posts msg, answering a digest challenge when needed, and returns the successful response with its body unread.
+func (c *Target) send(msg string) (*http.Response, error) {
 	msgBody := []byte(msg)
 	bodyReader := bytes.NewReader(msgBody)
 	req, err := http.NewRequest("POST", c.endpoint, bodyReader)
@@ -107,6 +192,7 @@ func (c *Target) Post(msg string) (response []byte, err error) {
 		return nil, err
 	}
 	if c.useDigest && res.StatusCode == 401 {
+		res.Body.Close()
 
 		if err := c.challenge.parseChallenge(res.Header.Get("WWW-Authenticate")); err != nil {
 			return nil, err
@@ -128,9 +214,8 @@ func (c *Target) Post(msg string) (response []byte, err error) {
 		}
 	}
 
-	defer res.Body.Close()
-
 	if res.StatusCode >= 400 {
+		defer res.Body.Close()
 		b, _ := io.ReadAll(res.Body)
 		if c.logAMTMessages {
 			logrus.Trace(string(b))
@@ -138,16 +223,22 @@ func (c *Target) Post(msg string) (response []byte, err error) {
 		return nil, fmt.Errorf("wsman.Client: post received %v\n'%v'", res.Status, string(b))
 	}
 
-	response, err = io.ReadAll(res.Body)
-	if c.logAMTMessages {
-		logrus.Trace(string(response))
-	}
+	return res, nil
+}
 
-	if err != nil && err.Error() != io.EOF.Error() {
-		return nil, err
-	}
+// ValidateRequests reports whether request inputs are validated before being sent
+func (c *Target) ValidateRequests() bool {
+	return !c.skipValidation
+}
 
-	return response, nil
+// StreamResponses reports whether responses are decoded from the HTTP body as it is received
+func (c *Target) StreamResponses() bool {
+	return c.stream
+}
+
+// StrictDecoding reports whether responses are decoded in strict mode
+func (c *Target) StrictDecoding() bool {
+	return c.strictDecoding
 }
 
 // ProxyUrl sets proxy address for the underlying Transport if supported
@@ -6,6 +6,7 @@
 package client
 
 import (
+	"io"
 	"strings"
 	"testing"
 
@@ -374,3 +375,51 @@ func TestClient_SimpleRountripper(t *testing.T) {
 		t.Error("Failed to detect proper transport")
 	}
 }
+
+func TestClient_PostStream(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", ContentType)
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("<SampleResponse>OK</SampleResponse>"))
+	}))
+	defer ts.Close()
+
+	client := NewWsman(Parameters{Target: ts.URL, StreamResponses: true})
+	client.endpoint = ts.URL
+	if !client.StreamResponses() {
+		t.Errorf("Expected StreamResponses to be true")
+	}
+
+	body, err := client.PostStream("<SampleRequest>Request</SampleRequest>")
+	if err != nil {
+		t.Fatalf("Unexpected error during POST: %v", err)
+	}
+	defer body.Close()
+	response, err := io.ReadAll(body)
+	if err != nil {
+		t.Errorf("Unexpected error reading body: %v", err)
+	}
+
+	expectedResponse := "<SampleResponse>OK</SampleResponse>"
+	if string(response) != expectedResponse {
+		t.Errorf("Expected response to be %s, but got %s", expectedResponse, response)
+	}
+}
+
+func TestClient_PostStreamUnauthorized(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer ts.Close()
+
+	client := NewWsman(Parameters{Target: ts.URL, Username: "user", Password: "password"})
+	client.endpoint = ts.URL
+
+	body, err := client.PostStream("<SampleRequest>Request</SampleRequest>")
+	if body != nil {
+		t.Errorf("Expected no body for an unauthorized response")
+	}
+	if err == nil || !strings.Contains(err.Error(), "401 Unauthorized") {
+		t.Errorf("Expected 401 error, but got %v", err)
+	}
+}
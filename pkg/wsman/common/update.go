@@ -0,0 +1,67 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package common
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+)
+
+// ErrConcurrentModification is returned by Update when concurrent modification detection is enabled and the instance changed between the initial Get and the Put.
+var ErrConcurrentModification = errors.New("instance was modified since it was read")
+
+// UpdateOptions configures a read-modify-write Update.
+type UpdateOptions struct {
+	DetectConcurrentModification bool // Read the instance again before the Put and abort with ErrConcurrentModification when it differs from the initial read.
+}
+
+// UpdateOption sets a field of UpdateOptions.
+type UpdateOption func(*UpdateOptions)
+
+// WithConcurrentModificationDetection enables the check for changes made by another client between the Get and the Put of an Update.
+func WithConcurrentModificationDetection() UpdateOption {
+	return func(o *UpdateOptions) {
+		o.DetectConcurrentModification = true
+	}
+}
+
+// Update reads the current state with get, applies mutate to it and writes the result with put.
+//
+// When concurrent modification detection is enabled the instance is read again just before the Put and compared with the initial read.
+// The comparison is made on the converted request, so only properties that can be written with put are considered.
+func Update[Request any](get func() (Request, error), put func(Request) error, mutate func(*Request), opts ...UpdateOption) error {
+	options := UpdateOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	request, err := get()
+	if err != nil {
+		return err
+	}
+	var original []byte
+	if options.DetectConcurrentModification {
+		// snapshot before mutate, which may change slice elements shared with request
+		if original, err = xml.Marshal(request); err != nil {
+			return err
+		}
+	}
+	mutate(&request)
+	if options.DetectConcurrentModification {
+		current, err := get()
+		if err != nil {
+			return err
+		}
+		snapshot, err := xml.Marshal(current)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(original, snapshot) {
+			return ErrConcurrentModification
+		}
+	}
+	return put(request)
+}
@@ -0,0 +1,63 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package common
+
+import (
+	"encoding/xml"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testRequest struct {
+	XMLName xml.Name `xml:"h:Test"`
+	Name    string   `xml:"h:Name"`
+	Values  []int    `xml:"h:Values"`
+}
+
+func TestUpdate(t *testing.T) {
+	t.Run("puts the mutated request", func(t *testing.T) {
+		var written testRequest
+		get := func() (testRequest, error) { return testRequest{Name: "before", Values: []int{1}}, nil }
+		put := func(r testRequest) error { written = r; return nil }
+		err := Update(get, put, func(r *testRequest) { r.Name = "after" })
+		assert.NoError(t, err)
+		assert.Equal(t, testRequest{Name: "after", Values: []int{1}}, written)
+	})
+
+	t.Run("returns the get error without a put", func(t *testing.T) {
+		get := func() (testRequest, error) { return testRequest{}, errors.New("get failed") }
+		put := func(r testRequest) error { t.Fatal("unexpected put"); return nil }
+		err := Update(get, put, func(r *testRequest) {})
+		assert.EqualError(t, err, "get failed")
+	})
+
+	t.Run("ignores changes between get and put without detection", func(t *testing.T) {
+		reads := 0
+		get := func() (testRequest, error) { reads++; return testRequest{Values: []int{reads}}, nil }
+		err := Update(get, func(r testRequest) error { return nil }, func(r *testRequest) {})
+		assert.NoError(t, err)
+		assert.Equal(t, 1, reads)
+	})
+
+	t.Run("detects changes between get and put", func(t *testing.T) {
+		reads := 0
+		get := func() (testRequest, error) { reads++; return testRequest{Values: []int{reads}}, nil }
+		put := func(r testRequest) error { t.Fatal("unexpected put"); return nil }
+		err := Update(get, put, func(r *testRequest) {}, WithConcurrentModificationDetection())
+		assert.ErrorIs(t, err, ErrConcurrentModification)
+	})
+
+	t.Run("compares against the state before mutate", func(t *testing.T) {
+		values := []int{1, 2}
+		get := func() (testRequest, error) { return testRequest{Values: values}, nil }
+		put := func(r testRequest) error { return nil }
+		// mutate writes through the slice shared with get, which must not be mistaken for a concurrent change
+		err := Update(get, put, func(r *testRequest) { r.Values = append([]int(nil), r.Values...); r.Values[0] = 5 }, WithConcurrentModificationDetection())
+		assert.NoError(t, err)
+	})
+}
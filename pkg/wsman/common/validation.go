@@ -0,0 +1,124 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package common
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// FieldViolation describes a single field of a request that failed validation.
+type FieldViolation struct {
+	Field  string // Path of the offending field, for example "WiFiEndpointSettings.PSKPassPhrase" or "TrustedCN[1]".
+	Reason string // Human readable description of the violation.
+}
+
+// ValidationError is returned when a request is rejected before it is sent to Intel® AMT. It reports every violation found rather than only the first one.
+type ValidationError struct {
+	Request    string
+	Violations []FieldViolation
+}
+
+// NewValidationError returns an empty ValidationError for the named request type.
+func NewValidationError(request string) *ValidationError {
+	return &ValidationError{Request: request}
+}
+
+// Error implements the error interface.
+func (e *ValidationError) Error() string {
+	reasons := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		reasons = append(reasons, v.Field+": "+v.Reason)
+	}
+	return fmt.Sprintf("invalid %s: %s", e.Request, strings.Join(reasons, "; "))
+}
+
+// Add records a violation for field.
+func (e *ValidationError) Add(field, format string, args ...interface{}) {
+	e.Violations = append(e.Violations, FieldViolation{Field: field, Reason: fmt.Sprintf(format, args...)})
+}
+
+// Merge adds the violations of a nested request, prefixing each field with prefix. Errors that are not a *ValidationError are recorded against prefix itself.
+func (e *ValidationError) Merge(prefix string, err error) {
+	if err == nil {
+		return
+	}
+	var nested *ValidationError
+	if !errors.As(err, &nested) {
+		e.Add(prefix, "%s", err.Error())
+		return
+	}
+	for _, v := range nested.Violations {
+		e.Violations = append(e.Violations, FieldViolation{Field: prefix + "." + v.Field, Reason: v.Reason})
+	}
+}
+
+// Index formats the path of the i-th element of an array field.
+func Index(field string, i int) string {
+	return fmt.Sprintf("%s[%d]", field, i)
+}
+
+// Err returns nil when no violations were recorded, otherwise it returns e.
+func (e *ValidationError) Err() error {
+	if len(e.Violations) == 0 {
+		return nil
+	}
+	return e
+}
+
+// Required records a violation when value is empty.
+func (e *ValidationError) Required(field, value string) {
+	if value == "" {
+		e.Add(field, "is required")
+	}
+}
+
+// MaxLength records a violation when value is longer than max bytes.
+func (e *ValidationError) MaxLength(field, value string, max int) {
+	if len(value) > max {
+		e.Add(field, "must be at most %d characters, got %d", max, len(value))
+	}
+}
+
+// Range records a violation when value is outside of [min, max].
+func (e *ValidationError) Range(field string, value, min, max int) {
+	if value < min || value > max {
+		e.Add(field, "must be between %d and %d, got %d", min, max, value)
+	}
+}
+
+// OneOf records a violation when value is not one of allowed.
+func (e *ValidationError) OneOf(field string, value int, allowed ...int) {
+	for _, a := range allowed {
+		if value == a {
+			return
+		}
+	}
+	e.Add(field, "must be one of %v, got %d", allowed, value)
+}
+
+// IPv4 records a violation when a non-empty value is not a dotted decimal IPv4 address.
+func (e *ValidationError) IPv4(field, value string) {
+	if value == "" {
+		return
+	}
+	if ip := net.ParseIP(value); ip == nil || ip.To4() == nil {
+		e.Add(field, "must be an IPv4 address, got %q", value)
+	}
+}
+
+// Base64 records a violation when a non-empty value is not valid standard base64.
+func (e *ValidationError) Base64(field, value string) {
+	if value == "" {
+		return
+	}
+	if _, err := base64.StdEncoding.DecodeString(value); err != nil {
+		e.Add(field, "must be base64 encoded")
+	}
+}
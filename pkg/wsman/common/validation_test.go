@@ -0,0 +1,55 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package common
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidationError(t *testing.T) {
+	t.Run("Err returns nil without violations", func(t *testing.T) {
+		v := NewValidationError("TestRequest")
+		v.Required("Name", "value")
+		v.MaxLength("Name", "value", 5)
+		v.Range("Count", 3, 1, 5)
+		v.OneOf("Mode", 2, 1, 2)
+		v.IPv4("Address", "192.168.0.1")
+		v.Base64("Blob", "dGVzdA==")
+		assert.NoError(t, v.Err())
+	})
+
+	t.Run("reports every violation with its path", func(t *testing.T) {
+		v := NewValidationError("TestRequest")
+		v.Required("Name", "")
+		v.MaxLength(Index("Items", 1), "toolong", 3)
+		v.Range("Count", 9, 1, 5)
+		v.OneOf("Mode", 7, 1, 2)
+		v.IPv4("Address", "not-an-ip")
+		v.Base64("Blob", "%%%")
+		err := v.Err()
+		var validationError *ValidationError
+		assert.True(t, errors.As(err, &validationError))
+		assert.Len(t, validationError.Violations, 6)
+		assert.Equal(t, "Items[1]", validationError.Violations[1].Field)
+		assert.Equal(t, `invalid TestRequest: Name: is required; Items[1]: must be at most 3 characters, got 7; Count: must be between 1 and 5, got 9; Mode: must be one of [1 2], got 7; Address: must be an IPv4 address, got "not-an-ip"; Blob: must be base64 encoded`, err.Error())
+	})
+
+	t.Run("Merge prefixes nested violations", func(t *testing.T) {
+		nested := NewValidationError("Nested")
+		nested.Required("SSID", "")
+		v := NewValidationError("TestRequest")
+		v.Merge("Settings", nested.Err())
+		v.Merge("Other", errors.New("broken"))
+		v.Merge("None", nil)
+		assert.Equal(t, []FieldViolation{
+			{Field: "Settings.SSID", Reason: "is required"},
+			{Field: "Other", Reason: "broken"},
+		}, v.Violations)
+	})
+}
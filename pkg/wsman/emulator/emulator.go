@@ -0,0 +1,294 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+// Package emulator serves the WS-Man endpoint of an AMT device over HTTP, so that the client.Target stack can be
+// tested end to end without hardware: digest challenges, nonces, TLS, retries and error responses.
+//
+// A Handler answers POST /wsman the way AMT does. Requests without valid digest credentials are challenged with a 401,
+// Identify is answered without authentication, and every other request is answered by a pluggable backend, usually a
+// simulator.Simulator. A *simulator.Fault returned by the backend is answered with its status and envelope, any other
+// error drops the connection.
+//
+// NewServer and NewTLSServer start a Handler on a local port like httptest does, the latter with a generated
+// self-signed certificate, and Server.Client returns a client.Target connected to it.
+package emulator
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/internal/envelope"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/client"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/simulator"
+)
+
+const (
+	wsmanPath          = "/wsman"
+	httpDigestProfile  = "http://schemas.dmtf.org/wbem/wsman/1/wsman/secprofile/http/digest"
+	httpsDigestProfile = "http://schemas.dmtf.org/wbem/wsman/1/wsman/secprofile/https/digest"
+	// maxNonces bounds the nonces a handler keeps, like the nonce table of AMT. The oldest ones are dropped first.
+	maxNonces = 256
+)
+
+// Handler is an http.Handler emulating the WS-Man endpoint of AMT. Its fields must not be changed while it serves
+// requests.
+type Handler struct {
+	// Backend answers the authenticated requests.
+	Backend  client.WSMan
+	Username string
+	Password string
+	// Realm is the digest realm of the challenges.
+	Realm string
+	// NonceLifetime is how long a nonce is accepted after it was issued, forever when zero. Requests with an expired
+	// nonce are challenged again with stale="true".
+	NonceLifetime time.Duration
+	// ProductVersion is the AMT version reported by Identify and the Server header.
+	ProductVersion string
+
+	mu         sync.Mutex
+	nonces     map[string]*nonce
+	challenges int
+}
+
+// nonce is a nonce issued in a challenge.
+type nonce struct {
+	issued time.Time
+	// count is the highest nonce count used with the nonce, a request has to use a higher one.
+	count uint64
+	stale bool
+}
+
+// New returns a handler answering from backend the requests authenticated with username and password. The realm is
+// the DigestRealm of AMT_GeneralSettings when backend is a simulator.Simulator that has one, so that it matches what
+// the device reports, and a random realm in the format of AMT otherwise.
+func New(backend client.WSMan, username, password string) *Handler {
+	realm := ""
+	if sim, ok := backend.(*simulator.Simulator); ok {
+		realm = sim.Property("AMT_GeneralSettings", "DigestRealm")
+	}
+	if realm == "" {
+		realm = "Digest:" + strings.ToUpper(randomHex(16))
+	}
+	return &Handler{
+		Backend:        backend,
+		Username:       username,
+		Password:       password,
+		Realm:          realm,
+		ProductVersion: "16.1",
+		nonces:         map[string]*nonce{},
+	}
+}
+
+// Challenges returns the number of requests answered with a digest challenge.
+func (h *Handler) Challenges() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.challenges
+}
+
+// ExpireNonces makes the nonces issued so far stale, as if their lifetime was over.
+func (h *Handler) ExpireNonces() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, n := range h.nonces {
+		n.stale = true
+	}
+}
+
+// ServeHTTP answers a WS-Man request.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != wsmanPath {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return
+	}
+	msg := string(body)
+	// the backend faults requests that are not envelopes, so a parse failure is left to it
+	request, _ := envelope.Parse(msg)
+	if request != nil && request.Body != nil {
+		if identify := request.Body.Child("Identify"); identify != nil && identify.Name.Space == client.NS_WSMID {
+			h.write(w, http.StatusOK, h.identifyResponse(r.TLS != nil))
+			return
+		}
+	}
+	if ok, stale := h.authenticate(r); !ok {
+		h.challenge(w, stale)
+		return
+	}
+
+	response, err := h.Backend.Post(msg)
+	var fault *simulator.Fault
+	switch {
+	case errors.As(err, &fault):
+		relatesTo := ""
+		if request != nil {
+			relatesTo = request.MessageID
+		}
+		h.write(w, fault.StatusCode(), fault.Envelope(relatesTo))
+	case err != nil:
+		// the client sees the connection closed without a response, like when the device resets it
+		panic(http.ErrAbortHandler)
+	default:
+		h.write(w, http.StatusOK, string(response))
+	}
+}
+
+// authenticate reports whether r has valid digest credentials for a nonce issued by h, and whether they were refused
+// only because the nonce expired.
+func (h *Handler) authenticate(r *http.Request) (ok, stale bool) {
+	params, ok := parseAuthorization(r.Header.Get("Authorization"))
+	if !ok || params["username"] != h.Username || params["realm"] != h.Realm || params["uri"] != r.URL.RequestURI() || params["qop"] != "auth" {
+		return false, false
+	}
+	count, err := strconv.ParseUint(params["nc"], 16, 64)
+	if err != nil {
+		return false, false
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	n := h.nonces[params["nonce"]]
+	if n == nil {
+		return false, false
+	}
+	if h.expired(n) {
+		delete(h.nonces, params["nonce"])
+		return false, true
+	}
+	credentials := hashMD5(h.Username + ":" + h.Realm + ":" + h.Password)
+	uri := hashMD5(r.Method + ":" + params["uri"])
+	expected := hashMD5(strings.Join([]string{credentials, params["nonce"], params["nc"], params["cnonce"], params["qop"], uri}, ":"))
+	if subtle.ConstantTimeCompare([]byte(expected), []byte(params["response"])) != 1 {
+		return false, false
+	}
+	if count <= n.count {
+		// a replayed request
+		return false, false
+	}
+	n.count = count
+	return true, false
+}
+
+// expired reports whether n can no longer be used. h.mu must be held.
+func (h *Handler) expired(n *nonce) bool {
+	return n.stale || (h.NonceLifetime > 0 && time.Since(n.issued) > h.NonceLifetime)
+}
+
+// prune drops the expired nonces and, when there are still maxNonces, the oldest ones to make room for a new one. A
+// request with a dropped nonce is challenged like one with an unknown nonce. h.mu must be held.
+func (h *Handler) prune() {
+	for value, n := range h.nonces {
+		if h.expired(n) {
+			delete(h.nonces, value)
+		}
+	}
+	for len(h.nonces) >= maxNonces {
+		oldest := ""
+		for value, n := range h.nonces {
+			if oldest == "" || n.issued.Before(h.nonces[oldest].issued) {
+				oldest = value
+			}
+		}
+		delete(h.nonces, oldest)
+	}
+}
+
+// challenge answers a digest challenge with a new nonce.
+func (h *Handler) challenge(w http.ResponseWriter, stale bool) {
+	value := randomHex(16)
+	h.mu.Lock()
+	h.prune()
+	h.nonces[value] = &nonce{issued: time.Now()}
+	h.challenges++
+	h.mu.Unlock()
+
+	w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Digest realm="%s", nonce="%s", stale="%t", qop="auth"`, h.Realm, value, stale))
+	h.write(w, http.StatusUnauthorized, "")
+}
+
+// write answers body with status and the headers of AMT.
+func (h *Handler) write(w http.ResponseWriter, status int, body string) {
+	w.Header().Set("Server", "Intel(R) Active Management Technology "+h.ProductVersion)
+	if body != "" {
+		w.Header().Set("Content-Type", client.ContentType)
+	}
+	w.WriteHeader(status)
+	_, _ = io.WriteString(w, body)
+}
+
+// identifyResponse returns the response to Identify, listing the security profile of the connection.
+func (h *Handler) identifyResponse(tls bool) string {
+	profile := httpDigestProfile
+	if tls {
+		profile = httpsDigestProfile
+	}
+	return `<?xml version="1.0" encoding="UTF-8"?>` +
+		`<a:Envelope xmlns:a="http://www.w3.org/2003/05/soap-envelope" xmlns:b="` + client.NS_WSMID + `">` +
+		`<a:Header></a:Header><a:Body><b:IdentifyResponse>` +
+		`<b:ProtocolVersion>` + client.NS_WSMAN + `</b:ProtocolVersion>` +
+		`<b:ProductVendor>Intel Corporation</b:ProductVendor>` +
+		`<b:ProductVersion>AMT ` + h.ProductVersion + `</b:ProductVersion>` +
+		`<b:SecurityProfiles><b:SecurityProfileName>` + profile + `</b:SecurityProfileName></b:SecurityProfiles>` +
+		`</b:IdentifyResponse></a:Body></a:Envelope>`
+}
+
+// parseAuthorization returns the parameters of the digest credentials header, quoted or not.
+func parseAuthorization(header string) (map[string]string, bool) {
+	scheme, rest, _ := strings.Cut(header, " ")
+	if !strings.EqualFold(scheme, "Digest") {
+		return nil, false
+	}
+	params := map[string]string{}
+	for rest = strings.TrimLeft(rest, " ,"); rest != ""; rest = strings.TrimLeft(rest, " ,") {
+		key, value, ok := strings.Cut(rest, "=")
+		if !ok {
+			return nil, false
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimLeft(value, " ")
+		if strings.HasPrefix(value, `"`) {
+			end := strings.Index(value[1:], `"`)
+			if end < 0 {
+				return nil, false
+			}
+			params[key] = value[1 : end+1]
+			rest = value[end+2:]
+		} else {
+			value, rest, _ = strings.Cut(value, ",")
+			params[key] = strings.TrimSpace(value)
+		}
+	}
+	return params, true
+}
+
+func hashMD5(data string) string {
+	return fmt.Sprintf("%x", md5.Sum([]byte(data)))
+}
+
+// randomHex returns n random bytes in hexadecimal.
+func randomHex(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("emulator: %v", err))
+	}
+	return fmt.Sprintf("%x", b)
+}
@@ -0,0 +1,109 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package emulator
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/client"
+)
+
+// Server is an emulated AMT listening on a local port. It must be closed like the httptest.Server it embeds.
+type Server struct {
+	*httptest.Server
+	Handler *Handler
+}
+
+// NewServer starts a server answering with h over HTTP, like AMT on port 16992.
+func NewServer(h *Handler) *Server {
+	return &Server{Server: httptest.NewServer(h), Handler: h}
+}
+
+// NewTLSServer starts a server answering with h over TLS with a self-signed certificate for localhost, like AMT on
+// port 16993.
+func NewTLSServer(h *Handler) *Server {
+	certificate, err := NewCertificate("localhost", "127.0.0.1", "::1")
+	if err != nil {
+		panic(fmt.Sprintf("emulator: failed to generate a certificate: %v", err))
+	}
+	s := httptest.NewUnstartedServer(h)
+	s.TLS = &tls.Config{Certificates: []tls.Certificate{certificate}}
+	s.StartTLS()
+	return &Server{Server: s, Handler: h}
+}
+
+// Parameters returns the parameters of a client of s using the credentials of its handler and accepting its
+// self-signed certificate.
+func (s *Server) Parameters() client.Parameters {
+	return client.Parameters{
+		Target:            "localhost",
+		Username:          s.Handler.Username,
+		Password:          s.Handler.Password,
+		UseDigest:         true,
+		UseTLS:            s.TLS != nil,
+		SelfSignedAllowed: true,
+	}
+}
+
+// Client returns the client.NewWsman client of cp connected to s, whatever the target and port of its endpoint.
+// Everything else, TLS verification included, is left to the client.
+func (s *Server) Client(cp client.Parameters) *client.Target {
+	target := client.NewWsman(cp)
+	addr := s.Listener.Addr().String()
+	dialer := &net.Dialer{}
+	// NewWsman always uses an *http.Transport
+	target.Transport.(*http.Transport).DialContext = func(ctx context.Context, network, _ string) (net.Conn, error) {
+		return dialer.DialContext(ctx, network, addr)
+	}
+	return target
+}
+
+// NewCertificate returns a self-signed certificate for hosts, DNS names or IP addresses, like the one AMT generates
+// when TLS is configured without a certificate signed by a CA.
+func NewCertificate(hosts ...string) (tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	template := x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: "Intel(r) AMT"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().AddDate(1, 0, 0),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, nil
+}
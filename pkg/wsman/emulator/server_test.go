@@ -0,0 +1,47 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package emulator
+
+import (
+	"crypto/x509"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/simulator"
+)
+
+func TestTLSServer(t *testing.T) {
+	s := NewTLSServer(New(simulator.New(), "admin", "P@ssw0rd"))
+	defer s.Close()
+	cp := s.Parameters()
+	assert.True(t, cp.UseTLS)
+
+	c := s.Client(cp)
+	settings, err := wsman.NewMessagesWithClient(c).AMT.GeneralSettings.Get()
+	require.NoError(t, err)
+	assert.Equal(t, s.Handler.Realm, settings.Body.GetResponse.DigestRealm)
+	response, err := c.Post(identify)
+	require.NoError(t, err)
+	assert.Contains(t, string(response), httpsDigestProfile)
+
+	cp.SelfSignedAllowed = false
+	_, err = wsman.NewMessagesWithClient(s.Client(cp)).AMT.GeneralSettings.Get()
+	assert.ErrorContains(t, err, "certificate", "the self-signed certificate is refused")
+}
+
+func TestNewCertificate(t *testing.T) {
+	certificate, err := NewCertificate("amt.example.com", "192.168.0.10")
+	require.NoError(t, err)
+	parsed, err := x509.ParseCertificate(certificate.Certificate[0])
+	require.NoError(t, err)
+	assert.Equal(t, []string{"amt.example.com"}, parsed.DNSNames)
+	require.Len(t, parsed.IPAddresses, 1)
+	assert.Equal(t, "192.168.0.10", parsed.IPAddresses[0].String())
+	assert.NoError(t, parsed.CheckSignatureFrom(parsed), "the certificate is self-signed")
+}
@@ -6,8 +6,6 @@
 package alarmclock
 
 import (
-	"encoding/xml"
-
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/internal/message"
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/client"
 )
@@ -34,7 +32,7 @@ func (occurrence Occurrence) Get(alarmName string) (response Response, err error
 	if err != nil {
 		return
 	}
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = occurrence.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -53,7 +51,7 @@ func (occurrence Occurrence) Delete(handle string) (response Response, err error
 	if err != nil {
 		return
 	}
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = occurrence.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -71,7 +69,7 @@ func (occurrence Occurrence) Enumerate() (response Response, err error) {
 	if err != nil {
 		return
 	}
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = occurrence.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -89,7 +87,7 @@ func (occurrence Occurrence) Pull(enumerationContext string) (response Response,
 	if err != nil {
 		return
 	}
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = occurrence.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
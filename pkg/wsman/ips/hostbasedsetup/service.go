@@ -7,7 +7,6 @@ package hostbasedsetup
 
 import (
 	"crypto/md5"
-	"encoding/xml"
 	"fmt"
 	"io"
 
@@ -34,7 +33,7 @@ func (service Service) Get() (response Response, err error) {
 	if err != nil {
 		return
 	}
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = service.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -52,7 +51,7 @@ func (service Service) Enumerate() (response Response, err error) {
 	if err != nil {
 		return
 	}
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = service.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -70,7 +69,7 @@ func (service Service) Pull(enumerationContext string) (response Response, err e
 	if err != nil {
 		return
 	}
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = service.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -80,12 +79,16 @@ func (service Service) Pull(enumerationContext string) (response Response, err e
 // Add a certificate to the provisioning certificate chain, to be used by AdminSetup or UpgradeClientToAdmin methods.
 func (service Service) AddNextCertInChain(cert string, isLeaf bool, isRoot bool) (response Response, err error) {
 	header := service.base.WSManMessageCreator.CreateHeader(methods.GenerateAction(IPS_HostBasedSetupService, AddNextCertInChain), IPS_HostBasedSetupService, nil, "", "")
-	body := service.base.WSManMessageCreator.CreateBody(methods.GenerateInputMethod(AddNextCertInChain), IPS_HostBasedSetupService, AddNextCertInChain_INPUT{
+	nextCert := AddNextCertInChain_INPUT{
 		H:                 "http://intel.com/wbem/wscim/1/ips-schema/1/IPS_HostBasedSetupService",
 		NextCertificate:   cert,
 		IsLeafCertificate: isLeaf,
 		IsRootCertificate: isRoot,
-	})
+	}
+	if err = service.base.Validate(nextCert); err != nil {
+		return
+	}
+	body := service.base.WSManMessageCreator.CreateBody(methods.GenerateInputMethod(AddNextCertInChain), IPS_HostBasedSetupService, nextCert)
 	response = Response{
 		Message: &client.Message{
 			XMLInput: service.base.WSManMessageCreator.CreateXML(header, body),
@@ -95,7 +98,7 @@ func (service Service) AddNextCertInChain(cert string, isLeaf bool, isRoot bool)
 	if err != nil {
 		return
 	}
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = service.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -109,14 +112,18 @@ func (service Service) AddNextCertInChain(cert string, isLeaf bool, isRoot bool)
 func (service Service) AdminSetup(adminPassEncryptionType AdminPassEncryptionType, digestRealm string, adminPassword string, mcNonce string, signingAlgorithm SigningAlgorithm, digitalSignature string) (response Response, err error) {
 	hashInHex := createMD5Hash(adminPassword, digestRealm)
 	header := service.base.WSManMessageCreator.CreateHeader(methods.GenerateAction(IPS_HostBasedSetupService, AdminSetup), IPS_HostBasedSetupService, nil, "", "")
-	body := service.base.WSManMessageCreator.CreateBody(methods.GenerateInputMethod(AdminSetup), IPS_HostBasedSetupService, AdminSetup_INPUT{
+	adminSetup := AdminSetup_INPUT{
 		H:                          "http://intel.com/wbem/wscim/1/ips-schema/1/IPS_HostBasedSetupService",
 		NetAdminPassEncryptionType: int(adminPassEncryptionType),
 		NetworkAdminPassword:       string(hashInHex),
 		McNonce:                    mcNonce,
 		SigningAlgorithm:           int(signingAlgorithm),
 		DigitalSignature:           digitalSignature,
-	})
+	}
+	if err = service.base.Validate(adminSetup); err != nil {
+		return
+	}
+	body := service.base.WSManMessageCreator.CreateBody(methods.GenerateInputMethod(AdminSetup), IPS_HostBasedSetupService, adminSetup)
 	response = Response{
 		Message: &client.Message{
 			XMLInput: service.base.WSManMessageCreator.CreateXML(header, body),
@@ -126,7 +133,7 @@ func (service Service) AdminSetup(adminPassEncryptionType AdminPassEncryptionTyp
 	if err != nil {
 		return
 	}
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = service.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -139,11 +146,15 @@ func (service Service) AdminSetup(adminPassEncryptionType AdminPassEncryptionTyp
 func (service Service) Setup(adminPassEncryptionType AdminPassEncryptionType, digestRealm, adminPassword string) (response Response, err error) {
 	hashInHex := createMD5Hash(adminPassword, digestRealm)
 	header := service.base.WSManMessageCreator.CreateHeader(methods.GenerateAction(IPS_HostBasedSetupService, Setup), IPS_HostBasedSetupService, nil, "", "")
-	body := service.base.WSManMessageCreator.CreateBody(methods.GenerateInputMethod(Setup), IPS_HostBasedSetupService, Setup_INPUT{
+	setup := Setup_INPUT{
 		H:                          "http://intel.com/wbem/wscim/1/ips-schema/1/IPS_HostBasedSetupService",
 		NetAdminPassEncryptionType: int(adminPassEncryptionType),
 		NetworkAdminPassword:       string(hashInHex),
-	})
+	}
+	if err = service.base.Validate(setup); err != nil {
+		return
+	}
+	body := service.base.WSManMessageCreator.CreateBody(methods.GenerateInputMethod(Setup), IPS_HostBasedSetupService, setup)
 	response = Response{
 		Message: &client.Message{
 			XMLInput: service.base.WSManMessageCreator.CreateXML(header, body),
@@ -153,7 +164,7 @@ func (service Service) Setup(adminPassEncryptionType AdminPassEncryptionType, di
 	if err != nil {
 		return
 	}
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = service.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -176,12 +187,16 @@ func createMD5Hash(adminPassword string, digestRealm string) string {
 // Upgrade Intel® AMT from Client to Admin Control Mode.
 func (service Service) UpgradeClientToAdmin(mcNonce string, signingAlgorithm SigningAlgorithm, digitalSignature string) (response Response, err error) {
 	header := service.base.WSManMessageCreator.CreateHeader(methods.GenerateAction(IPS_HostBasedSetupService, UpgradeClientToAdmin), IPS_HostBasedSetupService, nil, "", "")
-	body := service.base.WSManMessageCreator.CreateBody(methods.GenerateInputMethod(UpgradeClientToAdmin), IPS_HostBasedSetupService, UpgradeClientToAdmin_INPUT{
+	upgrade := UpgradeClientToAdmin_INPUT{
 		H:                "http://intel.com/wbem/wscim/1/ips-schema/1/IPS_HostBasedSetupService",
 		McNonce:          mcNonce,
 		SigningAlgorithm: int(signingAlgorithm),
 		DigitalSignature: digitalSignature,
-	})
+	}
+	if err = service.base.Validate(upgrade); err != nil {
+		return
+	}
+	body := service.base.WSManMessageCreator.CreateBody(methods.GenerateInputMethod(UpgradeClientToAdmin), IPS_HostBasedSetupService, upgrade)
 	response = Response{
 		Message: &client.Message{
 			XMLInput: service.base.WSManMessageCreator.CreateXML(header, body),
@@ -191,7 +206,7 @@ func (service Service) UpgradeClientToAdmin(mcNonce string, signingAlgorithm Sig
 	if err != nil {
 		return
 	}
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = service.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
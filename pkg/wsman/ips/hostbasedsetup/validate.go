@@ -0,0 +1,52 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package hostbasedsetup
+
+import (
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/common"
+)
+
+// Validate checks that a certificate is provided and that it is not marked as both leaf and root.
+func (r AddNextCertInChain_INPUT) Validate() error {
+	v := common.NewValidationError("AddNextCertInChain_INPUT")
+	v.Required("NextCertificate", r.NextCertificate)
+	if r.IsLeafCertificate && r.IsRootCertificate {
+		v.Add("IsRootCertificate", "a certificate cannot be both the leaf and the root of the chain")
+	}
+	return v.Err()
+}
+
+// Validate checks the password encryption type, the nonce and the signature parameters.
+func (r AdminSetup_INPUT) Validate() error {
+	v := common.NewValidationError("AdminSetup_INPUT")
+	v.Range("NetAdminPassEncryptionType", r.NetAdminPassEncryptionType, int(AdminPassEncryptionTypeNone), int(AdminPassEncryptionTypeHTTPDigestMD5A1))
+	v.Required("NetworkAdminPassword", r.NetworkAdminPassword)
+	validateSignature(v, r.McNonce, r.SigningAlgorithm, r.DigitalSignature)
+	return v.Err()
+}
+
+// Validate checks the password encryption type and that a password is provided.
+func (r Setup_INPUT) Validate() error {
+	v := common.NewValidationError("Setup_INPUT")
+	v.Range("NetAdminPassEncryptionType", r.NetAdminPassEncryptionType, int(AdminPassEncryptionTypeNone), int(AdminPassEncryptionTypeHTTPDigestMD5A1))
+	v.Required("NetworkAdminPassword", r.NetworkAdminPassword)
+	return v.Err()
+}
+
+// Validate checks the nonce and the signature parameters.
+func (r UpgradeClientToAdmin_INPUT) Validate() error {
+	v := common.NewValidationError("UpgradeClientToAdmin_INPUT")
+	validateSignature(v, r.McNonce, r.SigningAlgorithm, r.DigitalSignature)
+	return v.Err()
+}
+
+func validateSignature(v *common.ValidationError, mcNonce string, signingAlgorithm int, digitalSignature string) {
+	v.Required("McNonce", mcNonce)
+	v.Base64("McNonce", mcNonce)
+	v.Range("SigningAlgorithm", signingAlgorithm, int(SigningAlgorithmNone), int(SigningAlgorithmRSASHA2256))
+	v.Required("DigitalSignature", digitalSignature)
+	v.Base64("DigitalSignature", digitalSignature)
+}
@@ -6,8 +6,6 @@
 package ieee8021x
 
 import (
-	"encoding/xml"
-
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/internal/message"
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/client"
 )
@@ -30,7 +28,7 @@ func (credentialContext CredentialContext) Get() (response Response, err error)
 	if err != nil {
 		return
 	}
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = credentialContext.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -48,7 +46,7 @@ func (credentialContext CredentialContext) Enumerate() (response Response, err e
 	if err != nil {
 		return
 	}
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = credentialContext.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -66,7 +64,7 @@ func (credentialContext CredentialContext) Pull(enumerationContext string) (resp
 	if err != nil {
 		return
 	}
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = credentialContext.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -6,10 +6,9 @@
 package ieee8021x
 
 import (
-	"encoding/xml"
-
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/internal/message"
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/client"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/common"
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/ips/methods"
 )
 
@@ -31,7 +30,7 @@ func (settings Settings) Get() (response Response, err error) {
 	if err != nil {
 		return
 	}
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = settings.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -49,7 +48,7 @@ func (settings Settings) Enumerate() (response Response, err error) {
 	if err != nil {
 		return
 	}
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = settings.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -67,7 +66,7 @@ func (settings Settings) Pull(enumerationContext string) (response Response, err
 	if err != nil {
 		return
 	}
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = settings.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
@@ -76,6 +75,9 @@ func (settings Settings) Pull(enumerationContext string) (response Response, err
 
 // Put will change properties of the selected instance
 func (settings Settings) Put(ieee8021xSettings IEEE8021xSettingsRequest) (response Response, err error) {
+	if err = settings.base.Validate(ieee8021xSettings); err != nil {
+		return
+	}
 	response = Response{
 		Message: &client.Message{
 			XMLInput: settings.base.Put(ieee8021xSettings, false, nil),
@@ -85,22 +87,39 @@ func (settings Settings) Put(ieee8021xSettings IEEE8021xSettingsRequest) (respon
 	if err != nil {
 		return
 	}
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = settings.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
 	return
 }
 
+// Update reads the IEEE 802.1x settings, applies mutate to them and writes them back with Put.
+// Pass common.WithConcurrentModificationDetection to abort with common.ErrConcurrentModification when the settings change before the Put.
+func (settings Settings) Update(mutate func(*IEEE8021xSettingsRequest), opts ...common.UpdateOption) (response Response, err error) {
+	get := func() (IEEE8021xSettingsRequest, error) {
+		current, err := settings.Get()
+		return current.Body.IEEE8021xSettingsResponse.ToRequest(), err
+	}
+	put := func(ieee8021xSettings IEEE8021xSettingsRequest) (err error) {
+		response, err = settings.Put(ieee8021xSettings)
+		return
+	}
+	err = common.Update(get, put, mutate, opts...)
+	return
+}
+
 func (settings Settings) SetCertificates(serverCertificateIssuer, clientCertificate string) (response Response, err error) {
 	header := settings.base.WSManMessageCreator.CreateHeader(methods.GenerateAction(IPS_IEEE8021xSettings, SetCertificates), IPS_IEEE8021xSettings, nil, "", "")
-	body := settings.base.WSManMessageCreator.CreateBody(methods.GenerateInputMethod(SetCertificates), IPS_IEEE8021xSettings,
-		Certificate{
-			H:                       "http://intel.com/wbem/wscim/1/ips-schema/1/IPS_IEEE8021xSettings",
-			ServerCertificateIssuer: serverCertificateIssuer,
-			ClientCertificate:       clientCertificate,
-		},
-	)
+	certificate := Certificate{
+		H:                       "http://intel.com/wbem/wscim/1/ips-schema/1/IPS_IEEE8021xSettings",
+		ServerCertificateIssuer: serverCertificateIssuer,
+		ClientCertificate:       clientCertificate,
+	}
+	if err = settings.base.Validate(certificate); err != nil {
+		return
+	}
+	body := settings.base.WSManMessageCreator.CreateBody(methods.GenerateInputMethod(SetCertificates), IPS_IEEE8021xSettings, certificate)
 	response = Response{
 		Message: &client.Message{
 			XMLInput: settings.base.WSManMessageCreator.CreateXML(header, body),
@@ -110,7 +129,7 @@ func (settings Settings) SetCertificates(serverCertificateIssuer, clientCertific
 	if err != nil {
 		return
 	}
-	err = xml.Unmarshal([]byte(response.XMLOutput), &response)
+	err = settings.base.Decode(response.Message, &response)
 	if err != nil {
 		return
 	}
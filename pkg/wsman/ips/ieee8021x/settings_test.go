@@ -259,3 +259,19 @@ func TestNegativeIPS_IEEE8021xSettings(t *testing.T) {
 		}
 	})
 }
+
+func TestIEEE8021xSettingsToRequest(t *testing.T) {
+	wsmanMessageCreator := message.NewWSManMessageCreator("http://intel.com/wbem/wscim/1/ips-schema/1/")
+	client := wsmantesting.MockClient{PackageUnderTest: "ips/ieee8021x/settings", CurrentMessage: "Get"}
+	elementUnderTest := NewIEEE8021xSettingsWithClient(wsmanMessageCreator, &client)
+
+	response, err := elementUnderTest.Get()
+	assert.NoError(t, err)
+	assert.Equal(t, IEEE8021xSettingsRequest{
+		ElementName:   "Intel(r) AMT: 8021X Settings",
+		InstanceID:    "Intel(r) AMT: 8021X Settings",
+		Enabled:       Disabled,
+		PxeTimeout:    120,
+		AvailableInS0: false,
+	}, response.Body.IEEE8021xSettingsResponse.ToRequest(), "Enabled is converted to the request type")
+}
@@ -13,6 +13,8 @@ import (
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/common"
 )
 
+//go:generate go run ../../../../internal/tools/genconvert -type IEEE8021xSettingsResponse=IEEE8021xSettingsRequest -writeonly AuthenticationProtocol,RoamingIdentity,ServerCertificateName,ServerCertificateNameComparison,Username,Password,Domain,ProtectedAccessCredential,PACPassword,PSK
+
 // Package Types
 type (
 	Settings struct {
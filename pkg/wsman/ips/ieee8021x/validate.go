@@ -0,0 +1,33 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package ieee8021x
+
+import (
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/common"
+)
+
+// Validate checks that enumerated properties hold supported values and that the PxeTimeout is within range.
+func (r IEEE8021xSettingsRequest) Validate() error {
+	v := common.NewValidationError("IEEE8021xSettingsRequest")
+	v.Range("AuthenticationProtocol", int(r.AuthenticationProtocol), int(AuthenticationProtocolEAPTLS), int(AuthenticationProtocolEAPFAST_TLS))
+	if r.Enabled != 0 {
+		v.OneOf("Enabled", int(r.Enabled), int(EnabledWithCertificates), int(Disabled), int(EnabledWithoutCertificates))
+	}
+	v.MaxLength("Username", r.Username, 128)
+	v.MaxLength("Password", r.Password, 32)
+	v.MaxLength("Domain", r.Domain, 128)
+	v.MaxLength("PACPassword", r.PACPassword, 256)
+	v.Range("PxeTimeout", r.PxeTimeout, 0, 86400)
+	return v.Err()
+}
+
+// Validate checks that both certificate references are provided.
+func (r Certificate) Validate() error {
+	v := common.NewValidationError("Certificate")
+	v.Required("ServerCertificateIssuer", r.ServerCertificateIssuer)
+	v.Required("ClientCertificate", r.ClientCertificate)
+	return v.Err()
+}
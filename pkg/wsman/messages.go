@@ -15,7 +15,11 @@ import (
 
 // NewMessages instantiates a new Messages class with client connection parameters
 func NewMessages(cp client.Parameters) Messages {
-	client := client.NewWsman(cp)
+	return NewMessagesWithClient(client.NewWsman(cp))
+}
+
+// NewMessagesWithClient instantiates a new Messages class that sends its messages through client, for example a client decorated by cache.Cache
+func NewMessagesWithClient(client client.WSMan) Messages {
 	m := Messages{
 		client: client,
 	}
@@ -0,0 +1,233 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+// Package plan provides a dry-run client.WSMan that records the operations a configuration run intends to perform
+// instead of sending them, so the plan can be reviewed and approved before the run touches a device.
+//
+// Plan a run by creating its messages with wsman.NewMessagesWithClient(plan.New()). Nothing is sent, so a Plan answers
+// the writes and methods as if they succeeded: a Put with the instance it puts, a Create or a Delete with an empty body
+// and a method with a ReturnValue of 0 and no other output parameters. The reads, Get, Enumerate and Pull, return
+// ErrPlanned since their data is unknown. A run that checks errors can therefore be planned up to its first read, such
+// as the Get of an update or an Enumerate followed by a Pull of its context.
+//
+// The summaries of a plan show the parameters named after a password, a PSK or a passphrase as ***. The envelopes hold
+// the values as they would be sent, secrets included; pass them through an anonymize.Anonymizer before sharing them.
+package plan
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/internal/envelope"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/internal/message"
+)
+
+// ErrPlanned is returned for the reads recorded by a Plan.
+var ErrPlanned = errors.New("plan: request recorded, not sent")
+
+const (
+	addressingNamespace = "http://schemas.xmlsoap.org/ws/2004/08/addressing"
+	wsmanNamespace      = "http://schemas.dmtf.org/wbem/wsman/1/wsman.xsd"
+)
+
+// Operation is a request recorded by a Plan.
+type Operation struct {
+	Class     string
+	Action    string
+	Selectors map[string]string
+	// Summary describes the operation in one line, for example "Put AMT_GeneralSettings with HostName=host", with the
+	// secret parameters redacted.
+	Summary string
+	// Envelope is the request as it would have been sent.
+	Envelope string
+}
+
+// Plan is a client.WSMan recording the requests posted to it. It is safe for concurrent use.
+type Plan struct {
+	mu         sync.Mutex
+	operations []Operation
+}
+
+// New returns an empty plan.
+func New() *Plan {
+	return &Plan{}
+}
+
+// Post records msg and returns the synthetic response to it, ErrPlanned for a read, or the error parsing it when msg is
+// not a WS-Man envelope.
+func (p *Plan) Post(msg string) ([]byte, error) {
+	request, err := envelope.Parse(msg)
+	if err != nil {
+		return nil, fmt.Errorf("plan: %w", err)
+	}
+	operation := Operation{
+		Class:     path.Base(request.ResourceURI),
+		Action:    request.Action,
+		Selectors: request.Selectors,
+		Envelope:  msg,
+	}
+	operation.Summary = summary(operation, request.Body)
+
+	p.mu.Lock()
+	p.operations = append(p.operations, operation)
+	p.mu.Unlock()
+
+	body, ok := responseBody(request)
+	if !ok {
+		return nil, ErrPlanned
+	}
+	header := []*envelope.Node{
+		{Name: xml.Name{Space: addressingNamespace, Local: "Action"}, Text: request.Action + "Response"},
+		{Name: xml.Name{Space: addressingNamespace, Local: "RelatesTo"}, Text: request.MessageID},
+		{Name: xml.Name{Space: wsmanNamespace, Local: "ResourceURI"}, Text: request.ResourceURI},
+	}
+	response := &envelope.Node{
+		Name: xml.Name{Space: message.XMLBodySpace, Local: "Envelope"},
+		Children: []*envelope.Node{
+			{Name: xml.Name{Space: message.XMLBodySpace, Local: "Header"}, Children: header},
+			{Name: xml.Name{Space: message.XMLBodySpace, Local: "Body"}, Children: body},
+		},
+	}
+	return []byte(`<?xml version="1.0" encoding="UTF-8"?>` + response.XML()), nil
+}
+
+// responseBody returns the children of the body of the synthetic response to request, or false when request is a read.
+func responseBody(request *envelope.Envelope) ([]*envelope.Node, bool) {
+	switch request.Action {
+	case message.BaseActionsGet, message.BaseActionsEnumerate, message.BaseActionsPull:
+		return nil, false
+	case message.BaseActionsPut:
+		if request.Body == nil {
+			return nil, true
+		}
+		return request.Body.Children, true
+	case message.BaseActionsCreate, message.BaseActionsDelete:
+		return nil, true
+	default:
+		method := path.Base(request.Action)
+		return []*envelope.Node{{
+			Name:     xml.Name{Space: request.ResourceURI, Local: method + "_OUTPUT"},
+			Children: []*envelope.Node{{Name: xml.Name{Space: request.ResourceURI, Local: "ReturnValue"}, Text: "0"}},
+		}}, true
+	}
+}
+
+// Operations returns the operations recorded so far, in order.
+func (p *Plan) Operations() []Operation {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]Operation(nil), p.operations...)
+}
+
+// String returns the numbered summaries of the operations, one per line.
+func (p *Plan) String() string {
+	sb := strings.Builder{}
+	for i, o := range p.Operations() {
+		fmt.Fprintf(&sb, "%d. %s\n", i+1, o.Summary)
+	}
+	return sb.String()
+}
+
+// summary returns the summary of o, whose request has body.
+func summary(o Operation, body *envelope.Node) string {
+	var verb, target string
+	var input *envelope.Node
+	switch o.Action {
+	case message.BaseActionsGet, message.BaseActionsDelete, message.BaseActionsEnumerate, message.BaseActionsPull:
+		verb, target = path.Base(o.Action), o.Class
+	case message.BaseActionsPut, message.BaseActionsCreate:
+		verb, target = path.Base(o.Action), o.Class
+		if body != nil && len(body.Children) > 0 {
+			input = body.Children[0]
+		}
+	default:
+		verb, target = "Invoke", o.Class+"."+path.Base(o.Action)
+		input = body.Child(path.Base(o.Action) + "_INPUT")
+	}
+
+	sb := strings.Builder{}
+	sb.WriteString(verb + " " + target)
+	if len(o.Selectors) > 0 {
+		sb.WriteString(" (" + selectors(o.Selectors) + ")")
+	}
+	if input != nil && len(input.Children) > 0 {
+		sb.WriteString(" with " + parameters(input))
+	}
+	return sb.String()
+}
+
+// selectors returns the selectors sorted by name as name=value pairs.
+func selectors(s map[string]string) string {
+	names := make([]string, 0, len(s))
+	for name := range s {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = name + "=" + s[name]
+	}
+	return strings.Join(pairs, ", ")
+}
+
+// parameters returns the children of n as name=value pairs in order, joining the values of repeated children.
+func parameters(n *envelope.Node) string {
+	pairs := []string{}
+	index := map[string]int{}
+	for _, c := range n.Children {
+		value := parameter(c)
+		if isSecret(c.Name.Local) {
+			value = redacted
+		}
+		if i, ok := index[c.Name.Local]; ok {
+			pairs[i] += "," + value
+			continue
+		}
+		index[c.Name.Local] = len(pairs)
+		pairs = append(pairs, c.Name.Local+"="+value)
+	}
+	return strings.Join(pairs, ", ")
+}
+
+// redacted replaces the value of a secret parameter in a summary.
+const redacted = "***"
+
+// isSecret reports whether a parameter named name holds a password, a PSK or a passphrase, such as DigestPassword,
+// PSKPassPhrase or PACPassword.
+func isSecret(name string) bool {
+	name = strings.ToLower(name)
+	return strings.Contains(name, "password") || strings.Contains(name, "psk") || strings.Contains(name, "passphrase")
+}
+
+// parameter returns the value of n: its text, the class and selectors of an endpoint reference, or its parameters in
+// braces.
+func parameter(n *envelope.Node) string {
+	if len(n.Children) == 0 {
+		return n.Text
+	}
+	if reference := n.Child("ReferenceParameters"); reference != nil {
+		s := map[string]string{}
+		if set := reference.Child("SelectorSet"); set != nil {
+			for _, selector := range set.Children {
+				for _, a := range selector.Attrs {
+					if a.Name.Local == "Name" {
+						s[a.Value] = parameter(selector)
+					}
+				}
+			}
+		}
+		class := ""
+		if uri := reference.Child("ResourceURI"); uri != nil {
+			class = path.Base(uri.Text)
+		}
+		return class + "(" + selectors(s) + ")"
+	}
+	return "{" + parameters(n) + "}"
+}
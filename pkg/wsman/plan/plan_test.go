@@ -0,0 +1,124 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package plan
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/internal/message"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/amt/environmentdetection"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/amt/general"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/amt/remoteaccess"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/cim/power"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/ips/hostbasedsetup"
+)
+
+func TestPlan(t *testing.T) {
+	p := New()
+	m := wsman.NewMessagesWithClient(p)
+
+	_, err := m.AMT.GeneralSettings.Get()
+	assert.ErrorIs(t, err, ErrPlanned)
+	_, err = m.AMT.EnvironmentDetectionSettingData.Put(environmentdetection.EnvironmentDetectionSettingDataRequest{
+		ElementName:      "Intel(r) AMT Environment Detection Settings",
+		InstanceID:       "Intel(r) AMT Environment Detection Settings",
+		DetectionStrings: []string{"a.example.com", "b.example.com"},
+	})
+	assert.NoError(t, err)
+	_, err = m.AMT.ManagementPresenceRemoteSAP.Delete("Intel(r) AMT:Management Presence Server 0")
+	assert.NoError(t, err)
+	_, err = m.AMT.RemoteAccessService.AddRemoteAccessPolicyRule(remoteaccess.RemoteAccessPolicyRuleRequest{Trigger: remoteaccess.Periodic, TunnelLifeTime: 0, ExtendedData: "AAAAAAAAABk="}, "Intel(r) AMT:Management Presence Server 0")
+	assert.NoError(t, err)
+	_, err = m.CIM.PowerManagementService.RequestPowerStateChange(power.PowerOffHard)
+	assert.NoError(t, err)
+	_, err = m.AMT.SetupAndConfigurationService.CommitChanges()
+	assert.NoError(t, err)
+
+	operations := p.Operations()
+	require.Len(t, operations, 6)
+	assert.Equal(t, "AMT_EnvironmentDetectionSettingData", operations[1].Class)
+	assert.Equal(t, message.BaseActionsPut, operations[1].Action)
+	assert.Contains(t, operations[1].Envelope, "<h:DetectionStrings>b.example.com</h:DetectionStrings>")
+	assert.Equal(t, map[string]string{"Name": "Intel(r) AMT:Management Presence Server 0"}, operations[2].Selectors)
+	assert.Equal(t, "1. Get AMT_GeneralSettings\n"+
+		"2. Put AMT_EnvironmentDetectionSettingData (InstanceID=Intel(r) AMT Environment Detection Settings) with ElementName=Intel(r) AMT Environment Detection Settings, InstanceID=Intel(r) AMT Environment Detection Settings, DetectionAlgorithm=0, DetectionStrings=a.example.com,b.example.com\n"+
+		"3. Delete AMT_ManagementPresenceRemoteSAP (Name=Intel(r) AMT:Management Presence Server 0)\n"+
+		"4. Invoke AMT_RemoteAccessService.AddRemoteAccessPolicyRule with Trigger=2, TunnelLifeTime=0, ExtendedData=AAAAAAAAABk=, MpServer=AMT_ManagementPresenceRemoteSAP(Name=Intel(r) AMT:Management Presence Server 0)\n"+
+		"5. Invoke CIM_PowerManagementService.RequestPowerStateChange with PowerState=8, ManagedElement=CIM_ComputerSystem(CreationClassName=CIM_ComputerSystem, Name=ManagedSystem)\n"+
+		"6. Invoke AMT_SetupAndConfigurationService.CommitChanges\n",
+		p.String())
+}
+
+func TestPlanRunCheckingErrors(t *testing.T) {
+	p := New()
+	m := wsman.NewMessagesWithClient(p)
+
+	mps, err := m.AMT.RemoteAccessService.AddMPS(remoteaccess.AddMpServerRequest{
+		AccessInfo: "mps.example.com",
+		InfoFormat: remoteaccess.FQDN,
+		Port:       4433,
+		AuthMethod: remoteaccess.UsernamePasswordAuthentication,
+		Username:   "admin",
+		Password:   "P@ssw0rd",
+		CommonName: "mps.example.com",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "AddMpServer_OUTPUT", mps.Body.AddMpServerResponse.XMLName.Local)
+	commit, err := m.AMT.SetupAndConfigurationService.CommitChanges()
+	require.NoError(t, err)
+	assert.Equal(t, 0, commit.Body.CommitChanges_OUTPUT.ReturnValue)
+	detection, err := m.AMT.EnvironmentDetectionSettingData.Put(environmentdetection.EnvironmentDetectionSettingDataRequest{
+		ElementName:      "Intel(r) AMT Environment Detection Settings",
+		InstanceID:       "Intel(r) AMT Environment Detection Settings",
+		DetectionStrings: []string{"a.example.com"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a.example.com"}, detection.Body.GetAndPutResponse.DetectionStrings, "a Put answers the instance it puts")
+
+	_, err = m.AMT.GeneralSettings.Update(func(r *general.GeneralSettingsRequest) { r.HostName = "host" })
+	assert.ErrorIs(t, err, ErrPlanned, "the run stops at the read of the update")
+	assert.Equal(t, "1. Invoke AMT_RemoteAccessService.AddMpServer with AccessInfo=mps.example.com, InfoFormat=201, Port=4433, AuthMethod=2, Username=admin, Password=***, CN=mps.example.com\n"+
+		"2. Invoke AMT_SetupAndConfigurationService.CommitChanges\n"+
+		"3. Put AMT_EnvironmentDetectionSettingData (InstanceID=Intel(r) AMT Environment Detection Settings) with ElementName=Intel(r) AMT Environment Detection Settings, InstanceID=Intel(r) AMT Environment Detection Settings, DetectionAlgorithm=0, DetectionStrings=a.example.com\n"+
+		"4. Get AMT_GeneralSettings\n",
+		p.String())
+}
+
+func TestPlanRedactsSecrets(t *testing.T) {
+	p := New()
+	m := wsman.NewMessagesWithClient(p)
+	_, err := m.IPS.HostBasedSetupService.Setup(hostbasedsetup.AdminPassEncryptionTypeHTTPDigestMD5A1, "Digest:realm", "P@ssw0rd")
+	assert.NoError(t, err)
+	_, err = m.AMT.SetupAndConfigurationService.SetMEBXPassword("P@ssw0rd")
+	assert.NoError(t, err)
+
+	assert.Equal(t, "1. Invoke IPS_HostBasedSetupService.Setup with NetAdminPassEncryptionType=2, NetworkAdminPassword=***\n"+
+		"2. Invoke AMT_SetupAndConfigurationService.SetMEBxPassword with Password=***\n",
+		p.String())
+	operations := p.Operations()
+	require.Len(t, operations, 2)
+	assert.Contains(t, operations[1].Envelope, "P@ssw0rd", "the envelope holds the value as it would be sent")
+}
+
+func TestPlanValidatesRequests(t *testing.T) {
+	p := New()
+	m := wsman.NewMessagesWithClient(p)
+	_, err := m.AMT.RemoteAccessService.AddMPS(remoteaccess.AddMpServerRequest{})
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrPlanned, "invalid requests are not planned")
+	assert.Empty(t, p.Operations())
+}
+
+func TestPlanRejectsInvalidEnvelopes(t *testing.T) {
+	p := New()
+	_, err := p.Post("<Body/>")
+	assert.EqualError(t, err, "plan: root element Body is not an Envelope")
+	assert.Empty(t, p.String())
+}
@@ -0,0 +1,133 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package simulator
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// Namespaces of the WS-Man protocol elements of responses.
+const (
+	soapNamespace        = "http://www.w3.org/2003/05/soap-envelope"
+	addressingNamespace  = "http://schemas.xmlsoap.org/ws/2004/08/addressing"
+	wsmanNamespace       = "http://schemas.dmtf.org/wbem/wsman/1/wsman.xsd"
+	enumerationNamespace = "http://schemas.xmlsoap.org/ws/2004/09/enumeration"
+	transferNamespace    = "http://schemas.xmlsoap.org/ws/2004/09/transfer"
+	anonymousAddress     = "http://schemas.xmlsoap.org/ws/2004/08/addressing/role/anonymous"
+	faultAction          = "http://schemas.xmlsoap.org/ws/2004/08/addressing/fault"
+)
+
+// Fault is a SOAP fault answered by the simulated device. Post returns it as its error, which reads like the error of a
+// client.Target receiving the fault from a device.
+type Fault struct {
+	// Status is the HTTP status of the response, 400 Bad Request when zero.
+	Status int
+	// Code is the SOAP fault code, Sender or Receiver.
+	Code    string
+	Subcode xml.Name
+	Reason  string
+}
+
+// DestinationUnreachable is the fault AMT answers for an unknown resource or instance.
+func DestinationUnreachable(reason string) *Fault {
+	return &Fault{Code: "Sender", Subcode: xml.Name{Space: addressingNamespace, Local: "DestinationUnreachable"}, Reason: reason}
+}
+
+// ActionNotSupported is the fault AMT answers for an action the resource does not support.
+func ActionNotSupported(action string) *Fault {
+	return &Fault{Code: "Sender", Subcode: xml.Name{Space: addressingNamespace, Local: "ActionNotSupported"}, Reason: "The action is not supported by the service: " + action}
+}
+
+// InvalidEnumerationContext is the fault AMT answers for a Pull of an unknown or finished enumeration.
+func InvalidEnumerationContext() *Fault {
+	return &Fault{Code: "Receiver", Subcode: xml.Name{Space: enumerationNamespace, Local: "InvalidEnumerationContext"}, Reason: "The supplied enumeration context is invalid."}
+}
+
+// InvalidRepresentation is the fault AMT answers for a Put or Create whose body is not an instance of the resource.
+func InvalidRepresentation(reason string) *Fault {
+	return &Fault{Code: "Sender", Subcode: xml.Name{Space: wsmanNamespace, Local: "InvalidRepresentation"}, Reason: reason}
+}
+
+// AccessDenied is the fault AMT answers when the user is not allowed to use the resource.
+func AccessDenied() *Fault {
+	return &Fault{Code: "Sender", Status: http.StatusForbidden, Subcode: xml.Name{Space: wsmanNamespace, Local: "AccessDenied"}, Reason: "The sender was not authorized to access the resource."}
+}
+
+// InternalError is the fault AMT answers when it fails to process a valid request.
+func InternalError(reason string) *Fault {
+	return &Fault{Code: "Receiver", Status: http.StatusInternalServerError, Subcode: xml.Name{Space: wsmanNamespace, Local: "InternalError"}, Reason: reason}
+}
+
+// StatusCode returns the HTTP status of the response carrying f.
+func (f *Fault) StatusCode() int {
+	if f.Status == 0 {
+		return http.StatusBadRequest
+	}
+	return f.Status
+}
+
+// Error returns the error a client.Target returns for f.
+func (f *Fault) Error() string {
+	return fmt.Sprintf("wsman.Client: post received %d %s\n'%s'", f.StatusCode(), http.StatusText(f.StatusCode()), f.Envelope(""))
+}
+
+// Envelope returns the response envelope of f answering the request with message identifier relatesTo.
+func (f *Fault) Envelope(relatesTo string) string {
+	sb := strings.Builder{}
+	sb.WriteString(`<a:Fault><a:Code><a:Value>a:` + f.Code + `</a:Value><a:Subcode><a:Value xmlns:s="`)
+	_ = xml.EscapeText(&sb, []byte(f.Subcode.Space))
+	sb.WriteString(`">s:` + f.Subcode.Local + `</a:Value></a:Subcode></a:Code><a:Reason><a:Text xml:lang="en-US">`)
+	_ = xml.EscapeText(&sb, []byte(f.Reason))
+	sb.WriteString(`</a:Text></a:Reason><a:Detail></a:Detail></a:Fault>`)
+	return responseEnvelope(faultAction, "", relatesTo, "", sb.String())
+}
+
+// Injection scripts a failure of the requests it matches. The injections are tried in the order they were added and
+// the first one applying to a request decides its failure.
+type Injection struct {
+	// Class is the class of the requests matched, every class when empty.
+	Class string
+	// Action is the action of the requests matched, or its last segment such as Get, Pull or RequestPowerStateChange,
+	// every action when empty.
+	Action string
+	// Skip is the number of matching requests the injection lets through, to the injections added after it or to the
+	// device, before it applies.
+	Skip int
+	// Times is the number of matching requests failed once it applies, every one when zero.
+	Times int
+
+	// Err is returned by Post, as a transport error would be.
+	Err error
+	// Fault is answered to the request when Err is nil.
+	Fault *Fault
+	// ReturnValue is answered by a method without running it when Err and Fault are nil. Requests that are not method
+	// calls are not matched by such an injection.
+	ReturnValue int
+
+	matched int
+}
+
+// matches reports whether the injection matches a request for action on class and counts the match.
+func (i *Injection) matches(class, action string, method bool) bool {
+	if i.Class != "" && i.Class != class {
+		return false
+	}
+	if i.Action != "" && i.Action != action && i.Action != path.Base(action) {
+		return false
+	}
+	if i.Err == nil && i.Fault == nil && !method {
+		return false
+	}
+	i.matched++
+	if i.matched <= i.Skip {
+		return false
+	}
+	return i.Times == 0 || i.matched <= i.Skip+i.Times
+}
@@ -0,0 +1,72 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package simulator
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/cim/power"
+)
+
+func TestInject(t *testing.T) {
+	reset := errors.New("connection reset by peer")
+	sim := New()
+	sim.Inject(
+		Injection{Class: "AMT_GeneralSettings", Action: "Get", Skip: 1, Times: 2, Err: reset},
+		Injection{Class: "AMT_GeneralSettings", Times: 1, Fault: AccessDenied()},
+	)
+	m := wsman.NewMessagesWithClient(sim)
+
+	_, err := m.AMT.GeneralSettings.Get()
+	var fault *Fault
+	require.ErrorAs(t, err, &fault, "the request skipped by the first injection is failed by the second one")
+	assert.Equal(t, 403, fault.StatusCode())
+	assert.Equal(t, "AccessDenied", fault.Subcode.Local)
+	_, err = m.AMT.GeneralSettings.Get()
+	assert.Equal(t, reset, err)
+	_, err = m.AMT.GeneralSettings.Get()
+	assert.Equal(t, reset, err)
+	_, err = m.AMT.GeneralSettings.Get()
+	assert.NoError(t, err, "both injections are exhausted")
+
+	_, err = m.AMT.SetupAndConfigurationService.Get()
+	assert.NoError(t, err, "other classes are not matched")
+}
+
+func TestInjectReturnValue(t *testing.T) {
+	sim := New()
+	sim.Inject(Injection{Action: "RequestPowerStateChange", Times: 1, ReturnValue: 2})
+	m := wsman.NewMessagesWithClient(strict{sim})
+
+	_, err := m.CIM.PowerManagementService.Get()
+	assert.NoError(t, err, "requests that are not method calls are not matched")
+
+	response, err := m.CIM.PowerManagementService.RequestPowerStateChange(power.PowerOffHard)
+	require.NoError(t, err)
+	assert.Equal(t, power.ReturnValueUnknownOrUnspecifiedError, response.Body.RequestPowerStateChangeResponse.ReturnValue)
+	assert.Equal(t, "2", sim.Property("CIM_AssociatedPowerManagementService", "PowerState"), "the method did not run")
+
+	response, err = m.CIM.PowerManagementService.RequestPowerStateChange(power.PowerOffHard)
+	require.NoError(t, err)
+	assert.Equal(t, power.ReturnValueCompletedWithNoError, response.Body.RequestPowerStateChangeResponse.ReturnValue)
+	assert.Equal(t, "8", sim.Property("CIM_AssociatedPowerManagementService", "PowerState"))
+}
+
+func TestFaultEnvelope(t *testing.T) {
+	fault := DestinationUnreachable("no such <instance>")
+	assert.Equal(t, 400, fault.StatusCode())
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>`+
+		`<a:Envelope xmlns:a="http://www.w3.org/2003/05/soap-envelope" xmlns:b="http://schemas.xmlsoap.org/ws/2004/08/addressing" xmlns:c="http://schemas.dmtf.org/wbem/wsman/1/wsman.xsd" xmlns:g="http://schemas.xmlsoap.org/ws/2004/09/enumeration" xmlns:t="http://schemas.xmlsoap.org/ws/2004/09/transfer">`+
+		`<a:Header><b:To>http://schemas.xmlsoap.org/ws/2004/08/addressing/role/anonymous</b:To><b:RelatesTo>7</b:RelatesTo><b:Action a:mustUnderstand="true">http://schemas.xmlsoap.org/ws/2004/08/addressing/fault</b:Action></a:Header>`+
+		`<a:Body><a:Fault><a:Code><a:Value>a:Sender</a:Value><a:Subcode><a:Value xmlns:s="http://schemas.xmlsoap.org/ws/2004/08/addressing">s:DestinationUnreachable</a:Value></a:Subcode></a:Code>`+
+		`<a:Reason><a:Text xml:lang="en-US">no such &lt;instance&gt;</a:Text></a:Reason><a:Detail></a:Detail></a:Fault></a:Body></a:Envelope>`,
+		fault.Envelope("7"))
+}
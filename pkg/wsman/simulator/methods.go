@@ -0,0 +1,248 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package simulator
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/internal/envelope"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/internal/message"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/cim/power"
+)
+
+// Call is a call of a method of a class.
+type Call struct {
+	Class     string
+	Method    string
+	Selectors map[string]string
+	input     *envelope.Node
+}
+
+// Input returns the value of the input parameter name, the first one for arrays, or "" when the call does not have it.
+func (c *Call) Input(name string) string {
+	if n := c.input.Child(name); n != nil {
+		return n.Text
+	}
+	return ""
+}
+
+// Output is the answer of a method.
+type Output struct {
+	ReturnValue int
+	// Parameters are the output parameters other than ReturnValue, in order.
+	Parameters []Parameter
+}
+
+// Parameter is an output parameter holding a value, or a reference when Reference is set.
+type Parameter struct {
+	Name      string
+	Value     string
+	Reference *Reference
+}
+
+// MethodHandler runs call against the store of the simulated device. An error that is a *Fault is answered to the
+// request, any other error is returned by Post.
+type MethodHandler func(store *Store, call *Call) (*Output, error)
+
+// Return values of the simulated methods.
+const (
+	returnSuccess          = 0
+	returnNotSupported     = 1
+	returnInvalidState     = 2
+	returnInvalidParameter = 5
+	returnDuplicate        = 2058
+	returnInvalidOptInCode = 2066
+)
+
+const managementPresenceRemoteSAP = "AMT_ManagementPresenceRemoteSAP"
+
+// Opt-in states of IPS_OptInService.
+const (
+	optInNotStarted = "0"
+	optInDisplayed  = "2"
+	optInReceived   = "3"
+)
+
+// handleDefaults registers the handlers of the methods simulated by default.
+func (s *Simulator) handleDefaults() {
+	s.Handle("CIM_PowerManagementService", "RequestPowerStateChange", s.requestPowerStateChange)
+	s.Handle("AMT_RemoteAccessService", "AddMpServer", s.addMpServer)
+	s.Handle("AMT_SetupAndConfigurationService", "CommitChanges", s.commitChanges)
+	s.Handle("IPS_OptInService", "StartOptIn", s.startOptIn)
+	s.Handle("IPS_OptInService", "SendOptInCode", s.sendOptInCode)
+	s.Handle("IPS_OptInService", "CancelOptIn", s.cancelOptIn)
+}
+
+// requestPowerStateChange changes the PowerState of CIM_AssociatedPowerManagementService to the state the requested
+// change ends in. A powered off, sleeping or hibernating system only accepts to be powered on, a powered on system the
+// changes it was seeded with.
+func (s *Simulator) requestPowerStateChange(store *Store, call *Call) (*Output, error) {
+	requested, err := strconv.Atoi(call.Input("PowerState"))
+	if err != nil {
+		return &Output{ReturnValue: returnInvalidParameter}, nil
+	}
+	state, ok := power.ResultingPowerState(power.PowerState(requested))
+	if !ok {
+		return &Output{ReturnValue: returnInvalidParameter}, nil
+	}
+	associations := store.Instances(power.CIM_AssociatedPowerManagementService)
+	for _, a := range associations {
+		available := a.Properties("AvailableRequestedPowerStates")
+		if len(available) > 0 && !contains(available, strconv.Itoa(requested)) {
+			return &Output{ReturnValue: returnNotSupported}, nil
+		}
+	}
+	on := strconv.Itoa(int(power.PowerOn))
+	for _, a := range associations {
+		if a.Property("PowerState") == on && s.poweredOnStates == nil {
+			s.poweredOnStates = a.Properties("AvailableRequestedPowerStates")
+		}
+		a.SetProperty("PowerState", strconv.Itoa(int(state)))
+		switch {
+		case state != power.PowerOn:
+			a.SetProperty("AvailableRequestedPowerStates", on)
+		case s.poweredOnStates != nil:
+			a.SetProperty("AvailableRequestedPowerStates", s.poweredOnStates...)
+		}
+	}
+	for _, service := range store.Instances("CIM_PowerManagementService") {
+		service.SetProperty("RequestedState", strconv.Itoa(requested))
+	}
+	return &Output{ReturnValue: returnSuccess}, nil
+}
+
+// addMpServer creates the AMT_ManagementPresenceRemoteSAP of a new MPS and answers a reference to it.
+func (s *Simulator) addMpServer(store *Store, call *Call) (*Output, error) {
+	existing := store.Instances(managementPresenceRemoteSAP)
+	names := map[string]bool{}
+	for _, i := range existing {
+		if i.Property("AccessInfo") == call.Input("AccessInfo") && i.Property("Port") == call.Input("Port") {
+			return &Output{ReturnValue: returnDuplicate}, nil
+		}
+		names[i.Property("Name")] = true
+	}
+	name := ""
+	for n := 0; name == "" || names[name]; n++ {
+		name = fmt.Sprintf("Intel(r) AMT:Management Presence Server %d", n)
+	}
+	mps := newInstance(message.AMTSchema+managementPresenceRemoteSAP, managementPresenceRemoteSAP)
+	mps.SetProperty("AccessInfo", call.Input("AccessInfo"))
+	mps.SetProperty("CN", call.Input("CN"))
+	mps.SetProperty("CreationClassName", managementPresenceRemoteSAP)
+	mps.SetProperty("ElementName", "Intel(r) AMT:Management Presence Server")
+	mps.SetProperty("InfoFormat", call.Input("InfoFormat"))
+	mps.SetProperty("Name", name)
+	mps.SetProperty("Port", call.Input("Port"))
+	mps.SetProperty("SystemCreationClassName", "CIM_ComputerSystem")
+	mps.SetProperty("SystemName", "Intel(r) AMT")
+	store.Add(mps)
+	return &Output{
+		ReturnValue: returnSuccess,
+		Parameters: []Parameter{
+			{Name: "MpServer", Reference: mps.Reference("CreationClassName", "Name", "SystemCreationClassName", "SystemName")},
+		},
+	}, nil
+}
+
+// commitChanges commits the changes made by Put, Create and Delete, see Simulator.Uncommitted.
+func (s *Simulator) commitChanges(store *Store, call *Call) (*Output, error) {
+	s.commits++
+	s.uncommitted = false
+	return &Output{ReturnValue: returnSuccess}, nil
+}
+
+// startOptIn displays the opt-in code, see Simulator.OptInCode.
+func (s *Simulator) startOptIn(store *Store, call *Call) (*Output, error) {
+	return s.changeOptInState(store, optInNotStarted, optInDisplayed)
+}
+
+// sendOptInCode completes the opt-in when the code sent is the one displayed.
+func (s *Simulator) sendOptInCode(store *Store, call *Call) (*Output, error) {
+	to := optInReceived
+	if call.Input("OptInCode") != strconv.Itoa(s.OptInCode) {
+		to = optInDisplayed
+	}
+	output, err := s.changeOptInState(store, optInDisplayed, to)
+	if err == nil && output.ReturnValue == returnSuccess && to == optInDisplayed {
+		output.ReturnValue = returnInvalidOptInCode
+	}
+	return output, err
+}
+
+// cancelOptIn ends the opt-in, and the redirection sessions it allowed, in any state.
+func (s *Simulator) cancelOptIn(store *Store, call *Call) (*Output, error) {
+	return s.changeOptInState(store, "", optInNotStarted)
+}
+
+// changeOptInState moves IPS_OptInService from the state from, any when empty, to the state to.
+func (s *Simulator) changeOptInState(store *Store, from, to string) (*Output, error) {
+	services := store.Instances("IPS_OptInService")
+	if len(services) == 0 {
+		return nil, DestinationUnreachable("IPS_OptInService has no instance")
+	}
+	for _, service := range services {
+		if from != "" && service.Property("OptInState") != from {
+			return &Output{ReturnValue: returnInvalidState}, nil
+		}
+	}
+	for _, service := range services {
+		service.SetProperty("OptInState", to)
+	}
+	return &Output{ReturnValue: returnSuccess}, nil
+}
+
+// newInstance returns an instance of class without properties.
+func newInstance(resourceURI, class string) *Instance {
+	return &Instance{node: &envelope.Node{Name: xml.Name{Space: resourceURI, Local: class}}}
+}
+
+// xml returns the output element of the method of call, in the namespace resourceURI.
+func (o *Output) xml(resourceURI string, call *Call) string {
+	sb := strings.Builder{}
+	sb.WriteString(`<h:` + call.Method + `_OUTPUT xmlns:h="`)
+	_ = xml.EscapeText(&sb, []byte(resourceURI))
+	sb.WriteString(`">`)
+	for _, p := range o.Parameters {
+		if p.Reference != nil {
+			sb.WriteString(p.Reference.xml("h:" + p.Name))
+			continue
+		}
+		sb.WriteString(`<h:` + p.Name + `>`)
+		_ = xml.EscapeText(&sb, []byte(p.Value))
+		sb.WriteString(`</h:` + p.Name + `>`)
+	}
+	fmt.Fprintf(&sb, `<h:ReturnValue>%d</h:ReturnValue></h:%s_OUTPUT>`, o.ReturnValue, call.Method)
+	return sb.String()
+}
+
+// xml returns the reference as the element name, using the prefixes of the response envelope.
+func (r *Reference) xml(name string) string {
+	sb := strings.Builder{}
+	sb.WriteString(`<` + name + `><b:Address>` + anonymousAddress + `</b:Address><b:ReferenceParameters><c:ResourceURI>`)
+	_ = xml.EscapeText(&sb, []byte(r.ResourceURI))
+	sb.WriteString(`</c:ResourceURI><c:SelectorSet>`)
+	for _, selector := range r.Selectors {
+		sb.WriteString(`<c:Selector Name="`)
+		_ = xml.EscapeText(&sb, []byte(selector.Name))
+		sb.WriteString(`">`)
+		_ = xml.EscapeText(&sb, []byte(selector.Value))
+		sb.WriteString(`</c:Selector>`)
+	}
+	sb.WriteString(`</c:SelectorSet></b:ReferenceParameters></` + name + `>`)
+	return sb.String()
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,219 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package simulator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/internal/message"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/amt/environmentdetection"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/amt/remoteaccess"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/cim/power"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/ips/optin"
+)
+
+// unvalidated sends the requests without validating their inputs, to test how the simulator answers invalid ones.
+type unvalidated struct {
+	strict
+}
+
+func (unvalidated) ValidateRequests() bool {
+	return false
+}
+
+func TestRequestPowerStateChange(t *testing.T) {
+	sim := New()
+	m := wsman.NewMessagesWithClient(strict{sim})
+	seeded := sim.Instances("CIM_AssociatedPowerManagementService")[0].Properties("AvailableRequestedPowerStates")
+
+	response, err := m.CIM.PowerManagementService.RequestPowerStateChange(power.PowerOffHard)
+	require.NoError(t, err)
+	assert.Equal(t, power.ReturnValueCompletedWithNoError, response.Body.RequestPowerStateChangeResponse.ReturnValue)
+	assert.Equal(t, "8", sim.Property("CIM_AssociatedPowerManagementService", "PowerState"))
+	assert.Equal(t, []string{"2"}, sim.Instances("CIM_AssociatedPowerManagementService")[0].Properties("AvailableRequestedPowerStates"))
+	assert.Equal(t, "8", sim.Property("CIM_PowerManagementService", "RequestedState"))
+
+	response, err = m.CIM.PowerManagementService.RequestPowerStateChange(power.MasterBusReset)
+	require.NoError(t, err)
+	assert.Equal(t, power.ReturnValueNotSupported, response.Body.RequestPowerStateChangeResponse.ReturnValue, "a powered off system cannot be reset")
+	assert.Equal(t, "8", sim.Property("CIM_AssociatedPowerManagementService", "PowerState"))
+
+	response, err = m.CIM.PowerManagementService.RequestPowerStateChange(power.PowerOn)
+	require.NoError(t, err)
+	assert.Equal(t, power.ReturnValueCompletedWithNoError, response.Body.RequestPowerStateChangeResponse.ReturnValue)
+	assert.Equal(t, "2", sim.Property("CIM_AssociatedPowerManagementService", "PowerState"))
+	assert.Equal(t, seeded, sim.Instances("CIM_AssociatedPowerManagementService")[0].Properties("AvailableRequestedPowerStates"))
+
+	response, err = m.CIM.PowerManagementService.RequestPowerStateChange(power.MasterBusReset)
+	require.NoError(t, err)
+	assert.Equal(t, power.ReturnValueCompletedWithNoError, response.Body.RequestPowerStateChangeResponse.ReturnValue)
+	assert.Equal(t, "2", sim.Property("CIM_AssociatedPowerManagementService", "PowerState"))
+
+	_, err = m.CIM.PowerManagementService.RequestPowerStateChange(power.PowerState(1))
+	assert.Error(t, err, "the client rejects unknown power states")
+	response, err = wsman.NewMessagesWithClient(unvalidated{strict{sim}}).CIM.PowerManagementService.RequestPowerStateChange(power.PowerState(1))
+	require.NoError(t, err)
+	assert.Equal(t, power.ReturnValueInvalidParameter, response.Body.RequestPowerStateChangeResponse.ReturnValue)
+}
+
+func TestWaitForPowerState(t *testing.T) {
+	sim := New()
+	m := wsman.NewMessagesWithClient(strict{sim})
+	require.Len(t, sim.Instances(power.CIM_AssociatedPowerManagementService), 1)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	response, err := m.CIM.PowerManagementService.RequestPowerStateChangeWithParameters(power.PowerStateChangeRequest{
+		PowerState:    power.PowerOffSoftGraceful,
+		TimeoutPeriod: time.Minute,
+	})
+	require.NoError(t, err)
+	require.Equal(t, power.ReturnValueCompletedWithNoError, response.Body.RequestPowerStateChangeResponse.ReturnValue)
+	state, _ := power.ResultingPowerState(power.PowerOffSoftGraceful)
+	association, err := m.CIM.AssociatedPowerManagementService.WaitForPowerState(ctx, state, time.Millisecond)
+	require.NoError(t, err)
+	assert.Equal(t, power.PowerOffHard, association.PowerState)
+	assert.Equal(t, []power.PowerState{power.PowerOn}, association.AvailableRequestedPowerStates)
+
+	response, err = m.CIM.PowerManagementService.RequestPowerStateChange(power.PowerOn)
+	require.NoError(t, err)
+	require.Equal(t, power.ReturnValueCompletedWithNoError, response.Body.RequestPowerStateChangeResponse.ReturnValue)
+	association, err = m.CIM.AssociatedPowerManagementService.WaitForPowerState(ctx, power.PowerOn, time.Millisecond)
+	require.NoError(t, err)
+	assert.Len(t, association.AvailableRequestedPowerStates, 8)
+}
+
+func TestAddMPS(t *testing.T) {
+	sim := New()
+	m := wsman.NewMessagesWithClient(sim)
+	request := remoteaccess.AddMpServerRequest{
+		AccessInfo: "mps.example.com",
+		InfoFormat: remoteaccess.FQDN,
+		Port:       4433,
+		AuthMethod: remoteaccess.UsernamePasswordAuthentication,
+		Username:   "user",
+		Password:   "password",
+		CommonName: "mps.example.com",
+	}
+
+	response, err := m.AMT.RemoteAccessService.AddMPS(request)
+	require.NoError(t, err)
+	selectors := map[string]string{}
+	for _, s := range response.Body.AddMpServerResponse.MpServer.ReferenceParameters.SelectorSet.Selectors {
+		selectors[s.Name] = s.Text
+	}
+	assert.Equal(t, message.AMTSchema+"AMT_ManagementPresenceRemoteSAP", response.Body.AddMpServerResponse.MpServer.ReferenceParameters.ResourceURI)
+	assert.Equal(t, "Intel(r) AMT:Management Presence Server 1", selectors["Name"], "the seeded MPS is server 0")
+
+	enumerated, err := m.AMT.ManagementPresenceRemoteSAP.Enumerate()
+	require.NoError(t, err)
+	pulled, err := m.AMT.ManagementPresenceRemoteSAP.Pull(enumerated.Body.EnumerateResponse.EnumerationContext)
+	require.NoError(t, err)
+	require.Len(t, pulled.Body.PullResponse.ManagementRemoteItems, 2)
+	mps := pulled.Body.PullResponse.ManagementRemoteItems[1]
+	assert.Equal(t, "mps.example.com", mps.AccessInfo)
+	assert.Equal(t, 4433, mps.Port)
+	assert.Equal(t, selectors["Name"], mps.Name)
+
+	response, err = m.AMT.RemoteAccessService.AddMPS(request)
+	require.NoError(t, err)
+	assert.Contains(t, response.XMLOutput, "<h:ReturnValue>2058</h:ReturnValue>", "the MPS is a duplicate")
+	assert.Len(t, sim.Instances("AMT_ManagementPresenceRemoteSAP"), 2)
+}
+
+func TestCommitChanges(t *testing.T) {
+	sim := New()
+	m := wsman.NewMessagesWithClient(strict{sim})
+	assert.False(t, sim.Uncommitted())
+
+	_, err := m.AMT.EnvironmentDetectionSettingData.Put(environmentdetection.EnvironmentDetectionSettingDataRequest{
+		ElementName:      "Intel(r) AMT Environment Detection Settings",
+		InstanceID:       "Intel(r) AMT Environment Detection Settings",
+		DetectionStrings: []string{"example.com"},
+	})
+	require.NoError(t, err)
+	assert.True(t, sim.Uncommitted())
+
+	response, err := m.AMT.SetupAndConfigurationService.CommitChanges()
+	require.NoError(t, err)
+	assert.Equal(t, 0, response.Body.CommitChanges_OUTPUT.ReturnValue)
+	assert.False(t, sim.Uncommitted())
+	assert.Equal(t, 1, sim.Commits())
+}
+
+func TestOptIn(t *testing.T) {
+	sim := New()
+	sim.OptInCode = 654321
+	m := wsman.NewMessagesWithClient(strict{sim})
+	state := func() string {
+		return sim.Property("IPS_OptInService", "OptInState")
+	}
+
+	sent, err := m.IPS.OptInService.SendOptInCode(654321)
+	require.NoError(t, err)
+	assert.Equal(t, int(optin.ReturnValuePTStatusInvalidState), sent.Body.SendOptInCodeResponse.ReturnValue, "the opt-in was not started")
+
+	started, err := m.IPS.OptInService.StartOptIn()
+	require.NoError(t, err)
+	assert.Equal(t, 0, started.Body.StartOptInResponse.ReturnValue)
+	assert.Equal(t, "2", state())
+	started, err = m.IPS.OptInService.StartOptIn()
+	require.NoError(t, err)
+	assert.Equal(t, int(optin.ReturnValuePTStatusInvalidState), started.Body.StartOptInResponse.ReturnValue)
+
+	sent, err = m.IPS.OptInService.SendOptInCode(123456)
+	require.NoError(t, err)
+	assert.Equal(t, 2066, sent.Body.SendOptInCodeResponse.ReturnValue)
+	assert.Equal(t, "2", state())
+	sent, err = m.IPS.OptInService.SendOptInCode(654321)
+	require.NoError(t, err)
+	assert.Equal(t, 0, sent.Body.SendOptInCodeResponse.ReturnValue)
+	assert.Equal(t, "3", state())
+
+	canceled, err := m.IPS.OptInService.CancelOptIn()
+	require.NoError(t, err)
+	assert.Equal(t, 0, canceled.Body.CancelOptInResponse.ReturnValue)
+	assert.Equal(t, "0", state())
+
+	service, err := m.IPS.OptInService.Get()
+	require.NoError(t, err)
+	assert.Equal(t, 0, service.Body.GetResponse.OptInState)
+}
+
+func TestHandle(t *testing.T) {
+	sim := New()
+	sim.Handle("AMT_RemoteAccessService", "AddMpServer", func(store *Store, call *Call) (*Output, error) {
+		assert.Equal(t, "mps.example.com", call.Input("AccessInfo"))
+		return &Output{ReturnValue: 36, Parameters: []Parameter{{Name: "Reason", Value: "a < b"}}}, nil
+	})
+	sim.Handle("AMT_RemoteAccessService", "AddRemoteAccessPolicyRule", func(store *Store, call *Call) (*Output, error) {
+		return nil, InternalError("the flash is worn out")
+	})
+	m := wsman.NewMessagesWithClient(sim)
+
+	response, err := m.AMT.RemoteAccessService.AddMPS(remoteaccess.AddMpServerRequest{
+		AccessInfo: "mps.example.com",
+		InfoFormat: remoteaccess.FQDN,
+		Port:       4433,
+		AuthMethod: remoteaccess.UsernamePasswordAuthentication,
+		Username:   "user",
+		Password:   "password",
+	})
+	require.NoError(t, err)
+	assert.Contains(t, response.XMLOutput, "<h:Reason>a &lt; b</h:Reason><h:ReturnValue>36</h:ReturnValue></h:AddMpServer_OUTPUT>")
+
+	_, err = m.AMT.RemoteAccessService.AddRemoteAccessPolicyRule(remoteaccess.RemoteAccessPolicyRuleRequest{Trigger: remoteaccess.Periodic, TunnelLifeTime: 0, ExtendedData: "AAAAAAAAABk="}, "Intel(r) AMT:Management Presence Server 0")
+	var fault *Fault
+	require.ErrorAs(t, err, &fault)
+	assert.Equal(t, 500, fault.StatusCode())
+	assert.Contains(t, err.Error(), "wsman.Client: post received 500 Internal Server Error")
+}
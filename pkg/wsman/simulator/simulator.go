@@ -0,0 +1,376 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+// Package simulator is an in-process AMT device for tests: a client.WSMan keeping the instances of the CIM, AMT and
+// IPS classes in memory and answering requests from them the way a device does, so that workflows can be tested end to
+// end without hardware.
+//
+// New seeds the instances from the response fixtures of wsmantesting. Get, Enumerate and Pull read them, Put, Create
+// and Delete change them, and the methods with a handler run against them. By default:
+//
+//   - CIM_PowerManagementService.RequestPowerStateChange changes the PowerState of CIM_AssociatedPowerManagementService
+//   - AMT_RemoteAccessService.AddMpServer creates an AMT_ManagementPresenceRemoteSAP
+//   - AMT_SetupAndConfigurationService.CommitChanges commits the changes, see Simulator.Uncommitted
+//   - IPS_OptInService.StartOptIn, SendOptInCode and CancelOptIn move its OptInState
+//
+// Handle adds or replaces method handlers and Inject scripts failures of requests.
+package simulator
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io/fs"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/internal/envelope"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/internal/message"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/wsmantesting"
+)
+
+// Simulator is a simulated AMT device. It is safe for concurrent use.
+type Simulator struct {
+	// OptInCode is the code displayed by StartOptIn that SendOptInCode has to send. New sets it to 123456.
+	OptInCode int
+
+	mu              sync.Mutex
+	store           *Store
+	handlers        map[string]MethodHandler
+	injections      []*Injection
+	enumerations    map[string][]string
+	enumerationID   int
+	messageID       int
+	created         int
+	commits         int
+	uncommitted     bool
+	poweredOnStates []string
+}
+
+// New returns a simulator seeded with the instances of the fixtures of wsmantesting, see Store.Seed.
+func New() *Simulator {
+	store := NewStore()
+	fixtures, err := fs.Sub(wsmantesting.Responses, "responses")
+	if err == nil {
+		err = store.Seed(fixtures)
+	}
+	if err != nil {
+		// the fixtures are embedded, reading them only fails when the build is broken
+		panic(fmt.Sprintf("simulator: %v", err))
+	}
+	return NewWithStore(store)
+}
+
+// NewWithStore returns a simulator answering from store.
+func NewWithStore(store *Store) *Simulator {
+	s := &Simulator{
+		OptInCode:    123456,
+		store:        store,
+		handlers:     map[string]MethodHandler{},
+		enumerations: map[string][]string{},
+	}
+	s.handleDefaults()
+	return s
+}
+
+// Handle registers h as the handler of the method of class, replacing the handler registered before. Methods without a
+// handler are answered with an ActionNotSupported fault.
+func (s *Simulator) Handle(class, method string, h MethodHandler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[class+"/"+method] = h
+}
+
+// Inject adds injections to the script of failures.
+func (s *Simulator) Inject(injections ...Injection) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, i := range injections {
+		i := i
+		s.injections = append(s.injections, &i)
+	}
+}
+
+// Instances returns copies of the instances of class, see Store.Instances.
+func (s *Simulator) Instances(class string) []*Instance {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	instances := s.store.Instances(class)
+	for j, i := range instances {
+		instances[j] = i.copy()
+	}
+	return instances
+}
+
+// Property returns the value of the property name of the first instance of class, or "" when there is none.
+func (s *Simulator) Property(class, name string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	instances := s.store.Instances(class)
+	if len(instances) == 0 {
+		return ""
+	}
+	return instances[0].Property(name)
+}
+
+// SetProperty sets the property name of every instance of class to values, see Instance.SetProperty, and returns the
+// number of instances changed.
+func (s *Simulator) SetProperty(class, name string, values ...string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	instances := s.store.Instances(class)
+	for _, i := range instances {
+		i.SetProperty(name, values...)
+	}
+	return len(instances)
+}
+
+// AddInstance adds the instance doc, see ParseInstance.
+func (s *Simulator) AddInstance(doc string) error {
+	i, err := ParseInstance(doc)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.store.Add(i)
+	return nil
+}
+
+// Commits returns the number of CommitChanges calls answered.
+func (s *Simulator) Commits() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.commits
+}
+
+// Uncommitted reports whether Put, Create or Delete changed instances since the last CommitChanges.
+func (s *Simulator) Uncommitted() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.uncommitted
+}
+
+// request is a parsed request.
+type request struct {
+	*envelope.Envelope
+	class  string
+	method string // the method called, empty for the WS-Transfer and WS-Enumeration actions
+}
+
+// Post answers the request msg. A fault is returned as a *Fault error, with no response, like client.Target does.
+func (s *Simulator) Post(msg string) ([]byte, error) {
+	e, err := envelope.Parse(msg)
+	if err != nil {
+		return nil, &Fault{Code: "Sender", Subcode: xml.Name{Space: addressingNamespace, Local: "InvalidMessageInformationHeader"}, Reason: err.Error()}
+	}
+	r := &request{Envelope: e, class: path.Base(e.ResourceURI)}
+	if strings.HasPrefix(e.Action, e.ResourceURI+"/") {
+		r.method = strings.TrimPrefix(e.Action, e.ResourceURI+"/")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	body, injected, err := s.inject(r)
+	if !injected {
+		body, err = s.dispatch(r)
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.messageID++
+	messageID := fmt.Sprintf("uuid:00000000-8086-8086-8086-%012X", s.messageID)
+	return []byte(responseEnvelope(e.Action+"Response", e.ResourceURI, e.MessageID, messageID, body)), nil
+}
+
+// inject answers r as the first injection applying to it scripts, and reports whether one applied.
+func (s *Simulator) inject(r *request) (string, bool, error) {
+	for _, i := range s.injections {
+		if !i.matches(r.class, r.Action, r.method != "") {
+			continue
+		}
+		switch {
+		case i.Err != nil:
+			return "", true, i.Err
+		case i.Fault != nil:
+			return "", true, i.Fault
+		}
+		return (&Output{ReturnValue: i.ReturnValue}).xml(r.ResourceURI, r.call()), true, nil
+	}
+	return "", false, nil
+}
+
+func (s *Simulator) dispatch(r *request) (string, error) {
+	switch r.Action {
+	case message.BaseActionsGet:
+		return s.get(r)
+	case message.BaseActionsEnumerate:
+		return s.enumerate(r)
+	case message.BaseActionsPull:
+		return s.pull(r)
+	case message.BaseActionsPut:
+		return s.put(r)
+	case message.BaseActionsCreate:
+		return s.create(r)
+	case message.BaseActionsDelete:
+		return s.delete(r)
+	}
+	h, ok := s.handlers[r.class+"/"+r.method]
+	if r.method == "" || !ok {
+		return "", ActionNotSupported(r.Action)
+	}
+	call := r.call()
+	output, err := h(s.store, call)
+	if err != nil {
+		return "", err
+	}
+	return output.xml(r.ResourceURI, call), nil
+}
+
+// call returns the method call of r.
+func (r *request) call() *Call {
+	input := r.Body.Child(r.method + "_INPUT")
+	if input == nil {
+		input = &envelope.Node{}
+	}
+	return &Call{Class: r.class, Method: r.method, Selectors: r.Selectors, input: input}
+}
+
+// instance returns a copy of the instance in the body of r.
+func (r *request) instance() (*Instance, error) {
+	if n := r.Body.Child(r.class); n != nil {
+		return &Instance{node: copyNode(n)}, nil
+	}
+	return nil, InvalidRepresentation(fmt.Sprintf("the body has no %s", r.class))
+}
+
+// selectOne returns the first instance selected by r.
+func (s *Simulator) selectOne(r *request) (*Instance, error) {
+	selected := s.store.Select(r.class, r.Selectors)
+	if len(selected) == 0 {
+		return nil, DestinationUnreachable(fmt.Sprintf("%s has no instance matching the selectors", r.class))
+	}
+	return selected[0], nil
+}
+
+func (s *Simulator) get(r *request) (string, error) {
+	i, err := s.selectOne(r)
+	if err != nil {
+		return "", err
+	}
+	return i.XML(), nil
+}
+
+// enumerate takes a snapshot of the instances of the class, which is pulled with the enumeration context returned.
+func (s *Simulator) enumerate(r *request) (string, error) {
+	if !s.store.Known(r.class) {
+		return "", DestinationUnreachable(fmt.Sprintf("%s is not supported", r.class))
+	}
+	items := []string{}
+	for _, i := range s.store.Instances(r.class) {
+		items = append(items, i.XML())
+	}
+	s.enumerationID++
+	context := fmt.Sprintf("%08X-0000-0000-0000-000000000000", s.enumerationID)
+	s.enumerations[context] = items
+	return `<g:EnumerateResponse><g:EnumerationContext>` + context + `</g:EnumerationContext></g:EnumerateResponse>`, nil
+}
+
+// pull answers the next instances of an enumeration, up to MaxElements. The enumeration context ends with the last one.
+func (s *Simulator) pull(r *request) (string, error) {
+	pull := r.Body.Child("Pull")
+	context := pull.Child("EnumerationContext")
+	if context == nil {
+		return "", InvalidEnumerationContext()
+	}
+	items, ok := s.enumerations[context.Text]
+	if !ok {
+		return "", InvalidEnumerationContext()
+	}
+	count := len(items)
+	if max := pull.Child("MaxElements"); max != nil {
+		if n, err := strconv.Atoi(max.Text); err == nil && n > 0 && n < count {
+			count = n
+		}
+	}
+	sb := strings.Builder{}
+	sb.WriteString(`<g:PullResponse><g:Items>` + strings.Join(items[:count], "") + `</g:Items>`)
+	if count < len(items) {
+		s.enumerations[context.Text] = items[count:]
+		sb.WriteString(`<g:EnumerationContext>` + context.Text + `</g:EnumerationContext>`)
+	} else {
+		delete(s.enumerations, context.Text)
+		sb.WriteString(`<g:EndOfSequence></g:EndOfSequence>`)
+	}
+	sb.WriteString(`</g:PullResponse>`)
+	return sb.String(), nil
+}
+
+// put sets the properties of the selected instance to their values in the body. The other properties keep their values.
+func (s *Simulator) put(r *request) (string, error) {
+	update, err := r.instance()
+	if err != nil {
+		return "", err
+	}
+	i, err := s.selectOne(r)
+	if err != nil {
+		return "", err
+	}
+	i.merge(update)
+	s.uncommitted = true
+	return i.XML(), nil
+}
+
+// create adds the instance of the body, identified by its InstanceID which is generated when the body has none.
+func (s *Simulator) create(r *request) (string, error) {
+	i, err := r.instance()
+	if err != nil {
+		return "", err
+	}
+	i.node.Name.Space = r.ResourceURI
+	if i.node.Child("InstanceID") == nil {
+		s.created++
+		i.SetProperty("InstanceID", fmt.Sprintf("Intel(r) AMT:%s %d", r.class, s.created))
+	}
+	if len(s.store.Select(r.class, map[string]string{"InstanceID": i.Property("InstanceID")})) > 0 {
+		return "", &Fault{Code: "Sender", Subcode: xml.Name{Space: wsmanNamespace, Local: "AlreadyExists"}, Reason: fmt.Sprintf("%s %s already exists", r.class, i.Property("InstanceID"))}
+	}
+	s.store.Add(i)
+	s.uncommitted = true
+	return i.Reference("InstanceID").xml("t:ResourceCreated"), nil
+}
+
+func (s *Simulator) delete(r *request) (string, error) {
+	i, err := s.selectOne(r)
+	if err != nil {
+		return "", err
+	}
+	s.store.Remove(i)
+	s.uncommitted = true
+	return "", nil
+}
+
+// responseEnvelope returns a response envelope declaring the prefixes used by the bodies of the simulator. Empty
+// headers are left out.
+func responseEnvelope(action, resourceURI, relatesTo, messageID, body string) string {
+	sb := strings.Builder{}
+	sb.WriteString(`<?xml version="1.0" encoding="UTF-8"?>`)
+	fmt.Fprintf(&sb, `<a:Envelope xmlns:a="%s" xmlns:b="%s" xmlns:c="%s" xmlns:g="%s" xmlns:t="%s">`, soapNamespace, addressingNamespace, wsmanNamespace, enumerationNamespace, transferNamespace)
+	sb.WriteString(`<a:Header><b:To>` + anonymousAddress + `</b:To>`)
+	header := func(name, value string) {
+		if value == "" {
+			return
+		}
+		sb.WriteString(`<` + name + `>`)
+		_ = xml.EscapeText(&sb, []byte(value))
+		sb.WriteString(`</` + strings.Fields(name)[0] + `>`)
+	}
+	header("b:RelatesTo", relatesTo)
+	header(`b:Action a:mustUnderstand="true"`, action)
+	header("b:MessageID", messageID)
+	header("c:ResourceURI", resourceURI)
+	sb.WriteString(`</a:Header><a:Body>` + body + `</a:Body></a:Envelope>`)
+	return sb.String()
+}
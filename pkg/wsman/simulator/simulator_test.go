@@ -0,0 +1,212 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package simulator
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/internal/message"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/amt/environmentdetection"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/client"
+)
+
+// strict decodes the responses of the simulator in strict mode, which checks that they answer the requests and have
+// the shape of the response types.
+type strict struct {
+	*Simulator
+}
+
+func (strict) StrictDecoding() bool {
+	return true
+}
+
+func TestGetEnumeratePull(t *testing.T) {
+	sim := New()
+	m := wsman.NewMessagesWithClient(strict{sim})
+
+	settings, err := m.AMT.GeneralSettings.Get()
+	require.NoError(t, err)
+	assert.Equal(t, "Digest:6EE8C61BA74893E059F032EA919D699E", settings.Body.GetResponse.DigestRealm)
+
+	enumerated, err := m.AMT.ManagementPresenceRemoteSAP.Enumerate()
+	require.NoError(t, err)
+	context := enumerated.Body.EnumerateResponse.EnumerationContext
+	assert.NotEmpty(t, context)
+	pulled, err := m.AMT.ManagementPresenceRemoteSAP.Pull(context)
+	require.NoError(t, err)
+	require.Len(t, pulled.Body.PullResponse.ManagementRemoteItems, 1)
+	assert.Equal(t, "Intel(r) AMT:Management Presence Server 0", pulled.Body.PullResponse.ManagementRemoteItems[0].Name)
+
+	_, err = m.AMT.ManagementPresenceRemoteSAP.Pull(context)
+	var fault *Fault
+	require.ErrorAs(t, err, &fault, "the enumeration ended with the last instance")
+	assert.Equal(t, "InvalidEnumerationContext", fault.Subcode.Local)
+
+	// CIM_ServiceAvailableToElement answers the instances of its subclass it was seeded with
+	services, err := m.CIM.ServiceAvailableToElement.Enumerate()
+	require.NoError(t, err)
+	associations, err := m.CIM.ServiceAvailableToElement.Pull(services.Body.EnumerateResponse.EnumerationContext)
+	require.NoError(t, err)
+	require.Len(t, associations.Body.PullResponse.AssociatedPowerManagementService, 1)
+	assert.Equal(t, 2, associations.Body.PullResponse.AssociatedPowerManagementService[0].PowerState)
+}
+
+func TestPullMaxElements(t *testing.T) {
+	sim := New()
+	require.NoError(t, sim.AddInstance(`<AMT_ManagementPresenceRemoteSAP xmlns="http://intel.com/wbem/wscim/1/amt-schema/1/AMT_ManagementPresenceRemoteSAP"><Name>second</Name></AMT_ManagementPresenceRemoteSAP>`))
+	creator := message.NewWSManMessageCreator(message.AMTSchema)
+	base := message.NewBase(creator, "AMT_ManagementPresenceRemoteSAP")
+
+	response, err := sim.Post(base.Enumerate())
+	require.NoError(t, err)
+	context := between(string(response), "<g:EnumerationContext>", "</g:EnumerationContext>")
+	pull := strings.Replace(base.Pull(context), "<MaxElements>999</MaxElements>", "<MaxElements>1</MaxElements>", 1)
+
+	response, err = sim.Post(pull)
+	require.NoError(t, err)
+	assert.Contains(t, string(response), "Management Presence Server 0")
+	assert.Equal(t, context, between(string(response), "<g:EnumerationContext>", "</g:EnumerationContext>"), "the enumeration goes on")
+	response, err = sim.Post(pull)
+	require.NoError(t, err)
+	assert.Contains(t, string(response), "<Name>second</Name>")
+	assert.Contains(t, string(response), "<g:EndOfSequence>")
+}
+
+func TestPut(t *testing.T) {
+	sim := New()
+	m := wsman.NewMessagesWithClient(strict{sim})
+
+	response, err := m.AMT.EnvironmentDetectionSettingData.Put(environmentdetection.EnvironmentDetectionSettingDataRequest{
+		ElementName:      "Intel(r) AMT Environment Detection Settings",
+		InstanceID:       "Intel(r) AMT Environment Detection Settings",
+		DetectionStrings: []string{"a.example.com", "b.example.com"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a.example.com", "b.example.com"}, response.Body.GetAndPutResponse.DetectionStrings)
+	instances := sim.Instances("AMT_EnvironmentDetectionSettingData")
+	require.Len(t, instances, 1)
+	assert.Equal(t, []string{"a.example.com", "b.example.com"}, instances[0].Properties("DetectionStrings"))
+	assert.Equal(t, "0", instances[0].Property("DetectionAlgorithm"))
+	assert.True(t, sim.Uncommitted())
+
+	_, err = sim.Post(strings.ReplaceAll(response.XMLInput, "h:AMT_EnvironmentDetectionSettingData", "h:AMT_Other"))
+	var fault *Fault
+	require.ErrorAs(t, err, &fault)
+	assert.Equal(t, "InvalidRepresentation", fault.Subcode.Local)
+}
+
+func TestCreateDelete(t *testing.T) {
+	sim := New()
+	creator := message.NewWSManMessageCreator(message.AMTSchema)
+	base := message.NewBase(creator, "AMT_Simulated")
+	type simulated struct {
+		XMLName struct{} `xml:"h:AMT_Simulated"`
+		H       string   `xml:"xmlns:h,attr"`
+		Name    string   `xml:"h:Name"`
+	}
+
+	response, err := sim.Post(base.Create(&simulated{Name: "created"}, nil))
+	require.NoError(t, err)
+	assert.Contains(t, string(response), `<c:Selector Name="InstanceID">Intel(r) AMT:AMT_Simulated 1</c:Selector>`)
+	instances := sim.Instances("AMT_Simulated")
+	require.Len(t, instances, 1)
+	assert.Equal(t, "created", instances[0].Property("Name"))
+	assert.Equal(t, message.AMTSchema+"AMT_Simulated", instances[0].ResourceURI())
+
+	m := wsman.NewMessagesWithClient(sim)
+	_, err = m.AMT.ManagementPresenceRemoteSAP.Delete("Intel(r) AMT:Management Presence Server 0")
+	require.NoError(t, err)
+	assert.Empty(t, sim.Instances("AMT_ManagementPresenceRemoteSAP"))
+	_, err = m.AMT.ManagementPresenceRemoteSAP.Delete("Intel(r) AMT:Management Presence Server 0")
+	var fault *Fault
+	require.ErrorAs(t, err, &fault)
+	assert.Equal(t, "DestinationUnreachable", fault.Subcode.Local)
+}
+
+func TestUnknownRequests(t *testing.T) {
+	sim := New()
+	creator := message.NewWSManMessageCreator(message.AMTSchema)
+
+	unknown := message.NewBase(creator, "AMT_Unknown")
+	_, err := sim.Post(unknown.Enumerate())
+	var fault *Fault
+	require.ErrorAs(t, err, &fault)
+	assert.Equal(t, "DestinationUnreachable", fault.Subcode.Local)
+	assert.True(t, strings.HasPrefix(err.Error(), "wsman.Client: post received 400 Bad Request\n'<?xml"), err.Error())
+
+	settings := message.NewBase(creator, "AMT_GeneralSettings")
+	_, err = sim.Post(settings.RequestStateChange(message.AMTSchema+"AMT_GeneralSettings/Reboot", 2))
+	require.ErrorAs(t, err, &fault)
+	assert.Equal(t, "ActionNotSupported", fault.Subcode.Local)
+
+	_, err = sim.Post("<Body/>")
+	require.ErrorAs(t, err, &fault)
+	assert.Equal(t, "InvalidMessageInformationHeader", fault.Subcode.Local)
+}
+
+func TestSeed(t *testing.T) {
+	get := `<a:Envelope xmlns:a="http://www.w3.org/2003/05/soap-envelope" xmlns:w="http://schemas.dmtf.org/wbem/wsman/1/wsman.xsd"><a:Header><w:ResourceURI>urn:x/X_Class</w:ResourceURI></a:Header><a:Body><h:X_Class xmlns:h="urn:x/X_Class"><h:Name>%s</h:Name></h:X_Class></a:Body></a:Envelope>`
+	pull := `<a:Envelope xmlns:a="http://www.w3.org/2003/05/soap-envelope" xmlns:w="http://schemas.dmtf.org/wbem/wsman/1/wsman.xsd" xmlns:g="http://schemas.xmlsoap.org/ws/2004/09/enumeration"><a:Header><w:ResourceURI>urn:x/X_Class</w:ResourceURI></a:Header><a:Body><g:PullResponse><g:Items><h:X_Class xmlns:h="urn:x/X_Class"><h:Name>a</h:Name></h:X_Class><h:X_Sub xmlns:h="urn:x/X_Sub"><h:Name>b</h:Name></h:X_Sub></g:Items></g:PullResponse></a:Body></a:Envelope>`
+	fsys := fstest.MapFS{
+		"x/get.xml":        {Data: []byte(strings.Replace(get, "%s", "a", 1))},
+		"x/pull.xml":       {Data: []byte(pull)},
+		"x/other/pull.xml": {Data: []byte(pull)},
+		"y/get.xml":        {Data: []byte(strings.ReplaceAll(strings.Replace(get, "%s", "c", 1), "X_Class", "Y_Class"))},
+		"y/put.xml":        {Data: []byte(strings.ReplaceAll(strings.Replace(get, "%s", "d", 1), "X_Class", "Y_Class"))},
+		"z/get.xml":        {Data: []byte("not xml")},
+	}
+	store := NewStore()
+	require.NoError(t, store.Seed(fsys))
+
+	names := func(class string) []string {
+		names := []string{}
+		for _, i := range store.Instances(class) {
+			names = append(names, i.Property("Name"))
+		}
+		return names
+	}
+	assert.Equal(t, []string{"a", "b"}, names("X_Class"), "duplicates are dropped and subclasses are grouped")
+	assert.Equal(t, []string{"b"}, names("X_Sub"))
+	assert.Equal(t, []string{"c"}, names("Y_Class"))
+	assert.False(t, store.Known("Z_Class"))
+}
+
+func TestInstance(t *testing.T) {
+	i, err := ParseInstance(`<h:X_Class xmlns:h="urn:x/X_Class"><h:A>1</h:A><h:B>2</h:B><h:B>3</h:B><h:C>4</h:C></h:X_Class>`)
+	require.NoError(t, err)
+	assert.Equal(t, "X_Class", i.Class())
+	assert.Equal(t, "urn:x/X_Class", i.ResourceURI())
+	assert.Equal(t, []string{"2", "3"}, i.Properties("B"))
+
+	i.SetProperty("B", "5")
+	i.SetProperty("D", "6", "7")
+	i.SetProperty("A")
+	assert.Equal(t, `<X_Class xmlns="urn:x/X_Class"><B>5</B><C>4</C><D>6</D><D>7</D></X_Class>`, i.XML())
+	assert.Equal(t, &Reference{ResourceURI: "urn:x/X_Class", Selectors: []Selector{{Name: "C", Value: "4"}}}, i.Reference("C"))
+
+	_, err = ParseInstance(`<X_Class/>`)
+	assert.EqualError(t, err, "instance X_Class has no namespace")
+}
+
+func TestSimulatorIsAClient(t *testing.T) {
+	var c client.WSMan = New()
+	_, err := c.Post("")
+	assert.True(t, errors.As(err, new(*Fault)))
+}
+
+// between returns the text of s between the first start and the following end.
+func between(s, start, end string) string {
+	_, after, _ := strings.Cut(s, start)
+	text, _, _ := strings.Cut(after, end)
+	return text
+}
@@ -0,0 +1,325 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package simulator
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io/fs"
+	"path"
+
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/internal/envelope"
+)
+
+// Instance is an instance of a class, stored as the element AMT answers a Get with.
+type Instance struct {
+	node *envelope.Node
+}
+
+// ParseInstance parses the XML element of an instance such as
+// <h:AMT_GeneralSettings xmlns:h="http://intel.com/wbem/wscim/1/amt-schema/1/AMT_GeneralSettings">...</h:AMT_GeneralSettings>.
+func ParseInstance(doc string) (*Instance, error) {
+	n, err := envelope.ParseNode(doc)
+	if err != nil {
+		return nil, err
+	}
+	if n.Name.Space == "" {
+		return nil, fmt.Errorf("instance %s has no namespace", n.Name.Local)
+	}
+	return &Instance{node: n}, nil
+}
+
+// Class returns the name of the class of i.
+func (i *Instance) Class() string {
+	return i.node.Name.Local
+}
+
+// ResourceURI returns the resource URI of the class of i, the namespace of its element.
+func (i *Instance) ResourceURI() string {
+	return i.node.Name.Space
+}
+
+// Property returns the value of the property name, the first one for arrays, or "" when i does not have it.
+func (i *Instance) Property(name string) string {
+	if n := i.node.Child(name); n != nil {
+		return n.Text
+	}
+	return ""
+}
+
+// Properties returns the values of the array property name.
+func (i *Instance) Properties(name string) []string {
+	values := []string{}
+	for _, n := range i.node.Children {
+		if n.Name.Local == name {
+			values = append(values, n.Text)
+		}
+	}
+	return values
+}
+
+// SetProperty sets the property name to values, an array when there is more than one. The property keeps its position
+// when i has it, otherwise it is added last. No values removes the property.
+func (i *Instance) SetProperty(name string, values ...string) {
+	nodes := make([]*envelope.Node, len(values))
+	for j, value := range values {
+		nodes[j] = &envelope.Node{Name: xml.Name{Space: i.node.Name.Space, Local: name}, Text: value}
+	}
+	i.replace(name, nodes)
+}
+
+// replace replaces the properties named name with nodes.
+func (i *Instance) replace(name string, nodes []*envelope.Node) {
+	children := []*envelope.Node{}
+	replaced := false
+	for _, c := range i.node.Children {
+		if c.Name.Local != name {
+			children = append(children, c)
+			continue
+		}
+		if !replaced {
+			children = append(children, nodes...)
+			replaced = true
+		}
+	}
+	if !replaced {
+		children = append(children, nodes...)
+	}
+	i.node.Children = children
+}
+
+// merge sets the properties of i that update has to their values in update.
+func (i *Instance) merge(update *Instance) {
+	names := []string{}
+	groups := map[string][]*envelope.Node{}
+	for _, c := range update.node.Children {
+		if _, ok := groups[c.Name.Local]; !ok {
+			names = append(names, c.Name.Local)
+		}
+		groups[c.Name.Local] = append(groups[c.Name.Local], c)
+	}
+	for _, name := range names {
+		i.replace(name, groups[name])
+	}
+}
+
+// matches reports whether the properties of i have the values of selectors. Selectors that are not properties of i,
+// such as the InstanceID a Put of a singleton is sent with, are ignored.
+func (i *Instance) matches(selectors map[string]string) bool {
+	for name, value := range selectors {
+		n := i.node.Child(name)
+		if n == nil {
+			continue
+		}
+		if canonicalValue(n) != value {
+			return false
+		}
+	}
+	return true
+}
+
+// canonicalValue returns the value of n the way envelope.Parse returns the value of a selector.
+func canonicalValue(n *envelope.Node) string {
+	if len(n.Children) == 0 {
+		return n.Text
+	}
+	value := ""
+	for _, c := range n.Children {
+		value += c.String()
+	}
+	return value
+}
+
+// XML returns the element of i.
+func (i *Instance) XML() string {
+	return i.node.XML()
+}
+
+// Reference returns a reference to i selecting it by the properties keys.
+func (i *Instance) Reference(keys ...string) *Reference {
+	r := &Reference{ResourceURI: i.ResourceURI()}
+	for _, key := range keys {
+		r.Selectors = append(r.Selectors, Selector{Name: key, Value: i.Property(key)})
+	}
+	return r
+}
+
+func (i *Instance) copy() *Instance {
+	return &Instance{node: copyNode(i.node)}
+}
+
+func copyNode(n *envelope.Node) *envelope.Node {
+	c := *n
+	c.Attrs = append([]xml.Attr(nil), n.Attrs...)
+	c.Children = make([]*envelope.Node, len(n.Children))
+	for j, child := range n.Children {
+		c.Children[j] = copyNode(child)
+	}
+	return &c
+}
+
+// Reference is an endpoint reference to an instance, as returned by Create and by methods such as AddMpServer.
+type Reference struct {
+	ResourceURI string
+	Selectors   []Selector
+}
+
+// Selector is a key property of a Reference.
+type Selector struct {
+	Name  string
+	Value string
+}
+
+// Store holds the instances of a simulated device by class. It is not safe for concurrent use: the Simulator guards its
+// store and method handlers get it while it is locked.
+type Store struct {
+	instances map[string][]*Instance
+	// groups maps a class to the classes whose instances it returns, itself and the subclasses it was seeded with,
+	// as AMT answers CIM_ServiceAvailableToElement with CIM_AssociatedPowerManagementService instances.
+	groups map[string][]string
+}
+
+// NewStore returns an empty store.
+func NewStore() *Store {
+	return &Store{
+		instances: map[string][]*Instance{},
+		groups:    map[string][]string{},
+	}
+}
+
+// Known reports whether the store has, or had, instances of class.
+func (s *Store) Known(class string) bool {
+	_, ok := s.groups[class]
+	return ok
+}
+
+// Instances returns the instances of class and of the subclasses grouped with it. They are the stored instances, not
+// copies.
+func (s *Store) Instances(class string) []*Instance {
+	instances := []*Instance{}
+	for _, c := range s.groups[class] {
+		instances = append(instances, s.instances[c]...)
+	}
+	return instances
+}
+
+// Select returns the instances of class that have the values of selectors, see Instances.
+func (s *Store) Select(class string, selectors map[string]string) []*Instance {
+	selected := []*Instance{}
+	for _, i := range s.Instances(class) {
+		if i.matches(selectors) {
+			selected = append(selected, i)
+		}
+	}
+	return selected
+}
+
+// Add adds i to the instances of its class.
+func (s *Store) Add(i *Instance) {
+	s.group(i.Class(), i.Class())
+	s.instances[i.Class()] = append(s.instances[i.Class()], i)
+}
+
+// Remove removes i from the store and reports whether it was stored.
+func (s *Store) Remove(i *Instance) bool {
+	instances := s.instances[i.Class()]
+	for j, stored := range instances {
+		if stored == i {
+			s.instances[i.Class()] = append(instances[:j:j], instances[j+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// group makes class return the instances of member.
+func (s *Store) group(class, member string) {
+	for _, c := range s.groups[class] {
+		if c == member {
+			return
+		}
+	}
+	s.groups[class] = append(s.groups[class], member)
+	if class != member {
+		s.group(member, member)
+	}
+}
+
+// Seed adds the instances of the get.xml and pull.xml responses found in fsys, laid out like the fixtures of
+// wsmantesting. The instances of a class come from its Pull responses when there are any, otherwise from its Get
+// responses, and duplicates are dropped. Other files are ignored.
+func (s *Store) Seed(fsys fs.FS) error {
+	pulled := map[string][]*Instance{}
+	got := map[string][]*Instance{}
+	classes := []string{}
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		var found map[string][]*Instance
+		switch path.Base(name) {
+		case "pull.xml":
+			found = pulled
+		case "get.xml":
+			found = got
+		default:
+			return nil
+		}
+		b, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		e, err := envelope.Parse(string(b))
+		if err != nil || e.Body == nil || len(e.Body.Children) != 1 {
+			return nil
+		}
+		resource := path.Base(e.ResourceURI)
+		content := e.Body.Children[0]
+		items := []*envelope.Node{content}
+		switch {
+		case content.Name.Local == "PullResponse":
+			items = nil
+			if list := content.Child("Items"); list != nil {
+				items = list.Children
+			}
+		case content.Name.Local != resource:
+			return nil
+		}
+		for _, item := range items {
+			i := &Instance{node: item}
+			s.group(resource, i.Class())
+			if _, ok := pulled[i.Class()]; !ok {
+				if _, ok := got[i.Class()]; !ok {
+					classes = append(classes, i.Class())
+				}
+			}
+			found[i.Class()] = appendUnique(found[i.Class()], i)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, class := range classes {
+		instances := pulled[class]
+		if len(instances) == 0 {
+			instances = got[class]
+		}
+		for _, i := range instances {
+			s.Add(i)
+		}
+	}
+	return nil
+}
+
+func appendUnique(instances []*Instance, i *Instance) []*Instance {
+	for _, stored := range instances {
+		if stored.node.String() == i.node.String() {
+			return instances
+		}
+	}
+	return append(instances, i)
+}
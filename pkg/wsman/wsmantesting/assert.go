@@ -0,0 +1,160 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package wsmantesting
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/internal/envelope"
+)
+
+// Request describes the expected parts of a request envelope for AssertRequest. Empty fields are not checked.
+type Request struct {
+	Action      string
+	ResourceURI string
+	// Selectors are the expected selectors of the header, an empty map expects none. They are not checked when nil.
+	Selectors map[string]string
+	// Body is the expected content of the SOAP body, compared like AssertEnvelope compares envelopes.
+	Body string
+	// Fields maps the paths of elements of the body, the local names from the child of the body down separated by
+	// slashes such as "AMT_GeneralSettings/HostName", to their expected text.
+	Fields map[string]string
+}
+
+type tHelper interface {
+	Helper()
+}
+
+// CanonicalEnvelope returns the canonical form of the WS-Man envelope msg: the action, resource URI and selectors
+// followed by the other headers and the body, with namespace prefixes resolved, attributes and elements sorted by name
+// and the message identifier left out. Envelopes with the same canonical form are semantically equal.
+func CanonicalEnvelope(msg string) (string, error) {
+	e, err := envelope.Parse(msg)
+	if err != nil {
+		return "", err
+	}
+	sb := strings.Builder{}
+	fmt.Fprintf(&sb, "Action %s\nResourceURI %s\n", e.Action, e.ResourceURI)
+	for _, name := range sortedKeys(e.Selectors) {
+		fmt.Fprintf(&sb, "Selector %s=%q\n", name, e.Selectors[name])
+	}
+	if e.Header != nil {
+		for _, h := range e.Header.Sorted().Children {
+			switch h.Name.Local {
+			case "Action", "ResourceURI", "SelectorSet", "MessageID":
+				continue
+			}
+			sb.WriteString(h.String())
+		}
+	}
+	if e.Body != nil {
+		sb.WriteString(e.Body.Sorted().String())
+	}
+	return sb.String(), nil
+}
+
+// AssertEnvelope asserts that the envelopes expected and actual have the same canonical form, see CanonicalEnvelope.
+// On mismatch the failure shows the difference of the canonical forms.
+func AssertEnvelope(t assert.TestingT, expected, actual string, msgAndArgs ...interface{}) bool {
+	if h, ok := t.(tHelper); ok {
+		h.Helper()
+	}
+	want, err := CanonicalEnvelope(expected)
+	if err != nil {
+		return assert.Fail(t, fmt.Sprintf("expected envelope is invalid: %v", err), msgAndArgs...)
+	}
+	got, err := CanonicalEnvelope(actual)
+	if err != nil {
+		return assert.Fail(t, fmt.Sprintf("envelope is invalid: %v\n%s", err, actual), msgAndArgs...)
+	}
+	return assert.Equal(t, want, got, msgAndArgs...)
+}
+
+// AssertRequest asserts that the envelope actual has the parts described by expected. Every mismatch is reported.
+func AssertRequest(t assert.TestingT, expected Request, actual string, msgAndArgs ...interface{}) bool {
+	if h, ok := t.(tHelper); ok {
+		h.Helper()
+	}
+	e, err := envelope.Parse(actual)
+	if err != nil {
+		return assert.Fail(t, fmt.Sprintf("envelope is invalid: %v\n%s", err, actual), msgAndArgs...)
+	}
+	ok := true
+	if expected.Action != "" {
+		ok = assert.Equal(t, expected.Action, e.Action, label("Action", msgAndArgs)...) && ok
+	}
+	if expected.ResourceURI != "" {
+		ok = assert.Equal(t, expected.ResourceURI, e.ResourceURI, label("ResourceURI", msgAndArgs)...) && ok
+	}
+	if expected.Selectors != nil {
+		ok = assert.Equal(t, expected.Selectors, e.Selectors, label("Selectors", msgAndArgs)...) && ok
+	}
+	if expected.Body != "" {
+		want, err := envelope.ParseNode("<Body>" + expected.Body + "</Body>")
+		if err != nil {
+			return assert.Fail(t, fmt.Sprintf("expected body is invalid: %v", err), msgAndArgs...)
+		}
+		ok = assert.Equal(t, children(want), children(e.Body), label("Body", msgAndArgs)...) && ok
+	}
+	for _, path := range sortedKeys(expected.Fields) {
+		n := find(e.Body, strings.Split(path, "/"))
+		if n == nil {
+			ok = assert.Fail(t, fmt.Sprintf("body has no element %s", path), msgAndArgs...) && ok
+			continue
+		}
+		ok = assert.Equal(t, expected.Fields[path], n.Text, label(path, msgAndArgs)...) && ok
+	}
+	return ok
+}
+
+// label prefixes the message of msgAndArgs with the name of the checked part.
+func label(name string, msgAndArgs []interface{}) []interface{} {
+	switch {
+	case len(msgAndArgs) == 0:
+		return []interface{}{name}
+	case len(msgAndArgs) == 1:
+		return []interface{}{fmt.Sprintf("%s: %v", name, msgAndArgs[0])}
+	}
+	if format, ok := msgAndArgs[0].(string); ok {
+		return []interface{}{name + ": " + fmt.Sprintf(format, msgAndArgs[1:]...)}
+	}
+	return []interface{}{name + ": " + fmt.Sprint(msgAndArgs...)}
+}
+
+// children returns the canonical form of the sorted children of n.
+func children(n *envelope.Node) string {
+	if n == nil {
+		return ""
+	}
+	sb := strings.Builder{}
+	for _, c := range n.Sorted().Children {
+		sb.WriteString(c.String())
+	}
+	return sb.String()
+}
+
+// find returns the first element below n along the local names of path.
+func find(n *envelope.Node, path []string) *envelope.Node {
+	for _, name := range path {
+		if n = n.Child(name); n == nil {
+			return nil
+		}
+	}
+	return n
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,115 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package wsmantesting
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingT records the failures of the assertions instead of failing the test.
+type recordingT struct {
+	failures []string
+}
+
+func (t *recordingT) Errorf(format string, args ...interface{}) {
+	t.failures = append(t.failures, fmt.Sprintf(format, args...))
+}
+
+const putBody = `<h:AMT_GeneralSettings xmlns:h="http://intel.com/wbem/wscim/1/amt-schema/1/AMT_GeneralSettings"><h:HostName>host</h:HostName><h:LinkPolicy>1</h:LinkPolicy><h:LinkPolicy>14</h:LinkPolicy></h:AMT_GeneralSettings>`
+
+var putRequest = ExpectedResponse(3, "http://intel.com/wbem/wscim/1/amt-schema/1/", "AMT_GeneralSettings", PUT, `<w:SelectorSet><w:Selector Name="InstanceID">Intel(r) AMT: General Settings</w:Selector></w:SelectorSet>`, putBody)
+
+// reformatted is putRequest with other prefixes, message identifier, whitespace and order of headers and properties.
+const reformatted = `<?xml version="1.0" encoding="utf-8"?>
+<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope" xmlns:wsa="http://schemas.xmlsoap.org/ws/2004/08/addressing" xmlns:wsman="http://schemas.dmtf.org/wbem/wsman/1/wsman.xsd">
+  <s:Header>
+    <wsa:MessageID>uuid:1234</wsa:MessageID>
+    <wsa:To>/wsman</wsa:To>
+    <wsman:ResourceURI>http://intel.com/wbem/wscim/1/amt-schema/1/AMT_GeneralSettings</wsman:ResourceURI>
+    <wsa:Action>http://schemas.xmlsoap.org/ws/2004/09/transfer/Put</wsa:Action>
+    <wsa:ReplyTo><wsa:Address>http://schemas.xmlsoap.org/ws/2004/08/addressing/role/anonymous</wsa:Address></wsa:ReplyTo>
+    <wsman:OperationTimeout>PT60S</wsman:OperationTimeout>
+    <wsman:SelectorSet><wsman:Selector Name="InstanceID">Intel(r) AMT: General Settings</wsman:Selector></wsman:SelectorSet>
+  </s:Header>
+  <s:Body>
+    <AMT_GeneralSettings xmlns="http://intel.com/wbem/wscim/1/amt-schema/1/AMT_GeneralSettings">
+      <LinkPolicy>1</LinkPolicy>
+      <HostName>host</HostName>
+      <LinkPolicy>14</LinkPolicy>
+    </AMT_GeneralSettings>
+  </s:Body>
+</s:Envelope>`
+
+func TestAssertEnvelope(t *testing.T) {
+	AssertEnvelope(t, putRequest, reformatted)
+
+	for name, changed := range map[string]string{
+		"property value": strings.Replace(reformatted, "<HostName>host", "<HostName>other", 1),
+		"repeated order": strings.NewReplacer("<LinkPolicy>1<", "<LinkPolicy>14<", "<LinkPolicy>14<", "<LinkPolicy>1<").Replace(reformatted),
+		"namespace":      strings.Replace(reformatted, `xmlns="http://intel.com`, `xmlns="http://example.com`, 1),
+		"header":         strings.Replace(reformatted, "PT60S", "PT30S", 1),
+	} {
+		recorder := &recordingT{}
+		assert.False(t, AssertEnvelope(recorder, putRequest, changed, "checking %s", name), name)
+		if assert.Len(t, recorder.failures, 1, name) {
+			assert.Contains(t, recorder.failures[0], "Diff:", name)
+			assert.Contains(t, recorder.failures[0], "checking "+name, name)
+		}
+	}
+
+	recorder := &recordingT{}
+	assert.False(t, AssertEnvelope(recorder, putRequest, "<Envelope>"))
+	assert.Contains(t, recorder.failures[0], "envelope is invalid")
+}
+
+func TestAssertRequest(t *testing.T) {
+	AssertRequest(t, Request{
+		Action:      PUT,
+		ResourceURI: "http://intel.com/wbem/wscim/1/amt-schema/1/AMT_GeneralSettings",
+		Selectors:   map[string]string{"InstanceID": "Intel(r) AMT: General Settings"},
+		Body:        putBody,
+		Fields: map[string]string{
+			"AMT_GeneralSettings/HostName":   "host",
+			"AMT_GeneralSettings/LinkPolicy": "1",
+		},
+	}, reformatted)
+	AssertRequest(t, Request{Action: PUT}, reformatted)
+
+	recorder := &recordingT{}
+	assert.False(t, AssertRequest(recorder, Request{
+		Action:      GET,
+		ResourceURI: "http://intel.com/wbem/wscim/1/amt-schema/1/AMT_GeneralSettings",
+		Selectors:   map[string]string{},
+		Fields: map[string]string{
+			"AMT_GeneralSettings/HostName":   "other",
+			"AMT_GeneralSettings/DomainName": "domain",
+		},
+	}, reformatted))
+	if assert.Len(t, recorder.failures, 4) {
+		assert.Contains(t, recorder.failures[0], "Action")
+		assert.Contains(t, recorder.failures[1], "Selectors")
+		assert.Contains(t, recorder.failures[2], "body has no element AMT_GeneralSettings/DomainName")
+		assert.Contains(t, recorder.failures[3], "AMT_GeneralSettings/HostName")
+	}
+}
+
+func TestCanonicalEnvelope(t *testing.T) {
+	canonical, err := CanonicalEnvelope(ExpectedResponse(0, "http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/", "CIM_BIOSElement", ENUMERATE, "", ENUMERATE_BODY))
+	assert.NoError(t, err)
+	assert.Equal(t, `Action http://schemas.xmlsoap.org/ws/2004/09/enumeration/Enumerate
+ResourceURI http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_BIOSElement
+{http://schemas.dmtf.org/wbem/wsman/1/wsman.xsd}OperationTimeout "PT60S"
+{http://schemas.xmlsoap.org/ws/2004/08/addressing}ReplyTo
+  {http://schemas.xmlsoap.org/ws/2004/08/addressing}Address "http://schemas.xmlsoap.org/ws/2004/08/addressing/role/anonymous"
+{http://schemas.xmlsoap.org/ws/2004/08/addressing}To "/wsman"
+{http://www.w3.org/2003/05/soap-envelope}Body
+  {http://schemas.xmlsoap.org/ws/2004/09/enumeration}Enumerate
+`, canonical)
+}
@@ -11,6 +11,10 @@ import (
 type MockClient struct {
 	CurrentMessage   string
 	PackageUnderTest string
+	// Lenient decodes responses without checking them against the request and the response struct, see message.CheckResponse.
+	Lenient bool
+	// SkipValidation sends requests without validating their inputs, for tests of the envelope of any value.
+	SkipValidation bool
 }
 
 func (c *MockClient) Post(msg string) ([]byte, error) {
@@ -36,3 +40,28 @@ func (c *MockClient) Post(msg string) ([]byte, error) {
 	// Simulate a successful response for testing.
 	return []byte(xmlData), nil
 }
+
+// StrictDecoding reports whether responses are decoded in strict mode, which is the default so every recorded response is checked
+func (c *MockClient) StrictDecoding() bool {
+	return !c.Lenient
+}
+
+// ValidateRequests reports whether request inputs are validated before being sent
+func (c *MockClient) ValidateRequests() bool {
+	return !c.SkipValidation
+}
+
+// ActionClient answers Get and Put requests with the matching recorded response and keeps the requests sent.
+type ActionClient struct {
+	MockClient
+	Sent []string
+}
+
+func (c *ActionClient) Post(msg string) ([]byte, error) {
+	c.Sent = append(c.Sent, msg)
+	c.CurrentMessage = "Get"
+	if strings.Contains(msg, "<a:Action>"+PUT+"</a:Action>") {
+		c.CurrentMessage = "Put"
+	}
+	return c.MockClient.Post(msg)
+}
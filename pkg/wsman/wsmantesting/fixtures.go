@@ -0,0 +1,13 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package wsmantesting
+
+import "embed"
+
+// Responses holds the response fixtures read by MockClient, under responses/<PackageUnderTest>/<message>.xml.
+//
+//go:embed responses
+var Responses embed.FS
@@ -0,0 +1,62 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package wsmantesting
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io/fs"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/internal/message"
+)
+
+// fixtureHeader is the part of a response header that names the request it answers.
+type fixtureHeader struct {
+	Header struct {
+		Action      string `xml:"Action"`
+		ResourceURI string `xml:"ResourceURI"`
+	} `xml:"Header"`
+}
+
+// anyBody accepts any properties so that only the envelope, action and namespaces of a fixture are checked.
+type anyBody struct {
+	Body struct {
+		Content []struct {
+			Inner string `xml:",innerxml"`
+		} `xml:",any"`
+	} `xml:"Body"`
+}
+
+// fixtureRequest is the request a fixture answers, with only the headers message.CheckResponse compares.
+const fixtureRequest = `<Envelope xmlns="http://www.w3.org/2003/05/soap-envelope"><Header><Action>%s</Action><ResourceURI>%s</ResourceURI></Header><Body></Body></Envelope>`
+
+func TestResponsesDecodeStrictly(t *testing.T) {
+	err := fs.WalkDir(Responses, "responses", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(path, ".xml") {
+			return err
+		}
+		t.Run(strings.TrimPrefix(path, "responses/"), func(t *testing.T) {
+			data, err := Responses.ReadFile(path)
+			assert.NoError(t, err)
+			header := fixtureHeader{}
+			assert.NoError(t, xml.Unmarshal(data, &header))
+			action := strings.TrimSpace(header.Header.Action)
+			if strings.HasSuffix(action, "/fault") {
+				// faults answer any request and are checked by the tests that expect them
+				return
+			}
+			assert.True(t, strings.HasSuffix(action, "Response"), "action %s does not answer a request", action)
+			request := fmt.Sprintf(fixtureRequest, strings.TrimSuffix(action, "Response"), header.Header.ResourceURI)
+			assert.NoError(t, message.CheckResponse(request, string(data), &anyBody{}))
+		})
+		return nil
+	})
+	assert.NoError(t, err)
+}